@@ -0,0 +1,27 @@
+// fakeidp 本地模拟的统一身份认证服务，同时提供OIDC和CAS 3.0接口
+// 仅用于开发和联调，登录页直接选择预置用户，不校验密码
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"zhku-oj/internal/sso/ssotest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "监听地址")
+	issuer := flag.String("issuer", "http://localhost:9000", "OIDC签发者，需与服务配置的issuer一致")
+	clientID := flag.String("client-id", "zhku-oj", "OIDC client_id")
+	clientSecret := flag.String("client-secret", "fake-secret", "OIDC client_secret")
+	flag.Parse()
+
+	idp, err := ssotest.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("模拟统一身份认证启动: %s (OIDC issuer=%s, CAS server_url=%s/cas)", *addr, idp.Issuer(), idp.Issuer())
+	log.Fatal(http.ListenAndServe(*addr, idp.Handler()))
+}
@@ -12,6 +12,7 @@ import (
 	"zhku-oj/internal/pkg/database"
 	"zhku-oj/internal/pkg/logger"
 	"zhku-oj/internal/queue"
+	"zhku-oj/internal/realtime"
 	"zhku-oj/internal/repository/mongodb"
 )
 
@@ -41,20 +42,32 @@ func main() {
 	// 初始化Repository层
 	submissionRepo := mongodb.NewSubmissionRepository(mongoClient, cfg.MongoDB.Database)
 	problemRepo := mongodb.NewProblemRepository(mongoClient, cfg.MongoDB.Database)
+	judgeTaskRepo := mongodb.NewJudgeTaskRepository(mongoClient, cfg.MongoDB.Database)
 
-	// 初始化判题管理器
-	judgeManager, err := judge.NewManager(cfg.Judge, submissionRepo, problemRepo)
+	// 初始化消息队列
+	producer, err := queue.NewProducer(cfg.RabbitMQ, queue.WithRedisClient(redisClient))
 	if err != nil {
-		log.Fatalf("初始化判题管理器失败: %v", err)
+		log.Fatalf("初始化消息队列失败: %v", err)
 	}
+	defer producer.Close()
 
-	// 初始化消息队列消费者
-	consumer, err := queue.NewConsumer(cfg.RabbitMQ)
+	consumer, err := queue.NewConsumer(cfg.RabbitMQ, queue.WithRedisClient(redisClient))
 	if err != nil {
 		log.Fatalf("初始化消息队列失败: %v", err)
 	}
 	defer consumer.Close()
 
+	// 初始化判题事件推送，由Web服务转发给提交用户
+	events := realtime.NewRedisPublisher(redisClient)
+
+	// 初始化判题管理器
+	judgeManager, err := judge.NewManager(cfg.Judge, submissionRepo, problemRepo, judgeTaskRepo, producer, events)
+	if err != nil {
+		log.Fatalf("初始化判题管理器失败: %v", err)
+	}
+	// 启动任务维护：回收心跳超时任务、重新发布到期重试
+	judgeManager.Start()
+
 	// 启动判题任务消费者
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -63,7 +76,7 @@ func main() {
 	go func() {
 		logger.Info("判题服务已启动")
 		if err := consumer.ConsumeJudgeTasks(ctx, judgeManager.ProcessTask); err != nil {
-			logger.Error("判题任务消费失败: %v", err)
+			logger.Error("判题任务消费失败", "error", err)
 		}
 	}()
 
@@ -71,7 +84,7 @@ func main() {
 	go func() {
 		logger.Info("结果处理服务已启动")
 		if err := consumer.ConsumeJudgeResults(ctx, judgeManager.ProcessResult); err != nil {
-			logger.Error("结果处理失败: %v", err)
+			logger.Error("结果处理失败", "error", err)
 		}
 	}()
 
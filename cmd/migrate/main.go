@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/migration"
+	"zhku-oj/internal/pkg/database"
+	"zhku-oj/internal/pkg/logger"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "只列出将要执行的迁移操作，不修改数据库")
+	status := flag.Bool("status", false, "查看迁移执行状态")
+	flag.Parse()
+
+	// 初始化配置
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化日志
+	logger.Init(cfg.Logging)
+
+	// 初始化数据库连接
+	mongoClient, err := database.NewMongoDB(cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("连接MongoDB失败: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	migrator := migration.New(database.GetDatabase(mongoClient, cfg.MongoDB.Database))
+	ctx := context.Background()
+
+	if *status {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("查询迁移状态失败: %v", err)
+		}
+		for _, s := range statuses {
+			applied := "未执行"
+			if s.AppliedAt != nil {
+				applied = "已执行于 " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-10s  %s\n", s.Version, applied, s.Description)
+		}
+		return
+	}
+
+	results, err := migrator.Up(ctx, *dryRun)
+	for _, result := range results {
+		fmt.Printf("[%d] %s (%v)\n", result.Version, result.Description, result.Duration)
+		for _, action := range result.Actions {
+			fmt.Printf("      %s\n", action)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "数据库迁移失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case len(results) == 0:
+		fmt.Println("没有需要执行的迁移")
+	case *dryRun:
+		fmt.Printf("dry-run: %d个迁移待执行，未修改数据库\n", len(results))
+	default:
+		fmt.Printf("已执行%d个迁移\n", len(results))
+	}
+}
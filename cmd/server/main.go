@@ -12,13 +12,28 @@ import (
 	"zhku-oj/internal/config"
 	"zhku-oj/internal/handler/admin"
 	"zhku-oj/internal/handler/auth"
+	"zhku-oj/internal/handler/contest"
+	"zhku-oj/internal/handler/course"
+	"zhku-oj/internal/handler/event"
 	"zhku-oj/internal/handler/problem"
 	"zhku-oj/internal/handler/submission"
 	"zhku-oj/internal/handler/user"
+	"zhku-oj/internal/judge/language"
+	"zhku-oj/internal/mail"
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/migration"
 	"zhku-oj/internal/pkg/database"
+	"zhku-oj/internal/pkg/jwtauth"
 	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/ratelimit"
+	"zhku-oj/internal/rbac"
+	"zhku-oj/internal/realtime"
 	"zhku-oj/internal/repository/mongodb"
 	"zhku-oj/internal/service/impl"
+	"zhku-oj/internal/session"
+	"zhku-oj/internal/sso"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +48,11 @@ func main() {
 	// 初始化日志
 	logger.Init(cfg.Logging)
 
+	// release模式下不允许使用示例JWT密钥，否则任何人都能伪造token
+	if cfg.Server.Mode == gin.ReleaseMode && cfg.JWT.UsesDefaultSecret() {
+		log.Fatalf("release模式下必须修改jwt.secret或配置jwt.keys")
+	}
+
 	// 初始化数据库连接
 	mongoClient, err := database.NewMongoDB(cfg.MongoDB)
 	if err != nil {
@@ -50,19 +70,114 @@ func main() {
 	userRepo := mongodb.NewUserRepository(mongoClient, cfg.MongoDB.Database)
 	problemRepo := mongodb.NewProblemRepository(mongoClient, cfg.MongoDB.Database)
 	submissionRepo := mongodb.NewSubmissionRepository(mongoClient, cfg.MongoDB.Database)
+	judgeTaskRepo := mongodb.NewJudgeTaskRepository(mongoClient, cfg.MongoDB.Database)
+	contestRepo := mongodb.NewContestRepository(mongoClient, cfg.MongoDB.Database)
+	roleRepo := mongodb.NewRoleRepository(mongoClient, cfg.MongoDB.Database)
+	courseRepo := mongodb.NewCourseRepository(mongoClient, cfg.MongoDB.Database)
+	assignmentRepo := mongodb.NewAssignmentRepository(mongoClient, cfg.MongoDB.Database)
+
+	// 数据库迁移：创建索引、回填字段，未开启自动迁移时只提示未执行的迁移
+	migrator := migration.New(database.GetDatabase(mongoClient, cfg.MongoDB.Database))
+	if cfg.MongoDB.AutoMigrate {
+		if _, err := migrator.Up(context.Background(), false); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+	} else if pending, err := migrator.Pending(context.Background()); err != nil {
+		logger.Warn("查询数据库迁移状态失败", "error", err)
+	} else if len(pending) > 0 {
+		logger.Warn("存在未执行的数据库迁移，请运行 make migrate", "pending", len(pending))
+	}
+
+	// 初始化消息队列生产者
+	producer, err := queue.NewProducer(cfg.RabbitMQ, queue.WithRedisClient(redisClient))
+	if err != nil {
+		log.Fatalf("初始化消息队列失败: %v", err)
+	}
+	defer producer.Close()
+
+	// 初始化判题语言注册表，用于校验提交语言和题目允许的语言
+	languages, err := language.NewRegistry(cfg.Judge)
+	if err != nil {
+		log.Fatalf("初始化判题语言失败: %v", err)
+	}
+
+	// 初始化判题事件推送：判题机和本服务发布事件，本实例订阅后推送给在线用户
+	events := realtime.NewRedisPublisher(redisClient)
+	hub := realtime.NewHub(redisClient)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	go hub.Run(hubCtx)
+
+	// 初始化登录会话：访问token由JWT签发，撤销记录和会话保存在Redis中，每次认证都会检查
+	signer, err := jwtauth.NewSigner(cfg.JWT)
+	if err != nil {
+		log.Fatalf("初始化JWT签名失败: %v", err)
+	}
+	sessions := session.NewStore(redisClient)
+	middleware.SetSigner(signer)
+	middleware.SetTokenChecker(sessions.Active)
+
+	// 初始化角色权限：内置角色和配置文件之上叠加数据库中的角色，定期重新加载以同步其他实例的修改
+	authorizer, err := rbac.NewAuthorizer(cfg.RBAC, roleRepo.List)
+	if err != nil {
+		log.Fatalf("初始化角色权限失败: %v", err)
+	}
+	if err := authorizer.Reload(context.Background()); err != nil {
+		logger.Warn("加载数据库中的角色失败，暂时使用内置和配置文件中的角色", "error", err)
+	}
+	authorizerCtx, stopAuthorizer := context.WithCancel(context.Background())
+	defer stopAuthorizer()
+	go authorizer.Run(authorizerCtx)
+	middleware.SetAuthorizer(authorizer)
+
+	// 初始化密码策略和登录失败锁定
+	passwordPolicy, err := pwdpolicy.New(cfg.Password.Policy)
+	if err != nil {
+		log.Fatalf("初始化密码策略失败: %v", err)
+	}
+	loginGuard := ratelimit.NewLoginGuard(redisClient, cfg.Password.Lockout)
+
+	// 初始化统一身份认证(OIDC/CAS)
+	ssoProviders, err := sso.NewRegistry(cfg.SSO, nil)
+	if err != nil {
+		log.Fatalf("初始化统一身份认证失败: %v", err)
+	}
+	ssoStates := sso.NewStateStore(redisClient, cfg.SSO.StateTTL)
+
+	// 初始化邮件发送(找回密码等)
+	mailer, err := mail.NewSender(cfg.Mail)
+	if err != nil {
+		log.Fatalf("初始化邮件发送失败: %v", err)
+	}
 
 	// 初始化Service层
-	authService := impl.NewAuthService(userRepo, redisClient, cfg)
-	userService := impl.NewUserService(userRepo, redisClient)
+	authService := impl.NewAuthService(userRepo, sessions, signer, loginGuard, passwordPolicy, cfg)
+	ssoService := impl.NewSSOService(ssoProviders, ssoStates, userRepo, authService, cfg)
+	userService := impl.NewUserService(userRepo, courseRepo, redisClient, sessions, passwordPolicy, authorizer)
+	passwordService := impl.NewPasswordService(userRepo, redisClient, sessions, mailer, loginGuard, passwordPolicy, cfg)
 	problemService := impl.NewProblemService(problemRepo, redisClient)
-	submissionService := impl.NewSubmissionService(submissionRepo, problemRepo, redisClient, cfg)
+	contestService := impl.NewContestService(contestRepo, problemRepo, submissionRepo, userRepo)
+	scoreboardService := impl.NewScoreboardService(contestRepo, submissionRepo, userRepo, redisClient)
+	systemService := impl.NewSystemService(judgeTaskRepo, producer, redisClient, cfg)
+	roleService := impl.NewRoleService(roleRepo, userRepo, sessions, authorizer)
+	courseService := impl.NewCourseService(courseRepo, assignmentRepo, userRepo, submissionRepo)
+	assignmentService := impl.NewAssignmentService(assignmentRepo, courseRepo, problemRepo, userRepo, submissionRepo)
+	submissionService := impl.NewSubmissionService(submissionRepo, problemRepo, courseRepo, judgeTaskRepo, contestService, assignmentService, producer, events)
+
+	// 初始化限流：接口按路由组和角色限流，代码提交额外按题目限流并检查重复提交
+	rateLimiter := ratelimit.NewLimiter(redisClient)
+	duplicates := ratelimit.NewDuplicateGuard(redisClient, cfg.RateLimit.DuplicateWindow)
+	submitPolicy, _ := cfg.RateLimit.Policy("submit") // 提交接口按用户和题目的限流由处理器执行
 
 	// 初始化Handler层
-	authHandler := auth.NewAuthHandler(authService)
+	authHandler := auth.NewAuthHandler(authService, ssoService, passwordService, signer)
 	userHandler := user.NewUserHandler(userService)
 	problemHandler := problem.NewProblemHandler(problemService)
-	submissionHandler := submission.NewSubmissionHandler(submissionService)
-	adminHandler := admin.NewAdminHandler(userService, problemService, submissionService)
+	submissionHandler := submission.NewSubmissionHandler(submissionService, languages, duplicates, rateLimiter, submitPolicy)
+	contestHandler := contest.NewContestHandler(contestService, scoreboardService)
+	courseHandler := course.NewCourseHandler(courseService, assignmentService)
+	eventHandler := event.NewEventHandler(hub)
+	adminHandler := admin.NewAdminHandler(userService, systemService, passwordService, authService, roleService)
 
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
@@ -76,7 +191,13 @@ func main() {
 		userHandler,
 		problemHandler,
 		submissionHandler,
+		contestHandler,
+		courseHandler,
+		eventHandler,
 		adminHandler,
+		rateLimiter,
+		cfg.RateLimit,
+		rbac.ProblemOwner(problemRepo),
 	)
 	routerManager.SetupRoutes(router)
 
@@ -102,6 +223,9 @@ func main() {
 	<-quit
 	logger.Info("正在关闭服务器...")
 
+	// 先结束判题事件推送，WebSocket和SSE长连接随之关闭
+	stopHub()
+
 	// 优雅关闭服务器，等待5秒
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -42,12 +42,22 @@ func main() {
 	userRepo := mongodb.NewUserRepository(mongoClient, cfg.MongoDB.Database)
 	problemRepo := mongodb.NewProblemRepository(mongoClient, cfg.MongoDB.Database)
 	submissionRepo := mongodb.NewSubmissionRepository(mongoClient, cfg.MongoDB.Database)
+	contestRepo := mongodb.NewContestRepository(mongoClient, cfg.MongoDB.Database)
 
 	// 初始化Service层
-	statsService := impl.NewStatsService(userRepo, problemRepo, submissionRepo, redisClient)
+	statsService := impl.NewStatsService(userRepo, problemRepo, submissionRepo)
+	scoreboardService := impl.NewScoreboardService(contestRepo, submissionRepo, userRepo, redisClient)
+
+	// 竞赛提交先更新竞赛排行榜，再更新用户和题目统计；排行榜按提交记录重新计算，重复投递不会重复计数
+	handleStatsUpdate := func(ctx context.Context, update *queue.StatsUpdate) error {
+		if err := scoreboardService.ApplyResult(ctx, update); err != nil {
+			return err
+		}
+		return statsService.UpdateStats(ctx, update)
+	}
 
 	// 初始化消息队列消费者
-	consumer, err := queue.NewConsumer(cfg.RabbitMQ)
+	consumer, err := queue.NewConsumer(cfg.RabbitMQ, queue.WithRedisClient(redisClient))
 	if err != nil {
 		log.Fatalf("初始化消息队列失败: %v", err)
 	}
@@ -59,8 +69,8 @@ func main() {
 
 	go func() {
 		logger.Info("统计更新服务已启动")
-		if err := consumer.ConsumeStatsUpdates(ctx, statsService.UpdateStats); err != nil {
-			logger.Error("统计更新服务失败: %v", err)
+		if err := consumer.ConsumeStatsUpdates(ctx, handleStatsUpdate); err != nil {
+			logger.Error("统计更新服务失败", "error", err)
 		}
 	}()
 
@@ -68,7 +78,7 @@ func main() {
 	go func() {
 		logger.Info("通知服务已启动")
 		if err := consumer.ConsumeNotifications(ctx, statsService.ProcessNotification); err != nil {
-			logger.Error("通知服务失败: %v", err)
+			logger.Error("通知服务失败", "error", err)
 		}
 	}()
 
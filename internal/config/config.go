@@ -9,13 +9,18 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	MongoDB  MongoDBConfig  `yaml:"mongodb"`
-	Redis    RedisConfig    `yaml:"redis"`
-	RabbitMQ RabbitMQConfig `yaml:"rabbitmq"`
-	Judge    JudgeConfig    `yaml:"judge"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Server    ServerConfig    `yaml:"server"`
+	MongoDB   MongoDBConfig   `yaml:"mongodb"`
+	Redis     RedisConfig     `yaml:"redis"`
+	RabbitMQ  RabbitMQConfig  `yaml:"rabbitmq"`
+	Judge     JudgeConfig     `yaml:"judge"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	SSO       SSOConfig       `yaml:"sso"`
+	Mail      MailConfig      `yaml:"mail"`
+	Password  PasswordConfig  `yaml:"password"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	RBAC      RBACConfig      `yaml:"rbac"`
 }
 
 // ServerConfig 服务器配置
@@ -33,6 +38,7 @@ type MongoDBConfig struct {
 	ConnectTimeout time.Duration `yaml:"connect_timeout"`
 	MaxPoolSize    uint64        `yaml:"max_pool_size"`
 	MinPoolSize    uint64        `yaml:"min_pool_size"`
+	AutoMigrate    bool          `yaml:"auto_migrate"` // Web服务器启动时执行未执行的数据库迁移
 }
 
 // RedisConfig Redis配置
@@ -46,11 +52,19 @@ type RedisConfig struct {
 
 // RabbitMQConfig RabbitMQ配置
 type RabbitMQConfig struct {
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
-	VHost    string `yaml:"vhost"`
+	Driver     string        `yaml:"driver"` // rabbitmq, redis, memory
+	Host       string        `yaml:"host"`
+	Port       int           `yaml:"port"`
+	Username   string        `yaml:"username"`
+	Password   string        `yaml:"password"`
+	VHost      string        `yaml:"vhost"`
+	Exchange   string        `yaml:"exchange"`
+	Prefetch   int           `yaml:"prefetch"`
+	MaxRetries int           `yaml:"max_retries"` // 负数表示不重投
+	AckTimeout time.Duration `yaml:"ack_timeout"` // redis后端的消息确认超时
+	KeyPrefix  string        `yaml:"key_prefix"`  // redis后端的键前缀
+	// LaneWeights 判题通道权重(contest, homework, practice, rejudge)
+	LaneWeights map[string]int `yaml:"lane_weights"`
 }
 
 // JudgeConfig 判题配置
@@ -59,6 +73,42 @@ type JudgeConfig struct {
 	Compile        CompileConfig        `yaml:"compile"`
 	Runtime        RuntimeConfig        `yaml:"runtime"`
 	FileManagement FileManagementConfig `yaml:"file_management"`
+	Task           JudgeTaskConfig      `yaml:"task"`
+	// Languages 各语言的判题配置，覆盖内置默认值(java默认取compile.java/runtime.java)
+	Languages map[string]LanguageConfig `yaml:"languages"`
+	Checker   CheckerConfig             `yaml:"checker"`
+}
+
+// CheckerConfig 特殊判题程序配置
+type CheckerConfig struct {
+	TestlibPath string `yaml:"testlib_path"` // testlib.h路径，编译checker时复制到沙箱，为空时不提供
+	CPULimit    int64  `yaml:"cpu_limit"`    // 每次运行checker的CPU时间限制(纳秒)
+	MemoryLimit int64  `yaml:"memory_limit"` // 每次运行checker的内存限制(字节)
+	CacheSize   int    `yaml:"cache_size"`   // 每个判题机缓存的已编译checker数量
+}
+
+// LanguageConfig 语言判题配置，未配置的字段使用内置默认值
+// 命令中可使用 {src}(源文件名) 和 {exe}(编译产物名) 占位符
+type LanguageConfig struct {
+	Enabled        *bool    `yaml:"enabled"`
+	CompileCommand []string `yaml:"compile_command"`
+	RunCommand     []string `yaml:"run_command"`
+	Env            []string `yaml:"env"`
+	TimeFactor     float64  `yaml:"time_factor"`   // 题目时间限制倍数
+	MemoryFactor   float64  `yaml:"memory_factor"` // 题目内存限制倍数
+}
+
+// JudgeTaskConfig 判题任务生命周期配置
+type JudgeTaskConfig struct {
+	JudgeID           string        `yaml:"judge_id"`           // 判题机标识，为空时使用 主机名-进程号
+	MaxRetries        int           `yaml:"max_retries"`        // 系统错误最大重试次数，超过后标记为SYSTEM_ERROR
+	RetryBackoff      time.Duration `yaml:"retry_backoff"`      // 首次重试等待时间，之后每次翻倍
+	MaxBackoff        time.Duration `yaml:"max_backoff"`        // 重试等待时间上限
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"` // 判题中心跳间隔
+	HeartbeatTimeout  time.Duration `yaml:"heartbeat_timeout"`  // 心跳超时后任务被回收重试
+	ScanInterval      time.Duration `yaml:"scan_interval"`      // 扫描超时任务和到期重试的间隔
+	SandboxWait       time.Duration `yaml:"sandbox_wait"`       // 全部沙箱满载时的最长等待时间
+	CaseParallelism   int           `yaml:"case_parallelism"`   // 每个提交最多同时运行的测试用例数，受沙箱空闲名额限制
 }
 
 // SandboxConfig 沙箱配置
@@ -107,10 +157,183 @@ type FileManagementConfig struct {
 	AutoCleanup     bool          `yaml:"auto_cleanup"`
 }
 
-// JWTConfig JWT配置
+// DefaultJWTSecret 示例配置中的JWT密钥，release模式下不允许使用
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// JWTConfig JWT与登录会话配置
 type JWTConfig struct {
-	Secret string        `yaml:"secret"`
-	Expire time.Duration `yaml:"expire"`
+	Secret        string         `yaml:"secret"`         // HS256密钥，未配置keys时使用
+	Issuer        string         `yaml:"issuer"`         // 签发者(iss)，其他服务校验token时使用
+	SigningKey    string         `yaml:"signing_key"`    // 签名使用的密钥ID，为空时使用keys中第一个可签名的密钥
+	Keys          []JWTKeyConfig `yaml:"keys"`           // 签名和校验密钥，轮换时保留旧密钥直到其签发的token全部过期
+	AccessExpire  time.Duration  `yaml:"access_expire"`  // 访问token有效期，过期后用刷新token换取
+	RefreshExpire time.Duration  `yaml:"refresh_expire"` // 刷新token有效期，每次刷新轮换并重新计时
+	MaxSessions   int            `yaml:"max_sessions"`   // 每个用户同时登录的设备数，超过时注销最久未活动的会话
+}
+
+// JWTKeyConfig JWT密钥，kid写入token头部，校验时按kid选择密钥
+type JWTKeyConfig struct {
+	ID             string `yaml:"id"`
+	Algorithm      string `yaml:"algorithm"`        // HS256, RS256, EdDSA
+	Secret         string `yaml:"secret"`           // HS256密钥
+	PrivateKeyFile string `yaml:"private_key_file"` // RS256/EdDSA私钥(PEM)，用于签名
+	PublicKeyFile  string `yaml:"public_key_file"`  // RS256/EdDSA公钥(PEM)，只用于校验的旧密钥配置公钥即可
+}
+
+// UsesDefaultSecret 是否使用了示例密钥或空密钥签名/校验HS256 token
+func (c JWTConfig) UsesDefaultSecret() bool {
+	if len(c.Keys) == 0 {
+		return c.Secret == "" || c.Secret == DefaultJWTSecret
+	}
+	for _, key := range c.Keys {
+		if key.Algorithm == "HS256" && (key.Secret == "" || key.Secret == DefaultJWTSecret) {
+			return true
+		}
+	}
+	return false
+}
+
+// SSOConfig 统一身份认证配置
+type SSOConfig struct {
+	CallbackBase     string              `yaml:"callback_base"`     // 回调地址前缀，回调地址为 {callback_base}/{name}/callback
+	AllowedRedirects []string            `yaml:"allowed_redirects"` // 登录完成后允许跳转的前端地址前缀
+	AutoProvision    bool                `yaml:"auto_provision"`    // 首次登录时自动创建账号
+	StateTTL         time.Duration       `yaml:"state_ttl"`         // 发起登录到回调的最长时间
+	Providers        []SSOProviderConfig `yaml:"providers"`
+}
+
+// SSOProviderConfig 身份提供方配置
+type SSOProviderConfig struct {
+	Name         string   `yaml:"name"` // 路由中使用的标识
+	Type         string   `yaml:"type"` // oidc, cas
+	DisplayName  string   `yaml:"display_name"`
+	Issuer       string   `yaml:"issuer"`        // oidc: 签发者，从 {issuer}/.well-known/openid-configuration 读取端点
+	ClientID     string   `yaml:"client_id"`     // oidc
+	ClientSecret string   `yaml:"client_secret"` // oidc
+	Scopes       []string `yaml:"scopes"`        // oidc: 默认 openid profile email
+	UserInfo     bool     `yaml:"userinfo"`      // oidc: 合并userinfo端点返回的声明
+	ServerURL    string   `yaml:"server_url"`    // cas: CAS服务地址，如 https://cas.example.edu.cn/cas
+
+	Claims      SSOClaimMapping   `yaml:"claims"`
+	RoleMap     map[string]string `yaml:"role_map"`     // 身份声明中的角色值 -> student/teacher
+	DefaultRole string            `yaml:"default_role"` // 没有匹配的角色时使用，默认student
+}
+
+// SSOClaimMapping 身份声明到用户字段的映射，值为声明名；cas中 "user" 表示登录名
+type SSOClaimMapping struct {
+	StudentID string `yaml:"student_id"`
+	Username  string `yaml:"username"`
+	RealName  string `yaml:"real_name"`
+	Email     string `yaml:"email"`
+	Role      string `yaml:"role"`
+	Class     string `yaml:"class"`
+	Grade     string `yaml:"grade"`
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver  string     `yaml:"driver"`   // smtp, file
+	From    string     `yaml:"from"`     // 发件人，如 "ZHKU OJ <noreply@example.edu.cn>"
+	FileDir string     `yaml:"file_dir"` // file: 邮件保存为.eml文件的目录，用于开发和测试
+	SMTP    SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	TLS      string        `yaml:"tls"` // starttls(默认), ssl, none
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// PasswordConfig 密码策略、密码重置和登录失败锁定配置
+type PasswordConfig struct {
+	ResetURL      string               `yaml:"reset_url"`       // 前端重置密码页面，邮件中的链接为 {reset_url}?token=xxx
+	ResetTokenTTL time.Duration        `yaml:"reset_token_ttl"` // 重置链接有效期
+	ResetInterval time.Duration        `yaml:"reset_interval"`  // 同一用户两次发送重置邮件的最短间隔
+	Policy        PasswordPolicyConfig `yaml:"policy"`
+	Lockout       LoginLockoutConfig   `yaml:"lockout"`
+}
+
+// PasswordPolicyConfig 密码强度要求，创建用户、注册、修改和重置密码时检查
+type PasswordPolicyConfig struct {
+	MinLength       int      `yaml:"min_length"`       // 最短长度
+	MinClasses      int      `yaml:"min_classes"`      // 至少包含的字符种类数(小写字母、大写字母、数字、符号)
+	BannedPasswords []string `yaml:"banned_passwords"` // 在内置常见弱密码之外追加禁止的密码
+	BannedFile      string   `yaml:"banned_file"`      // 禁止的密码列表文件，每行一个
+	CheckPersonal   bool     `yaml:"check_personal"`   // 禁止密码包含用户名、学号、邮箱或与其过于相似
+}
+
+// LoginLockoutConfig 登录失败锁定，按账号和IP分别计数
+type LoginLockoutConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	AccountThreshold int           `yaml:"account_threshold"` // 同一账号在window内失败达到该次数后锁定账号
+	IPThreshold      int           `yaml:"ip_threshold"`      // 同一IP在window内失败达到该次数后锁定IP，0表示不按IP锁定
+	Window           time.Duration `yaml:"window"`            // 失败次数的统计时间
+	LockDuration     time.Duration `yaml:"lock_duration"`     // 首次锁定时长，之后每次锁定翻倍
+	MaxLockDuration  time.Duration `yaml:"max_lock_duration"` // 锁定时长上限
+}
+
+// RBACConfig 角色权限配置，数据库中通过管理接口保存的角色优先于这里的配置
+type RBACConfig struct {
+	// Roles 角色到权限的映射，覆盖内置角色的默认权限或新增角色
+	Roles map[string]RoleConfig `yaml:"roles"`
+	// ReloadInterval 重新加载数据库中角色的间隔，多个实例通过它同步角色修改
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// RoleConfig 角色配置
+type RoleConfig struct {
+	DisplayName string   `yaml:"display_name"`
+	Permissions []string `yaml:"permissions"` // 权限名称，支持 "*" 和 "problem:*" 形式的通配符
+}
+
+// RateLimitConfig 接口限流配置
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Policies 各路由组的限流策略：default(已认证接口)、auth(登录注册，按IP)、submit(代码提交)
+	Policies map[string]RateLimitPolicy `yaml:"policies"`
+	// DuplicateWindow 相同代码重复提交的最短间隔，0表示不检查
+	DuplicateWindow time.Duration `yaml:"duplicate_window"`
+}
+
+// RateLimitPolicy 路由组限流策略，已认证用户按用户计数，否则按IP计数
+type RateLimitPolicy struct {
+	Limit  int           `yaml:"limit"`  // 窗口内最多请求数，0表示不限流
+	Window time.Duration `yaml:"window"` // 滑动窗口长度
+	// PerProblem 同一用户对同一题目在窗口内最多请求数，0表示不单独限制；仅submit策略使用
+	PerProblem int `yaml:"per_problem"`
+	// Roles 按角色覆盖限流参数
+	Roles map[string]RateLimitRule `yaml:"roles"`
+}
+
+// RateLimitRule 限流参数
+type RateLimitRule struct {
+	Limit      int           `yaml:"limit"` // 0表示不限流
+	Window     time.Duration `yaml:"window"`
+	PerProblem int           `yaml:"per_problem"` // 0表示不单独限制
+}
+
+// ForRole 获取角色适用的限流参数
+func (p RateLimitPolicy) ForRole(role string) RateLimitRule {
+	if rule, ok := p.Roles[role]; ok {
+		if rule.Window <= 0 {
+			rule.Window = p.Window
+		}
+		return rule
+	}
+	return RateLimitRule{Limit: p.Limit, Window: p.Window, PerProblem: p.PerProblem}
+}
+
+// Policy 获取路由组的限流策略，未开启限流或未配置该路由组时返回false
+func (c RateLimitConfig) Policy(name string) (RateLimitPolicy, bool) {
+	policy, ok := c.Policies[name]
+	if !c.Enabled || !ok {
+		return RateLimitPolicy{}, false
+	}
+	return policy, true
 }
 
 // LoggingConfig 日志配置
@@ -174,11 +397,23 @@ func GetDefaultConfig() *Config {
 			PoolSize: 20,
 		},
 		RabbitMQ: RabbitMQConfig{
-			Host:     "localhost",
-			Port:     5672,
-			Username: "guest",
-			Password: "guest",
-			VHost:    "/",
+			Driver:     "rabbitmq",
+			Host:       "localhost",
+			Port:       5672,
+			Username:   "guest",
+			Password:   "guest",
+			VHost:      "/",
+			Exchange:   "oj.topic",
+			Prefetch:   4,
+			MaxRetries: 3,
+			AckTimeout: 5 * time.Minute,
+			KeyPrefix:  "oj:",
+			LaneWeights: map[string]int{
+				"contest":  8,
+				"homework": 4,
+				"practice": 2,
+				"rejudge":  1,
+			},
 		},
 		Judge: JudgeConfig{
 			Sandboxes: []SandboxConfig{
@@ -210,15 +445,92 @@ func GetDefaultConfig() *Config {
 					OutputLimit: 10240, // 10KB
 				},
 			},
+			Checker: CheckerConfig{
+				TestlibPath: "/usr/include/testlib.h",
+				CPULimit:    5000000000, // 5秒
+				MemoryLimit: 268435456,  // 256MB
+				CacheSize:   64,
+			},
 			FileManagement: FileManagementConfig{
 				CleanupInterval: 5 * time.Minute,
 				MaxCacheSize:    "1GB",
 				AutoCleanup:     true,
 			},
+			Task: JudgeTaskConfig{
+				MaxRetries:        3,
+				RetryBackoff:      5 * time.Second,
+				MaxBackoff:        5 * time.Minute,
+				HeartbeatInterval: 10 * time.Second,
+				HeartbeatTimeout:  time.Minute,
+				ScanInterval:      15 * time.Second,
+				SandboxWait:       time.Minute,
+				CaseParallelism:   4,
+			},
 		},
 		JWT: JWTConfig{
-			Secret: "your-secret-key-change-in-production",
-			Expire: 24 * time.Hour,
+			Secret:        DefaultJWTSecret,
+			Issuer:        "zhku-oj",
+			AccessExpire:  15 * time.Minute,
+			RefreshExpire: 7 * 24 * time.Hour,
+			MaxSessions:   10,
+		},
+		SSO: SSOConfig{
+			AutoProvision: true,
+			StateTTL:      10 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver:  "file",
+			From:    "ZHKU OJ <noreply@localhost>",
+			FileDir: "logs/mail",
+			SMTP: SMTPConfig{
+				Port:    587,
+				TLS:     "starttls",
+				Timeout: 10 * time.Second,
+			},
+		},
+		Password: PasswordConfig{
+			ResetURL:      "http://localhost:3000/reset-password",
+			ResetTokenTTL: 30 * time.Minute,
+			ResetInterval: time.Minute,
+			Policy: PasswordPolicyConfig{
+				MinLength:     8,
+				MinClasses:    2,
+				CheckPersonal: true,
+			},
+			Lockout: LoginLockoutConfig{
+				Enabled:          true,
+				AccountThreshold: 5,
+				IPThreshold:      50,
+				Window:           15 * time.Minute,
+				LockDuration:     time.Minute,
+				MaxLockDuration:  time.Hour,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Policies: map[string]RateLimitPolicy{
+				"default": {
+					Limit:  300,
+					Window: time.Minute,
+					Roles: map[string]RateLimitRule{
+						"admin": {Limit: 0},
+					},
+				},
+				"auth": {
+					Limit:  20,
+					Window: time.Minute,
+				},
+				"submit": {
+					Limit:      6,
+					Window:     time.Minute,
+					PerProblem: 3,
+					Roles: map[string]RateLimitRule{
+						"teacher": {Limit: 60},
+						"admin":   {Limit: 0},
+					},
+				},
+			},
+			DuplicateWindow: 10 * time.Second,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -232,5 +544,8 @@ func GetDefaultConfig() *Config {
 				MaxAge:     30,
 			},
 		},
+		RBAC: RBACConfig{
+			ReloadInterval: 30 * time.Second,
+		},
 	}
 }
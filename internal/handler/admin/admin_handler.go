@@ -0,0 +1,327 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/sheet"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportFileSize 导入文件的最大字节数
+const maxImportFileSize = 5 << 20
+
+// AdminHandler 管理员控制器
+type AdminHandler struct {
+	userService     interfaces.UserService
+	systemService   interfaces.SystemService
+	passwordService interfaces.PasswordService
+	authService     interfaces.AuthService
+	roleService     interfaces.RoleService
+}
+
+// NewAdminHandler 创建管理员控制器实例
+func NewAdminHandler(
+	userService interfaces.UserService,
+	systemService interfaces.SystemService,
+	passwordService interfaces.PasswordService,
+	authService interfaces.AuthService,
+	roleService interfaces.RoleService,
+) *AdminHandler {
+	return &AdminHandler{
+		userService:     userService,
+		systemService:   systemService,
+		passwordService: passwordService,
+		authService:     authService,
+		roleService:     roleService,
+	}
+}
+
+// DashboardResponse 管理员仪表板数据
+type DashboardResponse struct {
+	TotalUsers   int64                        `json:"total_users"`
+	StudentCount int64                        `json:"student_count"`
+	TeacherCount int64                        `json:"teacher_count"`
+	JudgeQueue   *interfaces.JudgeQueueStatus `json:"judge_queue"`
+}
+
+// Dashboard 管理员仪表板
+// 响应码: 0-成功, 10004-权限不足
+// GET /api/v1/admin/dashboard
+func (h *AdminHandler) Dashboard(c *gin.Context) {
+	ctx := c.Request.Context()
+	dashboard := &DashboardResponse{}
+
+	// 按角色统计用户数，只需要总数
+	counts := []struct {
+		role   string
+		target *int64
+	}{
+		{"", &dashboard.TotalUsers},
+		{model.RoleStudent, &dashboard.StudentCount},
+		{model.RoleTeacher, &dashboard.TeacherCount},
+	}
+	for _, item := range counts {
+		resp, err := h.userService.ListUsers(ctx, &interfaces.UserListRequest{Page: 1, PageSize: 1, Role: item.role})
+		if err != nil {
+			utils.HandleError(c, err)
+			return
+		}
+		*item.target = resp.Total
+	}
+
+	status, err := h.systemService.GetSystemStatus(ctx)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	dashboard.JudgeQueue = status.JudgeQueue
+
+	utils.SendSuccess(c, dashboard)
+}
+
+// SystemStatus 系统状态监控
+// 包含各组件健康状态，以及各判题通道的队列深度、判题中任务数和排队时长
+// 响应码: 0-成功, 10004-权限不足
+// GET /api/v1/admin/system/status
+func (h *AdminHandler) SystemStatus(c *gin.Context) {
+	status, err := h.systemService.GetSystemStatus(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, status)
+}
+
+// ImportUsers 批量导入用户
+// multipart表单: file(CSV或XLSX), role, dry_run, skip_invalid, credentials(json/csv/xlsx)
+// credentials为csv或xlsx且实际导入时，直接返回包含初始密码的账号表
+// 响应码: 0-成功, 10002-参数错误, 20022-导入数据有误(data为逐行校验结果)
+// POST /api/v1/admin/users/import
+func (h *AdminHandler) ImportUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+1<<20)
+
+	var req interfaces.ImportUsersRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+	req.Permissions = middleware.GetPermissions(c)
+	credentials := c.DefaultPostForm("credentials", "json")
+	if credentials != "json" && credentials != sheet.FormatCSV && credentials != sheet.FormatXLSX {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "credentials只能为json、csv或xlsx")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "请上传CSV或XLSX文件")
+		return
+	}
+	format, ok := sheet.FormatFromName(file.Filename)
+	if !ok {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "只支持CSV或XLSX文件")
+		return
+	}
+	if file.Size > maxImportFileSize {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "文件不能超过5MB")
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+	req.Records, err = sheet.Read(data, format)
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	result, err := h.userService.ImportUsers(c.Request.Context(), &req)
+	if err != nil {
+		// 校验未通过时把逐行错误返回给前端
+		if bizErr, ok := errors.GetBusinessError(err); ok && result != nil {
+			c.JSON(http.StatusOK, utils.Response{Code: bizErr.GetCode(), Message: bizErr.GetMessage(), Data: result})
+			return
+		}
+		utils.HandleError(c, err)
+		return
+	}
+
+	if req.DryRun || credentials == "json" {
+		utils.SendSuccess(c, result)
+		return
+	}
+
+	// 账号表包含全部行，导入失败的行在备注中注明原因
+	rows := [][]string{{"行号", "学号", "姓名", "班级", "年级", "用户名", "初始密码", "备注"}}
+	for _, row := range result.Rows {
+		note := "已创建"
+		if !row.Created {
+			note = strings.Join(row.Errors, "；")
+		}
+		rows = append(rows, []string{strconv.Itoa(row.Line), row.StudentID, row.RealName, row.Class, row.Grade, row.Username, row.Password, note})
+	}
+	c.Header("Cache-Control", "no-store")
+	sendSheet(c, fmt.Sprintf("user-credentials-%s", time.Now().Format("20060102-150405")), credentials, rows)
+}
+
+// ExportUsers 按筛选条件导出用户
+// GET /api/v1/admin/users/export?format=xlsx&role=student&class=计科1班&grade=2021&is_active=true&keyword=张
+// 响应码: 0-成功(文件), 10002-参数错误
+func (h *AdminHandler) ExportUsers(c *gin.Context) {
+	var req interfaces.UserListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		if isActive, err := strconv.ParseBool(isActiveStr); err == nil {
+			req.IsActive = &isActive
+		}
+	}
+	format := c.DefaultQuery("format", sheet.FormatXLSX)
+	if format != sheet.FormatCSV && format != sheet.FormatXLSX {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "format只能为csv或xlsx")
+		return
+	}
+
+	users, err := h.userService.ExportUsers(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	// 表头与导入文件一致，导出的文件修改后可直接导入
+	rows := [][]string{{"学号", "姓名", "班级", "年级", "用户名", "邮箱", "角色", "状态", "创建时间", "最后登录"}}
+	for _, user := range users {
+		status := "正常"
+		if !user.IsActive {
+			status = "已停用"
+		}
+		// 导入和统一身份认证生成的占位邮箱(.invalid)不导出，重新导入时会再次生成
+		email := user.Email
+		if strings.HasSuffix(email, ".invalid") {
+			email = ""
+		}
+		role, ok := roleLabels[user.Role]
+		if !ok {
+			role = user.Role // 自定义角色
+		}
+		lastLogin := ""
+		if user.LastLogin != nil {
+			lastLogin = user.LastLogin.Local().Format("2006-01-02 15:04:05")
+		}
+		rows = append(rows, []string{
+			user.StudentID, user.RealName, user.Class, user.Grade, user.Username, email,
+			role, status, user.CreatedAt.Local().Format("2006-01-02 15:04:05"), lastLogin,
+		})
+	}
+	sendSheet(c, fmt.Sprintf("users-%s", time.Now().Format("20060102-150405")), format, rows)
+}
+
+// roleLabels 导出文件中的角色名称
+var roleLabels = map[string]string{
+	model.RoleStudent: "学生",
+	model.RoleTA:      "助教",
+	model.RoleTeacher: "教师",
+	model.RoleAdmin:   "管理员",
+}
+
+// sendSheet 以附件形式返回表格
+func sendSheet(c *gin.Context, name, format string, rows [][]string) {
+	var buf bytes.Buffer
+	if err := sheet.Write(&buf, format, rows); err != nil {
+		utils.HandleError(c, errors.Wrap(errors.SYSTEM_ERROR, err))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Data(http.StatusOK, sheet.ContentType(format), buf.Bytes())
+}
+
+// ResetUserPassword 重置用户密码，未指定密码时随机生成
+// 用户使用临时密码登录后必须先修改密码，原有登录会话全部注销
+// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在, 20007-密码强度不足
+// PUT /api/v1/admin/users/{id}/reset-password
+func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+	var req interfaces.AdminResetPasswordRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.SendError(c, errors.INVALID_PARAMS)
+			return
+		}
+	}
+
+	result, err := h.passwordService.AdminResetPassword(c.Request.Context(), userID, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	utils.SendSuccess(c, result)
+}
+
+// UnlockUser 解除用户因登录失败过多被锁定的状态
+// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
+// PUT /api/v1/admin/users/{id}/unlock
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	unlocked, err := h.authService.UnlockUser(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, gin.H{"was_locked": unlocked})
+}
+
+// UnlockIP 解除IP因登录失败过多被锁定的状态
+// 响应码: 0-成功, 10002-参数错误
+// PUT /api/v1/admin/ips/{ip}/unlock
+func (h *AdminHandler) UnlockIP(c *gin.Context) {
+	ip := net.ParseIP(c.Param("ip"))
+	if ip == nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	unlocked, err := h.authService.UnlockIP(c.Request.Context(), ip.String())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, gin.H{"was_locked": unlocked})
+}
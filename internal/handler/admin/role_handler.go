@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListPermissions 获取全部权限及说明
+// 响应码: 0-成功, 10004-权限不足
+// GET /api/v1/admin/permissions
+func (h *AdminHandler) ListPermissions(c *gin.Context) {
+	utils.SendSuccess(c, h.roleService.ListPermissions())
+}
+
+// ListRoles 获取全部角色及其权限和用户数
+// 响应码: 0-成功, 10004-权限不足
+// GET /api/v1/admin/roles
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	utils.SendSuccess(c, roles)
+}
+
+// CreateRole 创建自定义角色
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 60011-角色已存在
+// POST /api/v1/admin/roles
+func (h *AdminHandler) CreateRole(c *gin.Context) {
+	var req interfaces.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	utils.SendSuccess(c, role)
+}
+
+// UpdateRole 修改角色的名称、说明和权限
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 60010-角色不存在, 60013-角色不可修改
+// PUT /api/v1/admin/roles/{name}
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	var req interfaces.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), c.Param("name"), &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	utils.SendSuccess(c, role)
+}
+
+// DeleteRole 删除自定义角色，或恢复内置角色的默认权限
+// 响应码: 0-成功, 10004-权限不足, 60010-角色不存在, 60012-角色仍有用户, 60013-角色不可修改
+// DELETE /api/v1/admin/roles/{name}
+func (h *AdminHandler) DeleteRole(c *gin.Context) {
+	if err := h.roleService.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	utils.SendSuccess(c, nil)
+}
+
+// AssignRole 修改用户角色，用户的登录会话全部注销
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 20001-用户不存在, 60010-角色不存在
+// PUT /api/v1/admin/users/{id}/role
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+	operatorID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+	var req interfaces.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	result, err := h.roleService.AssignRole(c.Request.Context(), operatorID, userID, req.Role)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	utils.SendSuccess(c, result)
+}
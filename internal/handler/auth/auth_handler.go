@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"net/http"
+
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/jwtauth"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuthHandler 认证控制器
+type AuthHandler struct {
+	authService     interfaces.AuthService
+	ssoService      interfaces.SSOService
+	passwordService interfaces.PasswordService
+	signer          *jwtauth.Signer
+}
+
+// NewAuthHandler 创建认证控制器实例
+func NewAuthHandler(authService interfaces.AuthService, ssoService interfaces.SSOService, passwordService interfaces.PasswordService, signer *jwtauth.Signer) *AuthHandler {
+	return &AuthHandler{
+		authService:     authService,
+		ssoService:      ssoService,
+		passwordService: passwordService,
+		signer:          signer,
+	}
+}
+
+func clientInfo(c *gin.Context) interfaces.ClientInfo {
+	return interfaces.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// currentUserID 当前登录用户ID
+func currentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+	if err != nil {
+		utils.SendError(c, errors.UNAUTHORIZED)
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
+
+// Register 用户注册
+// 响应码: 0-成功, 10002-参数错误, 20003-用户名已存在, 20004-邮箱已存在, 20005-学号已存在, 20007-密码强度不足
+// POST /api/v1/auth/register
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req interfaces.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	user, err := h.authService.Register(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, user)
+}
+
+// Login 用户登录，返回访问token和刷新token
+// 响应码: 0-成功, 10002-参数错误, 20008-用户已被禁用, 20010-登录失败, 20026-登录已锁定
+// POST /api/v1/auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req interfaces.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	pair, err := h.authService.Login(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, pair)
+}
+
+// RefreshToken 使用刷新token换取新的token，旧的刷新token随即失效
+// 响应码: 0-成功, 10002-参数错误, 10009-Token无效, 20008-用户已被禁用
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req interfaces.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	pair, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, pair)
+}
+
+// Logout 用户登出，注销当前会话
+// 响应码: 0-成功, 10003-未授权, 20011-登出失败
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), userID, middleware.GetSessionID(c)); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// ListSessions 获取当前用户的登录会话(设备)
+// 响应码: 0-成功, 10003-未授权
+// GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, sessions)
+}
+
+// RevokeSession 注销指定会话，该设备需要重新登录
+// 响应码: 0-成功, 10003-未授权, 20018-会话不存在
+// DELETE /api/v1/auth/sessions/{id}
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// RevokeOtherSessions 注销当前会话以外的全部会话
+// 响应码: 0-成功, 10003-未授权
+// DELETE /api/v1/auth/sessions
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.authService.RevokeOtherSessions(c.Request.Context(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, gin.H{"revoked": count})
+}
+
+// JWKS 签名公钥，其他服务按token头部的kid选择公钥校验token
+// 按JWKS标准格式返回，不使用统一响应结构；HMAC密钥不公开
+// GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.signer.JWKS())
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword 找回密码，向注册邮箱发送重置链接
+// 无论邮箱是否注册都返回成功
+// 响应码: 0-成功, 10002-参数错误
+// POST /api/v1/auth/password/forgot
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req interfaces.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(c.Request.Context(), &req, clientInfo(c)); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// ResetPassword 使用重置链接中的token设置新密码，成功后需要重新登录
+// 响应码: 0-成功, 10002-参数错误, 20007-密码强度不足, 20008-用户已被禁用, 20024-重置链接无效
+// POST /api/v1/auth/password/reset
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req interfaces.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(c.Request.Context(), &req); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
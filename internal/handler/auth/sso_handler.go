@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSOProviders 已配置的统一身份认证入口
+// 响应码: 0-成功
+// GET /api/v1/auth/sso/providers
+func (h *AuthHandler) SSOProviders(c *gin.Context) {
+	utils.SendSuccess(c, h.ssoService.Providers())
+}
+
+// SSOLogin 跳转到统一身份认证登录页
+// redirect为登录完成后跳转的前端地址，需在sso.allowed_redirects中；为空时回调直接返回JSON
+// 响应码: 302-跳转, 10002-参数错误, 20019-统一身份认证不存在
+// GET /api/v1/auth/sso/{provider}/login?redirect=
+func (h *AuthHandler) SSOLogin(c *gin.Context) {
+	loginURL, err := h.ssoService.LoginURL(c.Request.Context(), c.Param("provider"), c.Query("redirect"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, loginURL)
+}
+
+// SSOCallback 统一身份认证回调，登录成功后签发本系统的token
+// 发起登录时指定了redirect则跳转回前端，token或错误放在URL片段(#)中，否则返回JSON
+// 响应码: 0-成功, 20008-用户已被禁用, 20019-统一身份认证不存在, 20020-登录失败, 20021-账号未开通
+// GET /api/v1/auth/sso/{provider}/callback
+func (h *AuthHandler) SSOCallback(c *gin.Context) {
+	result, err := h.ssoService.Callback(c.Request.Context(), c.Param("provider"), c.Request.URL.Query(), clientInfo(c))
+	if result == nil || result.Redirect == "" {
+		if err != nil {
+			utils.HandleError(c, err)
+			return
+		}
+		utils.SendSuccess(c, result)
+		return
+	}
+
+	// 片段不会发送到服务器，也不会出现在Referer中
+	fragment := url.Values{}
+	if err != nil {
+		code, message := errors.SYSTEM_ERROR, errors.GetErrorMessage(errors.SYSTEM_ERROR)
+		if bizErr, ok := errors.GetBusinessError(err); ok {
+			code, message = bizErr.Code, bizErr.Message
+		}
+		fragment.Set("error", strconv.Itoa(code))
+		fragment.Set("error_description", message)
+	} else {
+		tokens := result.Tokens
+		fragment.Set("access_token", tokens.AccessToken)
+		fragment.Set("refresh_token", tokens.RefreshToken)
+		fragment.Set("token_type", tokens.TokenType)
+		fragment.Set("expires_in", strconv.FormatInt(tokens.ExpiresIn, 10))
+		fragment.Set("refresh_expires_in", strconv.FormatInt(tokens.RefreshExpiresIn, 10))
+		fragment.Set("session_id", tokens.SessionID)
+		fragment.Set("created", strconv.FormatBool(result.Created))
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, result.Redirect+"#"+fragment.Encode())
+}
@@ -0,0 +1,202 @@
+package contest
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContestHandler 竞赛控制器
+type ContestHandler struct {
+	contestService    interfaces.ContestService
+	scoreboardService interfaces.ScoreboardService
+}
+
+// NewContestHandler 创建竞赛控制器实例
+func NewContestHandler(contestService interfaces.ContestService, scoreboardService interfaces.ScoreboardService) *ContestHandler {
+	return &ContestHandler{
+		contestService:    contestService,
+		scoreboardService: scoreboardService,
+	}
+}
+
+// CreateContest 创建竞赛
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在
+// POST /api/v1/contests
+func (h *ContestHandler) CreateContest(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.CreateContestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	contest, err := h.contestService.CreateContest(c.Request.Context(), viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, contest)
+}
+
+// UpdateContest 更新竞赛，需有竞赛管理权限
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 70001-竞赛不存在
+// PUT /api/v1/contests/{id}
+func (h *ContestHandler) UpdateContest(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.UpdateContestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	contest, err := h.contestService.UpdateContest(c.Request.Context(), contestID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, contest)
+}
+
+// DeleteContest 删除竞赛，需有竞赛管理权限
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在
+// DELETE /api/v1/contests/{id}
+func (h *ContestHandler) DeleteContest(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.contestService.DeleteContest(c.Request.Context(), contestID, viewer); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// GetContest 获取竞赛详情，竞赛开始前对参赛者隐藏题目
+// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在
+// GET /api/v1/contests/{id}
+func (h *ContestHandler) GetContest(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.contestService.GetContest(c.Request.Context(), contestID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// ListContests 获取竞赛列表
+// 响应码: 0-成功, 10002-参数错误
+// GET /api/v1/contests?page=1&page_size=20&type=class&status=running&keyword=周赛
+func (h *ContestHandler) ListContests(c *gin.Context) {
+	var req interfaces.ContestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	response, err := h.contestService.ListContests(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccessWithPagination(c, response.Contests, response.Page, response.PageSize, response.Total)
+}
+
+// Register 报名竞赛
+// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70003-竞赛已结束, 70004-竞赛访问被拒绝
+// POST /api/v1/contests/{id}/register
+func (h *ContestHandler) Register(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.contestService.Register(c.Request.Context(), contestID, viewer); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// ListProblems 获取竞赛题目
+// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70002-竞赛未开始, 70004-竞赛访问被拒绝
+// GET /api/v1/contests/{id}/problems
+func (h *ContestHandler) ListProblems(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	problems, err := h.contestService.ListProblems(c.Request.Context(), contestID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, problems)
+}
+
+// currentViewer 获取当前登录用户，失败时已写入响应
+func currentViewer(c *gin.Context) (interfaces.Viewer, bool) {
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return interfaces.Viewer{}, false
+	}
+	return interfaces.Viewer{UserID: userID, Role: middleware.GetUserRole(c), Permissions: middleware.GetPermissions(c)}, true
+}
+
+// contestIDParam 解析路径中的竞赛ID，失败时已写入响应
+func contestIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	contestID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "竞赛ID格式错误")
+		return primitive.NilObjectID, false
+	}
+	return contestID, true
+}
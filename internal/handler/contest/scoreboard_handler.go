@@ -0,0 +1,74 @@
+package contest
+
+import (
+	"zhku-oj/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetScoreboard 获取竞赛排行榜
+// 封榜后没有竞赛管理权限的用户获取封榜视图；有管理权限的用户传frozen=true获取封榜视图
+// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70002-竞赛未开始
+// GET /api/v1/contests/{id}/scoreboard?frozen=true
+func (h *ContestHandler) GetScoreboard(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	standings, err := h.scoreboardService.GetScoreboard(c.Request.Context(), contestID, viewer, c.Query("frozen") == "true")
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, standings)
+}
+
+// ResolveStep 滚榜揭晓下一个封榜结果
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在, 70006-竞赛未结束, 70007-排行榜未封榜
+// POST /api/v1/contests/{id}/scoreboard/resolve
+func (h *ContestHandler) ResolveStep(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	step, err := h.scoreboardService.ResolveStep(c.Request.Context(), contestID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, step)
+}
+
+// Unfreeze 揭晓全部封榜结果
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在, 70006-竞赛未结束, 70007-排行榜未封榜
+// POST /api/v1/contests/{id}/scoreboard/unfreeze
+func (h *ContestHandler) Unfreeze(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	contestID, ok := contestIDParam(c)
+	if !ok {
+		return
+	}
+
+	standings, err := h.scoreboardService.Unfreeze(c.Request.Context(), contestID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, standings)
+}
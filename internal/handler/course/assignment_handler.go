@@ -0,0 +1,318 @@
+package course
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/sheet"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateAssignment 在课程中创建作业
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 80001-课程不存在
+// POST /api/v1/courses/{id}/assignments
+func (h *CourseHandler) CreateAssignment(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	detail, err := h.assignmentService.CreateAssignment(c.Request.Context(), courseID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// ListAssignments 获取课程的作业，学生只能看到已开放的作业
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}/assignments?page=1&page_size=20
+func (h *CourseHandler) ListAssignments(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.AssignmentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	response, err := h.assignmentService.ListAssignments(c.Request.Context(), courseID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccessWithPagination(c, response.Assignments, response.Page, response.PageSize, response.Total)
+}
+
+// GetAssignment 获取作业详情，课程学生同时返回本人的成绩
+// 响应码: 0-成功, 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在, 80006-作业尚未开放
+// GET /api/v1/assignments/{id}
+func (h *CourseHandler) GetAssignment(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.assignmentService.GetAssignment(c.Request.Context(), assignmentID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// UpdateAssignment 更新作业
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 80005-作业不存在
+// PUT /api/v1/assignments/{id}
+func (h *CourseHandler) UpdateAssignment(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.UpdateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	detail, err := h.assignmentService.UpdateAssignment(c.Request.Context(), assignmentID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// DeleteAssignment 删除作业
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80005-作业不存在
+// DELETE /api/v1/assignments/{id}
+func (h *CourseHandler) DeleteAssignment(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.assignmentService.DeleteAssignment(c.Request.Context(), assignmentID, viewer); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// SetExtension 为学生设置延期
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80005-作业不存在
+// PUT /api/v1/assignments/{id}/extensions
+func (h *CourseHandler) SetExtension(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.ExtensionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	extension, err := h.assignmentService.SetExtension(c.Request.Context(), assignmentID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, extension)
+}
+
+// RemoveExtension 取消学生的延期
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 10005-该学生没有延期, 80005-作业不存在
+// DELETE /api/v1/assignments/{id}/extensions/{user_id}
+func (h *CourseHandler) RemoveExtension(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "用户ID格式错误")
+		return
+	}
+
+	if err := h.assignmentService.RemoveExtension(c.Request.Context(), assignmentID, viewer, userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// GetGradebook 获取作业成绩册
+// 响应码: 0-成功, 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在
+// GET /api/v1/assignments/{id}/gradebook
+func (h *CourseHandler) GetGradebook(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	gradebook, err := h.assignmentService.GetGradebook(c.Request.Context(), assignmentID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, gradebook)
+}
+
+// ExportGradebook 导出作业成绩册
+// 默认导出教务系统成绩上传格式(学号、姓名、百分制成绩)，detail=true时导出各题得分
+// 响应码: 0-成功(文件), 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在
+// GET /api/v1/assignments/{id}/gradebook/export?format=csv&detail=false
+func (h *CourseHandler) ExportGradebook(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	assignmentID, ok := assignmentIDParam(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", sheet.FormatCSV)
+	if format != sheet.FormatCSV && format != sheet.FormatXLSX {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "format只能为csv或xlsx")
+		return
+	}
+	detail, _ := strconv.ParseBool(c.DefaultQuery("detail", "false"))
+
+	gradebook, err := h.assignmentService.GetGradebook(c.Request.Context(), assignmentID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var rows [][]string
+	if detail {
+		rows = gradebookDetailRows(gradebook)
+	} else {
+		rows = [][]string{{"学号", "姓名", "成绩"}}
+		for _, student := range gradebook.Students {
+			rows = append(rows, []string{student.StudentID, student.RealName, formatPoints(percentScore(student, gradebook))})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := sheet.Write(&buf, format, rows); err != nil {
+		utils.HandleError(c, errors.Wrap(errors.SYSTEM_ERROR, err))
+		return
+	}
+	name := fmt.Sprintf("gradebook-%s-%s", assignmentID.Hex(), time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Data(http.StatusOK, sheet.ContentType(format), buf.Bytes())
+}
+
+// gradebookDetailRows 成绩册明细：各题得分、总分、百分制成绩和延期
+func gradebookDetailRows(gradebook *interfaces.Gradebook) [][]string {
+	header := []string{"学号", "姓名", "班级"}
+	for i, problem := range gradebook.Problems {
+		title := problem.Title
+		if title == "" {
+			title = fmt.Sprintf("第%d题(已删除)", i+1)
+		}
+		header = append(header, fmt.Sprintf("%s(%d分)", title, problem.Points))
+	}
+	header = append(header, fmt.Sprintf("总分(%d分)", gradebook.MaxPoints), "成绩", "延期至")
+
+	rows := [][]string{header}
+	for _, student := range gradebook.Students {
+		row := []string{student.StudentID, student.RealName, student.Class}
+		for _, problem := range student.Problems {
+			row = append(row, formatPoints(problem.Points))
+		}
+		extended := ""
+		if student.Extended {
+			extended = student.DueTime.Local().Format("2006-01-02 15:04")
+		}
+		row = append(row, formatPoints(student.Total), formatPoints(percentScore(student, gradebook)), extended)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// percentScore 换算为百分制并取整，与教务系统成绩录入一致
+func percentScore(student *interfaces.StudentGrade, gradebook *interfaces.Gradebook) float64 {
+	if gradebook.MaxPoints <= 0 {
+		return 0
+	}
+	return math.Round(student.Total * 100 / float64(gradebook.MaxPoints))
+}
+
+// formatPoints 分数去掉多余的小数位
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// assignmentIDParam 解析路径中的作业ID，失败时已写入响应
+func assignmentIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "作业ID格式错误")
+		return primitive.NilObjectID, false
+	}
+	return assignmentID, true
+}
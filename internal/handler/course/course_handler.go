@@ -0,0 +1,370 @@
+package course
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CourseHandler 课程和作业控制器
+type CourseHandler struct {
+	courseService     interfaces.CourseService
+	assignmentService interfaces.AssignmentService
+}
+
+// NewCourseHandler 创建课程控制器实例
+func NewCourseHandler(courseService interfaces.CourseService, assignmentService interfaces.AssignmentService) *CourseHandler {
+	return &CourseHandler{
+		courseService:     courseService,
+		assignmentService: assignmentService,
+	}
+}
+
+// CreateCourse 创建课程，创建者作为课程的教师
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足
+// POST /api/v1/courses
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.CreateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	detail, err := h.courseService.CreateCourse(c.Request.Context(), viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// UpdateCourse 更新课程信息
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+// PUT /api/v1/courses/{id}
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.UpdateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	detail, err := h.courseService.UpdateCourse(c.Request.Context(), courseID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// DeleteCourse 删除课程
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+// DELETE /api/v1/courses/{id}
+func (h *CourseHandler) DeleteCourse(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.courseService.DeleteCourse(c.Request.Context(), courseID, viewer); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// GetCourse 获取课程详情
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.courseService.GetCourse(c.Request.Context(), courseID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// ListCourses 获取当前用户所在的课程，all=true时获取全部课程
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足
+// GET /api/v1/courses?page=1&page_size=20&term=2026-2027-1&keyword=Java&all=false
+func (h *CourseHandler) ListCourses(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.CourseListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	response, err := h.courseService.ListCourses(c.Request.Context(), viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccessWithPagination(c, response.Courses, response.Page, response.PageSize, response.Total)
+}
+
+// JoinCourse 通过邀请码加入课程
+// 响应码: 0-成功, 10002-参数错误, 80003-邀请码无效, 80004-课程已结课
+// POST /api/v1/courses/join
+func (h *CourseHandler) JoinCourse(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.JoinCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	detail, err := h.courseService.JoinCourse(c.Request.Context(), viewer, req.JoinCode)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// SetJoinCode 生成新的邀请码或停用邀请码
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+// PUT /api/v1/courses/{id}/join-code
+func (h *CourseHandler) SetJoinCode(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.JoinCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	detail, err := h.courseService.SetJoinCode(c.Request.Context(), courseID, viewer, req.Enabled)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, detail)
+}
+
+// ListMembers 获取课程成员
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}/members?role=student
+func (h *CourseHandler) ListMembers(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+	role := c.Query("role")
+	if role != "" && role != model.CourseRoleTeacher && role != model.CourseRoleTA && role != model.CourseRoleStudent {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "role只能为teacher、ta或student")
+		return
+	}
+
+	members, err := h.courseService.ListMembers(c.Request.Context(), courseID, viewer, role)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, members)
+}
+
+// AddMembers 按学号添加课程成员
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+// POST /api/v1/courses/{id}/members
+func (h *CourseHandler) AddMembers(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.AddCourseMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	result, err := h.courseService.AddMembers(c.Request.Context(), courseID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, result)
+}
+
+// RemoveMember 将用户移出课程
+// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 20001-用户不是课程成员, 80001-课程不存在
+// DELETE /api/v1/courses/{id}/members/{user_id}
+func (h *CourseHandler) RemoveMember(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "用户ID格式错误")
+		return
+	}
+
+	if err := h.courseService.RemoveMember(c.Request.Context(), courseID, viewer, userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, nil)
+}
+
+// ListSubmissions 获取课程学生的提交
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}/submissions?page=1&page_size=20&user_id=xxx&problem_id=xxx&status=ACCEPTED
+func (h *CourseHandler) ListSubmissions(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req interfaces.CourseSubmissionListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return
+	}
+
+	submissions, total, err := h.courseService.ListSubmissions(c.Request.Context(), courseID, viewer, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccessWithPagination(c, submissions, req.Page, req.PageSize, total)
+}
+
+// GetRanking 获取课程学生排名
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}/ranking
+func (h *CourseHandler) GetRanking(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	ranking, err := h.courseService.GetRanking(c.Request.Context(), courseID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, ranking)
+}
+
+// GetStats 获取课程统计
+// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+// GET /api/v1/courses/{id}/stats
+func (h *CourseHandler) GetStats(c *gin.Context) {
+	viewer, ok := currentViewer(c)
+	if !ok {
+		return
+	}
+	courseID, ok := courseIDParam(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.courseService.GetStats(c.Request.Context(), courseID, viewer)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, stats)
+}
+
+// currentViewer 获取当前登录用户，失败时已写入响应
+func currentViewer(c *gin.Context) (interfaces.Viewer, bool) {
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+	if err != nil {
+		utils.SendError(c, errors.INVALID_PARAMS)
+		return interfaces.Viewer{}, false
+	}
+	return interfaces.Viewer{UserID: userID, Role: middleware.GetUserRole(c), Permissions: middleware.GetPermissions(c)}, true
+}
+
+// courseIDParam 解析路径中的课程ID，失败时已写入响应
+func courseIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	courseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "课程ID格式错误")
+		return primitive.NilObjectID, false
+	}
+	return courseID, true
+}
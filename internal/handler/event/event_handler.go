@@ -0,0 +1,141 @@
+package event
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// pingInterval WebSocket ping和SSE心跳间隔，避免代理断开空闲连接
+	pingInterval = 30 * time.Second
+	// writeTimeout 单条消息的写超时
+	writeTimeout = 10 * time.Second
+	// pongTimeout 超过该时间未收到pong视为连接断开
+	pongTimeout = 2 * pingInterval
+)
+
+// upgrader 认证使用token而不是cookie，不存在跨站WebSocket劫持，跨域访问与CORS中间件保持一致
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// EventHandler 判题事件推送处理器
+// 向当前用户推送其提交的判题进度、编译结果、测试用例结果和最终结果
+type EventHandler struct {
+	hub *realtime.Hub
+}
+
+// NewEventHandler 创建判题事件推送处理器
+func NewEventHandler(hub *realtime.Hub) *EventHandler {
+	return &EventHandler{hub: hub}
+}
+
+// WebSocket 通过WebSocket推送判题事件
+// 浏览器无法设置WebSocket请求头，token可以通过access_token查询参数传递
+// 消息格式: {"type": "judge_progress", "data": {...}}，客户端发送的消息被忽略
+// 请求方法: GET
+// 路径: /api/v1/ws
+func (h *EventHandler) WebSocket(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade已向客户端返回错误响应
+		logger.Warn("WebSocket升级失败", "user_id", userID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	// 读取协程处理pong和关闭帧，连接断开时通知写循环退出
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case message, ok := <-events:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "服务器正在关闭"))
+				return
+			}
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Stream 通过SSE(Server-Sent Events)推送判题事件
+// 事件名为消息类型，数据为消息内容；EventSource无法设置请求头，token可以通过access_token查询参数传递
+// 请求方法: GET
+// 路径: /api/v1/submissions/events
+func (h *EventHandler) Stream(c *gin.Context) {
+	// 长连接不受服务器写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("清除SSE写超时失败", "error", err)
+	}
+
+	events, unsubscribe := h.hub.Subscribe(middleware.GetUserID(c))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭nginx缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case message, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(message.Type, message.Data)
+			return true
+		case <-ticker.C:
+			// 注释行作为心跳，EventSource会忽略
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return false
+			}
+			return true
+		}
+	})
+}
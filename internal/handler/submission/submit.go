@@ -1,73 +1,154 @@
 package submission
 
 import (
-	"net/http"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/language"
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
 	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
 	"zhku-oj/internal/service/interfaces"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Handler 代码提交处理器
-type Handler struct {
-	service interfaces.SubmissionService
+// SubmissionHandler 代码提交处理器
+type SubmissionHandler struct {
+	service      interfaces.SubmissionService
+	languages    *language.Registry
+	duplicates   *ratelimit.DuplicateGuard
+	limiter      *ratelimit.Limiter
+	submitPolicy config.RateLimitPolicy // 按用户和题目计数的部分(per_problem)，未开启限流时为空
 }
 
 // NewSubmissionHandler 创建代码提交处理器
-func NewSubmissionHandler(service interfaces.SubmissionService) *Handler {
-	return &Handler{
-		service: service,
+func NewSubmissionHandler(
+	service interfaces.SubmissionService,
+	languages *language.Registry,
+	duplicates *ratelimit.DuplicateGuard,
+	limiter *ratelimit.Limiter,
+	submitPolicy config.RateLimitPolicy,
+) *SubmissionHandler {
+	return &SubmissionHandler{
+		service:      service,
+		languages:    languages,
+		duplicates:   duplicates,
+		limiter:      limiter,
+		submitPolicy: submitPolicy,
 	}
 }
 
 // SubmitRequest 代码提交请求
 type SubmitRequest struct {
-	ProblemID string `json:"problem_id" binding:"required"`
-	Code      string `json:"code" binding:"required,max=50000"`
-	Language  string `json:"language" binding:"required,oneof=java"`
+	ProblemID    string `json:"problem_id" binding:"required"`
+	ContestID    string `json:"contest_id"`    // 竞赛提交时填写
+	AssignmentID string `json:"assignment_id"` // 作业提交时填写，只有作业提交计入作业成绩
+	Code         string `json:"code" binding:"required,max=50000"`
+	Language     string `json:"language" binding:"required"` // 取值见判题语言注册表
 }
 
 // Submit 提交代码接口
-// 用户提交解题代码，系统将进行在线判题
+// 用户提交解题代码，系统将进行在线判题；竞赛提交只能在竞赛进行中提交已报名竞赛的题目，
+// 作业提交只能在作业开放到关闭之间提交作业的题目
 // 请求方法: POST
 // 路径: /api/v1/submissions
-// 请求体: {"problem_id": "题目ID", "code": "源代码", "language": "编程语言"}
+// 请求体: {"problem_id": "题目ID", "contest_id": "竞赛ID(可选)", "assignment_id": "作业ID(可选)", "code": "源代码", "language": "编程语言"}
 // 响应: {"submission_id": "提交ID", "status": "PENDING"}
-func (h *Handler) Submit(c *gin.Context) {
+func (h *SubmissionHandler) Submit(c *gin.Context) {
 	var req SubmitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, err.Error())
+		return
+	}
+	if !h.languages.Supported(req.Language) {
+		utils.SendErrorWithDetail(c, errors.LANGUAGE_NOT_SUPPORTED, "可用语言: "+strings.Join(h.languages.IDs(), ", "))
 		return
 	}
 
 	// 获取用户ID
-	userIDStr := middleware.GetUserID(c)
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "用户ID格式错误")
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "用户ID格式错误")
 		return
 	}
 
-	// 验证题目ID
+	// 验证题目ID和竞赛ID
 	problemID, err := primitive.ObjectIDFromHex(req.ProblemID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "题目ID格式错误")
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "题目ID格式错误")
+		return
+	}
+	submitReq := &interfaces.SubmitRequest{
+		UserID:      userID,
+		Permissions: middleware.GetPermissions(c),
+		ProblemID:   problemID,
+		Code:        req.Code,
+		Language:    req.Language,
+	}
+	if req.ContestID != "" {
+		contestID, err := primitive.ObjectIDFromHex(req.ContestID)
+		if err != nil {
+			utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "竞赛ID格式错误")
+			return
+		}
+		submitReq.ContestID = &contestID
+	}
+	if req.AssignmentID != "" {
+		assignmentID, err := primitive.ObjectIDFromHex(req.AssignmentID)
+		if err != nil {
+			utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "作业ID格式错误")
+			return
+		}
+		submitReq.AssignmentID = &assignmentID
+	}
+
+	// 同一题目单独限流，避免对一道题反复试错占满判题队列；检查失败时不影响提交
+	ctx := c.Request.Context()
+	if rule := h.submitPolicy.ForRole(middleware.GetUserRole(c)); rule.PerProblem > 0 && rule.Window > 0 {
+		key := "submit:problem:user:" + userID.Hex() + ":" + problemID.Hex()
+		result, err := h.limiter.Allow(ctx, key, rule.PerProblem, rule.Window)
+		if err != nil {
+			logger.Warn("检查题目提交频率失败", "user_id", userID.Hex(), "error", err)
+		} else if !result.Allowed {
+			middleware.AbortTooManyRequests(c, result.RetryAfter, errors.SUBMISSION_TOO_FREQUENT,
+				fmt.Sprintf("同一题目%d秒内最多提交%d次，请稍后重试", int64(rule.Window/time.Second), rule.PerProblem))
+			return
+		}
+	}
+
+	// 拒绝短时间内重复提交相同代码；检查失败时不影响提交
+	content := []string{req.ProblemID, req.ContestID, req.AssignmentID, req.Language, req.Code}
+	acquired, wait, err := h.duplicates.Acquire(ctx, userID.Hex(), content...)
+	if err != nil {
+		logger.Warn("检查重复提交失败", "user_id", userID.Hex(), "error", err)
+	} else if !acquired {
+		seconds := middleware.SetRetryAfter(c, wait)
+		utils.SendErrorWithDetail(c, errors.DUPLICATE_SUBMISSION, fmt.Sprintf("请%d秒后再提交相同代码", seconds))
 		return
 	}
 
 	// 调用服务层处理提交
-	submission, err := h.service.Submit(c.Request.Context(), userID, problemID, req.Code, req.Language)
+	submission, err := h.service.Submit(ctx, submitReq)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "提交失败: "+err.Error())
+		// 提交未被受理，相同代码可以立即重新提交
+		if acquired {
+			if releaseErr := h.duplicates.Release(ctx, userID.Hex(), content...); releaseErr != nil {
+				logger.Warn("撤销重复提交记录失败", "user_id", userID.Hex(), "error", releaseErr)
+			}
+		}
+		utils.HandleError(c, err)
 		return
 	}
 
 	// 返回成功响应
-	utils.SuccessResponse(c, gin.H{
+	utils.SendSuccess(c, gin.H{
 		"submission_id": submission.ID.Hex(),
 		"status":        submission.Status,
 		"submitted_at":  submission.SubmittedAt,
@@ -79,42 +160,64 @@ func (h *Handler) Submit(c *gin.Context) {
 // 请求方法: GET
 // 路径: /api/v1/submissions/{id}
 // 响应: 提交详情包括状态、得分、测试结果等
-func (h *Handler) GetSubmission(c *gin.Context) {
-	submissionIDStr := c.Param("id")
-	submissionID, err := primitive.ObjectIDFromHex(submissionIDStr)
+func (h *SubmissionHandler) GetSubmission(c *gin.Context) {
+	submissionID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "提交ID格式错误")
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "提交ID格式错误")
 		return
 	}
 
 	// 获取当前用户ID
-	userIDStr := middleware.GetUserID(c)
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "用户ID格式错误")
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "用户ID格式错误")
 		return
 	}
 
 	// 获取提交详情
-	submission, err := h.service.GetSubmission(c.Request.Context(), submissionID, userID)
+	viewer := interfaces.Viewer{UserID: userID, Role: middleware.GetUserRole(c), Permissions: middleware.GetPermissions(c)}
+	submission, err := h.service.GetSubmission(c.Request.Context(), submissionID, viewer)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "提交不存在或无权限访问")
+		utils.HandleError(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, submission)
+	utils.SendSuccess(c, submission)
+}
+
+// RejudgeSubmission 重新判题接口
+// 提交回到PENDING，判题任务进入重判通道，结果通过实时推送发送给提交者
+// 请求方法: POST
+// 路径: /api/v1/submissions/{id}/rejudge
+// 响应: {"submission_id": "提交ID", "status": "PENDING"}
+func (h *SubmissionHandler) RejudgeSubmission(c *gin.Context) {
+	submissionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "提交ID格式错误")
+		return
+	}
+
+	submission, err := h.service.Rejudge(c.Request.Context(), submissionID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SendSuccess(c, gin.H{
+		"submission_id": submission.ID.Hex(),
+		"status":        submission.Status,
+	})
 }
 
 // ListSubmissions 获取提交列表接口
 // 获取用户的提交记录列表，支持分页和筛选
 // 请求方法: GET
-// 路径: /api/v1/submissions?page=1&page_size=20&problem_id=xxx&status=ACCEPTED
+// 路径: /api/v1/submissions?page=1&page_size=20&problem_id=xxx&contest_id=xxx&assignment_id=xxx&status=ACCEPTED
 // 响应: 分页的提交列表
-func (h *Handler) ListSubmissions(c *gin.Context) {
+func (h *SubmissionHandler) ListSubmissions(c *gin.Context) {
 	// 解析查询参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	problemIDStr := c.Query("problem_id")
 	status := c.Query("status")
 	language := c.Query("language")
 
@@ -130,10 +233,9 @@ func (h *Handler) ListSubmissions(c *gin.Context) {
 	}
 
 	// 获取当前用户ID
-	userIDStr := middleware.GetUserID(c)
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	userID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "用户ID格式错误")
+		utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, "用户ID格式错误")
 		return
 	}
 
@@ -142,13 +244,17 @@ func (h *Handler) ListSubmissions(c *gin.Context) {
 		"user_id": userID,
 	}
 
-	if problemIDStr != "" {
-		problemID, err := primitive.ObjectIDFromHex(problemIDStr)
+	for _, param := range []string{"problem_id", "contest_id", "assignment_id"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(value)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusBadRequest, "题目ID格式错误")
+			utils.SendErrorWithDetail(c, errors.INVALID_PARAMS, param+"格式错误")
 			return
 		}
-		filter["problem_id"] = problemID
+		filter[param] = id
 	}
 
 	if status != "" {
@@ -162,17 +268,9 @@ func (h *Handler) ListSubmissions(c *gin.Context) {
 	// 获取提交列表
 	submissions, total, err := h.service.ListSubmissions(c.Request.Context(), filter, page, pageSize)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "获取提交列表失败: "+err.Error())
+		utils.HandleError(c, err)
 		return
 	}
 
-	// 计算总页数
-	totalPages := (int(total) + pageSize - 1) / pageSize
-
-	utils.SuccessResponseWithPagination(c, submissions, utils.Pagination{
-		Page:       page,
-		PageSize:   pageSize,
-		Total:      total,
-		TotalPages: totalPages,
-	})
+	utils.SendSuccessWithPagination(c, submissions, page, pageSize, total)
 }
@@ -0,0 +1,201 @@
+package submission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/language"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// fakeSubmissionService 受理提交，err不为空时拒绝
+type fakeSubmissionService struct {
+	interfaces.SubmissionService
+	err       error
+	submitted int
+}
+
+func (s *fakeSubmissionService) Submit(ctx context.Context, req *interfaces.SubmitRequest) (*model.Submission, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.submitted++
+	return &model.Submission{ID: primitive.NewObjectID(), Status: model.StatusPending, SubmittedAt: time.Now()}, nil
+}
+
+type submitTest struct {
+	router  *gin.Engine
+	service *fakeSubmissionService
+	userID  string
+}
+
+func newSubmitTest(t *testing.T, policy config.RateLimitPolicy, duplicateWindow time.Duration) *submitTest {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	languages, err := language.NewRegistry(config.JudgeConfig{})
+	if err != nil {
+		t.Fatalf("创建语言注册表失败: %v", err)
+	}
+	st := &submitTest{service: &fakeSubmissionService{}, userID: primitive.NewObjectID().Hex()}
+	handler := NewSubmissionHandler(st.service, languages,
+		ratelimit.NewDuplicateGuard(client, duplicateWindow), ratelimit.NewLimiter(client), policy)
+
+	st.router = gin.New()
+	st.router.POST("/submissions", func(c *gin.Context) {
+		c.Set("user_id", st.userID)
+		c.Set("role", "student")
+	}, handler.Submit)
+	return st
+}
+
+// submit 提交代码，返回HTTP状态码、响应和Retry-After秒数
+func (st *submitTest) submit(t *testing.T, problemID, code string) (int, utils.Response, int) {
+	t.Helper()
+	body, _ := json.Marshal(SubmitRequest{ProblemID: problemID, Language: model.LanguageJava, Code: code})
+	req := httptest.NewRequest(http.MethodPost, "/submissions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	st.router.ServeHTTP(w, req)
+
+	var resp utils.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v (%s)", err, w.Body.String())
+	}
+	retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+	return w.Code, resp, retryAfter
+}
+
+// TestSubmitPerProblemLimit 同一题目的提交按用户和题目单独限流
+func TestSubmitPerProblemLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    config.RateLimitPolicy
+		submits   int
+		wantCodes []int // 各次提交的错误码
+	}{
+		{
+			name:      "per problem limit",
+			policy:    config.RateLimitPolicy{Limit: 10, Window: time.Minute, PerProblem: 2},
+			submits:   3,
+			wantCodes: []int{errors.SUCCESS, errors.SUCCESS, errors.SUBMISSION_TOO_FREQUENT},
+		},
+		{
+			name:      "no per problem limit",
+			policy:    config.RateLimitPolicy{Limit: 10, Window: time.Minute},
+			submits:   3,
+			wantCodes: []int{errors.SUCCESS, errors.SUCCESS, errors.SUCCESS},
+		},
+		{
+			name: "role override without per problem limit",
+			policy: config.RateLimitPolicy{Limit: 10, Window: time.Minute, PerProblem: 1,
+				Roles: map[string]config.RateLimitRule{"student": {Limit: 60}}},
+			submits:   2,
+			wantCodes: []int{errors.SUCCESS, errors.SUCCESS},
+		},
+		{
+			name:      "rate limit disabled",
+			policy:    config.RateLimitPolicy{},
+			submits:   2,
+			wantCodes: []int{errors.SUCCESS, errors.SUCCESS},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSubmitTest(t, tt.policy, 0)
+			problemID := primitive.NewObjectID().Hex()
+			for i := 0; i < tt.submits; i++ {
+				status, resp, retryAfter := st.submit(t, problemID, "class Main {} // "+strconv.Itoa(i))
+				if resp.Code != tt.wantCodes[i] {
+					t.Fatalf("第%d次提交错误码 = %d, 期望 %d (%s)", i+1, resp.Code, tt.wantCodes[i], resp.Message)
+				}
+				if resp.Code == errors.SUBMISSION_TOO_FREQUENT {
+					if status != http.StatusTooManyRequests || retryAfter < 1 || retryAfter > 60 {
+						t.Errorf("状态码 = %d, Retry-After = %d, 期望429和1-60秒", status, retryAfter)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestSubmitPerProblemLimitOtherProblem 一道题达到上限后其他题目仍可提交
+func TestSubmitPerProblemLimitOtherProblem(t *testing.T) {
+	st := newSubmitTest(t, config.RateLimitPolicy{Limit: 10, Window: time.Minute, PerProblem: 1}, 0)
+	first, second := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+
+	if _, resp, _ := st.submit(t, first, "a"); resp.Code != errors.SUCCESS {
+		t.Fatalf("首次提交错误码 = %d", resp.Code)
+	}
+	if _, resp, _ := st.submit(t, first, "b"); resp.Code != errors.SUBMISSION_TOO_FREQUENT {
+		t.Fatalf("同一题目第二次提交错误码 = %d, 期望 %d", resp.Code, errors.SUBMISSION_TOO_FREQUENT)
+	}
+	if _, resp, _ := st.submit(t, second, "b"); resp.Code != errors.SUCCESS {
+		t.Errorf("其他题目提交错误码 = %d, 期望成功", resp.Code)
+	}
+	if st.service.submitted != 2 {
+		t.Errorf("受理%d次提交, 期望2次", st.service.submitted)
+	}
+}
+
+// TestSubmitDuplicate 窗口内重复提交相同代码返回DUPLICATE_SUBMISSION和Retry-After
+func TestSubmitDuplicate(t *testing.T) {
+	st := newSubmitTest(t, config.RateLimitPolicy{}, 10*time.Second)
+	problemID := primitive.NewObjectID().Hex()
+
+	if _, resp, _ := st.submit(t, problemID, "class Main {}"); resp.Code != errors.SUCCESS {
+		t.Fatalf("首次提交错误码 = %d", resp.Code)
+	}
+	_, resp, retryAfter := st.submit(t, problemID, "class Main {}")
+	if resp.Code != errors.DUPLICATE_SUBMISSION {
+		t.Fatalf("重复提交错误码 = %d, 期望 %d", resp.Code, errors.DUPLICATE_SUBMISSION)
+	}
+	if retryAfter < 1 || retryAfter > 10 {
+		t.Errorf("Retry-After = %d, 期望1-10秒", retryAfter)
+	}
+	if _, resp, _ := st.submit(t, problemID, "class Main { }"); resp.Code != errors.SUCCESS {
+		t.Errorf("修改代码后提交错误码 = %d, 期望成功", resp.Code)
+	}
+}
+
+// TestSubmitDuplicateReleased 提交未被受理时撤销重复提交记录
+func TestSubmitDuplicateReleased(t *testing.T) {
+	st := newSubmitTest(t, config.RateLimitPolicy{}, 10*time.Second)
+	problemID := primitive.NewObjectID().Hex()
+
+	st.service.err = errors.New(errors.PROBLEM_NOT_FOUND)
+	if _, resp, _ := st.submit(t, problemID, "class Main {}"); resp.Code != errors.PROBLEM_NOT_FOUND {
+		t.Fatalf("错误码 = %d, 期望 %d", resp.Code, errors.PROBLEM_NOT_FOUND)
+	}
+	st.service.err = nil
+	if _, resp, _ := st.submit(t, problemID, "class Main {}"); resp.Code != errors.SUCCESS {
+		t.Errorf("未受理后重新提交错误码 = %d, 期望成功", resp.Code)
+	}
+}
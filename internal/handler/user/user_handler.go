@@ -5,6 +5,7 @@ import (
 	"zhku-oj/internal/middleware"
 	"zhku-oj/internal/pkg/errors"
 	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/rbac"
 	"zhku-oj/internal/service/interfaces"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +25,7 @@ func NewUserHandler(userService interfaces.UserService) *UserHandler {
 }
 
 // CreateUser 创建用户 (类似Spring的@PostMapping)
+// 创建学生以外角色的用户需要role:manage权限
 // POST /api/v1/admin/users
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req interfaces.CreateUserRequest
@@ -31,6 +33,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		utils.SendError(c, errors.INVALID_PARAMS)
 		return
 	}
+	req.Permissions = middleware.GetPermissions(c)
 
 	user, err := h.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
@@ -79,14 +82,20 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// 权限检查：只能修改自己的信息，或管理员可以修改任何人
-	currentUserID := middleware.GetUserID(c)
-	currentUserRole := middleware.GetUserRole(c)
-
-	if currentUserID != userID.Hex() && currentUserRole != "admin" {
+	// 权限检查：只能修改自己的信息，拥有user:manage时可以修改任何人；角色通过修改用户角色接口修改
+	perms := middleware.GetPermissions(c)
+	if middleware.GetUserID(c) != userID.Hex() && !perms.Has(rbac.UserManage) {
 		utils.SendError(c, errors.FORBIDDEN)
 		return
 	}
+	if req.StudentIDVerified != nil && !perms.Has(rbac.UserManage) {
+		utils.SendErrorWithDetail(c, errors.FORBIDDEN, "核实学号需要user:manage权限")
+		return
+	}
+	if req.Class != "" && !perms.Has(rbac.UserManage) {
+		utils.SendErrorWithDetail(c, errors.FORBIDDEN, "修改班级需要user:manage权限")
+		return
+	}
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID, &req)
 	if err != nil {
@@ -189,8 +198,9 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// 普通用户不能修改角色
-	req.Role = ""
+	// 普通用户不能自行核实学号，也不能修改班级(班级限制班级竞赛的报名)
+	req.StudentIDVerified = nil
+	req.Class = ""
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID, &req)
 	if err != nil {
@@ -202,7 +212,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 }
 
 // ChangePassword 修改密码
-// 响应码: 0-成功, 10002-参数错误, 20013-旧密码不正确
+// 响应码: 0-成功, 10002-参数错误, 20007-密码强度不足, 20013-旧密码不正确
 // PUT /api/v1/users/password
 func (h *UserHandler) ChangePassword(c *gin.Context) {
 	userIDStr := middleware.GetUserID(c)
@@ -0,0 +1,90 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/rbac"
+	"zhku-oj/internal/service/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// fakeUserService 记录更新请求
+type fakeUserService struct {
+	interfaces.UserService
+	updated *interfaces.UpdateUserRequest
+}
+
+func (s *fakeUserService) UpdateUser(ctx context.Context, id primitive.ObjectID, req *interfaces.UpdateUserRequest) (*model.User, error) {
+	s.updated = req
+	return &model.User{ID: id}, nil
+}
+
+// TestUpdateClass 班级限制班级竞赛的报名，用户不能自行修改，拥有user:manage时可以修改任何人的班级
+func TestUpdateClass(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	tests := []struct {
+		name      string
+		path      string
+		perms     rbac.Set
+		wantCode  int
+		wantClass string
+	}{
+		{name: "profile", path: "/users/profile", perms: rbac.NewSet()},
+		{name: "profile with user manage", path: "/users/profile", perms: rbac.NewSet(rbac.UserManage)},
+		{name: "update self", path: "/users/" + userID, perms: rbac.NewSet(), wantCode: errors.FORBIDDEN},
+		{name: "update with user manage", path: "/users/" + userID, perms: rbac.NewSet(rbac.UserManage), wantClass: "计科2101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeUserService{}
+			handler := NewUserHandler(service)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", userID)
+				c.Set("permissions", tt.perms)
+			})
+			router.PUT("/users/profile", handler.UpdateProfile)
+			router.PUT("/users/:id", handler.UpdateUser)
+
+			w := httptest.NewRecorder()
+			body := bytes.NewBufferString(`{"real_name": "张三", "class": "计科2101"}`)
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, tt.path, body))
+			var resp utils.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Fatalf("错误码 = %d, 期望 %d", resp.Code, tt.wantCode)
+			}
+			if tt.wantCode != errors.SUCCESS {
+				if service.updated != nil {
+					t.Error("被拒绝时不应更新用户")
+				}
+				return
+			}
+			if service.updated.Class != tt.wantClass || service.updated.RealName != "张三" {
+				t.Errorf("更新请求 = %+v, 期望班级 %q", service.updated, tt.wantClass)
+			}
+		})
+	}
+}
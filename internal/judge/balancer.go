@@ -0,0 +1,249 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/gojudge"
+	"zhku-oj/internal/pkg/logger"
+)
+
+// 沙箱默认参数
+const (
+	defaultSandboxWeight        = 1
+	defaultSandboxMaxConcurrent = 10
+	defaultSandboxTimeout       = 30 * time.Second
+	defaultHealthCheckInterval  = 10 * time.Second
+	unhealthyThreshold          = 2 // 连续探测失败次数达到该值时摘除实例
+)
+
+// ErrNoSandbox 等待可用沙箱超时
+var ErrNoSandbox = errors.New("没有可用的沙箱实例")
+
+// Sandbox go-judge沙箱实例
+type Sandbox struct {
+	URL           string
+	Weight        int
+	MaxConcurrent int
+	Timeout       time.Duration
+	Client        *gojudge.Client
+
+	healthInterval time.Duration
+
+	// 以下字段由Balancer.mu保护
+	inflight int
+	healthy  bool
+	failures int
+}
+
+// SandboxStatus 沙箱实例状态
+type SandboxStatus struct {
+	URL           string `json:"url"`
+	Weight        int    `json:"weight"`
+	MaxConcurrent int    `json:"max_concurrent"`
+	Inflight      int    `json:"inflight"`
+	Healthy       bool   `json:"healthy"`
+}
+
+// Balancer 沙箱负载均衡器
+// 按加权最少连接选择沙箱：inflight/weight最小的健康实例优先，
+// 每个实例的并发不超过max_concurrent；全部实例满载时等待，直到有实例释放或ctx结束
+type Balancer struct {
+	mu        sync.Mutex
+	sandboxes []*Sandbox
+	available chan struct{} // 有实例释放或恢复时关闭并重建，唤醒所有等待者
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewBalancer 创建沙箱负载均衡器并启动健康检查
+func NewBalancer(cfgs []config.SandboxConfig) (*Balancer, error) {
+	if len(cfgs) == 0 {
+		return nil, errors.New("未配置沙箱实例")
+	}
+
+	b := &Balancer{
+		available: make(chan struct{}),
+		stop:      make(chan struct{}),
+	}
+	for i, cfg := range cfgs {
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("第%d个沙箱未配置URL", i+1)
+		}
+		sandbox := &Sandbox{
+			URL:            strings.TrimRight(cfg.URL, "/"),
+			Weight:         cfg.Weight,
+			MaxConcurrent:  cfg.MaxConcurrent,
+			Timeout:        cfg.Timeout,
+			healthInterval: cfg.HealthCheckInterval,
+			healthy:        true, // 启动时视为健康，由首次探测确认
+		}
+		if sandbox.Weight <= 0 {
+			sandbox.Weight = defaultSandboxWeight
+		}
+		if sandbox.MaxConcurrent <= 0 {
+			sandbox.MaxConcurrent = defaultSandboxMaxConcurrent
+		}
+		if sandbox.Timeout <= 0 {
+			sandbox.Timeout = defaultSandboxTimeout
+		}
+		if sandbox.healthInterval <= 0 {
+			sandbox.healthInterval = defaultHealthCheckInterval
+		}
+		sandbox.Client = gojudge.NewClient(sandbox.URL, sandbox.Timeout)
+		b.sandboxes = append(b.sandboxes, sandbox)
+	}
+
+	for _, sandbox := range b.sandboxes {
+		b.wg.Add(1)
+		go b.healthLoop(sandbox)
+	}
+	return b, nil
+}
+
+// SelectSandbox 选择沙箱实例并占用一个并发名额
+// 全部实例满载或不可用时阻塞等待，ctx结束时返回错误；使用完毕后必须调用Release
+func (b *Balancer) SelectSandbox(ctx context.Context) (*Sandbox, error) {
+	for {
+		b.mu.Lock()
+		sandbox := b.pickLocked()
+		if sandbox != nil {
+			sandbox.inflight++
+			b.mu.Unlock()
+			return sandbox, nil
+		}
+		available := b.available
+		b.mu.Unlock()
+
+		select {
+		case <-available:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrNoSandbox, ctx.Err())
+		}
+	}
+}
+
+// TryAcquire 在指定实例上再占用一个并发名额，实例不健康或已满载时立即返回false
+// 用于同一提交在编译产物所在的实例上并发运行测试用例；成功后必须调用Release
+func (b *Balancer) TryAcquire(sandbox *Sandbox) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !sandbox.healthy || sandbox.inflight >= sandbox.MaxConcurrent {
+		return false
+	}
+	sandbox.inflight++
+	return true
+}
+
+// Release 释放沙箱实例的并发名额
+func (b *Balancer) Release(sandbox *Sandbox) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sandbox.inflight > 0 {
+		sandbox.inflight--
+	}
+	b.notifyLocked()
+}
+
+// Status 各沙箱实例当前状态
+func (b *Balancer) Status() []SandboxStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	statuses := make([]SandboxStatus, 0, len(b.sandboxes))
+	for _, sandbox := range b.sandboxes {
+		statuses = append(statuses, SandboxStatus{
+			URL:           sandbox.URL,
+			Weight:        sandbox.Weight,
+			MaxConcurrent: sandbox.MaxConcurrent,
+			Inflight:      sandbox.inflight,
+			Healthy:       sandbox.healthy,
+		})
+	}
+	return statuses
+}
+
+// Close 停止健康检查
+func (b *Balancer) Close() {
+	close(b.stop)
+	b.wg.Wait()
+}
+
+// pickLocked 选出inflight/weight最小且未满载的健康实例，没有时返回nil
+func (b *Balancer) pickLocked() *Sandbox {
+	var best *Sandbox
+	for _, sandbox := range b.sandboxes {
+		if !sandbox.healthy || sandbox.inflight >= sandbox.MaxConcurrent {
+			continue
+		}
+		// 比较 inflight/weight，交叉相乘避免浮点运算
+		if best == nil || sandbox.inflight*best.Weight < best.inflight*sandbox.Weight {
+			best = sandbox
+		}
+	}
+	return best
+}
+
+// notifyLocked 唤醒所有等待沙箱的调用方
+func (b *Balancer) notifyLocked() {
+	close(b.available)
+	b.available = make(chan struct{})
+}
+
+// healthLoop 定期探测沙箱实例
+func (b *Balancer) healthLoop(sandbox *Sandbox) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(sandbox.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		b.setHealth(sandbox, b.probe(sandbox))
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe 请求沙箱的/version接口
+func (b *Balancer) probe(sandbox *Sandbox) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sandbox.Timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	_, err := sandbox.Client.Version(ctx)
+	return err
+}
+
+// setHealth 根据探测结果摘除或恢复实例
+func (b *Balancer) setHealth(sandbox *Sandbox, probeErr error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probeErr == nil {
+		sandbox.failures = 0
+		if !sandbox.healthy {
+			sandbox.healthy = true
+			logger.Info("沙箱实例已恢复", "url", sandbox.URL)
+			b.notifyLocked()
+		}
+		return
+	}
+
+	sandbox.failures++
+	if sandbox.healthy && sandbox.failures >= unhealthyThreshold {
+		sandbox.healthy = false
+		logger.Warn("沙箱实例健康检查失败，已摘除", "url", sandbox.URL, "error", probeErr)
+	}
+}
@@ -0,0 +1,115 @@
+package judge
+
+import (
+	"context"
+	"sync"
+
+	"zhku-oj/internal/model"
+)
+
+// caseFunc 运行单个测试用例并生成结果，需支持并发调用
+type caseFunc func(ctx context.Context, testCase model.TestCase) (model.TestResult, error)
+
+// caseStop 判断用例结果是否使后续用例不再需要运行
+type caseStop func(result model.TestResult) bool
+
+// caseFailed 用例未通过
+func caseFailed(result model.TestResult) bool {
+	return result.Status != model.StatusAccepted
+}
+
+// caseScoredZero 用例得0分，min策略的子任务得分已确定为0
+func caseScoredZero(result model.TestResult) bool {
+	return caseScoreRatio(result) == 0
+}
+
+// caseExecutor 在编译产物所在的沙箱上并发运行测试用例
+// 提交本身占用一个并发名额，额外的并发只在沙箱有空闲名额时占用，最多parallelism个用例同时运行
+type caseExecutor struct {
+	balancer    *Balancer
+	sandbox     *Sandbox
+	parallelism int
+	processor   *ResultProcessor
+	run         caseFunc
+}
+
+// runCases 运行一组测试用例，结果与testCases一一对应
+// stop不为nil时，第一个(按顺序)满足stop的用例之后的用例不再运行，结果记为SKIPPED；
+// 任一用例沙箱出错时取消其余用例并返回error
+func (e *caseExecutor) runCases(ctx context.Context, testCases []model.TestCase, stop caseStop) ([]model.TestResult, error) {
+	if len(testCases) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		results  = make([]model.TestResult, len(testCases))
+		finished = make([]bool, len(testCases))
+		next     = 0
+		failed   = len(testCases) // 已知最靠前的满足stop的用例
+		firstErr error
+	)
+	worker := func() {
+		for {
+			mu.Lock()
+			if firstErr != nil || next >= len(testCases) || next > failed {
+				mu.Unlock()
+				return
+			}
+			i := next
+			next++
+			mu.Unlock()
+
+			result, err := e.run(ctx, testCases[i])
+
+			mu.Lock()
+			switch {
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			default:
+				results[i], finished[i] = result, true
+				if stop != nil && stop(result) && i < failed {
+					failed = i
+				}
+			}
+			mu.Unlock()
+		}
+	}
+
+	// 额外占用的名额在本组用例运行结束后释放
+	workers := 1
+	for workers < e.parallelism && workers < len(testCases) && e.balancer.TryAcquire(e.sandbox) {
+		workers++
+	}
+	defer func() {
+		for i := 1; i < workers; i++ {
+			e.balancer.Release(e.sandbox)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker()
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// 已开始运行但位于失败用例之后的结果同样丢弃，保证结果与顺序运行一致
+	for i := range results {
+		if !finished[i] || i > failed {
+			results[i] = e.processor.SkippedResult(testCases[i])
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,375 @@
+package judge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/language"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+)
+
+// testlib约定的checker退出码
+const (
+	checkerExitOK            = 0
+	checkerExitWrongAnswer   = 1
+	checkerExitPresentation  = 2
+	checkerExitFail          = 3
+	checkerExitDirt          = 4  // 输出末尾有多余内容
+	checkerExitPoints        = 7  // quitp：部分得分，输出"points <得分率> <说明>"
+	checkerExitPartially     = 16 // quitf(_pc(n))：部分得分，退出码为16+n，n为百分比
+	defaultCheckerLanguage   = model.LanguageCpp
+	defaultCheckerCacheSize  = 64
+	defaultCheckerCPULimit   = 5000000000 // 5秒
+	defaultCheckerMemLimit   = 268435456  // 256MB
+	checkerInputFile         = "input.txt"
+	checkerOutputFile        = "output.txt"
+	checkerAnswerFile        = "answer.txt"
+	checkerTestlibHeaderFile = "testlib.h"
+)
+
+// checker结论
+const (
+	CheckerVerdictOK                = "OK"
+	CheckerVerdictWrongAnswer       = "WRONG_ANSWER"
+	CheckerVerdictPresentationError = "PRESENTATION_ERROR"
+	CheckerVerdictPartiallyCorrect  = "PARTIALLY_CORRECT"
+)
+
+// CheckResult checker判定结果
+type CheckResult struct {
+	Status     string  // model.Status*
+	Verdict    string  // CheckerVerdict*
+	ScoreRatio float64 // 得分率(0~1)
+	Message    string
+	ExitStatus int
+}
+
+// Checker 已编译、缓存在沙箱中的特殊判题程序，可并发调用Check
+type Checker struct {
+	cache *checkerCache
+	key   string
+
+	mu    sync.Mutex
+	entry *checkerEntry
+}
+
+// Check 运行checker判定选手输出
+// 沙箱中的checker文件失效时重新编译并重试一次；checker自身出错(退出码3或运行异常)返回error
+func (c *Checker) Check(ctx context.Context, input, answer, output string) (*CheckResult, error) {
+	c.mu.Lock()
+	entry := c.entry
+	c.mu.Unlock()
+
+	result, err := c.check(ctx, entry, input, answer, output)
+	if errors.Is(err, errCachedFileLost) {
+		logger.Warn("checker缓存文件已失效，重新编译", "key", c.key)
+		c.cache.invalidate(c.key, entry)
+		entry, err = c.cache.compile(ctx, c.key, entry.judge, entry.code)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.entry = entry
+		c.mu.Unlock()
+		return c.check(ctx, entry, input, answer, output)
+	}
+	return result, err
+}
+
+func (c *Checker) check(ctx context.Context, entry *checkerEntry, input, answer, output string) (*CheckResult, error) {
+	files := map[string]string{
+		checkerInputFile:  input,
+		checkerOutputFile: output,
+		checkerAnswerFile: answer,
+	}
+	args := []string{checkerInputFile, checkerOutputFile, checkerAnswerFile}
+	run, err := entry.judge.run(ctx, entry.compiled, "", c.cache.limits, args, files)
+	if err != nil {
+		return nil, fmt.Errorf("运行checker失败: %w", err)
+	}
+
+	result := &CheckResult{
+		ExitStatus: run.ExitStatus,
+		Message:    strings.TrimSpace(run.Stderr),
+	}
+	if result.Message == "" {
+		result.Message = strings.TrimSpace(run.Output)
+	}
+
+	if run.Status != model.StatusAccepted && run.Status != model.StatusRuntimeError {
+		return nil, fmt.Errorf("checker运行异常(%s): %s", run.GoJudgeStatus, result.Message)
+	}
+	if err := result.judge(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// judge 按testlib退出码设置结论和得分率
+// 部分得分时得分率为1记为通过，为0记为答案错误，否则为PARTIAL_ACCEPTED
+func (r *CheckResult) judge() error {
+	switch {
+	case r.ExitStatus == checkerExitOK:
+		r.Status, r.Verdict, r.ScoreRatio = model.StatusAccepted, CheckerVerdictOK, 1
+		return nil
+	case r.ExitStatus == checkerExitWrongAnswer:
+		r.Status, r.Verdict = model.StatusWrongAnswer, CheckerVerdictWrongAnswer
+		return nil
+	case r.ExitStatus == checkerExitPresentation, r.ExitStatus == checkerExitDirt:
+		r.Status, r.Verdict = model.StatusPresentationError, CheckerVerdictPresentationError
+		return nil
+	case r.ExitStatus == checkerExitFail:
+		return fmt.Errorf("checker判定失败: %s", r.Message)
+	case r.ExitStatus == checkerExitPoints:
+		ratio, err := parseCheckerPoints(r.Message)
+		if err != nil {
+			return err
+		}
+		r.ScoreRatio = ratio
+	case r.ExitStatus >= checkerExitPartially && r.ExitStatus <= checkerExitPartially+100:
+		r.ScoreRatio = float64(r.ExitStatus-checkerExitPartially) / 100
+	default:
+		return fmt.Errorf("checker返回未知退出码%d: %s", r.ExitStatus, r.Message)
+	}
+
+	r.Verdict = CheckerVerdictPartiallyCorrect
+	switch r.ScoreRatio {
+	case 1:
+		r.Status = model.StatusAccepted
+	case 0:
+		r.Status = model.StatusWrongAnswer
+	default:
+		r.Status = model.StatusPartialAccepted
+	}
+	return nil
+}
+
+// parseCheckerPoints 解析quitp输出的得分率，格式为"points <得分率> <说明>"
+func parseCheckerPoints(message string) (float64, error) {
+	fields := strings.Fields(strings.TrimPrefix(message, "points"))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("checker未输出得分: %s", message)
+	}
+	ratio, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || !(ratio >= 0 && ratio <= 1) {
+		return 0, fmt.Errorf("checker输出的得分率无效(应为0~1): %s", message)
+	}
+	return ratio, nil
+}
+
+// checkerCache 已编译checker缓存
+// 同一沙箱实例上相同的checker只编译一次，超过容量时淘汰最久未使用的并删除沙箱中的文件
+type checkerCache struct {
+	languages   *language.Registry
+	fileManager *FileManager
+	testlib     string
+	limits      RunLimits
+	capacity    int
+
+	mu      sync.Mutex
+	entries map[string]*checkerEntry // key: 沙箱地址 + checker代码摘要
+}
+
+// checkerEntry 缓存项，ready关闭后compiled/err可读，lastUsed由checkerCache.mu保护
+type checkerEntry struct {
+	ready    chan struct{}
+	judge    *LanguageJudge
+	code     string
+	compiled *CompileResult
+	err      error
+	lastUsed time.Time
+}
+
+// newCheckerCache 创建checker缓存，读取testlib.h
+func newCheckerCache(cfg config.CheckerConfig, languages *language.Registry, fileManager *FileManager) (*checkerCache, error) {
+	c := &checkerCache{
+		languages:   languages,
+		fileManager: fileManager,
+		limits: RunLimits{
+			CPULimit:    cfg.CPULimit,
+			MemoryLimit: cfg.MemoryLimit,
+		},
+		capacity: cfg.CacheSize,
+		entries:  make(map[string]*checkerEntry),
+	}
+	if c.limits.CPULimit <= 0 {
+		c.limits.CPULimit = defaultCheckerCPULimit
+	}
+	if c.limits.MemoryLimit <= 0 {
+		c.limits.MemoryLimit = defaultCheckerMemLimit
+	}
+	if c.capacity <= 0 {
+		c.capacity = defaultCheckerCacheSize
+	}
+
+	if cfg.TestlibPath != "" {
+		content, err := os.ReadFile(cfg.TestlibPath)
+		switch {
+		case err == nil:
+			c.testlib = string(content)
+		case os.IsNotExist(err):
+			logger.Warn("未找到testlib.h，checker无法使用testlib", "path", cfg.TestlibPath)
+		default:
+			return nil, fmt.Errorf("读取testlib.h失败: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// get 获取题目在该沙箱上的checker，未编译时编译并缓存
+func (c *checkerCache) get(ctx context.Context, sandbox *Sandbox, spec *model.Checker) (*Checker, error) {
+	if spec == nil || strings.TrimSpace(spec.Code) == "" {
+		return nil, errors.New("题目开启了特殊判题但未配置checker")
+	}
+	langID := spec.Language
+	if langID == "" {
+		langID = defaultCheckerLanguage
+	}
+	lang, ok := c.languages.Get(langID)
+	if !ok {
+		return nil, fmt.Errorf("checker使用了不支持的语言: %s", langID)
+	}
+
+	sum := sha256.Sum256([]byte(langID + "\x00" + spec.Code))
+	key := sandbox.URL + "#" + hex.EncodeToString(sum[:])
+
+	entry, err := c.compile(ctx, key, NewLanguageJudge(sandbox, lang), spec.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{cache: c, key: key, entry: entry}, nil
+}
+
+// compile 编译checker并放入缓存，并发请求同一checker时只编译一次
+// 缓存中只保留编译中或编译成功的项，编译失败的项会被移除，下次请求重新编译
+func (c *checkerCache) compile(ctx context.Context, key string, judge *LanguageJudge, code string) (*checkerEntry, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		entry.lastUsed = time.Now()
+		c.mu.Unlock()
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return entry, entry.err
+	}
+	entry := &checkerEntry{
+		ready:    make(chan struct{}),
+		judge:    judge,
+		code:     code,
+		lastUsed: time.Now(),
+	}
+	c.entries[key] = entry
+	evicted := c.evictLocked()
+	c.mu.Unlock()
+
+	for _, old := range evicted {
+		c.release(old)
+	}
+
+	var extraFiles map[string]string
+	if c.testlib != "" {
+		extraFiles = map[string]string{checkerTestlibHeaderFile: c.testlib}
+	}
+	compiled, err := judge.compile(ctx, code, extraFiles)
+	if err == nil && compiled.Status != model.StatusAccepted {
+		err = fmt.Errorf("checker编译失败: %s", compiled.ErrorMessage)
+	}
+
+	c.mu.Lock()
+	entry.compiled, entry.err = compiled, err
+	if err != nil && c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	close(entry.ready)
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("checker编译完成", "sandbox", judge.client.BaseURL(), "language", judge.lang.ID)
+	return entry, nil
+}
+
+// invalidate 移除失效的缓存项
+func (c *checkerCache) invalidate(key string, entry *checkerEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+}
+
+// evictLocked 超过容量时移除最久未使用的已编译项
+func (c *checkerCache) evictLocked() []*checkerEntry {
+	var evicted []*checkerEntry
+	for len(c.entries) > c.capacity {
+		var oldestKey string
+		var oldest *checkerEntry
+		for key, entry := range c.entries {
+			if !isClosed(entry.ready) {
+				continue
+			}
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldestKey, oldest = key, entry
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(c.entries, oldestKey)
+		evicted = append(evicted, oldest)
+	}
+	return evicted
+}
+
+// release 删除沙箱中缓存的checker文件
+func (c *checkerCache) release(entry *checkerEntry) {
+	if entry.compiled == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupRequestTimeout)
+	defer cancel()
+	for _, fileID := range entry.compiled.FileIDs {
+		if err := entry.judge.CleanupFile(ctx, fileID); err != nil {
+			c.fileManager.Defer(entry.judge.client, fileID)
+		}
+	}
+}
+
+// close 删除全部缓存的checker文件
+func (c *checkerCache) close() {
+	c.mu.Lock()
+	entries := make([]*checkerEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if isClosed(entry.ready) {
+			entries = append(entries, entry)
+		}
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	for _, entry := range entries {
+		c.release(entry)
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
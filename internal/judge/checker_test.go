@@ -0,0 +1,97 @@
+package judge
+
+import (
+	"testing"
+
+	"zhku-oj/internal/model"
+)
+
+func TestCheckResultJudge(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitStatus  int
+		message     string
+		wantStatus  string
+		wantVerdict string
+		wantRatio   float64
+		wantErr     bool
+	}{
+		{name: "ok", exitStatus: 0, wantStatus: model.StatusAccepted, wantVerdict: CheckerVerdictOK, wantRatio: 1},
+		{name: "wrong answer", exitStatus: 1, wantStatus: model.StatusWrongAnswer, wantVerdict: CheckerVerdictWrongAnswer},
+		{name: "presentation error", exitStatus: 2,
+			wantStatus: model.StatusPresentationError, wantVerdict: CheckerVerdictPresentationError},
+		{name: "dirt", exitStatus: 4, message: "wrong output format Extra information in the output file",
+			wantStatus: model.StatusPresentationError, wantVerdict: CheckerVerdictPresentationError},
+		{name: "fail", exitStatus: 3, message: "FAIL answer is invalid", wantErr: true},
+		{name: "points", exitStatus: 7, message: "points 0.25 3 of 12 queries",
+			wantStatus: model.StatusPartialAccepted, wantVerdict: CheckerVerdictPartiallyCorrect, wantRatio: 0.25},
+		{name: "full points", exitStatus: 7, message: "points 1",
+			wantStatus: model.StatusAccepted, wantVerdict: CheckerVerdictPartiallyCorrect, wantRatio: 1},
+		{name: "zero points", exitStatus: 7, message: "points 0 nothing found",
+			wantStatus: model.StatusWrongAnswer, wantVerdict: CheckerVerdictPartiallyCorrect},
+		{name: "points missing", exitStatus: 7, message: "points", wantErr: true},
+		{name: "points invalid", exitStatus: 7, message: "points abc", wantErr: true},
+		{name: "points out of range", exitStatus: 7, message: "points 30", wantErr: true},
+		{name: "points nan", exitStatus: 7, message: "points NaN", wantErr: true},
+		{name: "partially", exitStatus: 16 + 40, message: "partially correct (40) too slow",
+			wantStatus: model.StatusPartialAccepted, wantVerdict: CheckerVerdictPartiallyCorrect, wantRatio: 0.4},
+		{name: "partially zero", exitStatus: 16,
+			wantStatus: model.StatusWrongAnswer, wantVerdict: CheckerVerdictPartiallyCorrect},
+		{name: "partially full", exitStatus: 16 + 100,
+			wantStatus: model.StatusAccepted, wantVerdict: CheckerVerdictPartiallyCorrect, wantRatio: 1},
+		{name: "partially out of range", exitStatus: 16 + 101, wantErr: true},
+		{name: "unknown", exitStatus: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &CheckResult{ExitStatus: tt.exitStatus, Message: tt.message}
+			err := result.judge()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("judge = %v, 期望返回error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Status != tt.wantStatus || result.Verdict != tt.wantVerdict || result.ScoreRatio != tt.wantRatio {
+				t.Errorf("结果 = %s/%s/%v, 期望 %s/%s/%v", result.Status, result.Verdict, result.ScoreRatio,
+					tt.wantStatus, tt.wantVerdict, tt.wantRatio)
+			}
+		})
+	}
+}
+
+func TestTestCaseResultScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        string
+		check      *CheckResult
+		wantStatus string
+		wantScore  int
+		wantRatio  float64
+	}{
+		{name: "accepted", run: model.StatusAccepted,
+			check: &CheckResult{Status: model.StatusAccepted, ScoreRatio: 1}, wantStatus: model.StatusAccepted, wantScore: 10, wantRatio: 1},
+		{name: "partial", run: model.StatusAccepted,
+			check: &CheckResult{Status: model.StatusPartialAccepted, ScoreRatio: 0.3}, wantStatus: model.StatusPartialAccepted, wantScore: 3, wantRatio: 0.3},
+		{name: "partial rounds down", run: model.StatusAccepted,
+			check: &CheckResult{Status: model.StatusPartialAccepted, ScoreRatio: 0.25}, wantStatus: model.StatusPartialAccepted, wantScore: 2, wantRatio: 0.25},
+		{name: "wrong answer", run: model.StatusAccepted,
+			check: &CheckResult{Status: model.StatusWrongAnswer}, wantStatus: model.StatusWrongAnswer},
+		{name: "time limit exceeded", run: model.StatusTimeLimitExceeded, wantStatus: model.StatusTimeLimitExceeded},
+	}
+
+	processor := NewResultProcessor()
+	testCase := model.TestCase{ID: "c1", Output: "1\n", Score: 10}
+	// 特殊判题和运行未通过时不比对输出，不需要comparator
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &RunResult{Status: tt.run, Output: "1\n"}
+			got := processor.TestCaseResult(testCase, run, nil, tt.check)
+			if got.Status != tt.wantStatus || got.Score != tt.wantScore || got.ScoreRatio != tt.wantRatio {
+				t.Errorf("用例结果 = %s/%d/%v, 期望 %s/%d/%v", got.Status, got.Score, got.ScoreRatio,
+					tt.wantStatus, tt.wantScore, tt.wantRatio)
+			}
+		})
+	}
+}
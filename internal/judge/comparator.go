@@ -0,0 +1,238 @@
+package judge
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"zhku-oj/internal/model"
+)
+
+// 比对默认值
+const (
+	defaultFloatEpsilon = 1e-6
+	diffLineMaxRunes    = 64 // 差异摘要中每行最多展示的字符数
+)
+
+// CompareResult 输出比对结果
+type CompareResult struct {
+	Status string // ACCEPTED、WRONG_ANSWER 或 PRESENTATION_ERROR
+	Diff   string // 第一处不一致的摘要，通过时为空
+}
+
+// Comparator 输出比对器
+type Comparator interface {
+	Compare(expected, actual string) CompareResult
+}
+
+// NewComparator 根据题目配置创建输出比对器，模式为空时使用lines
+func NewComparator(cfg model.OutputComparison) (Comparator, error) {
+	switch cfg.Mode {
+	case model.CompareExact:
+		return exactComparator{}, nil
+	case "", model.CompareLines:
+		return lineComparator{}, nil
+	case model.CompareCaseInsensitive:
+		return lineComparator{ignoreCase: true}, nil
+	case model.CompareTokens:
+		return tokenComparator{}, nil
+	case model.CompareFloat:
+		if cfg.AbsEpsilon < 0 || cfg.RelEpsilon < 0 {
+			return nil, fmt.Errorf("浮点比对误差不能为负数")
+		}
+		c := tokenComparator{float: true, absEpsilon: cfg.AbsEpsilon, relEpsilon: cfg.RelEpsilon}
+		if c.absEpsilon == 0 && c.relEpsilon == 0 {
+			c.absEpsilon = defaultFloatEpsilon
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("不支持的输出比对模式: %s", cfg.Mode)
+	}
+}
+
+// exactComparator 逐字节比对，单词一致时判为格式错误
+type exactComparator struct{}
+
+func (exactComparator) Compare(expected, actual string) CompareResult {
+	if expected == actual {
+		return CompareResult{Status: model.StatusAccepted}
+	}
+	return mismatch(splitLines(expected), splitLines(actual), sameTokens(expected, actual, false), exactLine)
+}
+
+// lineComparator 逐行比对，忽略行末空白和末尾空行，单词一致时判为格式错误
+type lineComparator struct {
+	ignoreCase bool
+}
+
+func (c lineComparator) Compare(expected, actual string) CompareResult {
+	expectedLines := trimLines(splitLines(expected))
+	actualLines := trimLines(splitLines(actual))
+	if len(expectedLines) == len(actualLines) {
+		equal := true
+		for i := range expectedLines {
+			if !c.equal(expectedLines[i], actualLines[i]) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return CompareResult{Status: model.StatusAccepted}
+		}
+	}
+	return mismatch(expectedLines, actualLines, sameTokens(expected, actual, c.ignoreCase), c.equal)
+}
+
+func (c lineComparator) equal(a, b string) bool {
+	if c.ignoreCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// tokenComparator 按空白分隔的单词比对，float为true时数值按误差比较
+type tokenComparator struct {
+	float      bool
+	absEpsilon float64
+	relEpsilon float64
+}
+
+func (c tokenComparator) Compare(expected, actual string) CompareResult {
+	expectedTokens := tokenize(expected)
+	actualTokens := tokenize(actual)
+	for i := 0; i < len(expectedTokens) || i < len(actualTokens); i++ {
+		if i >= len(expectedTokens) || i >= len(actualTokens) || !c.equal(expectedTokens[i].text, actualTokens[i].text) {
+			return CompareResult{
+				Status: model.StatusWrongAnswer,
+				Diff:   tokenDiff(expected, actual, expectedTokens, actualTokens, i),
+			}
+		}
+	}
+	return CompareResult{Status: model.StatusAccepted}
+}
+
+func (c tokenComparator) equal(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	if !c.float {
+		return false
+	}
+	e, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil || math.IsNaN(a) || math.IsInf(a, 0) {
+		return false
+	}
+	diff := math.Abs(a - e)
+	return diff <= c.absEpsilon || diff <= c.relEpsilon*math.Abs(e)
+}
+
+// token 单词及其所在行号(从0开始)
+type token struct {
+	text string
+	line int
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	for i, line := range splitLines(s) {
+		for _, field := range strings.Fields(line) {
+			tokens = append(tokens, token{text: field, line: i})
+		}
+	}
+	return tokens
+}
+
+// sameTokens 两段输出按空白分隔后是否一致
+func sameTokens(expected, actual string, ignoreCase bool) bool {
+	e, a := strings.Fields(expected), strings.Fields(actual)
+	if len(e) != len(a) {
+		return false
+	}
+	for i := range e {
+		if e[i] != a[i] && !(ignoreCase && strings.EqualFold(e[i], a[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func exactLine(a, b string) bool {
+	return a == b
+}
+
+// mismatch 生成逐行比对失败的结果
+func mismatch(expectedLines, actualLines []string, presentation bool, equal func(a, b string) bool) CompareResult {
+	result := CompareResult{Status: model.StatusWrongAnswer}
+	if presentation {
+		result.Status = model.StatusPresentationError
+	}
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		if i >= len(expectedLines) || i >= len(actualLines) || !equal(expectedLines[i], actualLines[i]) {
+			result.Diff = lineDiff(expectedLines, actualLines, i)
+			return result
+		}
+	}
+	result.Diff = "输出末尾的换行不同"
+	return result
+}
+
+// tokenDiff 单词比对失败时，展示第一个不一致单词所在的行
+func tokenDiff(expected, actual string, expectedTokens, actualTokens []token, index int) string {
+	expectedLines, actualLines := splitLines(expected), splitLines(actual)
+	switch {
+	case index >= len(actualTokens):
+		return fmt.Sprintf("第%d行: 期望 %s, 实际输出已结束", expectedTokens[index].line+1, quoteLine(expectedLines[expectedTokens[index].line]))
+	case index >= len(expectedTokens):
+		return fmt.Sprintf("第%d行: 期望输出已结束, 实际 %s", actualTokens[index].line+1, quoteLine(actualLines[actualTokens[index].line]))
+	default:
+		return fmt.Sprintf("第%d行: 期望 %s, 实际 %s", actualTokens[index].line+1,
+			quoteLine(expectedLines[expectedTokens[index].line]), quoteLine(actualLines[actualTokens[index].line]))
+	}
+}
+
+// lineDiff 第index行(从0开始)的差异摘要
+func lineDiff(expectedLines, actualLines []string, index int) string {
+	switch {
+	case index >= len(actualLines):
+		return fmt.Sprintf("第%d行: 期望 %s, 实际输出已结束", index+1, quoteLine(expectedLines[index]))
+	case index >= len(expectedLines):
+		return fmt.Sprintf("第%d行: 期望输出已结束, 实际 %s", index+1, quoteLine(actualLines[index]))
+	default:
+		return fmt.Sprintf("第%d行: 期望 %s, 实际 %s", index+1, quoteLine(expectedLines[index]), quoteLine(actualLines[index]))
+	}
+}
+
+// quoteLine 截断过长的行并加引号，空白字符转义后可见
+func quoteLine(line string) string {
+	runes := []rune(line)
+	if len(runes) > diffLineMaxRunes {
+		return strconv.Quote(string(runes[:diffLineMaxRunes])) + "..."
+	}
+	return strconv.Quote(line)
+}
+
+// splitLines 按换行拆分，保留每行的其他字符(包括\r)
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// trimLines 去除每行行末空白和末尾空行
+func trimLines(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(trimmed) > 0 && trimmed[len(trimmed)-1] == "" {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	return trimmed
+}
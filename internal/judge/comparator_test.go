@@ -0,0 +1,119 @@
+package judge
+
+import (
+	"strings"
+	"testing"
+
+	"zhku-oj/internal/model"
+)
+
+func TestComparator(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	tests := []struct {
+		name       string
+		cfg        model.OutputComparison
+		expected   string
+		actual     string
+		wantStatus string
+		wantDiff   string // 通过时为空
+	}{
+		// exact
+		{name: "exact identical", cfg: model.OutputComparison{Mode: model.CompareExact},
+			expected: "1 2\n3\n", actual: "1 2\n3\n", wantStatus: model.StatusAccepted},
+		{name: "exact missing final newline", cfg: model.OutputComparison{Mode: model.CompareExact},
+			expected: "1 2\n", actual: "1 2", wantStatus: model.StatusPresentationError, wantDiff: "输出末尾的换行不同"},
+		{name: "exact extra space", cfg: model.OutputComparison{Mode: model.CompareExact},
+			expected: "1 2\n", actual: "1  2\n", wantStatus: model.StatusPresentationError, wantDiff: `第1行: 期望 "1 2", 实际 "1  2"`},
+		{name: "exact trailing space", cfg: model.OutputComparison{Mode: model.CompareExact},
+			expected: "1 2\n", actual: "1 2 \n", wantStatus: model.StatusPresentationError, wantDiff: `第1行: 期望 "1 2", 实际 "1 2 "`},
+		{name: "exact wrong value", cfg: model.OutputComparison{Mode: model.CompareExact},
+			expected: "1 2\n", actual: "1 3\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1 2", 实际 "1 3"`},
+
+		// lines，模式为空时默认
+		{name: "lines ignores trailing whitespace and blank lines",
+			expected: "1 2\n3\n", actual: "1 2  \r\n3\t\r\n\n\n", wantStatus: model.StatusAccepted},
+		{name: "lines empty output", expected: "", actual: "\n\n", wantStatus: model.StatusAccepted},
+		{name: "lines inner spaces", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: "1 2\n3\n", actual: "1  2\n3\n", wantStatus: model.StatusPresentationError, wantDiff: `第1行: 期望 "1 2", 实际 "1  2"`},
+		{name: "lines joined", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: "1\n2\n", actual: "1 2\n", wantStatus: model.StatusPresentationError, wantDiff: `第1行: 期望 "1", 实际 "1 2"`},
+		{name: "lines missing line", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: "1\n2\n", actual: "1\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第2行: 期望 "2", 实际输出已结束`},
+		{name: "lines extra line", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: "1\n", actual: "1\n2\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第2行: 期望输出已结束, 实际 "2"`},
+		{name: "lines case differs", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: "YES\n", actual: "yes\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "YES", 实际 "yes"`},
+		{name: "lines long line truncated", cfg: model.OutputComparison{Mode: model.CompareLines},
+			expected: long + "\n", actual: "b\n", wantStatus: model.StatusWrongAnswer,
+			wantDiff: `第1行: 期望 "` + long[:diffLineMaxRunes] + `"..., 实际 "b"`},
+
+		// case_insensitive
+		{name: "case insensitive", cfg: model.OutputComparison{Mode: model.CompareCaseInsensitive},
+			expected: "YES\nNo\n", actual: "yes\nNO  \n", wantStatus: model.StatusAccepted},
+		{name: "case insensitive inner spaces", cfg: model.OutputComparison{Mode: model.CompareCaseInsensitive},
+			expected: "Yes No\n", actual: "yes  no\n", wantStatus: model.StatusPresentationError, wantDiff: `第1行: 期望 "Yes No", 实际 "yes  no"`},
+		{name: "case insensitive wrong", cfg: model.OutputComparison{Mode: model.CompareCaseInsensitive},
+			expected: "YES\n", actual: "NO\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "YES", 实际 "NO"`},
+
+		// tokens
+		{name: "tokens ignore layout", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1 2\n3\n", actual: "1\n2   3", wantStatus: model.StatusAccepted},
+		{name: "tokens wrong value", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1 2 3\n", actual: "1 2 4\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1 2 3", 实际 "1 2 4"`},
+		{name: "tokens diff on actual line", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1 2\n3\n", actual: "1\n2\n4\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第3行: 期望 "3", 实际 "4"`},
+		{name: "tokens output ended", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1 2\n3\n", actual: "1 2\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第2行: 期望 "3", 实际输出已结束`},
+		{name: "tokens extra output", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1\n", actual: "1\n2\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第2行: 期望输出已结束, 实际 "2"`},
+		{name: "tokens numbers compared as text", cfg: model.OutputComparison{Mode: model.CompareTokens},
+			expected: "1.0\n", actual: "1\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1.0", 实际 "1"`},
+
+		// float
+		{name: "float default epsilon", cfg: model.OutputComparison{Mode: model.CompareFloat},
+			expected: "0.3333333\n", actual: "0.333333333\n", wantStatus: model.StatusAccepted},
+		{name: "float beyond default epsilon", cfg: model.OutputComparison{Mode: model.CompareFloat},
+			expected: "1.0\n", actual: "1.00001\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1.0", 实际 "1.00001"`},
+		{name: "float absolute epsilon", cfg: model.OutputComparison{Mode: model.CompareFloat, AbsEpsilon: 1e-3},
+			expected: "3.1416\n", actual: "3.1415926\n", wantStatus: model.StatusAccepted},
+		{name: "float relative epsilon", cfg: model.OutputComparison{Mode: model.CompareFloat, RelEpsilon: 1e-6},
+			expected: "1000000\n", actual: "1000000.5\n", wantStatus: model.StatusAccepted},
+		{name: "float relative epsilon too small", cfg: model.OutputComparison{Mode: model.CompareFloat, RelEpsilon: 1e-6},
+			expected: "1\n", actual: "1.5\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1", 实际 "1.5"`},
+		{name: "float scientific notation", cfg: model.OutputComparison{Mode: model.CompareFloat},
+			expected: "1.5e3\n", actual: "1500.0000001\n", wantStatus: model.StatusAccepted},
+		{name: "float mixed with text", cfg: model.OutputComparison{Mode: model.CompareFloat},
+			expected: "Case 1: 2.5\n", actual: "Case 1: 2.5000000001\n", wantStatus: model.StatusAccepted},
+		{name: "float text differs", cfg: model.OutputComparison{Mode: model.CompareFloat},
+			expected: "Case 1: 2.5\n", actual: "case 1: 2.5\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "Case 1: 2.5", 实际 "case 1: 2.5"`},
+		{name: "float nan", cfg: model.OutputComparison{Mode: model.CompareFloat, AbsEpsilon: 1e9},
+			expected: "1\n", actual: "NaN\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1", 实际 "NaN"`},
+		{name: "float inf", cfg: model.OutputComparison{Mode: model.CompareFloat, AbsEpsilon: 1e9},
+			expected: "1\n", actual: "+Inf\n", wantStatus: model.StatusWrongAnswer, wantDiff: `第1行: 期望 "1", 实际 "+Inf"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparator, err := NewComparator(tt.cfg)
+			if err != nil {
+				t.Fatalf("创建比对器失败: %v", err)
+			}
+			got := comparator.Compare(tt.expected, tt.actual)
+			if got.Status != tt.wantStatus || got.Diff != tt.wantDiff {
+				t.Errorf("Compare(%q, %q) = %+v, 期望 {Status:%s Diff:%s}", tt.expected, tt.actual, got, tt.wantStatus, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestNewComparatorInvalid(t *testing.T) {
+	for _, cfg := range []model.OutputComparison{
+		{Mode: "regex"},
+		{Mode: model.CompareFloat, AbsEpsilon: -1},
+		{Mode: model.CompareFloat, RelEpsilon: -1e-6},
+	} {
+		if _, err := NewComparator(cfg); err == nil {
+			t.Errorf("NewComparator(%+v) 期望返回error", cfg)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package judge
+
+import (
+	"context"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/realtime"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// publishEvent 向提交用户推送判题事件
+// 推送失败不影响判题，客户端可通过提交详情接口获取结果
+func (m *Manager) publishEvent(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) {
+	if err := m.events.Publish(ctx, userID, eventType, data); err != nil {
+		logger.Warn("推送判题事件失败", "user_id", userID.Hex(), "type", eventType, "error", err)
+	}
+}
+
+// publishStage 推送判题阶段变化
+func (m *Manager) publishStage(ctx context.Context, task *queue.JudgeTask, stage string, current, total int) {
+	m.publishEvent(ctx, task.UserID, realtime.EventJudgeProgress, &realtime.JudgeProgress{
+		SubmissionID:    task.SubmissionID.Hex(),
+		Stage:           stage,
+		CurrentTestCase: current,
+		TotalTestCases:  total,
+		Timestamp:       time.Now(),
+	})
+}
+
+// publishCompileResult 推送编译结果
+func (m *Manager) publishCompileResult(ctx context.Context, task *queue.JudgeTask, compileResult *CompileResult) {
+	event := &realtime.CompileResult{
+		SubmissionID:  task.SubmissionID.Hex(),
+		CompileStatus: "SUCCESS",
+		TimeUsed:      compileResult.Time,
+		MemoryUsed:    compileResult.Memory,
+		Timestamp:     time.Now(),
+	}
+	if compileResult.Status != model.StatusAccepted {
+		event.CompileStatus = "FAILED"
+		event.Message = compileResult.ErrorMessage
+	}
+	m.publishEvent(ctx, task.UserID, realtime.EventCompileResult, event)
+}
+
+// publishTestCaseResult 推送单个测试用例结果，不包含测试数据和程序输出
+func (m *Manager) publishTestCaseResult(ctx context.Context, task *queue.JudgeTask, result *model.TestResult, index, completed, total int) {
+	m.publishEvent(ctx, task.UserID, realtime.EventTestCaseResult, &realtime.TestCaseResult{
+		SubmissionID:   task.SubmissionID.Hex(),
+		TestCaseID:     result.TestCaseID,
+		TestCaseIndex:  index,
+		CompletedCases: completed,
+		TotalTestCases: total,
+		Status:         result.Status,
+		Score:          result.Score,
+		TimeUsed:       result.TimeUsed,
+		MemoryUsed:     result.MemoryUsed,
+		Timestamp:      time.Now(),
+	})
+}
+
+// publishSubmissionResult 推送已落库的最终结果
+func (m *Manager) publishSubmissionResult(ctx context.Context, result *queue.JudgeResult) {
+	event := &realtime.SubmissionResult{
+		SubmissionID: result.SubmissionID.Hex(),
+		Status:       result.Status,
+		Score:        result.Score,
+		TimeUsed:     result.TimeUsed,
+		MemoryUsed:   result.MemoryUsed,
+		JudgedAt:     result.JudgedAt,
+	}
+	if result.CompileInfo.Status != "" {
+		compileInfo := result.CompileInfo
+		event.CompileInfo = &compileInfo
+	}
+	m.publishEvent(ctx, result.UserID, realtime.EventSubmissionResult, event)
+}
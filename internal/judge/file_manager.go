@@ -0,0 +1,119 @@
+package judge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/gojudge"
+	"zhku-oj/internal/pkg/logger"
+)
+
+// 缓存文件清理参数
+const (
+	defaultCleanupInterval = 5 * time.Minute
+	maxCleanupAttempts     = 5
+	cleanupRequestTimeout  = 10 * time.Second
+)
+
+// FileManager 沙箱缓存文件管理器
+// 判题结束后未能删除的缓存文件(如沙箱短暂不可用)在此登记，定期重试删除，避免沙箱中文件堆积
+type FileManager struct {
+	cfg     config.FileManagementConfig
+	mu      sync.Mutex
+	pending map[string]*leakedFile // key: 沙箱地址 + 文件ID
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// leakedFile 待重试删除的缓存文件
+type leakedFile struct {
+	client   *gojudge.Client
+	fileID   string
+	attempts int
+}
+
+// NewFileManager 创建文件管理器
+func NewFileManager(cfg config.FileManagementConfig) *FileManager {
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+	return &FileManager{
+		cfg:     cfg,
+		pending: make(map[string]*leakedFile),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Defer 登记删除失败的缓存文件，由清理协程重试
+func (f *FileManager) Defer(client *gojudge.Client, fileID string) {
+	if fileID == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := client.BaseURL() + "/" + fileID
+	if _, ok := f.pending[key]; !ok {
+		f.pending[key] = &leakedFile{client: client, fileID: fileID}
+	}
+}
+
+// Pending 待删除的缓存文件数
+func (f *FileManager) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// Start 启动清理协程，未开启auto_cleanup时不启动
+func (f *FileManager) Start() {
+	if !f.cfg.AutoCleanup {
+		return
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		ticker := time.NewTicker(f.cfg.CleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				f.cleanup()
+			}
+		}
+	}()
+}
+
+// Close 停止清理协程
+func (f *FileManager) Close() {
+	close(f.stop)
+	f.wg.Wait()
+}
+
+// cleanup 重试删除登记的缓存文件，超过最大次数后放弃
+func (f *FileManager) cleanup() {
+	f.mu.Lock()
+	files := make(map[string]*leakedFile, len(f.pending))
+	for key, file := range f.pending {
+		files[key] = file
+	}
+	f.mu.Unlock()
+
+	for key, file := range files {
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupRequestTimeout)
+		err := file.client.DeleteFile(ctx, file.fileID)
+		cancel()
+
+		f.mu.Lock()
+		if err == nil || err == gojudge.ErrFileNotFound {
+			delete(f.pending, key)
+		} else if file.attempts++; file.attempts >= maxCleanupAttempts {
+			delete(f.pending, key)
+			logger.Error("多次删除缓存文件失败，放弃清理", "sandbox", file.client.BaseURL(), "file_id", file.fileID, "error", err)
+		}
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,128 @@
+package gojudge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrFileNotFound 缓存文件不存在
+var ErrFileNotFound = errors.New("go-judge文件不存在")
+
+// APIError go-judge返回的非200响应
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("go-judge返回状态码%d: %s", e.StatusCode, e.Message)
+}
+
+// Client go-judge HTTP客户端
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient 创建go-judge客户端，timeout为单次请求超时时间
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        10,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
+	}
+}
+
+// BaseURL 沙箱地址
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Run 执行命令 POST /run，返回结果与请求中的命令一一对应
+func (c *Client) Run(ctx context.Context, req *Request) ([]Result, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("序列化go-judge请求失败: %w", err)
+	}
+
+	var results []Result
+	if err := c.do(ctx, http.MethodPost, "/run", bytes.NewReader(body), &results); err != nil {
+		return nil, err
+	}
+	if len(results) != len(req.Cmd) {
+		return nil, fmt.Errorf("go-judge返回%d个结果，期望%d个", len(results), len(req.Cmd))
+	}
+	return results, nil
+}
+
+// DeleteFile 删除缓存文件 DELETE /file/{fileId}，文件不存在时返回ErrFileNotFound
+func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
+	err := c.do(ctx, http.MethodDelete, "/file/"+url.PathEscape(fileID), nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return ErrFileNotFound
+	}
+	return err
+}
+
+// ListFiles 列出缓存文件 GET /file，返回 文件ID -> 文件名
+func (c *Client) ListFiles(ctx context.Context) (map[string]string, error) {
+	files := make(map[string]string)
+	if err := c.do(ctx, http.MethodGet, "/file", nil, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Version 获取版本信息 GET /version，用于健康检查
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.do(ctx, http.MethodGet, "/version", nil, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// do 发送请求并解析JSON响应，out为nil时丢弃响应体
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("创建go-judge请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求go-judge失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析go-judge响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package gojudge_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/judge/gojudge"
+	"zhku-oj/internal/judge/gojudge/gojudgetest"
+)
+
+func newTestClient(t *testing.T) (*gojudgetest.Server, *gojudge.Client) {
+	t.Helper()
+	srv := gojudgetest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, gojudge.NewClient(srv.URL, 5*time.Second)
+}
+
+// TestRunCopyOutCached 编译命令的copyOutCached文件缓存在沙箱中，后续命令按文件ID复制进来
+func TestRunCopyOutCached(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+
+	results, err := client.Run(ctx, &gojudge.Request{Cmd: []gojudge.Cmd{{
+		Args:          []string{"/usr/bin/javac", "Main.java"},
+		Files:         []*gojudge.CmdFile{gojudge.MemoryFile(""), gojudge.Collector("stdout", 1024), gojudge.Collector("stderr", 1024)},
+		CopyIn:        map[string]gojudge.CmdFile{"Main.java": *gojudge.MemoryFile("class Main {}")},
+		CopyOut:       []string{"stdout", "stderr"},
+		CopyOutCached: []string{"Main.class"},
+	}}})
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	fileID := results[0].FileIDs["Main.class"]
+	if results[0].Status != gojudge.StatusAccepted || fileID == "" {
+		t.Fatalf("编译结果 = %+v, 期望返回Main.class的文件ID", results[0])
+	}
+	if _, ok := results[0].Files["Main.class"]; ok {
+		t.Error("copyOutCached文件不应出现在Files中")
+	}
+	if name := srv.CachedFiles()[fileID]; name != "Main.class" {
+		t.Errorf("沙箱缓存的文件 = %v, 期望%s为Main.class", srv.CachedFiles(), fileID)
+	}
+
+	var copied string
+	srv.SetRunFunc(func(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result {
+		copied = files["Main.class"]
+		return gojudge.Result{Status: gojudge.StatusAccepted, Files: map[string]string{"stdout": stdin}}
+	})
+	results, err = client.Run(ctx, &gojudge.Request{Cmd: []gojudge.Cmd{{
+		Args:   []string{"/usr/bin/java", "Main"},
+		Files:  []*gojudge.CmdFile{gojudge.MemoryFile("1 2"), gojudge.Collector("stdout", 1024)},
+		CopyIn: map[string]gojudge.CmdFile{"Main.class": *gojudge.CachedFile(fileID)},
+	}}})
+	if err != nil {
+		t.Fatalf("运行失败: %v", err)
+	}
+	if copied != "compiled:Main.class" || results[0].Files["stdout"] != "1 2" {
+		t.Errorf("复制的文件 = %q, 输出 = %q", copied, results[0].Files["stdout"])
+	}
+}
+
+func TestRunMissingCachedFile(t *testing.T) {
+	_, client := newTestClient(t)
+	results, err := client.Run(context.Background(), &gojudge.Request{Cmd: []gojudge.Cmd{{
+		Args:   []string{"/usr/bin/java", "Main"},
+		CopyIn: map[string]gojudge.CmdFile{"Main.class": *gojudge.CachedFile("FILE999999")},
+	}}})
+	if err != nil {
+		t.Fatalf("运行失败: %v", err)
+	}
+	if results[0].Status != gojudge.StatusFileError {
+		t.Errorf("状态 = %s, 期望%s", results[0].Status, gojudge.StatusFileError)
+	}
+}
+
+func TestDeleteFile(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+	fileID := srv.PutFile("Main.class", "bytecode")
+
+	files, err := client.ListFiles(ctx)
+	if err != nil || files[fileID] != "Main.class" {
+		t.Fatalf("文件列表 = %v, 错误 = %v", files, err)
+	}
+	if err := client.DeleteFile(ctx, fileID); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	if len(srv.CachedFiles()) != 0 {
+		t.Errorf("删除后仍有缓存文件: %v", srv.CachedFiles())
+	}
+	if err := client.DeleteFile(ctx, fileID); !errors.Is(err, gojudge.ErrFileNotFound) {
+		t.Errorf("重复删除的错误 = %v, 期望ErrFileNotFound", err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	srv, client := newTestClient(t)
+	ctx := context.Background()
+
+	if version, err := client.Version(ctx); err != nil || version.BuildVersion == "" {
+		t.Fatalf("版本 = %+v, 错误 = %v", version, err)
+	}
+
+	srv.SetHealthy(false)
+	_, err := client.Version(ctx)
+	var apiErr *gojudge.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Errorf("不健康时的错误 = %v, 期望503", err)
+	}
+}
@@ -0,0 +1,259 @@
+// Package gojudgetest 本地模拟的go-judge HTTP服务
+// 实现 /run、/file、/version 接口，用于在没有真实沙箱的环境下验证整个判题流程
+package gojudgetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"zhku-oj/internal/judge/gojudge"
+)
+
+// RunFunc 模拟执行单个命令
+// files为copyIn文件名到内容的映射，stdin为标准输入；返回结果的Files中
+// 属于copyOutCached的文件由服务缓存并转换为FileIDs；并发请求会并发调用RunFunc
+type RunFunc func(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result
+
+// Server 模拟的go-judge服务
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	runFunc RunFunc
+	healthy bool
+	files   map[string]cachedFile
+	nextID  int
+	cmds    []gojudge.Cmd
+}
+
+type cachedFile struct {
+	name    string
+	content string
+}
+
+// NewServer 启动模拟服务，默认使用DefaultRun
+func NewServer() *Server {
+	s := &Server{
+		runFunc: DefaultRun,
+		healthy: true,
+		files:   make(map[string]cachedFile),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.HandleFunc("/run", s.handleRun)
+	mux.HandleFunc("/file", s.handleListFiles)
+	mux.HandleFunc("/file/", s.handleFile)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// DefaultRun 默认行为：带copyOutCached的命令视为编译，成功并缓存输出文件；
+// 其余命令视为运行，将标准输入原样输出
+func DefaultRun(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result {
+	result := gojudge.Result{
+		Status: gojudge.StatusAccepted,
+		Files:  map[string]string{"stdout": "", "stderr": ""},
+	}
+	if len(cmd.CopyOutCached) > 0 {
+		for _, name := range cmd.CopyOutCached {
+			result.Files[name] = "compiled:" + name
+		}
+		return result
+	}
+	result.Files["stdout"] = stdin
+	return result
+}
+
+// SetRunFunc 设置命令执行行为
+func (s *Server) SetRunFunc(fn RunFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runFunc = fn
+}
+
+// SetHealthy 设置健康状态，不健康时 /version 返回503
+func (s *Server) SetHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+}
+
+// PutFile 预置缓存文件，返回文件ID
+func (s *Server) PutFile(name, content string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.putFileLocked(name, content)
+}
+
+// CachedFiles 当前缓存的文件，文件ID -> 文件名
+func (s *Server) CachedFiles() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make(map[string]string, len(s.files))
+	for id, file := range s.files {
+		files[id] = file.name
+	}
+	return files
+}
+
+// Cmds 已收到的全部命令
+func (s *Server) Cmds() []gojudge.Cmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]gojudge.Cmd(nil), s.cmds...)
+}
+
+func (s *Server) putFileLocked(name, content string) string {
+	s.nextID++
+	id := fmt.Sprintf("FILE%06d", s.nextID)
+	s.files[id] = cachedFile{name: name, content: content}
+	return id
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	healthy := s.healthy
+	s.mu.Unlock()
+	if !healthy {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, gojudge.Version{BuildVersion: "gojudgetest", GoVersion: "go", Platform: "fake", OS: "linux"})
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req gojudge.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]gojudge.Result, 0, len(req.Cmd))
+	for _, cmd := range req.Cmd {
+		results = append(results, s.run(cmd))
+	}
+	writeJSON(w, results)
+}
+
+// run 解析输入文件、执行命令并缓存copyOutCached文件
+// RunFunc在锁外调用，与真实沙箱一样可以并发执行多个请求
+func (s *Server) run(cmd gojudge.Cmd) gojudge.Result {
+	s.mu.Lock()
+	s.cmds = append(s.cmds, cmd)
+	runFunc := s.runFunc
+	files, stdin, err := s.readInputsLocked(cmd)
+	s.mu.Unlock()
+	if err != nil {
+		return gojudge.Result{Status: gojudge.StatusFileError, Error: err.Error()}
+	}
+
+	result := runFunc(cmd, files, stdin)
+
+	// 输出超过收集上限时截断并判为输出超限
+	for _, collector := range cmd.Files {
+		if collector == nil || collector.Name == "" || collector.Max <= 0 {
+			continue
+		}
+		if output := result.Files[collector.Name]; int64(len(output)) > collector.Max {
+			result.Files[collector.Name] = output[:collector.Max]
+			if result.Status == gojudge.StatusAccepted {
+				result.Status = gojudge.StatusOutputLimitExceeded
+			}
+		}
+	}
+
+	if result.Status == gojudge.StatusAccepted {
+		s.mu.Lock()
+		for _, name := range cmd.CopyOutCached {
+			content, ok := result.Files[name]
+			if !ok {
+				continue
+			}
+			delete(result.Files, name)
+			if result.FileIDs == nil {
+				result.FileIDs = make(map[string]string)
+			}
+			result.FileIDs[name] = s.putFileLocked(name, content)
+		}
+		s.mu.Unlock()
+	}
+	return result
+}
+
+// readInputsLocked 读取copyIn文件和标准输入
+func (s *Server) readInputsLocked(cmd gojudge.Cmd) (map[string]string, string, error) {
+	files := make(map[string]string, len(cmd.CopyIn))
+	for name, file := range cmd.CopyIn {
+		content, err := s.readLocked(&file)
+		if err != nil {
+			return nil, "", err
+		}
+		files[name] = content
+	}
+	stdin := ""
+	if len(cmd.Files) > 0 && cmd.Files[0] != nil {
+		content, err := s.readLocked(cmd.Files[0])
+		if err != nil {
+			return nil, "", err
+		}
+		stdin = content
+	}
+	return files, stdin, nil
+}
+
+// readLocked 读取输入文件内容
+func (s *Server) readLocked(file *gojudge.CmdFile) (string, error) {
+	switch {
+	case file.Content != nil:
+		return *file.Content, nil
+	case file.FileID != "":
+		cached, ok := s.files[file.FileID]
+		if !ok {
+			return "", fmt.Errorf("file not found: %s", file.FileID)
+		}
+		return cached.content, nil
+	case file.Src != "":
+		return "", fmt.Errorf("src not supported: %s", file.Src)
+	}
+	return "", nil
+}
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.CachedFiles())
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/file/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[id]
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		_, _ = w.Write([]byte(file.content))
+	case http.MethodDelete:
+		delete(s.files, id)
+		writeJSON(w, nil)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+// Package gojudge go-judge沙箱HTTP客户端
+// 请求和响应结构与go-judge的 /run、/file、/version 接口一一对应
+package gojudge
+
+// go-judge运行状态
+const (
+	StatusAccepted            = "Accepted"
+	StatusMemoryLimitExceeded = "Memory Limit Exceeded"
+	StatusTimeLimitExceeded   = "Time Limit Exceeded"
+	StatusOutputLimitExceeded = "Output Limit Exceeded"
+	StatusFileError           = "File Error"
+	StatusNonzeroExitStatus   = "Nonzero Exit Status"
+	StatusSignalled           = "Signalled"
+	StatusDangerousSyscall    = "Dangerous Syscall"
+	StatusInternalError       = "Internal Error"
+)
+
+// Request POST /run 请求
+type Request struct {
+	Cmd []Cmd `json:"cmd"`
+}
+
+// Cmd 单个待执行命令
+type Cmd struct {
+	Args  []string   `json:"args"`
+	Env   []string   `json:"env,omitempty"`
+	Files []*CmdFile `json:"files,omitempty"` // 依次对应 stdin、stdout、stderr
+
+	CPULimit    uint64 `json:"cpuLimit,omitempty"`    // 纳秒
+	ClockLimit  uint64 `json:"clockLimit,omitempty"`  // 纳秒
+	MemoryLimit uint64 `json:"memoryLimit,omitempty"` // 字节
+	StackLimit  uint64 `json:"stackLimit,omitempty"`  // 字节
+	ProcLimit   uint64 `json:"procLimit,omitempty"`
+
+	CopyIn        map[string]CmdFile `json:"copyIn,omitempty"`
+	CopyOut       []string           `json:"copyOut,omitempty"`
+	CopyOutCached []string           `json:"copyOutCached,omitempty"` // 输出文件保存在沙箱中，返回文件ID
+	CopyOutMax    uint64             `json:"copyOutMax,omitempty"`
+}
+
+// CmdFile 命令的输入输出文件
+// Content、FileID、Src 三选一表示输入文件，Name+Max 表示收集输出
+type CmdFile struct {
+	Src     string  `json:"src,omitempty"`
+	Content *string `json:"content,omitempty"`
+	FileID  string  `json:"fileId,omitempty"`
+	Name    string  `json:"name,omitempty"`
+	Max     int64   `json:"max,omitempty"`
+}
+
+// MemoryFile 内容直接写在请求中的文件
+func MemoryFile(content string) *CmdFile {
+	return &CmdFile{Content: &content}
+}
+
+// CachedFile 沙箱中缓存的文件
+func CachedFile(fileID string) *CmdFile {
+	return &CmdFile{FileID: fileID}
+}
+
+// Collector 收集stdout/stderr输出，最多max字节
+func Collector(name string, max int64) *CmdFile {
+	return &CmdFile{Name: name, Max: max}
+}
+
+// Result 单个命令的执行结果
+type Result struct {
+	Status     string            `json:"status"`
+	ExitStatus int               `json:"exitStatus"`
+	Error      string            `json:"error,omitempty"`
+	Time       uint64            `json:"time"`    // CPU时间，纳秒
+	Memory     uint64            `json:"memory"`  // 字节
+	RunTime    uint64            `json:"runTime"` // 墙上时间，纳秒
+	Files      map[string]string `json:"files,omitempty"`
+	FileIDs    map[string]string `json:"fileIds,omitempty"`
+	FileError  []FileError       `json:"fileError,omitempty"`
+}
+
+// FileError 文件收集错误
+type FileError struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+}
+
+// Version GET /version 响应
+type Version struct {
+	BuildVersion string `json:"buildVersion"`
+	GoVersion    string `json:"goVersion"`
+	Platform     string `json:"platform"`
+	OS           string `json:"os"`
+}
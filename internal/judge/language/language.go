@@ -0,0 +1,318 @@
+// Package language 判题语言注册表
+// 以语言ID为键，描述每种语言的源文件名、编译/运行命令模板、资源限制倍数和需要缓存的编译产物
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+)
+
+// 命令模板占位符
+const (
+	PlaceholderSource     = "{src}"
+	PlaceholderExecutable = "{exe}"
+)
+
+// 编译和运行默认限制
+const (
+	defaultCompileCPULimit    = 10000000000 // 10秒(纳秒)
+	defaultCompileMemoryLimit = 536870912   // 512MB(字节)
+	defaultCompileProcLimit   = 50
+	defaultRunCPULimit        = 1000000000 // 1秒(纳秒)，题目未设置时间限制时使用
+	defaultRunMemoryLimit     = 268435456  // 256MB(字节)，题目未设置内存限制时使用
+	defaultRunProcLimit       = 1
+	defaultOutputLimit        = 10240 // 10KB
+)
+
+var defaultEnv = []string{"PATH=/usr/local/bin:/usr/bin:/bin"}
+
+// Language 判题语言
+type Language struct {
+	ID         string
+	Name       string
+	Compiled   bool     // 是否需要编译
+	SourceFile string   // 源文件名
+	Artifacts  []string // 编译产物，通过copyOutCached缓存在沙箱中供各测试用例复用
+
+	CompileCommand []string // 命令模板，可使用{src}和{exe}
+	RunCommand     []string
+	CompileEnv     []string
+	RunEnv         []string
+
+	CompileCPULimit    int64 // 纳秒
+	CompileMemoryLimit int64 // 字节
+	CompileProcLimit   int
+	RunCPULimit        int64 // 题目未设置时间限制时的默认值，纳秒
+	RunMemoryLimit     int64 // 题目未设置内存限制时的默认值，字节
+	RunProcLimit       int
+	OutputLimit        int // 标准输出上限，字节
+
+	TimeFactor   float64 // 题目时间限制倍数
+	MemoryFactor float64 // 题目内存限制倍数
+}
+
+// Executable 编译产物名(第一个产物)，解释型语言为源文件
+func (l *Language) Executable() string {
+	if len(l.Artifacts) > 0 {
+		return l.Artifacts[0]
+	}
+	return l.SourceFile
+}
+
+// CompileArgs 展开后的编译命令
+func (l *Language) CompileArgs() []string {
+	return l.expand(l.CompileCommand)
+}
+
+// RunArgs 展开后的运行命令
+func (l *Language) RunArgs() []string {
+	return l.expand(l.RunCommand)
+}
+
+// expand 替换命令模板中的占位符
+func (l *Language) expand(command []string) []string {
+	replacer := strings.NewReplacer(PlaceholderSource, l.SourceFile, PlaceholderExecutable, l.Executable())
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
+
+// ScaleTime 按语言倍数换算题目时间限制(毫秒)，返回纳秒；未设置时使用语言默认值
+func (l *Language) ScaleTime(timeLimitMS int) int64 {
+	if timeLimitMS <= 0 {
+		return l.RunCPULimit
+	}
+	return int64(float64(timeLimitMS) * l.TimeFactor * 1e6)
+}
+
+// ScaleMemory 按语言倍数换算题目内存限制(MB)，返回字节；未设置时使用语言默认值
+func (l *Language) ScaleMemory(memoryLimitMB int) int64 {
+	if memoryLimitMB <= 0 {
+		return l.RunMemoryLimit
+	}
+	return int64(float64(memoryLimitMB) * l.MemoryFactor * 1024 * 1024)
+}
+
+// builtinLanguages 内置语言定义
+func builtinLanguages(cfg config.JudgeConfig) []*Language {
+	java := &Language{
+		ID:                 model.LanguageJava,
+		Name:               "Java",
+		Compiled:           true,
+		SourceFile:         "Main.java",
+		Artifacts:          []string{"Main.class"},
+		CompileCommand:     append(append([]string{}, orDefault(cfg.Compile.Java.Command, "/usr/bin/javac")...), PlaceholderSource),
+		RunCommand:         append(append([]string{}, orDefault(cfg.Runtime.Java.Command, "/usr/bin/java")...), "Main"),
+		CompileEnv:         cfg.Compile.Java.Env,
+		RunEnv:             cfg.Runtime.Java.Env,
+		CompileCPULimit:    cfg.Compile.Java.CPULimit,
+		CompileMemoryLimit: cfg.Compile.Java.MemoryLimit,
+		CompileProcLimit:   cfg.Compile.Java.ProcLimit,
+		RunCPULimit:        cfg.Runtime.Java.CPULimit,
+		RunMemoryLimit:     cfg.Runtime.Java.MemoryLimit,
+		RunProcLimit:       cfg.Runtime.Java.ProcLimit,
+		OutputLimit:        cfg.Runtime.Java.OutputLimit,
+		TimeFactor:         2,
+		MemoryFactor:       1,
+	}
+
+	return []*Language{
+		java,
+		{
+			ID:             model.LanguageC,
+			Name:           "C (GCC, C11)",
+			Compiled:       true,
+			SourceFile:     "main.c",
+			Artifacts:      []string{"main"},
+			CompileCommand: []string{"/usr/bin/gcc", "-O2", "-std=c11", "-o", PlaceholderExecutable, PlaceholderSource, "-lm"},
+			RunCommand:     []string{"./" + PlaceholderExecutable},
+		},
+		{
+			ID:             model.LanguageCpp,
+			Name:           "C++ (G++, C++17)",
+			Compiled:       true,
+			SourceFile:     "main.cpp",
+			Artifacts:      []string{"main"},
+			CompileCommand: []string{"/usr/bin/g++", "-O2", "-std=c++17", "-o", PlaceholderExecutable, PlaceholderSource},
+			RunCommand:     []string{"./" + PlaceholderExecutable},
+		},
+		{
+			ID:           model.LanguagePython3,
+			Name:         "Python 3",
+			SourceFile:   "main.py",
+			RunCommand:   []string{"/usr/bin/python3", PlaceholderSource},
+			RunEnv:       []string{"PATH=/usr/local/bin:/usr/bin:/bin", "PYTHONIOENCODING=utf-8"},
+			TimeFactor:   3,
+			MemoryFactor: 2,
+		},
+		{
+			ID:             model.LanguageGo,
+			Name:           "Go",
+			Compiled:       true,
+			SourceFile:     "main.go",
+			Artifacts:      []string{"main"},
+			CompileCommand: []string{"/usr/local/go/bin/go", "build", "-o", PlaceholderExecutable, PlaceholderSource},
+			RunCommand:     []string{"./" + PlaceholderExecutable},
+			// go build需要可写的缓存目录，并会启动多个子进程
+			CompileEnv:       []string{"PATH=/usr/local/go/bin:/usr/bin:/bin", "GOCACHE=/tmp/gocache", "GOPATH=/tmp/gopath", "CGO_ENABLED=0"},
+			CompileProcLimit: 128,
+			// Go运行时会创建多个线程
+			RunProcLimit: 32,
+		},
+	}
+}
+
+// applyConfig 使用配置覆盖内置定义
+func (l *Language) applyConfig(cfg config.LanguageConfig) {
+	if len(cfg.CompileCommand) > 0 {
+		l.CompileCommand = cfg.CompileCommand
+	}
+	if len(cfg.RunCommand) > 0 {
+		l.RunCommand = cfg.RunCommand
+	}
+	if len(cfg.Env) > 0 {
+		l.CompileEnv = cfg.Env
+		l.RunEnv = cfg.Env
+	}
+	if cfg.TimeFactor > 0 {
+		l.TimeFactor = cfg.TimeFactor
+	}
+	if cfg.MemoryFactor > 0 {
+		l.MemoryFactor = cfg.MemoryFactor
+	}
+}
+
+// applyDefaults 填充未设置的限制
+func (l *Language) applyDefaults() {
+	if len(l.CompileEnv) == 0 {
+		l.CompileEnv = defaultEnv
+	}
+	if len(l.RunEnv) == 0 {
+		l.RunEnv = defaultEnv
+	}
+	if l.CompileCPULimit <= 0 {
+		l.CompileCPULimit = defaultCompileCPULimit
+	}
+	if l.CompileMemoryLimit <= 0 {
+		l.CompileMemoryLimit = defaultCompileMemoryLimit
+	}
+	if l.CompileProcLimit <= 0 {
+		l.CompileProcLimit = defaultCompileProcLimit
+	}
+	if l.RunCPULimit <= 0 {
+		l.RunCPULimit = defaultRunCPULimit
+	}
+	if l.RunMemoryLimit <= 0 {
+		l.RunMemoryLimit = defaultRunMemoryLimit
+	}
+	if l.RunProcLimit <= 0 {
+		l.RunProcLimit = defaultRunProcLimit
+	}
+	if l.OutputLimit <= 0 {
+		l.OutputLimit = defaultOutputLimit
+	}
+	if l.TimeFactor <= 0 {
+		l.TimeFactor = 1
+	}
+	if l.MemoryFactor <= 0 {
+		l.MemoryFactor = 1
+	}
+}
+
+// validate 检查语言定义是否完整
+func (l *Language) validate() error {
+	if l.SourceFile == "" {
+		return fmt.Errorf("语言%s未设置源文件名", l.ID)
+	}
+	if len(l.RunCommand) == 0 {
+		return fmt.Errorf("语言%s未设置运行命令", l.ID)
+	}
+	if l.Compiled && (len(l.CompileCommand) == 0 || len(l.Artifacts) == 0) {
+		return fmt.Errorf("语言%s未设置编译命令或编译产物", l.ID)
+	}
+	return nil
+}
+
+// Registry 语言注册表
+type Registry struct {
+	languages map[string]*Language
+}
+
+// NewRegistry 根据判题配置创建语言注册表
+// 内置java、c、cpp、python3、go，配置中的同名语言覆盖内置值，enabled: false 的语言被移除
+func NewRegistry(cfg config.JudgeConfig) (*Registry, error) {
+	r := &Registry{languages: make(map[string]*Language)}
+	for _, lang := range builtinLanguages(cfg) {
+		if override, ok := cfg.Languages[lang.ID]; ok {
+			if override.Enabled != nil && !*override.Enabled {
+				continue
+			}
+			lang.applyConfig(override)
+		}
+		lang.applyDefaults()
+		if err := lang.validate(); err != nil {
+			return nil, err
+		}
+		r.languages[lang.ID] = lang
+	}
+
+	for id := range cfg.Languages {
+		if _, ok := r.languages[id]; !ok && !isBuiltin(id) {
+			return nil, fmt.Errorf("不支持的语言配置: %s", id)
+		}
+	}
+	return r, nil
+}
+
+// Get 根据语言ID获取语言定义
+func (r *Registry) Get(id string) (*Language, bool) {
+	lang, ok := r.languages[id]
+	return lang, ok
+}
+
+// Supported 语言是否可用
+func (r *Registry) Supported(id string) bool {
+	_, ok := r.languages[id]
+	return ok
+}
+
+// IDs 全部可用语言ID，按字母排序
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.languages))
+	for id := range r.languages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Validate 检查语言列表(如题目允许的语言)是否都可用
+func (r *Registry) Validate(ids []string) error {
+	for _, id := range ids {
+		if !r.Supported(id) {
+			return fmt.Errorf("不支持的编程语言: %s", id)
+		}
+	}
+	return nil
+}
+
+func isBuiltin(id string) bool {
+	switch id {
+	case model.LanguageJava, model.LanguageC, model.LanguageCpp, model.LanguagePython3, model.LanguageGo:
+		return true
+	}
+	return false
+}
+
+func orDefault(command []string, fallback ...string) []string {
+	if len(command) > 0 {
+		return command
+	}
+	return fallback
+}
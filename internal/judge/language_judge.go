@@ -0,0 +1,258 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"zhku-oj/internal/judge/gojudge"
+	"zhku-oj/internal/judge/language"
+	"zhku-oj/internal/model"
+)
+
+// 编译输出和标准错误的收集上限
+const (
+	stderrMaxBytes  = 10240
+	compileOutBytes = 10240
+)
+
+// errCachedFileLost 沙箱中的缓存文件已不存在(如沙箱重启)，需要重新编译
+var errCachedFileLost = errors.New("沙箱缓存文件已失效")
+
+// CompileResult 编译结果
+type CompileResult struct {
+	Status        string // model.Status*，编译成功为ACCEPTED
+	GoJudgeStatus string
+	Time          int64 // 纳秒
+	Memory        int64 // 字节
+	ErrorMessage  string
+	FileIDs       map[string]string // 编译产物 -> 沙箱缓存文件ID
+
+	source string // 解释型语言的源代码，运行时直接复制到沙箱
+}
+
+// RunResult 单个测试用例的运行结果
+type RunResult struct {
+	Status        string // model.Status*，正常退出为ACCEPTED，输出是否正确由调用方比对
+	GoJudgeStatus string
+	Output        string
+	Stderr        string
+	Time          int64 // 纳秒
+	Memory        int64 // 字节
+	ExitStatus    int
+	ErrorMessage  string
+}
+
+// RunLimits 运行限制
+type RunLimits struct {
+	CPULimit    int64 // 纳秒
+	MemoryLimit int64 // 字节
+}
+
+// LimitsFromProblem 按题目的时间(毫秒)和内存(MB)限制及语言倍数生成运行限制
+func LimitsFromProblem(problem *model.Problem, lang *language.Language) RunLimits {
+	return RunLimits{
+		CPULimit:    lang.ScaleTime(problem.TimeLimit),
+		MemoryLimit: lang.ScaleMemory(problem.MemoryLimit),
+	}
+}
+
+// LanguageJudge 按语言注册表定义判题
+// 编译型语言通过copyOutCached将编译产物缓存在沙箱中，运行各测试用例时按文件ID复用；
+// 解释型语言跳过编译，每次运行时复制源代码
+type LanguageJudge struct {
+	client *gojudge.Client
+	lang   *language.Language
+}
+
+// NewLanguageJudge 创建语言判题器
+func NewLanguageJudge(sandbox *Sandbox, lang *language.Language) *LanguageJudge {
+	return &LanguageJudge{
+		client: sandbox.Client,
+		lang:   lang,
+	}
+}
+
+// Compile 编译代码
+// 编译失败返回Status为COMPILE_ERROR的结果；沙箱调用失败或沙箱内部错误返回error
+func (j *LanguageJudge) Compile(ctx context.Context, code string) (*CompileResult, error) {
+	return j.compile(ctx, code, nil)
+}
+
+// compile 编译代码，extraFiles为额外复制到沙箱的文件(如testlib.h)
+func (j *LanguageJudge) compile(ctx context.Context, code string, extraFiles map[string]string) (*CompileResult, error) {
+	if !j.lang.Compiled {
+		return &CompileResult{Status: model.StatusAccepted, source: code}, nil
+	}
+
+	copyIn := map[string]gojudge.CmdFile{
+		j.lang.SourceFile: *gojudge.MemoryFile(code),
+	}
+	for name, content := range extraFiles {
+		copyIn[name] = *gojudge.MemoryFile(content)
+	}
+
+	cmd := gojudge.Cmd{
+		Args: j.lang.CompileArgs(),
+		Env:  j.lang.CompileEnv,
+		Files: []*gojudge.CmdFile{
+			gojudge.MemoryFile(""),
+			gojudge.Collector("stdout", compileOutBytes),
+			gojudge.Collector("stderr", compileOutBytes),
+		},
+		CPULimit:      uint64(j.lang.CompileCPULimit),
+		ClockLimit:    uint64(j.lang.CompileCPULimit) * 2,
+		MemoryLimit:   uint64(j.lang.CompileMemoryLimit),
+		ProcLimit:     uint64(j.lang.CompileProcLimit),
+		CopyIn:        copyIn,
+		CopyOut:       []string{"stdout", "stderr"},
+		CopyOutCached: j.lang.Artifacts,
+	}
+
+	res, err := j.runOne(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CompileResult{
+		Status:        mapGoJudgeStatus(res.Status, true),
+		GoJudgeStatus: res.Status,
+		Time:          int64(res.Time),
+		Memory:        int64(res.Memory),
+	}
+	if result.Status == model.StatusSystemError {
+		return nil, fmt.Errorf("沙箱编译出错(%s): %s", res.Status, res.Error)
+	}
+	if result.Status != model.StatusAccepted {
+		result.Status = model.StatusCompileError
+		result.ErrorMessage = compileMessage(res)
+		return result, nil
+	}
+
+	result.FileIDs = res.FileIDs
+	for _, artifact := range j.lang.Artifacts {
+		if result.FileIDs[artifact] == "" {
+			return nil, fmt.Errorf("沙箱未返回%s的文件ID", artifact)
+		}
+	}
+	return result, nil
+}
+
+// Run 运行一个测试用例
+// 沙箱调用失败或沙箱内部错误返回error，其余运行状态通过RunResult.Status返回
+func (j *LanguageJudge) Run(ctx context.Context, compiled *CompileResult, input string, limits RunLimits) (*RunResult, error) {
+	return j.run(ctx, compiled, input, limits, nil, nil)
+}
+
+// run 运行程序，extraArgs追加到运行命令之后，extraFiles为额外复制到沙箱的文件
+func (j *LanguageJudge) run(ctx context.Context, compiled *CompileResult, input string, limits RunLimits, extraArgs []string, extraFiles map[string]string) (*RunResult, error) {
+	copyIn := make(map[string]gojudge.CmdFile, len(compiled.FileIDs)+len(extraFiles)+1)
+	if j.lang.Compiled {
+		for name, fileID := range compiled.FileIDs {
+			copyIn[name] = *gojudge.CachedFile(fileID)
+		}
+	} else {
+		copyIn[j.lang.SourceFile] = *gojudge.MemoryFile(compiled.source)
+	}
+	for name, content := range extraFiles {
+		copyIn[name] = *gojudge.MemoryFile(content)
+	}
+
+	cmd := gojudge.Cmd{
+		Args: append(j.lang.RunArgs(), extraArgs...),
+		Env:  j.lang.RunEnv,
+		Files: []*gojudge.CmdFile{
+			gojudge.MemoryFile(input),
+			gojudge.Collector("stdout", int64(j.lang.OutputLimit)),
+			gojudge.Collector("stderr", stderrMaxBytes),
+		},
+		CPULimit:    uint64(limits.CPULimit),
+		ClockLimit:  uint64(limits.CPULimit) * 2,
+		MemoryLimit: uint64(limits.MemoryLimit),
+		ProcLimit:   uint64(j.lang.RunProcLimit),
+		CopyIn:      copyIn,
+		CopyOut:     []string{"stdout", "stderr"},
+	}
+
+	res, err := j.runOne(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{
+		Status:        mapGoJudgeStatus(res.Status, false),
+		GoJudgeStatus: res.Status,
+		Output:        res.Files["stdout"],
+		Stderr:        res.Files["stderr"],
+		Time:          int64(res.Time),
+		Memory:        int64(res.Memory),
+		ExitStatus:    res.ExitStatus,
+		ErrorMessage:  res.Error,
+	}
+	if res.Status == gojudge.StatusFileError {
+		return nil, fmt.Errorf("%w: %s", errCachedFileLost, res.Error)
+	}
+	if result.Status == model.StatusSystemError {
+		return nil, fmt.Errorf("沙箱运行出错(%s): %s", res.Status, res.Error)
+	}
+	return result, nil
+}
+
+// CleanupFile 删除沙箱中缓存的文件，文件已不存在时视为成功
+func (j *LanguageJudge) CleanupFile(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return nil
+	}
+	if err := j.client.DeleteFile(ctx, fileID); err != nil && err != gojudge.ErrFileNotFound {
+		return err
+	}
+	return nil
+}
+
+// runOne 执行单个命令
+func (j *LanguageJudge) runOne(ctx context.Context, cmd gojudge.Cmd) (*gojudge.Result, error) {
+	results, err := j.client.Run(ctx, &gojudge.Request{Cmd: []gojudge.Cmd{cmd}})
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// mapGoJudgeStatus go-judge状态转换为提交状态
+// 非零退出在编译阶段为编译错误，运行阶段为运行时错误
+func mapGoJudgeStatus(status string, compiling bool) string {
+	switch status {
+	case gojudge.StatusAccepted:
+		return model.StatusAccepted
+	case gojudge.StatusMemoryLimitExceeded:
+		return model.StatusMemoryLimitExceeded
+	case gojudge.StatusTimeLimitExceeded:
+		return model.StatusTimeLimitExceeded
+	case gojudge.StatusOutputLimitExceeded:
+		return model.StatusOutputLimitExceeded
+	case gojudge.StatusNonzeroExitStatus:
+		if compiling {
+			return model.StatusCompileError
+		}
+		return model.StatusRuntimeError
+	case gojudge.StatusSignalled:
+		return model.StatusRuntimeError
+	case gojudge.StatusDangerousSyscall:
+		return model.StatusDangerousSyscall
+	default:
+		return model.StatusSystemError
+	}
+}
+
+// compileMessage 编译错误信息，优先使用stderr
+func compileMessage(res *gojudge.Result) string {
+	message := strings.TrimSpace(res.Files["stderr"])
+	if message == "" {
+		message = strings.TrimSpace(res.Files["stdout"])
+	}
+	if message == "" {
+		message = res.Status
+	}
+	return message
+}
@@ -0,0 +1,226 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/gojudge"
+	"zhku-oj/internal/judge/gojudge/gojudgetest"
+	"zhku-oj/internal/judge/language"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	os.Exit(m.Run())
+}
+
+// 模拟的Java程序：源代码中的注释决定程序行为
+const (
+	javaSum         = "public class Main { /* sum */ }"
+	javaWrongAnswer = "public class Main { /* zero */ }"
+	javaSyntaxError = "public class Main { syntax error }"
+)
+
+// fakeJava 模拟javac和java：javac把源代码作为Main.class输出，含syntax error时编译失败；
+// java读取Main.class，sum程序输出标准输入中两个整数之和，zero程序总是输出0
+func fakeJava(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result {
+	if len(cmd.CopyOutCached) > 0 {
+		source := files["Main.java"]
+		if strings.Contains(source, "syntax error") {
+			return gojudge.Result{
+				Status:     gojudge.StatusNonzeroExitStatus,
+				ExitStatus: 1,
+				Files:      map[string]string{"stdout": "", "stderr": "Main.java:1: error: ';' expected\n"},
+			}
+		}
+		return gojudge.Result{
+			Status: gojudge.StatusAccepted,
+			Files:  map[string]string{"stdout": "", "stderr": "", "Main.class": source},
+		}
+	}
+
+	class, ok := files["Main.class"]
+	if !ok {
+		return gojudge.Result{
+			Status:     gojudge.StatusNonzeroExitStatus,
+			ExitStatus: 1,
+			Files:      map[string]string{"stderr": "Error: Could not find or load main class Main"},
+		}
+	}
+	output := "0\n"
+	if strings.Contains(class, "sum") {
+		var a, b int
+		fmt.Sscan(stdin, &a, &b)
+		output = fmt.Sprintf("%d\n", a+b)
+	}
+	return gojudge.Result{
+		Status: gojudge.StatusAccepted,
+		Time:   uint64(20 * time.Millisecond),
+		Memory: 32 << 20,
+		Files:  map[string]string{"stdout": output, "stderr": ""},
+	}
+}
+
+func newJavaJudge(t *testing.T) (*gojudgetest.Server, *LanguageJudge) {
+	t.Helper()
+	srv := gojudgetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetRunFunc(fakeJava)
+
+	languages, err := language.NewRegistry(config.JudgeConfig{})
+	if err != nil {
+		t.Fatalf("创建语言注册表失败: %v", err)
+	}
+	java, ok := languages.Get(model.LanguageJava)
+	if !ok {
+		t.Fatal("未注册Java语言")
+	}
+	sandbox := &Sandbox{URL: srv.URL, Client: gojudge.NewClient(srv.URL, 5*time.Second)}
+	return srv, NewLanguageJudge(sandbox, java)
+}
+
+func TestLanguageJudgeCompile(t *testing.T) {
+	srv, judge := newJavaJudge(t)
+	ctx := context.Background()
+
+	compiled, err := judge.Compile(ctx, javaSum)
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	fileID := compiled.FileIDs["Main.class"]
+	if compiled.Status != model.StatusAccepted || fileID == "" {
+		t.Fatalf("编译结果 = %+v, 期望返回Main.class的文件ID", compiled)
+	}
+	if name := srv.CachedFiles()[fileID]; name != "Main.class" {
+		t.Errorf("沙箱缓存的文件 = %v", srv.CachedFiles())
+	}
+	cmd := srv.Cmds()[0]
+	if len(cmd.CopyOutCached) != 1 || cmd.CopyOutCached[0] != "Main.class" || cmd.CopyIn["Main.java"].Content == nil {
+		t.Errorf("编译命令 = %+v, 期望复制Main.java并缓存Main.class", cmd)
+	}
+
+	failed, err := judge.Compile(ctx, javaSyntaxError)
+	if err != nil {
+		t.Fatalf("编译错误不应返回error: %v", err)
+	}
+	if failed.Status != model.StatusCompileError || !strings.Contains(failed.ErrorMessage, "';' expected") {
+		t.Errorf("编译错误结果 = %+v", failed)
+	}
+	if len(failed.FileIDs) != 0 {
+		t.Errorf("编译失败不应缓存文件: %v", failed.FileIDs)
+	}
+}
+
+func TestLanguageJudgeCompileSystemError(t *testing.T) {
+	srv, judge := newJavaJudge(t)
+	srv.SetRunFunc(func(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result {
+		return gojudge.Result{Status: gojudge.StatusInternalError, Error: "sandbox broken"}
+	})
+	if _, err := judge.Compile(context.Background(), javaSum); err == nil {
+		t.Fatal("沙箱内部错误应返回error")
+	}
+}
+
+func TestLanguageJudgeRunStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   gojudge.Result
+		want     string
+		wantErr  bool
+		wantLost bool
+	}{
+		{name: "accepted", result: gojudge.Result{Status: gojudge.StatusAccepted}, want: model.StatusAccepted},
+		{name: "time limit", result: gojudge.Result{Status: gojudge.StatusTimeLimitExceeded}, want: model.StatusTimeLimitExceeded},
+		{name: "memory limit", result: gojudge.Result{Status: gojudge.StatusMemoryLimitExceeded}, want: model.StatusMemoryLimitExceeded},
+		{name: "output limit", result: gojudge.Result{Status: gojudge.StatusOutputLimitExceeded}, want: model.StatusOutputLimitExceeded},
+		{name: "nonzero exit", result: gojudge.Result{Status: gojudge.StatusNonzeroExitStatus, ExitStatus: 1}, want: model.StatusRuntimeError},
+		{name: "signalled", result: gojudge.Result{Status: gojudge.StatusSignalled}, want: model.StatusRuntimeError},
+		{name: "dangerous syscall", result: gojudge.Result{Status: gojudge.StatusDangerousSyscall}, want: model.StatusDangerousSyscall},
+		{name: "internal error", result: gojudge.Result{Status: gojudge.StatusInternalError}, wantErr: true},
+		{name: "file error", result: gojudge.Result{Status: gojudge.StatusFileError}, wantErr: true, wantLost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, judge := newJavaJudge(t)
+			ctx := context.Background()
+			compiled, err := judge.Compile(ctx, javaSum)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+
+			srv.SetRunFunc(func(cmd gojudge.Cmd, files map[string]string, stdin string) gojudge.Result {
+				return tt.result
+			})
+			run, err := judge.Run(ctx, compiled, "1 2", RunLimits{CPULimit: int64(time.Second), MemoryLimit: 256 << 20})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回error, 得到%+v", run)
+				}
+				if lost := errors.Is(err, errCachedFileLost); lost != tt.wantLost {
+					t.Errorf("errCachedFileLost = %v, 期望 %v (错误: %v)", lost, tt.wantLost, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("运行失败: %v", err)
+			}
+			if run.Status != tt.want || run.GoJudgeStatus != tt.result.Status {
+				t.Errorf("运行状态 = %s(%s), 期望 %s", run.Status, run.GoJudgeStatus, tt.want)
+			}
+		})
+	}
+}
+
+// TestLanguageJudgeRunLostFile 沙箱重启后缓存的编译产物丢失
+func TestLanguageJudgeRunLostFile(t *testing.T) {
+	_, judge := newJavaJudge(t)
+	ctx := context.Background()
+	compiled, err := judge.Compile(ctx, javaSum)
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	if err := judge.CleanupFile(ctx, compiled.FileIDs["Main.class"]); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	if _, err := judge.Run(ctx, compiled, "1 2", RunLimits{}); !errors.Is(err, errCachedFileLost) {
+		t.Errorf("错误 = %v, 期望errCachedFileLost", err)
+	}
+}
+
+func TestLanguageJudgeCleanupFile(t *testing.T) {
+	srv, judge := newJavaJudge(t)
+	ctx := context.Background()
+	compiled, err := judge.Compile(ctx, javaSum)
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	fileID := compiled.FileIDs["Main.class"]
+
+	if err := judge.CleanupFile(ctx, fileID); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	if len(srv.CachedFiles()) != 0 {
+		t.Errorf("删除后仍有缓存文件: %v", srv.CachedFiles())
+	}
+	// 文件已不存在和空文件ID都视为成功
+	if err := judge.CleanupFile(ctx, fileID); err != nil {
+		t.Errorf("重复删除应视为成功: %v", err)
+	}
+	if err := judge.CleanupFile(ctx, ""); err != nil {
+		t.Errorf("空文件ID应视为成功: %v", err)
+	}
+
+	srv.Close()
+	if err := judge.CleanupFile(ctx, "FILE000001"); err == nil {
+		t.Error("沙箱不可用时应返回error")
+	}
+}
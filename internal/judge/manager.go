@@ -2,14 +2,18 @@ package judge
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/language"
 	"zhku-oj/internal/model"
 	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/realtime"
 	"zhku-oj/internal/repository/interfaces"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -20,8 +24,14 @@ type Manager struct {
 	cfg            config.JudgeConfig
 	submissionRepo interfaces.SubmissionRepository
 	problemRepo    interfaces.ProblemRepository
+	taskRepo       interfaces.JudgeTaskRepository
+	producer       queue.Producer
+	events         realtime.Publisher
+	taskCfg        config.JudgeTaskConfig
+	languages      *language.Registry
 	balancer       *Balancer
 	fileManager    *FileManager
+	checkers       *checkerCache
 	processor      *ResultProcessor
 	wg             sync.WaitGroup
 	shutdown       chan struct{}
@@ -32,7 +42,16 @@ func NewManager(
 	cfg config.JudgeConfig,
 	submissionRepo interfaces.SubmissionRepository,
 	problemRepo interfaces.ProblemRepository,
+	taskRepo interfaces.JudgeTaskRepository,
+	producer queue.Producer,
+	events realtime.Publisher,
 ) (*Manager, error) {
+	// 创建语言注册表
+	languages, err := language.NewRegistry(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建语言注册表失败: %w", err)
+	}
+
 	// 创建沙箱负载均衡器
 	balancer, err := NewBalancer(cfg.Sandboxes)
 	if err != nil {
@@ -42,6 +61,12 @@ func NewManager(
 	// 创建文件管理器
 	fileManager := NewFileManager(cfg.FileManagement)
 
+	// 创建checker缓存
+	checkers, err := newCheckerCache(cfg.Checker, languages, fileManager)
+	if err != nil {
+		return nil, fmt.Errorf("创建checker缓存失败: %w", err)
+	}
+
 	// 创建结果处理器
 	processor := NewResultProcessor()
 
@@ -49,85 +74,189 @@ func NewManager(
 		cfg:            cfg,
 		submissionRepo: submissionRepo,
 		problemRepo:    problemRepo,
+		taskRepo:       taskRepo,
+		producer:       producer,
+		events:         events,
+		taskCfg:        withTaskDefaults(cfg.Task),
+		languages:      languages,
 		balancer:       balancer,
 		fileManager:    fileManager,
+		checkers:       checkers,
 		processor:      processor,
 		shutdown:       make(chan struct{}),
 	}, nil
 }
 
 // ProcessTask 处理判题任务
-// 接收代码提交任务，执行Java代码编译和运行
-func (m *Manager) ProcessTask(ctx context.Context, task *JudgeTask) error {
-	logger.Info("开始处理判题任务", "submission_id", task.SubmissionID.Hex())
-
-	// 更新提交状态为判题中
-	if err := m.updateSubmissionStatus(ctx, task.SubmissionID, model.StatusJudging); err != nil {
-		logger.Error("更新提交状态失败", "error", err)
+// 接收代码提交任务，按提交语言编译和运行
+// 判题中的系统错误记录到judge_queue并按退避策略重试，不返回给消息队列；
+// 只有任务状态无法持久化时才返回错误，由消息队列重新投递
+func (m *Manager) ProcessTask(ctx context.Context, task *queue.JudgeTask) error {
+	logger.Info("开始处理判题任务", "submission_id", task.SubmissionID.Hex(), "lane", task.Lane)
+
+	// 登记并领取任务，重复投递或正由其他判题机处理的任务直接跳过
+	priority := task.Priority
+	if priority == 0 {
+		priority = queue.LanePriority(task.Lane)
+	}
+	if err := m.taskRepo.Create(ctx, &model.JudgeTask{
+		SubmissionID: task.SubmissionID,
+		Lane:         queue.NormalizeLane(task.Lane),
+		Priority:     priority,
+		CreatedAt:    task.CreatedAt,
+	}); err != nil {
+		logger.Error("登记判题任务失败", "error", err)
 		return err
 	}
-
-	// 获取题目信息
-	problem, err := m.problemRepo.GetByID(ctx, task.ProblemID)
+	record, err := m.taskRepo.Acquire(ctx, task.SubmissionID, m.taskCfg.JudgeID)
 	if err != nil {
-		logger.Error("获取题目信息失败", "error", err)
+		logger.Error("领取判题任务失败", "error", err)
 		return err
 	}
-
-	// 选择可用的沙箱实例
-	sandbox := m.balancer.SelectSandbox()
-	if sandbox == nil {
-		logger.Error("没有可用的沙箱实例")
-		return fmt.Errorf("没有可用的沙箱实例")
+	if record == nil {
+		logger.Info("判题任务已完成或正在处理，跳过", "submission_id", task.SubmissionID.Hex())
+		return nil
 	}
 
-	// 创建Java判题器
-	javaJudge := NewJavaJudge(sandbox, m.cfg.Compile.Java, m.cfg.Runtime.Java)
+	// 判题期间定期心跳，任务被回收时中止判题
+	judgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	progress := &taskProgress{}
+	var lost atomic.Bool
+	stopHeartbeat := m.startHeartbeat(judgeCtx, cancel, task.SubmissionID, progress, &lost)
 
-	// 执行判题
-	result, err := m.executeJudge(ctx, javaJudge, task, problem)
+	result, err := m.judge(judgeCtx, task, progress)
+	stopHeartbeat()
+
+	// 判题机正在退出：归还任务，消息重新入队后由其他判题机处理
+	if ctx.Err() != nil {
+		if releaseErr := m.taskRepo.Release(context.Background(), task.SubmissionID, m.taskCfg.JudgeID); releaseErr != nil {
+			logger.Error("归还判题任务失败", "submission_id", task.SubmissionID.Hex(), "error", releaseErr)
+		}
+		return ctx.Err()
+	}
+	if lost.Load() {
+		return nil
+	}
+	if errors.Is(err, interfaces.ErrStatusTransition) {
+		return m.skipJudged(ctx, task, err)
+	}
 	if err != nil {
-		logger.Error("执行判题失败", "error", err)
-		// 更新为系统错误
-		m.updateSubmissionWithError(ctx, task.SubmissionID, model.StatusSystemError, err.Error())
-		return err
+		logger.Error("执行判题失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+		errType := model.JudgeErrorInternal
+		var te *taskError
+		if errors.As(err, &te) {
+			errType = te.Type
+		}
+		return m.failTask(ctx, record, errType, err)
 	}
 
 	// 更新提交结果
 	if err := m.updateSubmissionResult(ctx, task.SubmissionID, result); err != nil {
+		if errors.Is(err, interfaces.ErrStatusTransition) {
+			return m.skipJudged(ctx, task, err)
+		}
 		logger.Error("更新提交结果失败", "error", err)
-		return err
+		return m.failTask(ctx, record, model.JudgeErrorInternal, err)
+	}
+	if _, err := m.taskRepo.Complete(ctx, task.SubmissionID, m.taskCfg.JudgeID); err != nil {
+		logger.Error("更新判题任务状态失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+	}
+
+	// 发布判题结果，由结果处理服务分发统计更新和通知
+	result.SubmissionID = task.SubmissionID
+	result.ProblemID = task.ProblemID
+	result.UserID = task.UserID
+	result.ContestID = task.ContestID
+	result.Language = task.Language
+	m.publishSubmissionResult(ctx, result)
+	if err := m.producer.PublishJudgeResult(ctx, result); err != nil {
+		// 提交结果已落库，发布失败只影响统计和通知
+		logger.Error("发布判题结果失败", "submission_id", task.SubmissionID.Hex(), "error", err)
 	}
 
 	logger.Info("判题任务完成", "submission_id", task.SubmissionID.Hex(), "status", result.Status)
 	return nil
 }
 
+// skipJudged 提交已有判题结果(如重复投递的旧消息)，不再判题也不覆盖结果，直接结束任务
+func (m *Manager) skipJudged(ctx context.Context, task *queue.JudgeTask, cause error) error {
+	logger.Warn("提交已有判题结果，跳过", "submission_id", task.SubmissionID.Hex(), "reason", cause)
+	if _, err := m.taskRepo.Complete(ctx, task.SubmissionID, m.taskCfg.JudgeID); err != nil {
+		logger.Error("更新判题任务状态失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+	}
+	return nil
+}
+
+// judge 准备题目和沙箱并执行判题
+func (m *Manager) judge(ctx context.Context, task *queue.JudgeTask, progress *taskProgress) (*queue.JudgeResult, error) {
+	// 更新提交状态为判题中
+	if err := m.updateSubmissionStatus(ctx, task.SubmissionID, model.StatusJudging); err != nil {
+		return nil, &taskError{Type: model.JudgeErrorInternal, Err: fmt.Errorf("更新提交状态失败: %w", err)}
+	}
+
+	// 获取题目信息
+	problem, err := m.problemRepo.GetByID(ctx, task.ProblemID)
+	if err != nil {
+		return nil, &taskError{Type: model.JudgeErrorInternal, Err: fmt.Errorf("获取题目信息失败: %w", err)}
+	}
+
+	// 语言不可用或题目不允许该语言时直接判为编译错误，不占用沙箱
+	lang, ok := m.languages.Get(task.Language)
+	if !ok {
+		return m.processor.CompileErrorResult(&CompileResult{ErrorMessage: "不支持的编程语言: " + task.Language}), nil
+	}
+	if !problem.AllowsLanguage(lang.ID) {
+		return m.processor.CompileErrorResult(&CompileResult{ErrorMessage: "该题目不允许使用" + lang.Name}), nil
+	}
+
+	// 选择可用的沙箱实例
+	// 全部沙箱满载时最多等待sandbox_wait，超时按沙箱错误重试
+	selectCtx, cancel := context.WithTimeout(ctx, m.taskCfg.SandboxWait)
+	sandbox, err := m.balancer.SelectSandbox(selectCtx)
+	cancel()
+	if err != nil {
+		return nil, &taskError{Type: model.JudgeErrorSandbox, Err: err}
+	}
+	defer m.balancer.Release(sandbox)
+
+	// 创建对应语言的判题器
+	judge := NewLanguageJudge(sandbox, lang)
+
+	result, err := m.executeJudge(ctx, sandbox, judge, lang, task, problem, progress)
+	if err != nil {
+		return nil, &taskError{Type: model.JudgeErrorSandbox, Err: err}
+	}
+	return result, nil
+}
+
 // executeJudge 执行判题逻辑
-func (m *Manager) executeJudge(ctx context.Context, judge *JavaJudge, task *JudgeTask, problem *model.Problem) (*JudgeResult, error) {
-	// 1. 编译Java代码
+func (m *Manager) executeJudge(ctx context.Context, sandbox *Sandbox, judge *LanguageJudge, lang *language.Language, task *queue.JudgeTask, problem *model.Problem, progress *taskProgress) (*queue.JudgeResult, error) {
+	// 子任务配置错误时无法计分，不再编译运行
+	var plan *subtaskPlan
+	if len(problem.Subtasks) > 0 {
+		var err error
+		if plan, err = newSubtaskPlan(problem); err != nil {
+			return nil, fmt.Errorf("题目子任务配置错误: %w", err)
+		}
+	}
+
+	// 1. 编译代码(解释型语言跳过)
+	total := len(problem.TestCases)
+	progress.setStage(model.JudgeStageCompiling, total)
+	m.publishStage(ctx, task, model.JudgeStageCompiling, 0, total)
 	compileResult, err := judge.Compile(ctx, task.Code)
 	if err != nil {
 		return nil, fmt.Errorf("编译失败: %w", err)
 	}
+	m.publishCompileResult(ctx, task, compileResult)
 
 	// 检查编译是否成功
-	if compileResult.Status != "Accepted" {
-		return &JudgeResult{
-			Status:     model.StatusCompileError,
-			Score:      0,
-			TimeUsed:   int(compileResult.Time / 1000000), // 纳秒转毫秒
-			MemoryUsed: int(compileResult.Memory / 1024),  // 字节转KB
-			CompileInfo: model.CompileInfo{
-				Status:     "FAILED",
-				TimeUsed:   compileResult.Time,
-				MemoryUsed: compileResult.Memory,
-				Message:    compileResult.ErrorMessage,
-			},
-			TestResults: []model.TestResult{},
-			JudgedAt:    time.Now(),
-		}, nil
+	if compileResult.Status != model.StatusAccepted {
+		return m.processor.CompileErrorResult(compileResult), nil
 	}
+	// 无论判题是否成功都清理缓存的编译产物
+	defer m.cleanupFiles(judge, compileResult.FileIDs)
 
 	// 编译成功，记录编译信息
 	compileInfo := model.CompileInfo{
@@ -137,103 +266,135 @@ func (m *Manager) executeJudge(ctx context.Context, judge *JavaJudge, task *Judg
 		Message:    "",
 	}
 
-	// 2. 运行测试用例
-	testResults := make([]model.TestResult, 0, len(problem.TestCases))
-	totalScore := 0
-	maxTime := 0
-	maxMemory := 0
-
-	for _, testCase := range problem.TestCases {
-		runResult, err := judge.Run(ctx, compileResult.ClassFileID, testCase.Input)
+	// 特殊判题：获取该沙箱上已编译的checker，首次使用时编译；否则按题目配置的模式比对输出
+	var checker *Checker
+	var comparator Comparator
+	if problem.Constraints.SpecialJudge {
+		checker, err = m.checkers.get(ctx, sandbox, problem.Constraints.Checker)
 		if err != nil {
-			logger.Error("运行测试用例失败", "test_case_id", testCase.ID, "error", err)
-			continue
+			return nil, fmt.Errorf("准备checker失败: %w", err)
 		}
-
-		// 比对输出结果
-		status := model.StatusWrongAnswer
-		score := 0
-		if strings.TrimSpace(runResult.Output) == strings.TrimSpace(testCase.Output) {
-			status = model.StatusAccepted
-			score = testCase.Score
-			totalScore += score
+	} else {
+		comparator, err = NewComparator(problem.Constraints.Comparison)
+		if err != nil {
+			return nil, fmt.Errorf("题目输出比对配置错误: %w", err)
 		}
+	}
 
-		// 检查时间和内存限制
-		timeUsed := int(runResult.Time / 1000000)  // 纳秒转毫秒
-		memoryUsed := int(runResult.Memory / 1024) // 字节转KB
-
-		if runResult.Status == "Time Limit Exceeded" {
-			status = model.StatusTimeLimitExceeded
-			score = 0
-		} else if runResult.Status == "Memory Limit Exceeded" {
-			status = model.StatusMemoryLimitExceeded
-			score = 0
-		} else if runResult.Status != "Accepted" {
-			status = model.StatusRuntimeError
-			score = 0
+	// 2. 运行测试用例
+	progress.setStage(model.JudgeStageRunning, total)
+	m.publishStage(ctx, task, model.JudgeStageRunning, 0, total)
+	limits := LimitsFromProblem(problem, lang)
+	caseIndex := make(map[string]int, total)
+	for i, testCase := range problem.TestCases {
+		caseIndex[testCase.ID] = i + 1
+	}
+	runCase := func(ctx context.Context, testCase model.TestCase) (model.TestResult, error) {
+		runResult, err := judge.Run(ctx, compileResult, testCase.Input, limits)
+		if err != nil {
+			// 沙箱调用失败属于系统错误，整个提交重新判题
+			return model.TestResult{}, fmt.Errorf("运行测试用例%s失败: %w", testCase.ID, err)
 		}
 
-		if timeUsed > maxTime {
-			maxTime = timeUsed
-		}
-		if memoryUsed > maxMemory {
-			maxMemory = memoryUsed
+		var check *CheckResult
+		if checker != nil && runResult.Status == model.StatusAccepted {
+			check, err = checker.Check(ctx, testCase.Input, testCase.Output, runResult.Output)
+			if err != nil {
+				return model.TestResult{}, fmt.Errorf("测试用例%s特殊判题失败: %w", testCase.ID, err)
+			}
 		}
+		result := m.processor.TestCaseResult(testCase, runResult, comparator, check)
+		m.publishTestCaseResult(ctx, task, &result, caseIndex[testCase.ID], progress.advance(), total)
+		return result, nil
+	}
+	executor := &caseExecutor{
+		balancer:    m.balancer,
+		sandbox:     sandbox,
+		parallelism: m.taskCfg.CaseParallelism,
+		processor:   m.processor,
+		run:         runCase,
+	}
+	stopOnFailure := problem.Constraints.RunPolicy == model.RunPolicyStopOnFailure
 
-		testResult := model.TestResult{
-			TestCaseID:     testCase.ID,
-			Status:         status,
-			TimeUsed:       timeUsed,
-			MemoryUsed:     memoryUsed,
-			Score:          score,
-			Input:          testCase.Input,
-			ExpectedOutput: testCase.Output,
-			ActualOutput:   runResult.Output,
-			JudgeDetails: model.JudgeDetail{
-				GoJudgeStatus: runResult.Status,
-				ExitStatus:    runResult.ExitStatus,
-				RuntimeNS:     runResult.Time,
-			},
-		}
-		testResults = append(testResults, testResult)
+	if plan != nil {
+		return m.judgeSubtasks(ctx, compileInfo, problem, plan, executor, stopOnFailure)
 	}
 
-	// 3. 清理缓存文件
-	if err := judge.CleanupFile(ctx, compileResult.ClassFileID); err != nil {
-		logger.Error("清理缓存文件失败", "file_id", compileResult.ClassFileID, "error", err)
+	var stop caseStop
+	if stopOnFailure {
+		stop = caseFailed
+	}
+	testResults, err := executor.runCases(ctx, problem.TestCases, stop)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. 计算最终状态
-	finalStatus := model.StatusAccepted
-	if totalScore == 0 {
-		finalStatus = model.StatusWrongAnswer
-		// 检查是否有特殊错误状态
-		for _, result := range testResults {
-			if result.Status == model.StatusTimeLimitExceeded ||
-				result.Status == model.StatusMemoryLimitExceeded ||
-				result.Status == model.StatusRuntimeError {
-				finalStatus = result.Status
-				break
-			}
+	// 3. 计算最终结果
+	return m.processor.FinalResult(compileInfo, testResults), nil
+}
+
+// judgeSubtasks 按子任务评测并计分，测试用例结果按题目中的顺序排列
+func (m *Manager) judgeSubtasks(ctx context.Context, compileInfo model.CompileInfo, problem *model.Problem, plan *subtaskPlan, executor *caseExecutor, stopOnFailure bool) (*queue.JudgeResult, error) {
+	results, subtaskResults, err := plan.execute(ctx, executor, stopOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	testResults := make([]model.TestResult, 0, len(problem.TestCases))
+	for _, testCase := range problem.TestCases {
+		result, ok := results[testCase.ID]
+		if !ok {
+			result = m.processor.SkippedResult(testCase)
 		}
+		testResults = append(testResults, result)
 	}
+	return m.processor.SubtaskFinalResult(compileInfo, testResults, subtaskResults), nil
+}
 
-	return &JudgeResult{
-		Status:      finalStatus,
-		Score:       totalScore,
-		TimeUsed:    maxTime,
-		MemoryUsed:  maxMemory,
-		CompileInfo: compileInfo,
-		TestResults: testResults,
-		JudgedAt:    time.Now(),
-	}, nil
+// cleanupFiles 删除沙箱中的缓存文件，失败时交给文件管理器稍后重试
+func (m *Manager) cleanupFiles(judge *LanguageJudge, fileIDs map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupRequestTimeout)
+	defer cancel()
+	for _, fileID := range fileIDs {
+		if err := judge.CleanupFile(ctx, fileID); err != nil {
+			logger.Error("清理缓存文件失败", "file_id", fileID, "error", err)
+			m.fileManager.Defer(judge.client, fileID)
+		}
+	}
 }
 
 // ProcessResult 处理判题结果
-func (m *Manager) ProcessResult(ctx context.Context, result *JudgeResult) error {
-	// 这里可以添加结果后处理逻辑
-	// 比如更新统计信息、发送通知等
+// 将结果转发为统计更新和用户通知，由worker服务消费
+func (m *Manager) ProcessResult(ctx context.Context, result *queue.JudgeResult) error {
+	update := &queue.StatsUpdate{
+		SubmissionID: result.SubmissionID,
+		UserID:       result.UserID,
+		ProblemID:    result.ProblemID,
+		ContestID:    result.ContestID,
+		Language:     result.Language,
+		Status:       result.Status,
+		Score:        result.Score,
+		TimeUsed:     result.TimeUsed,
+		MemoryUsed:   result.MemoryUsed,
+		JudgedAt:     result.JudgedAt,
+	}
+	if err := m.producer.PublishStatsUpdate(ctx, update); err != nil {
+		return fmt.Errorf("发布统计更新失败: %w", err)
+	}
+
+	notification := &queue.Notification{
+		Type:   queue.NotificationSubmissionResult,
+		UserID: result.UserID,
+		Title:  "判题完成",
+		Data: map[string]interface{}{
+			"submission_id": result.SubmissionID.Hex(),
+			"problem_id":    result.ProblemID.Hex(),
+			"status":        result.Status,
+			"score":         result.Score,
+		},
+	}
+	if err := m.producer.PublishNotification(ctx, notification); err != nil {
+		return fmt.Errorf("发布通知失败: %w", err)
+	}
 	return nil
 }
 
@@ -243,16 +404,17 @@ func (m *Manager) updateSubmissionStatus(ctx context.Context, submissionID primi
 }
 
 // updateSubmissionResult 更新提交结果
-func (m *Manager) updateSubmissionResult(ctx context.Context, submissionID primitive.ObjectID, result *JudgeResult) error {
+func (m *Manager) updateSubmissionResult(ctx context.Context, submissionID primitive.ObjectID, result *queue.JudgeResult) error {
 	submission := &model.Submission{
-		ID:          submissionID,
-		Status:      result.Status,
-		Score:       result.Score,
-		TimeUsed:    result.TimeUsed,
-		MemoryUsed:  result.MemoryUsed,
-		CompileInfo: result.CompileInfo,
-		TestResults: result.TestResults,
-		JudgedAt:    &result.JudgedAt,
+		ID:             submissionID,
+		Status:         result.Status,
+		Score:          result.Score,
+		TimeUsed:       result.TimeUsed,
+		MemoryUsed:     result.MemoryUsed,
+		CompileInfo:    result.CompileInfo,
+		TestResults:    result.TestResults,
+		SubtaskResults: result.SubtaskResults,
+		JudgedAt:       &result.JudgedAt,
 	}
 	return m.submissionRepo.UpdateResult(ctx, submission)
 }
@@ -278,5 +440,8 @@ func (m *Manager) updateSubmissionWithError(ctx context.Context, submissionID pr
 func (m *Manager) Shutdown() {
 	close(m.shutdown)
 	m.wg.Wait()
+	m.checkers.close()
+	m.balancer.Close()
+	m.fileManager.Close()
 	logger.Info("判题管理器已关闭")
 }
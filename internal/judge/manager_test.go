@@ -0,0 +1,227 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/judge/gojudge/gojudgetest"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 判题管理器用到的内存仓储和消息队列；嵌入的接口为nil，调用未实现的方法会panic
+
+type fakeSubmissionRepo struct {
+	interfaces.SubmissionRepository
+
+	mu          sync.Mutex
+	submissions map[primitive.ObjectID]*model.Submission
+}
+
+func (r *fakeSubmissionRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission, ok := r.submissions[id]
+	if !ok {
+		return errors.New("提交记录不存在")
+	}
+	submission.Status = status
+	return nil
+}
+
+func (r *fakeSubmissionRepo) UpdateResult(ctx context.Context, result *model.Submission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission, ok := r.submissions[result.ID]
+	if !ok {
+		return errors.New("提交记录不存在")
+	}
+	submission.Status = result.Status
+	submission.Score = result.Score
+	submission.CompileInfo = result.CompileInfo
+	submission.TestResults = result.TestResults
+	submission.JudgedAt = result.JudgedAt
+	return nil
+}
+
+type fakeProblemRepo struct {
+	interfaces.ProblemRepository
+	problem *model.Problem
+}
+
+func (r *fakeProblemRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Problem, error) {
+	if id != r.problem.ID {
+		return nil, errors.New("题目不存在")
+	}
+	return r.problem, nil
+}
+
+type fakeJudgeTaskRepo struct {
+	interfaces.JudgeTaskRepository
+
+	mu    sync.Mutex
+	tasks map[primitive.ObjectID]*model.JudgeTask
+}
+
+func (r *fakeJudgeTaskRepo) Create(ctx context.Context, task *model.JudgeTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[task.SubmissionID]; !ok {
+		copied := *task
+		copied.Status = model.JudgeTaskPending
+		r.tasks[task.SubmissionID] = &copied
+	}
+	return nil
+}
+
+func (r *fakeJudgeTaskRepo) Acquire(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (*model.JudgeTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task := r.tasks[submissionID]
+	if task == nil || task.Status != model.JudgeTaskPending {
+		return nil, nil
+	}
+	task.Status = model.JudgeTaskProcessing
+	task.AssignedJudge = judgeID
+	copied := *task
+	return &copied, nil
+}
+
+func (r *fakeJudgeTaskRepo) Heartbeat(ctx context.Context, submissionID primitive.ObjectID, judgeID string, progress model.JudgeProgress) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeJudgeTaskRepo) Complete(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task := r.tasks[submissionID]
+	if task == nil || task.AssignedJudge != judgeID {
+		return false, nil
+	}
+	task.Status = model.JudgeTaskCompleted
+	return true, nil
+}
+
+type fakeProducer struct {
+	queue.Producer
+
+	mu      sync.Mutex
+	results []*queue.JudgeResult
+}
+
+func (p *fakeProducer) PublishJudgeResult(ctx context.Context, result *queue.JudgeResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = append(p.results, result)
+	return nil
+}
+
+type fakeEvents struct{}
+
+func (fakeEvents) Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) error {
+	return nil
+}
+
+// TestProcessTaskJava 在模拟沙箱上完整评测Java提交：编译一次、按文件ID运行各测试用例、判题结束后清理编译产物
+func TestProcessTaskJava(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		wantStatus string
+		wantScore  int
+		wantRuns   int
+	}{
+		{name: "accepted", code: javaSum, wantStatus: model.StatusAccepted, wantScore: 100, wantRuns: 2},
+		{name: "wrong answer", code: javaWrongAnswer, wantStatus: model.StatusWrongAnswer, wantScore: 0, wantRuns: 2},
+		{name: "compile error", code: javaSyntaxError, wantStatus: model.StatusCompileError, wantScore: 0, wantRuns: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := gojudgetest.NewServer()
+			defer srv.Close()
+			srv.SetRunFunc(fakeJava)
+
+			problem := &model.Problem{
+				ID:          primitive.NewObjectID(),
+				TimeLimit:   1000,
+				MemoryLimit: 256,
+				TestCases: []model.TestCase{
+					{ID: "1", Input: "1 2\n", Output: "3\n", Score: 40},
+					{ID: "2", Input: "20 22\n", Output: "42\n", Score: 60},
+				},
+			}
+			submission := &model.Submission{
+				ID:        primitive.NewObjectID(),
+				UserID:    primitive.NewObjectID(),
+				ProblemID: problem.ID,
+				Language:  model.LanguageJava,
+				Code:      tt.code,
+				Status:    model.StatusPending,
+			}
+			submissions := &fakeSubmissionRepo{submissions: map[primitive.ObjectID]*model.Submission{submission.ID: submission}}
+			tasks := &fakeJudgeTaskRepo{tasks: make(map[primitive.ObjectID]*model.JudgeTask)}
+			producer := &fakeProducer{}
+
+			manager, err := NewManager(config.JudgeConfig{
+				Sandboxes: []config.SandboxConfig{{URL: srv.URL, Timeout: 5 * time.Second}},
+				Task:      config.JudgeTaskConfig{JudgeID: "judge-test"},
+			}, submissions, &fakeProblemRepo{problem: problem}, tasks, producer, fakeEvents{})
+			if err != nil {
+				t.Fatalf("创建判题管理器失败: %v", err)
+			}
+			defer manager.Shutdown()
+
+			err = manager.ProcessTask(context.Background(), &queue.JudgeTask{
+				SubmissionID: submission.ID,
+				ProblemID:    problem.ID,
+				UserID:       submission.UserID,
+				Code:         tt.code,
+				Language:     model.LanguageJava,
+				Lane:         queue.LanePractice,
+				CreatedAt:    time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("处理判题任务失败: %v", err)
+			}
+
+			if submission.Status != tt.wantStatus || submission.Score != tt.wantScore {
+				t.Errorf("提交结果 = %s/%d, 期望 %s/%d", submission.Status, submission.Score, tt.wantStatus, tt.wantScore)
+			}
+			if submission.JudgedAt == nil {
+				t.Error("未记录判题时间")
+			}
+			if task := tasks.tasks[submission.ID]; task.Status != model.JudgeTaskCompleted {
+				t.Errorf("判题任务状态 = %s, 期望%s", task.Status, model.JudgeTaskCompleted)
+			}
+			if len(producer.results) != 1 || producer.results[0].Status != tt.wantStatus || producer.results[0].SubmissionID != submission.ID {
+				t.Errorf("发布的判题结果 = %+v", producer.results)
+			}
+
+			compiles, runs := 0, 0
+			for _, cmd := range srv.Cmds() {
+				if len(cmd.CopyOutCached) > 0 {
+					compiles++
+					continue
+				}
+				runs++
+				if cmd.CopyIn["Main.class"].FileID == "" {
+					t.Errorf("运行命令应按文件ID复制Main.class: %+v", cmd.CopyIn)
+				}
+			}
+			if compiles != 1 || runs != tt.wantRuns {
+				t.Errorf("编译%d次、运行%d次, 期望编译1次、运行%d次", compiles, runs, tt.wantRuns)
+			}
+			if files := srv.CachedFiles(); len(files) != 0 {
+				t.Errorf("判题结束后仍有缓存文件: %v", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package judge
+
+import (
+	"math"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/queue"
+)
+
+// ResultProcessor 判题结果处理器
+// 负责比对输出、生成测试用例结果和汇总最终结果
+type ResultProcessor struct{}
+
+// NewResultProcessor 创建结果处理器
+func NewResultProcessor() *ResultProcessor {
+	return &ResultProcessor{}
+}
+
+// TestCaseResult 根据运行结果生成测试用例结果
+// check为特殊判题结果，为nil时使用comparator比对输出
+func (p *ResultProcessor) TestCaseResult(testCase model.TestCase, run *RunResult, comparator Comparator, check *CheckResult) model.TestResult {
+	status := run.Status
+	var diff string
+	if status == model.StatusAccepted {
+		if check != nil {
+			status = check.Status
+		} else {
+			compared := comparator.Compare(testCase.Output, run.Output)
+			status, diff = compared.Status, compared.Diff
+		}
+	}
+	ratio := 0.0
+	switch {
+	case status == model.StatusAccepted:
+		ratio = 1
+	case check != nil && run.Status == model.StatusAccepted:
+		ratio = check.ScoreRatio
+	}
+
+	result := model.TestResult{
+		TestCaseID:     testCase.ID,
+		Status:         status,
+		TimeUsed:       int(run.Time / 1000000), // 纳秒转毫秒
+		MemoryUsed:     int(run.Memory / 1024),  // 字节转KB
+		Score:          scaleScore(testCase.Score, ratio),
+		ScoreRatio:     ratio,
+		Input:          testCase.Input,
+		ExpectedOutput: testCase.Output,
+		ActualOutput:   run.Output,
+		DiffSummary:    diff,
+		JudgeDetails: model.JudgeDetail{
+			GoJudgeStatus: run.GoJudgeStatus,
+			ExitStatus:    run.ExitStatus,
+			RuntimeNS:     run.Time,
+		},
+	}
+	if check != nil {
+		result.CheckerVerdict = check.Verdict
+		result.CheckerMessage = check.Message
+	}
+	return result
+}
+
+// scaleScore 按得分率折算分数，向下取整；加上微小的误差避免0.3*10这类浮点误差少算1分
+func scaleScore(score int, ratio float64) int {
+	return int(math.Floor(float64(score)*ratio + 1e-9))
+}
+
+// caseScoreRatio 用例得分率，通过的用例为1，未评测的用例为0
+func caseScoreRatio(result model.TestResult) float64 {
+	if result.Status == model.StatusAccepted {
+		return 1
+	}
+	return result.ScoreRatio
+}
+
+// SkippedResult 未评测的测试用例结果
+func (p *ResultProcessor) SkippedResult(testCase model.TestCase) model.TestResult {
+	return model.TestResult{
+		TestCaseID:     testCase.ID,
+		Status:         model.StatusSkipped,
+		Input:          testCase.Input,
+		ExpectedOutput: testCase.Output,
+	}
+}
+
+// SubtaskResult 按计分策略计算子任务得分
+// 用例均未设置分数时每个用例权重相同；特殊判题部分得分的用例按得分率计分；
+// results与testCases一一对应，未评测的用例为SKIPPED
+func (p *ResultProcessor) SubtaskResult(subtask model.Subtask, testCases []model.TestCase, results []model.TestResult) model.SubtaskResult {
+	result := model.SubtaskResult{
+		SubtaskID: subtask.ID,
+		Status:    model.StatusAccepted,
+		FullScore: subtask.Score,
+	}
+
+	weighted := false
+	for _, testCase := range testCases {
+		if testCase.Score > 0 {
+			weighted = true
+			break
+		}
+	}
+	totalWeight, earnedWeight, minRatio := 0, 0.0, 1.0
+	for i, caseResult := range results {
+		weight := 1
+		if weighted {
+			weight = testCases[i].Score
+		}
+		ratio := caseScoreRatio(caseResult)
+		totalWeight += weight
+		earnedWeight += float64(weight) * ratio
+		minRatio = math.Min(minRatio, ratio)
+		if caseResult.Status != model.StatusAccepted && result.Status == model.StatusAccepted {
+			result.Status = caseResult.Status
+		}
+	}
+
+	switch subtask.Policy {
+	case model.SubtaskPolicySum:
+		if totalWeight > 0 {
+			result.Score = scaleScore(subtask.Score, earnedWeight/float64(totalWeight))
+		}
+	case model.SubtaskPolicyMin:
+		result.Score = scaleScore(subtask.Score, minRatio)
+	default:
+		if result.Status == model.StatusAccepted {
+			result.Score = subtask.Score
+		}
+	}
+	return result
+}
+
+// SkippedSubtaskResult 前置子任务未通过时的子任务结果
+func (p *ResultProcessor) SkippedSubtaskResult(subtask model.Subtask) model.SubtaskResult {
+	return model.SubtaskResult{
+		SubtaskID: subtask.ID,
+		Status:    model.StatusSkipped,
+		FullScore: subtask.Score,
+	}
+}
+
+// CompileErrorResult 编译失败的判题结果
+func (p *ResultProcessor) CompileErrorResult(compile *CompileResult) *queue.JudgeResult {
+	return &queue.JudgeResult{
+		Status:     model.StatusCompileError,
+		Score:      0,
+		TimeUsed:   int(compile.Time / 1000000), // 纳秒转毫秒
+		MemoryUsed: int(compile.Memory / 1024),  // 字节转KB
+		CompileInfo: model.CompileInfo{
+			Status:     "FAILED",
+			TimeUsed:   compile.Time,
+			MemoryUsed: compile.Memory,
+			Message:    compile.ErrorMessage,
+		},
+		TestResults: []model.TestResult{},
+		JudgedAt:    time.Now(),
+	}
+}
+
+// FinalResult 汇总测试用例结果
+// 全部通过为ACCEPTED，否则取第一个未通过(且已评测)用例的状态；得分为各用例得分之和
+func (p *ResultProcessor) FinalResult(compileInfo model.CompileInfo, testResults []model.TestResult) *queue.JudgeResult {
+	status := model.StatusAccepted
+	totalScore, maxTime, maxMemory := 0, 0, 0
+	for _, result := range testResults {
+		if status == model.StatusAccepted && result.Status != model.StatusAccepted && result.Status != model.StatusSkipped {
+			status = result.Status
+		}
+		totalScore += result.Score
+		if result.TimeUsed > maxTime {
+			maxTime = result.TimeUsed
+		}
+		if result.MemoryUsed > maxMemory {
+			maxMemory = result.MemoryUsed
+		}
+	}
+
+	return &queue.JudgeResult{
+		Status:      status,
+		Score:       totalScore,
+		TimeUsed:    maxTime,
+		MemoryUsed:  maxMemory,
+		CompileInfo: compileInfo,
+		TestResults: testResults,
+		JudgedAt:    time.Now(),
+	}
+}
+
+// SubtaskFinalResult 汇总子任务题目的结果
+// 全部子任务通过为ACCEPTED，部分得分为PARTIAL_ACCEPTED，0分时取第一个未通过用例的状态；得分为子任务得分之和
+func (p *ResultProcessor) SubtaskFinalResult(compileInfo model.CompileInfo, testResults []model.TestResult, subtaskResults []model.SubtaskResult) *queue.JudgeResult {
+	result := p.FinalResult(compileInfo, testResults)
+	result.SubtaskResults = subtaskResults
+
+	allPassed := true
+	result.Score = 0
+	for _, subtask := range subtaskResults {
+		result.Score += subtask.Score
+		if subtask.Status != model.StatusAccepted {
+			allPassed = false
+		}
+	}
+	switch {
+	case allPassed:
+		result.Status = model.StatusAccepted
+	case result.Score > 0:
+		result.Status = model.StatusPartialAccepted
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+
+	"zhku-oj/internal/model"
+)
+
+// subtaskPlan 子任务评测计划，子任务按依赖关系排序
+type subtaskPlan struct {
+	subtasks  []model.Subtask // 前置子任务在前
+	order     []string        // 题目中定义的子任务顺序
+	testCases map[string]model.TestCase
+}
+
+// ValidateSubtasks 检查题目的子任务配置
+// 子任务ID唯一、计分策略有效、引用的测试用例和前置子任务存在、依赖无环，且每个测试用例至少属于一个子任务
+func ValidateSubtasks(problem *model.Problem) error {
+	if len(problem.Subtasks) == 0 {
+		return nil
+	}
+	_, err := newSubtaskPlan(problem)
+	return err
+}
+
+// newSubtaskPlan 校验子任务配置并按依赖关系排序
+func newSubtaskPlan(problem *model.Problem) (*subtaskPlan, error) {
+	plan := &subtaskPlan{testCases: make(map[string]model.TestCase, len(problem.TestCases))}
+	for _, testCase := range problem.TestCases {
+		plan.testCases[testCase.ID] = testCase
+	}
+
+	defined := make(map[string]bool, len(problem.Subtasks))
+	referenced := make(map[string]bool, len(problem.TestCases))
+	for _, subtask := range problem.Subtasks {
+		if subtask.ID == "" {
+			return nil, fmt.Errorf("子任务ID不能为空")
+		}
+		if defined[subtask.ID] {
+			return nil, fmt.Errorf("子任务ID重复: %s", subtask.ID)
+		}
+		defined[subtask.ID] = true
+		plan.order = append(plan.order, subtask.ID)
+
+		switch subtask.Policy {
+		case model.SubtaskPolicyAllOrNothing, model.SubtaskPolicyMin, model.SubtaskPolicySum:
+		default:
+			return nil, fmt.Errorf("子任务%s的计分策略无效: %s", subtask.ID, subtask.Policy)
+		}
+		if subtask.Score < 0 {
+			return nil, fmt.Errorf("子任务%s的分数不能为负数", subtask.ID)
+		}
+		if len(subtask.TestCaseIDs) == 0 {
+			return nil, fmt.Errorf("子任务%s没有测试用例", subtask.ID)
+		}
+		for _, id := range subtask.TestCaseIDs {
+			if _, ok := plan.testCases[id]; !ok {
+				return nil, fmt.Errorf("子任务%s引用了不存在的测试用例: %s", subtask.ID, id)
+			}
+			referenced[id] = true
+		}
+	}
+	for _, testCase := range problem.TestCases {
+		if !referenced[testCase.ID] {
+			return nil, fmt.Errorf("测试用例%s不属于任何子任务", testCase.ID)
+		}
+	}
+	for _, subtask := range problem.Subtasks {
+		for _, dep := range subtask.Dependencies {
+			if !defined[dep] {
+				return nil, fmt.Errorf("子任务%s的前置子任务不存在: %s", subtask.ID, dep)
+			}
+		}
+	}
+
+	// 按定义顺序反复挑选前置子任务均已排好的子任务，无法继续时说明存在循环依赖
+	placed := make(map[string]bool, len(problem.Subtasks))
+	for len(plan.subtasks) < len(problem.Subtasks) {
+		progressed := false
+		for _, subtask := range problem.Subtasks {
+			if placed[subtask.ID] || !allPlaced(subtask.Dependencies, placed) {
+				continue
+			}
+			placed[subtask.ID] = true
+			plan.subtasks = append(plan.subtasks, subtask)
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("子任务存在循环依赖")
+		}
+	}
+	return plan, nil
+}
+
+func allPlaced(ids []string, placed map[string]bool) bool {
+	for _, id := range ids {
+		if !placed[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// execute 按子任务评测测试用例
+// 前置子任务未全部满分时跳过本子任务；all_or_nothing策略在用例未通过后、min策略在用例得0分后跳过剩余用例；
+// stopOnFailure为true时第一个未通过的子任务之后的子任务全部跳过。
+// 同一测试用例属于多个子任务时只运行一次。返回已运行用例的结果(按用例ID)和各子任务结果(按题目定义顺序)
+func (p *subtaskPlan) execute(ctx context.Context, executor *caseExecutor, stopOnFailure bool) (map[string]model.TestResult, []model.SubtaskResult, error) {
+	processor := executor.processor
+	results := make(map[string]model.TestResult, len(p.testCases))
+	subtaskResults := make(map[string]model.SubtaskResult, len(p.subtasks))
+	stopped := false
+
+	for _, subtask := range p.subtasks {
+		if stopped || !p.dependenciesPassed(subtask, subtaskResults) {
+			subtaskResults[subtask.ID] = processor.SkippedSubtaskResult(subtask)
+			continue
+		}
+
+		// 已在其他子任务中运行过的用例直接复用结果
+		var stop caseStop
+		switch {
+		case stopOnFailure || subtask.Policy == model.SubtaskPolicyAllOrNothing:
+			stop = caseFailed
+		case subtask.Policy == model.SubtaskPolicyMin:
+			stop = caseScoredZero
+		}
+		testCases := make([]model.TestCase, len(subtask.TestCaseIDs))
+		caseResults := make([]model.TestResult, len(subtask.TestCaseIDs))
+		var pending []model.TestCase
+		var pendingIndex []int
+		cachedStop := false
+		for i, id := range subtask.TestCaseIDs {
+			testCases[i] = p.testCases[id]
+			if result, ok := results[id]; ok {
+				caseResults[i] = result
+				cachedStop = cachedStop || (stop != nil && stop(result))
+				continue
+			}
+			pending = append(pending, testCases[i])
+			pendingIndex = append(pendingIndex, i)
+		}
+
+		if cachedStop {
+			for _, i := range pendingIndex {
+				caseResults[i] = processor.SkippedResult(testCases[i])
+			}
+		} else {
+			ran, err := executor.runCases(ctx, pending, stop)
+			if err != nil {
+				return nil, nil, err
+			}
+			for j, i := range pendingIndex {
+				caseResults[i] = ran[j]
+				if ran[j].Status != model.StatusSkipped {
+					results[testCases[i].ID] = ran[j]
+				}
+			}
+		}
+
+		result := processor.SubtaskResult(subtask, testCases, caseResults)
+		subtaskResults[subtask.ID] = result
+		if stopOnFailure && result.Status != model.StatusAccepted {
+			stopped = true
+		}
+	}
+
+	ordered := make([]model.SubtaskResult, 0, len(p.order))
+	for _, id := range p.order {
+		ordered = append(ordered, subtaskResults[id])
+	}
+	return results, ordered, nil
+}
+
+// dependenciesPassed 前置子任务是否均已满分通过
+func (p *subtaskPlan) dependenciesPassed(subtask model.Subtask, results map[string]model.SubtaskResult) bool {
+	for _, dep := range subtask.Dependencies {
+		if results[dep].Status != model.StatusAccepted {
+			return false
+		}
+	}
+	return true
+}
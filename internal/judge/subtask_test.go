@@ -0,0 +1,260 @@
+package judge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"zhku-oj/internal/model"
+)
+
+// subtaskProblem 5个用例、3个子任务的题目：c2和c4被两个子任务共用，s3依赖s1
+func subtaskProblem() *model.Problem {
+	problem := &model.Problem{}
+	for _, id := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		problem.TestCases = append(problem.TestCases, model.TestCase{ID: id, Input: id, Output: id})
+	}
+	problem.Subtasks = []model.Subtask{
+		{ID: "s1", Score: 20, Policy: model.SubtaskPolicyAllOrNothing, TestCaseIDs: []string{"c1", "c2"}},
+		{ID: "s2", Score: 30, Policy: model.SubtaskPolicySum, TestCaseIDs: []string{"c2", "c3", "c4"}},
+		{ID: "s3", Score: 50, Policy: model.SubtaskPolicyMin, TestCaseIDs: []string{"c4", "c5"}, Dependencies: []string{"s1"}},
+	}
+	return problem
+}
+
+func TestValidateSubtasks(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *model.Problem)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(p *model.Problem) {}},
+		{name: "no subtasks", modify: func(p *model.Problem) { p.Subtasks = nil }},
+		{name: "empty id", modify: func(p *model.Problem) { p.Subtasks[0].ID = "" }, wantErr: true},
+		{name: "duplicate id", modify: func(p *model.Problem) { p.Subtasks[1].ID = "s1" }, wantErr: true},
+		{name: "invalid policy", modify: func(p *model.Problem) { p.Subtasks[0].Policy = "max" }, wantErr: true},
+		{name: "negative score", modify: func(p *model.Problem) { p.Subtasks[0].Score = -1 }, wantErr: true},
+		{name: "no test cases", modify: func(p *model.Problem) { p.Subtasks[0].TestCaseIDs = nil }, wantErr: true},
+		{name: "unknown test case", modify: func(p *model.Problem) { p.Subtasks[0].TestCaseIDs = []string{"c1", "c9"} }, wantErr: true},
+		{name: "test case without subtask", modify: func(p *model.Problem) {
+			p.TestCases = append(p.TestCases, model.TestCase{ID: "c6"})
+		}, wantErr: true},
+		{name: "unknown dependency", modify: func(p *model.Problem) { p.Subtasks[2].Dependencies = []string{"s9"} }, wantErr: true},
+		{name: "self dependency", modify: func(p *model.Problem) { p.Subtasks[0].Dependencies = []string{"s1"} }, wantErr: true},
+		{name: "cyclic dependencies", modify: func(p *model.Problem) { p.Subtasks[0].Dependencies = []string{"s3"} }, wantErr: true},
+		{name: "dependency defined later", modify: func(p *model.Problem) {
+			p.Subtasks[2].Dependencies = nil
+			p.Subtasks[0].Dependencies = []string{"s3"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := subtaskProblem()
+			tt.modify(problem)
+			if err := ValidateSubtasks(problem); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSubtasks = %v, 期望返回error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubtaskResult(t *testing.T) {
+	results := func(statuses ...string) []model.TestResult {
+		var list []model.TestResult
+		for _, status := range statuses {
+			list = append(list, model.TestResult{Status: status})
+		}
+		return list
+	}
+	unweighted := []model.TestCase{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	weighted := []model.TestCase{{ID: "a", Score: 10}, {ID: "b", Score: 30}, {ID: "c", Score: 60}}
+	ac, wa, tle, skipped := model.StatusAccepted, model.StatusWrongAnswer, model.StatusTimeLimitExceeded, model.StatusSkipped
+	// 特殊判题部分得分的用例结果
+	partial := func(ratio float64) model.TestResult {
+		return model.TestResult{Status: model.StatusPartialAccepted, ScoreRatio: ratio}
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		testCases  []model.TestCase
+		results    []model.TestResult
+		wantStatus string
+		wantScore  int
+	}{
+		{name: "all or nothing passed", policy: model.SubtaskPolicyAllOrNothing, testCases: unweighted,
+			results: results(ac, ac, ac), wantStatus: ac, wantScore: 60},
+		{name: "all or nothing failed", policy: model.SubtaskPolicyAllOrNothing, testCases: unweighted,
+			results: results(ac, tle, skipped), wantStatus: tle, wantScore: 0},
+		{name: "all or nothing partial", policy: model.SubtaskPolicyAllOrNothing, testCases: unweighted,
+			results: []model.TestResult{{Status: ac}, partial(0.9), {Status: ac}}, wantStatus: model.StatusPartialAccepted, wantScore: 0},
+		{name: "min passed", policy: model.SubtaskPolicyMin, testCases: weighted,
+			results: results(ac, ac, ac), wantStatus: ac, wantScore: 60},
+		{name: "min failed", policy: model.SubtaskPolicyMin, testCases: weighted,
+			results: results(ac, ac, wa), wantStatus: wa, wantScore: 0},
+		{name: "min partial", policy: model.SubtaskPolicyMin, testCases: weighted,
+			results: []model.TestResult{{Status: ac}, partial(0.8), partial(0.5)}, wantStatus: model.StatusPartialAccepted, wantScore: 30},
+		{name: "min partial rounds down", policy: model.SubtaskPolicyMin, testCases: unweighted,
+			results: []model.TestResult{partial(0.25), {Status: ac}, {Status: ac}}, wantStatus: model.StatusPartialAccepted, wantScore: 15},
+		{name: "sum unweighted", policy: model.SubtaskPolicySum, testCases: unweighted,
+			results: results(ac, wa, ac), wantStatus: wa, wantScore: 40},
+		{name: "sum weighted", policy: model.SubtaskPolicySum, testCases: weighted,
+			results: results(wa, ac, tle), wantStatus: wa, wantScore: 18},
+		{name: "sum rounds down", policy: model.SubtaskPolicySum, testCases: []model.TestCase{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+			results: results(ac, wa, wa), wantStatus: wa, wantScore: 20},
+		{name: "sum passed", policy: model.SubtaskPolicySum, testCases: weighted,
+			results: results(ac, ac, ac), wantStatus: ac, wantScore: 60},
+		{name: "sum partial", policy: model.SubtaskPolicySum, testCases: weighted,
+			results: []model.TestResult{partial(0.5), {Status: ac}, {Status: wa}}, wantStatus: model.StatusPartialAccepted, wantScore: 21},
+	}
+
+	processor := NewResultProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtask := model.Subtask{ID: "s", Score: 60, Policy: tt.policy}
+			got := processor.SubtaskResult(subtask, tt.testCases, tt.results)
+			want := model.SubtaskResult{SubtaskID: "s", Status: tt.wantStatus, Score: tt.wantScore, FullScore: 60}
+			if got != want {
+				t.Errorf("SubtaskResult = %+v, 期望 %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestJudgeSubtasks 按子任务评测：共用的用例只运行一次，前置子任务未满分时跳过，
+// all_or_nothing在用例未通过后、min在用例得0分后跳过剩余用例，stop_on_failure时第一个未通过的子任务之后全部跳过
+func TestJudgeSubtasks(t *testing.T) {
+	ac, wa, tle, skipped, partial := model.StatusAccepted, model.StatusWrongAnswer, model.StatusTimeLimitExceeded,
+		model.StatusSkipped, model.StatusPartialAccepted
+	subtask := func(id, status string, score, full int) model.SubtaskResult {
+		return model.SubtaskResult{SubtaskID: id, Status: status, Score: score, FullScore: full}
+	}
+
+	tests := []struct {
+		name          string
+		failing       map[string]string  // 未通过的用例及其状态
+		partial       map[string]float64 // 部分得分的用例及其得分率
+		stopOnFailure bool
+		wantRuns      []string
+		wantSubtasks  []model.SubtaskResult
+		wantStatus    string
+		wantScore     int
+	}{
+		{
+			name:         "all passed",
+			wantRuns:     []string{"c1", "c2", "c3", "c4", "c5"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", ac, 30, 30), subtask("s3", ac, 50, 50)},
+			wantStatus:   ac, wantScore: 100,
+		},
+		{
+			// s1未通过时跳过c2，c2在s2中仍需运行；s3依赖s1，不评测
+			name:         "dependency failed",
+			failing:      map[string]string{"c1": wa},
+			wantRuns:     []string{"c1", "c2", "c3", "c4"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", wa, 0, 20), subtask("s2", ac, 30, 30), subtask("s3", skipped, 0, 50)},
+			wantStatus:   partial, wantScore: 30,
+		},
+		{
+			name:         "sum continues after failure",
+			failing:      map[string]string{"c3": tle},
+			wantRuns:     []string{"c1", "c2", "c3", "c4", "c5"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", tle, 20, 30), subtask("s3", ac, 50, 50)},
+			wantStatus:   partial, wantScore: 90,
+		},
+		{
+			// c4已在s2中未通过，s3按min策略直接跳过c5
+			name:         "shared case failed",
+			failing:      map[string]string{"c4": wa},
+			wantRuns:     []string{"c1", "c2", "c3", "c4"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", wa, 20, 30), subtask("s3", wa, 0, 50)},
+			wantStatus:   partial, wantScore: 40,
+		},
+		{
+			// c4部分得分，min策略继续运行c5，按得分率最低的用例计分
+			name:         "shared case partial",
+			partial:      map[string]float64{"c4": 0.5},
+			wantRuns:     []string{"c1", "c2", "c3", "c4", "c5"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", partial, 25, 30), subtask("s3", partial, 25, 50)},
+			wantStatus:   partial, wantScore: 70,
+		},
+		{
+			name:         "min partial",
+			partial:      map[string]float64{"c5": 0.2},
+			wantRuns:     []string{"c1", "c2", "c3", "c4", "c5"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", ac, 30, 30), subtask("s3", partial, 10, 50)},
+			wantStatus:   partial, wantScore: 60,
+		},
+		{
+			name:          "stop on failure",
+			failing:       map[string]string{"c3": tle},
+			stopOnFailure: true,
+			wantRuns:      []string{"c1", "c2", "c3"},
+			wantSubtasks:  []model.SubtaskResult{subtask("s1", ac, 20, 20), subtask("s2", tle, 10, 30), subtask("s3", skipped, 0, 50)},
+			wantStatus:    partial, wantScore: 30,
+		},
+		{
+			// 0分时取第一个未通过用例的状态
+			name:         "nothing passed",
+			failing:      map[string]string{"c1": tle, "c2": wa, "c3": wa, "c4": wa, "c5": wa},
+			wantRuns:     []string{"c1", "c2", "c3", "c4"},
+			wantSubtasks: []model.SubtaskResult{subtask("s1", tle, 0, 20), subtask("s2", wa, 0, 30), subtask("s3", skipped, 0, 50)},
+			wantStatus:   tle, wantScore: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := subtaskProblem()
+			plan, err := newSubtaskPlan(problem)
+			if err != nil {
+				t.Fatalf("子任务配置无效: %v", err)
+			}
+
+			var runs []string
+			processor := NewResultProcessor()
+			executor := &caseExecutor{
+				parallelism: 1,
+				processor:   processor,
+				run: func(ctx context.Context, testCase model.TestCase) (model.TestResult, error) {
+					runs = append(runs, testCase.ID)
+					result := model.TestResult{TestCaseID: testCase.ID, Status: model.StatusAccepted}
+					if failed, ok := tt.failing[testCase.ID]; ok {
+						result.Status = failed
+					}
+					if ratio, ok := tt.partial[testCase.ID]; ok {
+						result.Status, result.ScoreRatio = model.StatusPartialAccepted, ratio
+					}
+					return result, nil
+				},
+			}
+			manager := &Manager{processor: processor}
+			result, err := manager.judgeSubtasks(context.Background(), model.CompileInfo{}, problem, plan, executor, tt.stopOnFailure)
+			if err != nil {
+				t.Fatalf("评测失败: %v", err)
+			}
+
+			if !reflect.DeepEqual(runs, tt.wantRuns) {
+				t.Errorf("运行的用例 = %v, 期望 %v", runs, tt.wantRuns)
+			}
+			if !reflect.DeepEqual(result.SubtaskResults, tt.wantSubtasks) {
+				t.Errorf("子任务结果 = %+v, 期望 %+v", result.SubtaskResults, tt.wantSubtasks)
+			}
+			if result.Status != tt.wantStatus || result.Score != tt.wantScore {
+				t.Errorf("判题结果 = %s/%d, 期望 %s/%d", result.Status, result.Score, tt.wantStatus, tt.wantScore)
+			}
+			if len(result.TestResults) != len(problem.TestCases) {
+				t.Fatalf("用例结果%d个, 期望每个用例一个", len(result.TestResults))
+			}
+			for i, testResult := range result.TestResults {
+				ran := false
+				for _, id := range runs {
+					ran = ran || id == testResult.TestCaseID
+				}
+				if testResult.TestCaseID != problem.TestCases[i].ID || ran == (testResult.Status == skipped) {
+					t.Errorf("第%d个用例结果 = %s/%s, 运行过: %v", i+1, testResult.TestCaseID, testResult.Status, ran)
+				}
+			}
+		})
+	}
+}
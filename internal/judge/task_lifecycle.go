@@ -0,0 +1,323 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/realtime"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 判题任务生命周期默认参数
+const (
+	defaultTaskMaxRetries    = 3
+	defaultRetryBackoff      = 5 * time.Second
+	defaultMaxBackoff        = 5 * time.Minute
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultHeartbeatTimeout  = time.Minute
+	defaultScanInterval      = 15 * time.Second
+	defaultSandboxWait       = time.Minute
+	defaultCaseParallelism   = 4
+	taskScanBatch            = 100
+)
+
+// withTaskDefaults 填充未配置的任务生命周期参数
+func withTaskDefaults(cfg config.JudgeTaskConfig) config.JudgeTaskConfig {
+	if cfg.JudgeID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "judger"
+		}
+		cfg.JudgeID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultTaskMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.HeartbeatTimeout <= cfg.HeartbeatInterval {
+		cfg.HeartbeatTimeout = defaultHeartbeatTimeout
+		if cfg.HeartbeatTimeout <= cfg.HeartbeatInterval {
+			cfg.HeartbeatTimeout = 3 * cfg.HeartbeatInterval
+		}
+	}
+	if cfg.ScanInterval <= 0 {
+		cfg.ScanInterval = defaultScanInterval
+	}
+	if cfg.SandboxWait <= 0 {
+		cfg.SandboxWait = defaultSandboxWait
+	}
+	if cfg.CaseParallelism <= 0 {
+		cfg.CaseParallelism = defaultCaseParallelism
+	}
+	return cfg
+}
+
+// taskError 判题过程中的系统错误，按ErrorType记录到judge_queue
+type taskError struct {
+	Type string
+	Err  error
+}
+
+func (e *taskError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *taskError) Unwrap() error {
+	return e.Err
+}
+
+// taskProgress 判题进度，由判题流程更新、心跳协程读取
+type taskProgress struct {
+	mu       sync.Mutex
+	progress model.JudgeProgress
+}
+
+// setStage 更新判题阶段
+func (p *taskProgress) setStage(stage string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.Stage = stage
+	p.progress.TotalTestCases = total
+}
+
+// advance 完成一个测试用例，返回已完成的用例数
+func (p *taskProgress) advance() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.CurrentTestCase++
+	return p.progress.CurrentTestCase
+}
+
+// snapshot 当前进度
+func (p *taskProgress) snapshot() model.JudgeProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.progress
+}
+
+// startHeartbeat 启动心跳协程，定期刷新心跳和进度
+// 任务被回收(不再属于本判题机)时取消判题并将lost置为true
+// 返回的函数停止心跳并等待协程退出
+func (m *Manager) startHeartbeat(ctx context.Context, cancel context.CancelFunc, submissionID primitive.ObjectID, progress *taskProgress, lost *atomic.Bool) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.taskCfg.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				owned, err := m.taskRepo.Heartbeat(ctx, submissionID, m.taskCfg.JudgeID, progress.snapshot())
+				if err != nil {
+					// 偶发的存储错误不中断判题，超时前还有多次心跳机会
+					logger.Warn("更新判题心跳失败", "submission_id", submissionID.Hex(), "error", err)
+					continue
+				}
+				if !owned {
+					logger.Warn("判题任务已被回收，停止判题", "submission_id", submissionID.Hex())
+					lost.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// retryDelay 第retryCount次失败后的重试等待时间(指数退避)
+func (m *Manager) retryDelay(retryCount int) time.Duration {
+	delay := m.taskCfg.RetryBackoff
+	for i := 0; i < retryCount; i++ {
+		delay *= 2
+		if delay >= m.taskCfg.MaxBackoff {
+			return m.taskCfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// failTask 记录判题失败
+// 未超过最大重试次数时按指数退避安排重试，提交回到PENDING(沙箱错误记为SYSTEM_ERROR)；否则任务标记为FAILED，提交判为SYSTEM_ERROR
+func (m *Manager) failTask(ctx context.Context, task *model.JudgeTask, errType string, cause error) error {
+	info := model.JudgeErrorInfo{
+		ErrorType:    errType,
+		ErrorMessage: cause.Error(),
+	}
+
+	if task.RetryCount < m.taskCfg.MaxRetries {
+		retryAt := time.Now().Add(m.retryDelay(task.RetryCount))
+		owned, err := m.taskRepo.RecordFailure(ctx, task.SubmissionID, task.AssignedJudge, info, &retryAt)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return nil
+		}
+		logger.Warn("判题失败，等待重试",
+			"submission_id", task.SubmissionID.Hex(),
+			"error_type", errType,
+			"error", cause,
+			"retry_count", task.RetryCount+1,
+			"retry_at", retryAt)
+		// 沙箱错误时提交先记为SYSTEM_ERROR，不保留部分用例的结果；重试开始判题时再更新为JUDGING
+		if errType == model.JudgeErrorSandbox {
+			return m.updateSubmissionWithError(ctx, task.SubmissionID, model.StatusSystemError, "沙箱错误，等待重新判题: "+cause.Error())
+		}
+		return m.updateSubmissionStatus(ctx, task.SubmissionID, model.StatusPending)
+	}
+
+	owned, err := m.taskRepo.RecordFailure(ctx, task.SubmissionID, task.AssignedJudge, info, nil)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return nil
+	}
+	logger.Error("判题失败且超过最大重试次数",
+		"submission_id", task.SubmissionID.Hex(),
+		"error_type", errType,
+		"error", cause,
+		"retry_count", task.RetryCount)
+	if err := m.updateSubmissionWithError(ctx, task.SubmissionID, model.StatusSystemError, cause.Error()); err != nil {
+		return err
+	}
+	m.publishSystemError(ctx, task.SubmissionID)
+	return nil
+}
+
+// publishSystemError 放弃重试后向提交用户推送最终的系统错误
+func (m *Manager) publishSystemError(ctx context.Context, submissionID primitive.ObjectID) {
+	submission, err := m.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		logger.Warn("获取提交失败，未推送判题结果", "submission_id", submissionID.Hex(), "error", err)
+		return
+	}
+	result := &queue.JudgeResult{
+		SubmissionID: submission.ID,
+		UserID:       submission.UserID,
+		Status:       submission.Status,
+		CompileInfo:  submission.CompileInfo,
+	}
+	if submission.JudgedAt != nil {
+		result.JudgedAt = *submission.JudgedAt
+	}
+	m.publishSubmissionResult(ctx, result)
+}
+
+// Start 启动维护协程：回收心跳超时的任务，重新发布到期的重试任务，清理残留的缓存文件
+func (m *Manager) Start() {
+	m.fileManager.Start()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.taskCfg.ScanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.shutdown:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), m.taskCfg.ScanInterval)
+				m.reclaimStaleTasks(ctx)
+				m.requeueDueTasks(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// reclaimStaleTasks 回收判题机停止心跳的任务，按一次失败处理
+func (m *Manager) reclaimStaleTasks(ctx context.Context) {
+	staleBefore := time.Now().Add(-m.taskCfg.HeartbeatTimeout)
+	tasks, err := m.taskRepo.ListStale(ctx, staleBefore, taskScanBatch)
+	if err != nil {
+		logger.Error("查询超时判题任务失败", "error", err)
+		return
+	}
+
+	for _, task := range tasks {
+		cause := fmt.Errorf("判题机%s心跳超时", task.AssignedJudge)
+		if err := m.failTask(ctx, task, model.JudgeErrorHeartbeatLost, cause); err != nil {
+			logger.Error("回收超时判题任务失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+			continue
+		}
+		logger.Warn("已回收超时判题任务", "submission_id", task.SubmissionID.Hex(), "judge", task.AssignedJudge)
+	}
+}
+
+// requeueDueTasks 重新发布重试时间已到的任务
+func (m *Manager) requeueDueTasks(ctx context.Context) {
+	for i := 0; i < taskScanBatch; i++ {
+		now := time.Now()
+		// 租约期内其他判题机不会重复发布；发布失败时租约到期后重新领取
+		leaseUntil := now.Add(m.taskCfg.HeartbeatTimeout)
+		task, err := m.taskRepo.ClaimDueRetry(ctx, now, leaseUntil)
+		if err != nil {
+			logger.Error("领取重试任务失败", "error", err)
+			return
+		}
+		if task == nil {
+			return
+		}
+
+		submission, err := m.submissionRepo.GetByID(ctx, task.SubmissionID)
+		if err != nil {
+			logger.Error("获取重试任务的提交失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+			continue
+		}
+
+		judgeTask := &queue.JudgeTask{
+			SubmissionID: submission.ID,
+			ProblemID:    submission.ProblemID,
+			UserID:       submission.UserID,
+			ContestID:    submission.ContestID,
+			Code:         submission.Code,
+			Language:     submission.Language,
+			Lane:         task.Lane,
+			Priority:     task.Priority,
+			CreatedAt:    task.CreatedAt,
+		}
+		if err := m.producer.PublishJudgeTask(ctx, judgeTask); err != nil {
+			logger.Error("重新发布判题任务失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+			continue
+		}
+		if err := m.taskRepo.ClearRetry(ctx, task.SubmissionID, leaseUntil); err != nil {
+			logger.Warn("清除重试时间失败", "submission_id", task.SubmissionID.Hex(), "error", err)
+		}
+		m.publishStage(ctx, judgeTask, realtime.StageQueued, 0, 0)
+		logger.Info("判题任务已重新发布", "submission_id", task.SubmissionID.Hex(), "retry_count", task.RetryCount)
+	}
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+)
+
+// fileSender 把邮件保存为.eml文件并记录日志，不实际发送，用于开发和测试
+type fileSender struct {
+	dir  string
+	from *mail.Address
+}
+
+func newFileSender(dir string, from *mail.Address) (*fileSender, error) {
+	if dir == "" {
+		dir = "logs/mail"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+	return &fileSender{dir: dir, from: from}, nil
+}
+
+// Send 写入 {dir}/{时间}-{随机串}.eml
+func (s *fileSender) Send(ctx context.Context, msg *Message) error {
+	data, err := build(s.from, msg)
+	if err != nil {
+		return err
+	}
+	suffix, err := utils.RandomToken(6)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, time.Now().Format("20060102-150405")+"-"+suffix+".eml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("保存邮件失败: %w", err)
+	}
+	logger.Info("邮件已保存到文件", "to", msg.To, "subject", msg.Subject, "path", path)
+	return nil
+}
@@ -0,0 +1,82 @@
+// Package mail 邮件发送，通过配置选择SMTP或写入本地文件
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/config"
+)
+
+// Message 纯文本邮件
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender 邮件发送接口
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender 根据配置创建邮件发送器
+func NewSender(cfg config.MailConfig) (Sender, error) {
+	if cfg.From == "" {
+		cfg.From = "ZHKU OJ <noreply@localhost>"
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("发件人地址无效: %w", err)
+	}
+
+	switch cfg.Driver {
+	case "smtp":
+		return newSMTPSender(cfg.SMTP, from)
+	case "file", "":
+		return newFileSender(cfg.FileDir, from)
+	}
+	return nil, fmt.Errorf("不支持的邮件发送方式: %s", cfg.Driver)
+}
+
+// build 生成RFC 5322邮件，主题和正文使用UTF-8，正文按base64编码
+func build(from *mail.Address, msg *Message) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("收件人为空")
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("收件人地址无效: %w", err)
+		}
+		to = append(to, parsed.String())
+	}
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "base64")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes(), nil
+}
@@ -1,14 +1,52 @@
 package middleware
 
 import (
+	"context"
+	stdErrors "errors"
 	"net/http"
 	"strings"
 
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/jwtauth"
+	"zhku-oj/internal/pkg/logger"
 	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
+// TokenChecker 检查访问token是否已撤销或所属会话是否已注销
+type TokenChecker func(ctx context.Context, userID, sessionID, tokenID string) (bool, error)
+
+var (
+	signer       *jwtauth.Signer
+	tokenChecker TokenChecker
+	authorizer   *rbac.Authorizer
+)
+
+// passwordChangeRoutes 需要修改密码的用户仍可访问的接口
+var passwordChangeRoutes = map[string]bool{
+	"PUT /api/v1/auth/password": true,
+	"POST /api/v1/auth/logout":  true,
+	"GET /api/v1/auth/verify":   true,
+	"GET /api/v1/users/profile": true,
+}
+
+// SetSigner 设置校验token使用的签名器，未设置时AuthRequired拒绝全部请求
+func SetSigner(s *jwtauth.Signer) {
+	signer = s
+}
+
+// SetTokenChecker 设置token状态检查，设置后AuthRequired拒绝已注销的token
+func SetTokenChecker(checker TokenChecker) {
+	tokenChecker = checker
+}
+
+// SetAuthorizer 设置角色到权限的映射，未设置时认证用户没有任何权限
+func SetAuthorizer(a *rbac.Authorizer) {
+	authorizer = a
+}
+
 // AuthRequired JWT认证中间件
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -33,8 +71,17 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		if signer == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"code":    503,
+				"message": "认证服务暂时不可用",
+			})
+			c.Abort()
+			return
+		}
+
 		token := parts[1]
-		claims, err := utils.ParseJWT(token)
+		claims, err := signer.Parse(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
@@ -44,43 +91,116 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		if tokenChecker != nil {
+			active, err := tokenChecker(c.Request.Context(), claims.UserID, claims.SessionID, claims.ID)
+			if err != nil {
+				// 无法确认token状态时拒绝请求，避免已注销的token继续使用
+				logger.Error("检查token状态失败", "user_id", claims.UserID, "error", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"code":    503,
+					"message": "认证服务暂时不可用",
+				})
+				c.Abort()
+				return
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    401,
+					"message": "token已失效，请重新登录",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		// 管理员重置密码后，修改密码并刷新token之前只能访问修改密码等接口
+		if claims.PasswordChange && !passwordChangeRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.Response{
+				Code:    errors.PASSWORD_CHANGE_REQUIRED,
+				Message: errors.GetErrorMessage(errors.PASSWORD_CHANGE_REQUIRED),
+			})
+			return
+		}
+
 		// 将用户信息保存到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_id", claims.ID)
+		if authorizer != nil {
+			c.Set("permissions", authorizer.Permissions(claims.Role))
+		}
 		c.Next()
 	}
 }
 
-// RoleRequired 角色权限检查中间件
-func RoleRequired(roles ...string) gin.HandlerFunc {
+// TokenFromQuery 从access_token查询参数读取token，放在AuthRequired之前
+// 仅用于浏览器无法设置请求头的WebSocket和SSE接口，请求已携带Authorization头时不覆盖
+func TokenFromQuery() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    403,
-				"message": "无权限访问",
-			})
-			c.Abort()
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// Require 权限检查中间件，放在AuthRequired之后，拥有其中任一权限即可访问
+func Require(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !GetPermissions(c).HasAny(perms...) {
+			abortForbidden(c, perms...)
 			return
 		}
+		c.Next()
+	}
+}
 
-		role := userRole.(string)
-		for _, requiredRole := range roles {
-			if role == requiredRole {
+// RequireOwner 资源权限检查中间件，放在AuthRequired之后，资源ID取路径参数id
+// 拥有anyPerm，或拥有ownPerm且是资源创建者时可以访问；资源不存在时交给handler返回对应的错误码
+func RequireOwner(lookup rbac.OwnerLookup, ownPerm, anyPerm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perms := GetPermissions(c)
+		if perms.Has(anyPerm) {
+			c.Next()
+			return
+		}
+		if !perms.Has(ownPerm) {
+			abortForbidden(c, ownPerm, anyPerm)
+			return
+		}
+
+		owner, err := lookup(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			if stdErrors.Is(err, rbac.ErrResourceNotFound) {
 				c.Next()
 				return
 			}
+			logger.Error("查询资源创建者失败", "path", c.FullPath(), "id", c.Param("id"), "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, utils.Response{
+				Code:    errors.SYSTEM_ERROR,
+				Message: errors.GetErrorMessage(errors.SYSTEM_ERROR),
+			})
+			return
 		}
-
-		c.JSON(http.StatusForbidden, gin.H{
-			"code":    403,
-			"message": "权限不足",
-		})
-		c.Abort()
+		if owner.Hex() != GetUserID(c) {
+			abortForbidden(c, anyPerm)
+			return
+		}
+		c.Next()
 	}
 }
 
+// abortForbidden 返回403和所需的权限
+func abortForbidden(c *gin.Context, perms ...string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, utils.Response{
+		Code:    errors.FORBIDDEN,
+		Message: "权限不足",
+		Data:    gin.H{"required": perms},
+	})
+}
+
 // GetUserID 获取当前用户ID
 func GetUserID(c *gin.Context) string {
 	if userID, exists := c.Get("user_id"); exists {
@@ -97,6 +217,22 @@ func GetUsername(c *gin.Context) string {
 	return ""
 }
 
+// GetSessionID 获取当前登录会话ID
+func GetSessionID(c *gin.Context) string {
+	if sessionID, exists := c.Get("session_id"); exists {
+		return sessionID.(string)
+	}
+	return ""
+}
+
+// GetPermissions 获取当前用户的权限，未认证时为空集合
+func GetPermissions(c *gin.Context) rbac.Set {
+	if perms, exists := c.Get("permissions"); exists {
+		return perms.(rbac.Set)
+	}
+	return rbac.Set{}
+}
+
 // GetUserRole 获取当前用户角色
 func GetUserRole(c *gin.Context) string {
 	if role, exists := c.Get("role"); exists {
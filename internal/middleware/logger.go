@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"zhku-oj/internal/pkg/logger"
@@ -14,7 +16,7 @@ func Logger() gin.HandlerFunc {
 		logger.Info("请求日志",
 			"status", param.StatusCode,
 			"method", param.Method,
-			"path", param.Path,
+			"path", redactQuery(param.Path),
 			"ip", param.ClientIP,
 			"user_agent", param.Request.UserAgent(),
 			"latency", param.Latency,
@@ -40,3 +42,18 @@ func Recovery() gin.HandlerFunc {
 		})
 	})
 }
+
+// redactQuery 隐去请求路径中查询参数携带的token，避免写入日志
+func redactQuery(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 {
+		return path
+	}
+	// 部分参数格式错误时仍然隐去能解析出的token
+	query, _ := url.ParseQuery(path[i+1:])
+	if !query.Has("access_token") {
+		return path
+	}
+	query.Set("access_token", "redacted")
+	return path[:i+1] + query.Encode()
+}
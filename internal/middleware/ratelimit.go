@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit 限流中间件，name为路由组名，同名路由组共享计数
+// 放在AuthRequired之后时按用户计数并按角色选择限流参数，否则按IP计数；
+// 超过限制时返回429和Retry-After，errCode为响应中的错误码
+func RateLimit(limiter *ratelimit.Limiter, name string, policy config.RateLimitPolicy, errCode int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := policy.ForRole(GetUserRole(c))
+		if rule.Limit <= 0 || rule.Window <= 0 {
+			c.Next()
+			return
+		}
+
+		subject := "ip:" + c.ClientIP()
+		if userID := GetUserID(c); userID != "" {
+			subject = "user:" + userID
+		}
+		result, err := limiter.Allow(c.Request.Context(), name+":"+subject, rule.Limit, rule.Window)
+		if err != nil {
+			// Redis不可用时放行，不影响正常使用
+			logger.Warn("限流检查失败，放行请求", "group", name, "subject", subject, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			AbortTooManyRequests(c, result.RetryAfter, errCode)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortTooManyRequests 返回429和Retry-After并中止请求，detail为空时使用错误码的默认信息
+func AbortTooManyRequests(c *gin.Context, wait time.Duration, errCode int, detail ...string) {
+	SetRetryAfter(c, wait)
+	message := errors.GetErrorMessage(errCode)
+	if len(detail) > 0 && detail[0] != "" {
+		message = detail[0]
+	}
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, utils.Response{
+		Code:    errCode,
+		Message: message,
+	})
+}
+
+// SetRetryAfter 设置Retry-After响应头并返回等待秒数，不足1秒按1秒
+func SetRetryAfter(c *gin.Context, wait time.Duration) int64 {
+	seconds := int64((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	return seconds
+}
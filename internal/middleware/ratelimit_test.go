@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newRateLimitRouter 使用miniredis的限流路由，请求头X-User和X-Role模拟已认证用户
+func newRateLimitRouter(t *testing.T, policy config.RateLimitPolicy) (*miniredis.Miniredis, *gin.Engine) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set("user_id", userID)
+			c.Set("role", c.GetHeader("X-Role"))
+		}
+	})
+	router.Use(RateLimit(ratelimit.NewLimiter(client), "submit", policy, errors.SUBMISSION_TOO_FREQUENT))
+	router.POST("/", func(c *gin.Context) { utils.SendSuccess(c, nil) })
+	return server, router
+}
+
+func doRequest(router *gin.Engine, userID, role string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if userID != "" {
+		req.Header.Set("X-User", userID)
+		req.Header.Set("X-Role", role)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	policy := config.RateLimitPolicy{
+		Limit:  2,
+		Window: time.Minute,
+		Roles: map[string]config.RateLimitRule{
+			"teacher": {Limit: 4},
+			"admin":   {Limit: 0},
+		},
+	}
+	tests := []struct {
+		name        string
+		userID      string
+		role        string
+		requests    int
+		wantAllowed int
+	}{
+		{name: "student", userID: "u1", role: "student", requests: 4, wantAllowed: 2},
+		{name: "teacher override", userID: "u2", role: "teacher", requests: 6, wantAllowed: 4},
+		{name: "admin unlimited", userID: "u3", role: "admin", requests: 10, wantAllowed: 10},
+		{name: "anonymous by ip", requests: 3, wantAllowed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := newRateLimitRouter(t, policy)
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				w := doRequest(router, tt.userID, tt.role)
+				if w.Code == http.StatusOK {
+					allowed++
+					continue
+				}
+				if w.Code != http.StatusTooManyRequests {
+					t.Fatalf("状态码 = %d, 期望200或429", w.Code)
+				}
+				retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+				if err != nil || retryAfter < 1 || retryAfter > 60 {
+					t.Errorf("Retry-After = %q, 期望1-60秒", w.Header().Get("Retry-After"))
+				}
+				if w.Header().Get("X-RateLimit-Remaining") != "0" {
+					t.Errorf("X-RateLimit-Remaining = %q, 期望0", w.Header().Get("X-RateLimit-Remaining"))
+				}
+				var resp utils.Response
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != errors.SUBMISSION_TOO_FREQUENT {
+					t.Errorf("响应 = %s, 期望错误码%d", w.Body.String(), errors.SUBMISSION_TOO_FREQUENT)
+				}
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("允许%d次请求, 期望%d次", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+// TestRateLimitPerSubject 不同用户分别计数
+func TestRateLimitPerSubject(t *testing.T) {
+	_, router := newRateLimitRouter(t, config.RateLimitPolicy{Limit: 1, Window: time.Minute})
+	if w := doRequest(router, "u1", "student"); w.Code != http.StatusOK {
+		t.Fatalf("u1首次请求状态码 = %d", w.Code)
+	}
+	if w := doRequest(router, "u1", "student"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("u1第二次请求状态码 = %d, 期望429", w.Code)
+	}
+	if w := doRequest(router, "u2", "student"); w.Code != http.StatusOK {
+		t.Errorf("u2首次请求状态码 = %d, 期望200", w.Code)
+	}
+}
+
+// TestRateLimitRedisUnavailable Redis不可用时放行
+func TestRateLimitRedisUnavailable(t *testing.T) {
+	server, router := newRateLimitRouter(t, config.RateLimitPolicy{Limit: 1, Window: time.Minute})
+	server.Close()
+	for i := 0; i < 3; i++ {
+		if w := doRequest(router, "u1", "student"); w.Code != http.StatusOK {
+			t.Fatalf("第%d次请求状态码 = %d, 期望放行", i+1, w.Code)
+		}
+	}
+}
+
+func TestSetRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want int64
+	}{
+		{wait: 0, want: 1},
+		{wait: 300 * time.Millisecond, want: 1},
+		{wait: time.Second, want: 1},
+		{wait: 1001 * time.Millisecond, want: 2},
+		{wait: 42 * time.Second, want: 42},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if got := SetRetryAfter(c, tt.wait); got != tt.want || c.Writer.Header().Get("Retry-After") != strconv.FormatInt(tt.want, 10) {
+			t.Errorf("SetRetryAfter(%v) = %d, 期望 %d", tt.wait, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations 全部迁移，按版本号递增排列，新迁移追加在末尾
+// 索引定义见database_design.md
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "创建用户集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			// 用户名、邮箱、学号唯一，避免ExistsBy*检查与Create之间并发注册产生重复用户
+			return step.CreateIndexes(ctx, "users",
+				mongo.IndexModel{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "student_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "class", Value: 1}, {Key: "stats.ranking", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "role", Value: 1}, {Key: "is_active", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "created_at", Value: 1}}},
+			)
+		},
+	},
+	{
+		Version:     2,
+		Description: "创建题目集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			return step.CreateIndexes(ctx, "problems",
+				mongo.IndexModel{Keys: bson.D{{Key: "difficulty", Value: 1}, {Key: "is_public", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "tags", Value: 1}, {Key: "is_active", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "created_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "stats.acceptance_rate", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}}},
+			)
+		},
+	},
+	{
+		Version:     3,
+		Description: "创建提交记录集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			return step.CreateIndexes(ctx, "submissions",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "submitted_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "problem_id", Value: 1}, {Key: "status", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "submitted_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "problem_id", Value: 1}, {Key: "status", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "contest_id", Value: 1}, {Key: "submitted_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "judged_at", Value: -1}}},
+			)
+		},
+	},
+	{
+		Version:     4,
+		Description: "创建判题队列索引",
+		Up: func(ctx context.Context, step *Step) error {
+			return step.CreateIndexes(ctx, "judge_queue",
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "submission_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "assigned_judge", Value: 1}, {Key: "status", Value: 1}}},
+				// 按通道统计队列状态
+				mongo.IndexModel{Keys: bson.D{{Key: "lane", Value: 1}, {Key: "status", Value: 1}}},
+				// 回收心跳超时任务、领取到期重试任务
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "heartbeat", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_retry_at", Value: 1}}},
+			)
+		},
+	},
+	{
+		Version:     5,
+		Description: "回填提交记录的code_length和contest_id",
+		Up: func(ctx context.Context, step *Step) error {
+			// code_length按字符数计算，与提交记录仓储创建提交时一致
+			codeLength := mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"code_length": bson.M{"$strLenCP": bson.M{"$ifNull": bson.A{"$code", ""}}}}}},
+			}
+			if err := step.Backfill(ctx, "submissions", "code_length",
+				bson.M{"code_length": bson.M{"$exists": false}}, codeLength); err != nil {
+				return err
+			}
+			// 历史提交均不属于竞赛
+			return step.Backfill(ctx, "submissions", "contest_id",
+				bson.M{"contest_id": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"contest_id": nil}})
+		},
+	},
+	{
+		Version:     6,
+		Description: "回填判题任务的lane",
+		Up: func(ctx context.Context, step *Step) error {
+			// 判题通道引入前的任务归入日常练习通道
+			return step.Backfill(ctx, "judge_queue", "lane",
+				bson.M{"$or": bson.A{bson.M{"lane": bson.M{"$exists": false}}, bson.M{"lane": ""}}},
+				bson.M{"$set": bson.M{"lane": "practice"}})
+		},
+	},
+	{
+		Version:     7,
+		Description: "创建竞赛集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			if err := step.CreateIndexes(ctx, "contests",
+				mongo.IndexModel{Keys: bson.D{{Key: "start_time", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "type", Value: 1}, {Key: "start_time", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "created_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "participant_ids", Value: 1}}},
+			); err != nil {
+				return err
+			}
+			// 竞赛提交次数限制按竞赛、用户、题目计数
+			return step.CreateIndexes(ctx, "submissions",
+				mongo.IndexModel{Keys: bson.D{{Key: "contest_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "problem_id", Value: 1}}},
+			)
+		},
+	},
+	{
+		Version:     8,
+		Description: "回填用户的student_id_verified",
+		Up: func(ctx context.Context, step *Step) error {
+			// 无法区分历史账号是否自行注册，均视为未核实，由管理员核实后才能通过统一身份认证登录
+			return step.Backfill(ctx, "users", "student_id_verified",
+				bson.M{"student_id_verified": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"student_id_verified": false}})
+		},
+	},
+	{
+		Version:     9,
+		Description: "创建角色集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			return step.CreateIndexes(ctx, "roles",
+				mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+			)
+		},
+	},
+	{
+		Version:     10,
+		Description: "创建课程集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			return step.CreateIndexes(ctx, "courses",
+				mongo.IndexModel{Keys: bson.D{{Key: "teacher_ids", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "ta_ids", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "student_ids", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "class_name", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "term", Value: 1}, {Key: "start_date", Value: -1}}},
+				// 停用邀请码时删除该字段，稀疏索引只约束已启用的邀请码
+				mongo.IndexModel{Keys: bson.D{{Key: "join_code", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+			)
+		},
+	},
+	{
+		Version:     11,
+		Description: "创建作业集合索引",
+		Up: func(ctx context.Context, step *Step) error {
+			// 计算成绩时按学生和提交时间读取提交，使用已有的submissions{user_id, submitted_at}索引
+			return step.CreateIndexes(ctx, "assignments",
+				mongo.IndexModel{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "due_time", Value: -1}}},
+			)
+		},
+	},
+	{
+		Version:     12,
+		Description: "回填提交记录的assignment_id",
+		Up: func(ctx context.Context, step *Step) error {
+			// 计算成绩时按作业和学生读取提交
+			if err := step.CreateIndexes(ctx, "submissions",
+				mongo.IndexModel{Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "submitted_at", Value: 1}}},
+			); err != nil {
+				return err
+			}
+			if err := tagAssignmentSubmissions(ctx, step); err != nil {
+				return err
+			}
+			return step.Backfill(ctx, "submissions", "assignment_id",
+				bson.M{"assignment_id": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"assignment_id": nil}})
+		},
+	},
+}
+
+// tagAssignmentSubmissions 把历史提交归入作业
+// 此前作业成绩按课程学生在计分时间内对作业题目的非竞赛提交计算，按同样的条件回填，已有成绩不变；
+// 同一提交符合多个作业时归入截止时间最早的作业
+func tagAssignmentSubmissions(ctx context.Context, step *Step) error {
+	cursor, err := step.db.Collection("assignments").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "due_time", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("查询作业失败: %w", err)
+	}
+	var assignments []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		CourseID primitive.ObjectID `bson:"course_id"`
+		Problems []struct {
+			ProblemID primitive.ObjectID `bson:"problem_id"`
+		} `bson:"problems"`
+		OpenTime   time.Time `bson:"open_time"`
+		CloseTime  time.Time `bson:"close_time"`
+		Extensions []struct {
+			CloseTime time.Time `bson:"close_time"`
+		} `bson:"extensions"`
+	}
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return fmt.Errorf("读取作业失败: %w", err)
+	}
+
+	for _, assignment := range assignments {
+		var course struct {
+			StudentIDs []primitive.ObjectID `bson:"student_ids"`
+		}
+		err := step.db.Collection("courses").FindOne(ctx, bson.M{"_id": assignment.CourseID}).Decode(&course)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("查询课程失败: %w", err)
+		}
+		if len(course.StudentIDs) == 0 || len(assignment.Problems) == 0 {
+			continue
+		}
+
+		problemIDs := make([]primitive.ObjectID, 0, len(assignment.Problems))
+		for _, item := range assignment.Problems {
+			problemIDs = append(problemIDs, item.ProblemID)
+		}
+		closeTime := assignment.CloseTime
+		for _, extension := range assignment.Extensions {
+			if extension.CloseTime.After(closeTime) {
+				closeTime = extension.CloseTime
+			}
+		}
+		filter := bson.M{
+			"assignment_id": bson.M{"$exists": false},
+			"contest_id":    nil,
+			"user_id":       bson.M{"$in": course.StudentIDs},
+			"problem_id":    bson.M{"$in": problemIDs},
+			"submitted_at":  bson.M{"$gte": assignment.OpenTime, "$lt": closeTime},
+		}
+		if err := step.Backfill(ctx, "submissions", "assignment_id", filter,
+			bson.M{"$set": bson.M{"assignment_id": assignment.ID}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
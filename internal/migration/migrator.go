@@ -0,0 +1,373 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"zhku-oj/internal/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 迁移锁配置
+const (
+	lockID            = "lock"           // migrations集合中迁移锁文档的_id
+	lockTTL           = 30 * time.Minute // 持有者异常退出时，超过该时间的锁可被接管
+	lockRetryInterval = time.Second
+)
+
+// Migration 数据库迁移，版本号递增，已发布的迁移不可修改，只能追加新版本
+// Up需要可重复执行：执行过程中失败时版本不会被记录，下次会从头重新执行
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, step *Step) error
+}
+
+// Record 已执行的迁移记录 (migrations集合)
+type Record struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+	DurationMs  int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// Status 迁移执行状态
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"` // 未执行时为空
+}
+
+// Result 单个迁移的执行结果，Actions为执行(或dry-run时将要执行)的操作
+type Result struct {
+	Version     int
+	Description string
+	Actions     []string
+	Duration    time.Duration
+}
+
+// Migrator 按版本顺序执行未执行的迁移，并在migrations集合中记录已执行的版本
+type Migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+	owner      string // 迁移锁持有者标识
+}
+
+// New 创建迁移执行器，使用本包注册的全部迁移
+func New(db *mongo.Database) *Migrator {
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		collection: db.Collection("migrations"),
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Status 查询全部迁移的执行状态，按版本排序
+// 数据库中存在当前程序未知的版本(由更新的程序执行)时同样列出
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		if !known[version] {
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, Status{Version: version, Description: record.Description, AppliedAt: &appliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending 查询未执行的迁移
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up 按版本顺序执行全部未执行的迁移
+// dryRun为true时只检查将要执行的操作，不修改数据也不记录版本；
+// 否则执行期间持有迁移锁，多个服务实例同时启动时只有一个实例执行，其余实例等待其完成。
+// 迁移失败时返回已完成迁移的结果和错误，失败的迁移不记录版本
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Result, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer m.unlock()
+	}
+
+	// 持有锁之后再查询，跳过等待期间其他实例已执行的迁移
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(pending))
+	for _, migration := range pending {
+		start := time.Now()
+		step := &Step{db: m.db, dryRun: dryRun}
+		if err := migration.Up(ctx, step); err != nil {
+			return results, fmt.Errorf("执行迁移%d(%s)失败: %w", migration.Version, migration.Description, err)
+		}
+		result := Result{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Actions:     step.actions,
+			Duration:    time.Since(start),
+		}
+
+		if !dryRun {
+			record := Record{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now(),
+				DurationMs:  result.Duration.Milliseconds(),
+			}
+			if _, err := m.collection.InsertOne(ctx, record); err != nil {
+				return results, fmt.Errorf("记录迁移版本%d失败: %w", migration.Version, err)
+			}
+			logger.Info("数据库迁移已执行", "version", migration.Version, "description", migration.Description,
+				"duration", result.Duration)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// validate 检查迁移版本号为正数且严格递增
+func (m *Migrator) validate() error {
+	last := 0
+	for _, migration := range m.migrations {
+		if migration.Version <= last {
+			return fmt.Errorf("迁移版本号必须为正数且严格递增: %d", migration.Version)
+		}
+		if migration.Up == nil {
+			return fmt.Errorf("迁移%d缺少Up函数", migration.Version)
+		}
+		last = migration.Version
+	}
+	return nil
+}
+
+// applied 查询已执行的迁移记录，按版本号索引
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("解析迁移记录失败: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// lock 获取迁移锁，锁被其他实例持有时等待，持有时间超过lockTTL的锁视为持有者已退出并接管
+func (m *Migrator) lock(ctx context.Context) error {
+	for {
+		now := time.Now()
+		_, err := m.collection.InsertOne(ctx, bson.M{"_id": lockID, "owner": m.owner, "locked_at": now})
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+
+		filter := bson.M{"_id": lockID, "locked_at": bson.M{"$lt": now.Add(-lockTTL)}}
+		update := bson.M{"$set": bson.M{"owner": m.owner, "locked_at": now}}
+		result, err := m.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if result.MatchedCount > 0 {
+			logger.Warn("接管超时的迁移锁", "owner", m.owner)
+			return nil
+		}
+
+		logger.Info("迁移锁被其他实例持有，等待迁移完成", "owner", m.owner)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待迁移锁失败: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// unlock 释放迁移锁，使用独立的context保证调用方取消后仍能释放
+func (m *Migrator) unlock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": m.owner}); err != nil {
+		logger.Error("释放迁移锁失败", "owner", m.owner, "error", err)
+	}
+}
+
+// Step 迁移中的操作，dry-run时只统计将要执行的操作
+type Step struct {
+	db      *mongo.Database
+	dryRun  bool
+	actions []string
+}
+
+// CreateIndexes 在集合上创建索引，已存在的同名索引跳过
+func (s *Step) CreateIndexes(ctx context.Context, collection string, indexes ...mongo.IndexModel) error {
+	coll := s.db.Collection(collection)
+	existing, err := indexNames(ctx, coll)
+	if err != nil {
+		return err
+	}
+
+	var missing []mongo.IndexModel
+	for _, index := range indexes {
+		name := indexName(index)
+		if existing[name] {
+			continue
+		}
+		missing = append(missing, index)
+		s.record("%s: 创建索引 %s", collection, name)
+		if s.dryRun && index.Options != nil && index.Options.Unique != nil && *index.Options.Unique {
+			duplicates, err := countDuplicates(ctx, coll, index.Keys.(bson.D))
+			if err != nil {
+				return err
+			}
+			if duplicates > 0 {
+				s.record("%s: 警告: 索引 %s 存在%d组重复值，需先清理重复数据", collection, name, duplicates)
+			}
+		}
+	}
+	if s.dryRun || len(missing) == 0 {
+		return nil
+	}
+
+	if _, err := coll.Indexes().CreateMany(ctx, missing); err != nil {
+		return fmt.Errorf("创建%s集合索引失败: %w", collection, err)
+	}
+	return nil
+}
+
+// Backfill 为匹配filter的文档执行update(可以是聚合管道)，用于给历史数据补充新字段
+// filter应排除已回填的文档，保证重复执行时不会重复修改
+func (s *Step) Backfill(ctx context.Context, collection, field string, filter interface{}, update interface{}) error {
+	coll := s.db.Collection(collection)
+	if s.dryRun {
+		count, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("统计%s待回填文档失败: %w", collection, err)
+		}
+		s.record("%s: 回填字段 %s, 共%d条文档", collection, field, count)
+		return nil
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("回填%s.%s失败: %w", collection, field, err)
+	}
+	s.record("%s: 回填字段 %s, 共%d条文档", collection, field, result.ModifiedCount)
+	return nil
+}
+
+func (s *Step) record(format string, args ...interface{}) {
+	s.actions = append(s.actions, fmt.Sprintf(format, args...))
+}
+
+// indexNames 查询集合已有的索引名，集合不存在时返回空
+func indexNames(ctx context.Context, coll *mongo.Collection) (map[string]bool, error) {
+	names := make(map[string]bool)
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		// 集合不存在时MongoDB返回NamespaceNotFound(26)
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 26 {
+			return names, nil
+		}
+		return nil, fmt.Errorf("查询%s集合索引失败: %w", coll.Name(), err)
+	}
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+	return names, nil
+}
+
+// countDuplicates 统计在keys上取值重复的文档组数
+func countDuplicates(ctx context.Context, coll *mongo.Collection, keys bson.D) (int, error) {
+	group := bson.M{}
+	for _, key := range keys {
+		group[key.Key] = "$" + key.Key
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": group, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$count", Value: "duplicates"}},
+	}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("统计%s集合重复数据失败: %w", coll.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		Duplicates int `bson:"duplicates"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return 0, fmt.Errorf("解析%s集合重复数据失败: %w", coll.Name(), err)
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+	return counts[0].Duplicates, nil
+}
+
+// indexName 索引名，未指定时按MongoDB默认规则由键生成，如 status_1_priority_-1
+func indexName(index mongo.IndexModel) string {
+	if index.Options != nil && index.Options.Name != nil {
+		return *index.Options.Name
+	}
+	name := ""
+	for i, key := range index.Keys.(bson.D) {
+		if i > 0 {
+			name += "_"
+		}
+		name += fmt.Sprintf("%s_%v", key.Key, key.Value)
+	}
+	return name
+}
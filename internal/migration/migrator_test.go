@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestValidate(t *testing.T) {
+	up := func(ctx context.Context, step *Step) error { return nil }
+	tests := []struct {
+		name       string
+		migrations []Migration
+		wantErr    bool
+	}{
+		{name: "empty"},
+		{name: "increasing", migrations: []Migration{{Version: 1, Up: up}, {Version: 2, Up: up}, {Version: 5, Up: up}}},
+		{name: "zero version", migrations: []Migration{{Version: 0, Up: up}}, wantErr: true},
+		{name: "negative version", migrations: []Migration{{Version: -1, Up: up}}, wantErr: true},
+		{name: "duplicate version", migrations: []Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}, wantErr: true},
+		{name: "out of order", migrations: []Migration{{Version: 1, Up: up}, {Version: 3, Up: up}, {Version: 2, Up: up}}, wantErr: true},
+		{name: "missing up", migrations: []Migration{{Version: 1, Up: up}, {Version: 2}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Migrator{migrations: tt.migrations}
+			if err := m.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate = %v, 期望返回error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRegisteredMigrations 注册的迁移版本从1开始连续递增，每个迁移都有说明
+func TestRegisteredMigrations(t *testing.T) {
+	if err := (&Migrator{migrations: migrations}).validate(); err != nil {
+		t.Fatalf("注册的迁移无效: %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("第%d个迁移版本号 = %d, 期望 %d", i+1, migration.Version, i+1)
+		}
+		if migration.Description == "" {
+			t.Errorf("迁移%d缺少说明", migration.Version)
+		}
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	tests := []struct {
+		name  string
+		index mongo.IndexModel
+		want  string
+	}{
+		{name: "single key", index: mongo.IndexModel{Keys: bson.D{{Key: "username", Value: 1}}}, want: "username_1"},
+		{name: "compound", index: mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "submitted_at", Value: -1}}},
+			want: "status_1_submitted_at_-1"},
+		{name: "nested field", index: mongo.IndexModel{Keys: bson.D{{Key: "stats.ranking", Value: 1}}}, want: "stats.ranking_1"},
+		{name: "text", index: mongo.IndexModel{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}}},
+			want: "title_text_description_text"},
+		{name: "unique without name", index: mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+			want: "email_1"},
+		{name: "named", index: mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("ttl")},
+			want: "ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexName(tt.index); got != tt.want {
+				t.Errorf("indexName = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JudgeTask 判题任务状态 (judge_queue集合)
+// 每个提交对应一条记录，记录判题任务的完整生命周期
+type JudgeTask struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubmissionID  primitive.ObjectID `bson:"submission_id" json:"submission_id"`
+	Status        string             `bson:"status" json:"status"`     // PENDING, PROCESSING, COMPLETED, FAILED
+	Lane          string             `bson:"lane" json:"lane"`         // 判题通道: contest, homework, practice, rejudge
+	Priority      int                `bson:"priority" json:"priority"` // 1-10, 10最高
+	RetryCount    int                `bson:"retry_count" json:"retry_count"`
+	AssignedJudge string             `bson:"assigned_judge" json:"assigned_judge"`
+	Heartbeat     *time.Time         `bson:"heartbeat,omitempty" json:"heartbeat,omitempty"`         // 判题机最近一次心跳
+	NextRetryAt   *time.Time         `bson:"next_retry_at,omitempty" json:"next_retry_at,omitempty"` // 下次重试时间
+	Progress      JudgeProgress      `bson:"progress" json:"progress"`
+	ErrorInfo     *JudgeErrorInfo    `bson:"error_info,omitempty" json:"error_info,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"` // 任务入队时间
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// JudgeProgress 判题进度
+type JudgeProgress struct {
+	Stage           string     `bson:"stage" json:"stage"` // COMPILING, RUNNING, COMPLETED
+	CurrentTestCase int        `bson:"current_test_case" json:"current_test_case"`
+	TotalTestCases  int        `bson:"total_test_cases" json:"total_test_cases"`
+	StartTime       *time.Time `bson:"start_time,omitempty" json:"start_time,omitempty"`
+}
+
+// JudgeErrorInfo 判题错误信息
+type JudgeErrorInfo struct {
+	ErrorType    string `bson:"error_type" json:"error_type"`
+	ErrorMessage string `bson:"error_message" json:"error_message"`
+	ErrorCount   int    `bson:"error_count" json:"error_count"`
+}
+
+// JudgeLaneStats 判题通道统计
+type JudgeLaneStats struct {
+	Lane       string `bson:"_id" json:"lane"`
+	Pending    int64  `bson:"pending" json:"pending"`       // 等待重试或被归还的任务
+	Processing int64  `bson:"processing" json:"processing"` // 判题中的任务
+	Started    int64  `bson:"started" json:"started"`       // 统计窗口内开始判题的任务数
+	AvgWaitMS  int64  `bson:"avg_wait_ms" json:"avg_wait_ms"`
+	MaxWaitMS  int64  `bson:"max_wait_ms" json:"max_wait_ms"`
+}
+
+// 判题任务状态常量
+const (
+	JudgeTaskPending    = "PENDING"
+	JudgeTaskProcessing = "PROCESSING"
+	JudgeTaskCompleted  = "COMPLETED"
+	JudgeTaskFailed     = "FAILED"
+)
+
+// 判题阶段常量
+const (
+	JudgeStageCompiling = "COMPILING"
+	JudgeStageRunning   = "RUNNING"
+	JudgeStageCompleted = "COMPLETED"
+)
+
+// 判题错误类型常量
+const (
+	JudgeErrorSandbox       = "SANDBOX_ERROR"
+	JudgeErrorHeartbeatLost = "HEARTBEAT_LOST"
+	JudgeErrorInternal      = "INTERNAL_ERROR"
+)
@@ -14,8 +14,8 @@ type User struct {
 	Password  string             `bson:"password" json:"-"`
 	Email     string             `bson:"email" json:"email"`
 	RealName  string             `bson:"real_name" json:"real_name"`
-	Role      string             `bson:"role" json:"role"` // student, teacher, admin
-	Class     string             `bson:"class" json:"class"`
+	Role      string             `bson:"role" json:"role"`   // student, ta, teacher, admin或自定义角色
+	Class     string             `bson:"class" json:"class"` // 自填的班级名称，任课关系以课程(Course)为准
 	Grade     string             `bson:"grade" json:"grade"`
 	Avatar    string             `bson:"avatar" json:"avatar"`
 	IsActive  bool               `bson:"is_active" json:"is_active"`
@@ -23,6 +23,13 @@ type User struct {
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 	LastLogin *time.Time         `bson:"last_login,omitempty" json:"last_login,omitempty"`
+
+	// MustChangePassword 管理员重置密码后为true，修改密码前只能访问修改密码等少数接口
+	MustChangePassword bool `bson:"must_change_password" json:"must_change_password"`
+
+	// StudentIDVerified 学号已核实(管理员创建、批量导入或统一身份认证开通)，自行注册的账号为false；
+	// 统一身份认证只登录学号已核实的账号，避免他人抢注学号后冒用或占用统一身份认证登录
+	StudentIDVerified bool `bson:"student_id_verified" json:"student_id_verified"`
 }
 
 // UserStats 用户统计信息
@@ -47,6 +54,8 @@ type Problem struct {
 	Difficulty   string             `bson:"difficulty" json:"difficulty"`     // easy, medium, hard
 	Tags         []string           `bson:"tags" json:"tags"`
 	TestCases    []TestCase         `bson:"test_cases" json:"test_cases"`
+	Subtasks     []Subtask          `bson:"subtasks,omitempty" json:"subtasks,omitempty"` // 为空时按测试用例分数累加
+	Constraints  ProblemConstraints `bson:"constraints" json:"constraints"`
 	Stats        ProblemStats       `bson:"stats" json:"stats"`
 	IsPublic     bool               `bson:"is_public" json:"is_public"`
 	CreatedBy    primitive.ObjectID `bson:"created_by" json:"created_by"`
@@ -54,6 +63,59 @@ type Problem struct {
 	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// ProblemConstraints 题目判题约束
+type ProblemConstraints struct {
+	AllowedLanguages []string `bson:"allowed_languages,omitempty" json:"allowed_languages,omitempty"` // 为空时不限制
+	SpecialJudge     bool     `bson:"special_judge" json:"special_judge"`
+	Checker          *Checker `bson:"checker,omitempty" json:"checker,omitempty"` // special_judge为true时使用
+	// Comparison 未开启特殊判题时的输出比对方式
+	Comparison OutputComparison `bson:"comparison" json:"comparison"`
+	// RunPolicy 测试用例运行策略，为空时使用run_all
+	RunPolicy string `bson:"run_policy,omitempty" json:"run_policy,omitempty"`
+}
+
+// OutputComparison 输出比对配置
+type OutputComparison struct {
+	Mode       string  `bson:"mode,omitempty" json:"mode,omitempty"`               // Compare*，为空时使用lines
+	AbsEpsilon float64 `bson:"abs_epsilon,omitempty" json:"abs_epsilon,omitempty"` // float模式的绝对误差
+	RelEpsilon float64 `bson:"rel_epsilon,omitempty" json:"rel_epsilon,omitempty"` // float模式的相对误差
+}
+
+// Checker 特殊判题程序，遵循testlib约定：
+// 以 input output answer 三个文件为参数运行，退出码0为通过、1为答案错误、2为格式错误、3为checker自身错误
+type Checker struct {
+	Language string `bson:"language" json:"language"`
+	Code     string `bson:"code" json:"code"`
+}
+
+// AllowsLanguage 题目是否允许使用该语言
+func (p *Problem) AllowsLanguage(language string) bool {
+	if len(p.Constraints.AllowedLanguages) == 0 {
+		return true
+	}
+	for _, allowed := range p.Constraints.AllowedLanguages {
+		if allowed == language {
+			return true
+		}
+	}
+	return false
+}
+
+// FullScore 题目满分：设置了子任务时为子任务分数之和，否则为测试用例分数之和；用例均未设置分数时为0
+func (p *Problem) FullScore() int {
+	total := 0
+	if len(p.Subtasks) > 0 {
+		for _, subtask := range p.Subtasks {
+			total += subtask.Score
+		}
+		return total
+	}
+	for _, testCase := range p.TestCases {
+		total += testCase.Score
+	}
+	return total
+}
+
 // TestCase 测试用例
 type TestCase struct {
 	ID       string `bson:"id" json:"id"`
@@ -63,6 +125,15 @@ type TestCase struct {
 	IsPublic bool   `bson:"is_public" json:"is_public"`
 }
 
+// Subtask 子任务，一组测试用例按计分策略共同计分
+type Subtask struct {
+	ID           string   `bson:"id" json:"id"`
+	Score        int      `bson:"score" json:"score"`
+	Policy       string   `bson:"policy" json:"policy"` // SubtaskPolicy*
+	TestCaseIDs  []string `bson:"test_case_ids" json:"test_case_ids"`
+	Dependencies []string `bson:"dependencies,omitempty" json:"dependencies,omitempty"` // 前置子任务，未全部满分时本子任务不评测、记0分
+}
+
 // ProblemStats 题目统计信息
 type ProblemStats struct {
 	TotalSubmissions int     `bson:"total_submissions" json:"total_submissions"`
@@ -79,14 +150,21 @@ type Submission struct {
 	ProblemID   primitive.ObjectID `bson:"problem_id" json:"problem_id"`
 	Code        string             `bson:"code" json:"code"`
 	Language    string             `bson:"language" json:"language"`
+	CodeLength  int                `bson:"code_length" json:"code_length"` // 代码字符数
 	Status      string             `bson:"status" json:"status"`
 	Score       int                `bson:"score" json:"score"`
 	TimeUsed    int                `bson:"time_used" json:"time_used"`     // 毫秒
 	MemoryUsed  int                `bson:"memory_used" json:"memory_used"` // KB
 	CompileInfo CompileInfo        `bson:"compile_info" json:"compile_info"`
 	TestResults []TestResult       `bson:"test_results" json:"test_results"`
-	SubmittedAt time.Time          `bson:"submitted_at" json:"submitted_at"`
-	JudgedAt    *time.Time         `bson:"judged_at,omitempty" json:"judged_at,omitempty"`
+	// SubtaskResults 子任务结果，题目未设置子任务时为空
+	SubtaskResults []SubtaskResult `bson:"subtask_results,omitempty" json:"subtask_results,omitempty"`
+	SubmittedAt    time.Time       `bson:"submitted_at" json:"submitted_at"`
+	JudgedAt       *time.Time      `bson:"judged_at,omitempty" json:"judged_at,omitempty"`
+	// ContestID 所属竞赛，非竞赛提交为null
+	ContestID *primitive.ObjectID `bson:"contest_id" json:"contest_id"`
+	// AssignmentID 所属作业，只有作业提交计入作业成绩；非作业提交为null
+	AssignmentID *primitive.ObjectID `bson:"assignment_id" json:"assignment_id"`
 }
 
 // CompileInfo 编译信息
@@ -104,12 +182,24 @@ type TestResult struct {
 	TimeUsed       int         `bson:"time_used" json:"time_used"`
 	MemoryUsed     int         `bson:"memory_used" json:"memory_used"`
 	Score          int         `bson:"score" json:"score"`
+	ScoreRatio     float64     `bson:"score_ratio" json:"score_ratio"` // 得分率(0~1)，特殊判题可给出部分得分
 	Input          string      `bson:"input,omitempty" json:"input,omitempty"`
 	ExpectedOutput string      `bson:"expected_output,omitempty" json:"expected_output,omitempty"`
 	ActualOutput   string      `bson:"actual_output,omitempty" json:"actual_output,omitempty"`
+	CheckerVerdict string      `bson:"checker_verdict,omitempty" json:"checker_verdict,omitempty"` // 特殊判题结论
+	CheckerMessage string      `bson:"checker_message,omitempty" json:"checker_message,omitempty"` // 特殊判题输出的说明
+	DiffSummary    string      `bson:"diff_summary,omitempty" json:"diff_summary,omitempty"`       // 第一处不一致的行
 	JudgeDetails   JudgeDetail `bson:"judge_details" json:"judge_details"`
 }
 
+// SubtaskResult 子任务结果
+type SubtaskResult struct {
+	SubtaskID string `bson:"subtask_id" json:"subtask_id"`
+	Status    string `bson:"status" json:"status"` // 满分为ACCEPTED，前置子任务未通过为SKIPPED，否则为第一个未通过用例的状态
+	Score     int    `bson:"score" json:"score"`
+	FullScore int    `bson:"full_score" json:"full_score"`
+}
+
 // JudgeDetail go-judge详细信息
 type JudgeDetail struct {
 	GoJudgeStatus string `bson:"go_judge_status" json:"go_judge_status"`
@@ -117,17 +207,222 @@ type JudgeDetail struct {
 	RuntimeNS     int64  `bson:"runtime_ns" json:"runtime_ns"`
 }
 
-// Contest 竞赛模型 (预留)
+// Contest 竞赛模型
+// 竞赛状态由当前时间和起止时间决定，不存储
 type Contest struct {
-	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Title       string               `bson:"title" json:"title"`
-	Description string               `bson:"description" json:"description"`
-	ProblemIDs  []primitive.ObjectID `bson:"problem_ids" json:"problem_ids"`
-	StartTime   time.Time            `bson:"start_time" json:"start_time"`
-	EndTime     time.Time            `bson:"end_time" json:"end_time"`
-	CreatedBy   primitive.ObjectID   `bson:"created_by" json:"created_by"`
-	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description" json:"description"`
+	Type        string             `bson:"type" json:"type"`                           // public, class
+	Classes     []string           `bson:"classes,omitempty" json:"classes,omitempty"` // type为class时允许报名的班级
+	// ProblemIDs 竞赛题目，按题号顺序排列；竞赛开始前只对创建者和管理员可见
+	ProblemIDs     []primitive.ObjectID `bson:"problem_ids" json:"problem_ids,omitempty"`
+	ParticipantIDs []primitive.ObjectID `bson:"participant_ids" json:"-"` // 已报名用户
+	Settings       ContestSettings      `bson:"settings" json:"settings"`
+	StartTime      time.Time            `bson:"start_time" json:"start_time"`
+	EndTime        time.Time            `bson:"end_time" json:"end_time"`
+	// ScoreboardUnfrozen 封榜结果已全部揭晓，所有用户看到最终排行榜
+	ScoreboardUnfrozen bool               `bson:"scoreboard_unfrozen" json:"scoreboard_unfrozen"`
+	CreatedBy          primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ContestSettings 竞赛设置
+type ContestSettings struct {
+	Rule           string `bson:"rule" json:"rule"`                       // 赛制: icpc, oi，默认icpc
+	PenaltyTime    int    `bson:"penalty_time" json:"penalty_time"`       // 错误提交罚时(秒)，仅ICPC
+	FreezeTime     int    `bson:"freeze_time" json:"freeze_time"`         // 结束前封榜时长(秒)，0为不封榜
+	MaxSubmissions int    `bson:"max_submissions" json:"max_submissions"` // 每人每题最多提交次数，0为不限制
+}
+
+// StatusAt 竞赛在now时刻的状态
+func (c *Contest) StatusAt(now time.Time) string {
+	switch {
+	case now.Before(c.StartTime):
+		return ContestStatusUpcoming
+	case now.Before(c.EndTime):
+		return ContestStatusRunning
+	default:
+		return ContestStatusEnded
+	}
+}
+
+// FreezeStart 封榜开始时间，未设置封榜时为结束时间
+func (c *Contest) FreezeStart() time.Time {
+	return c.EndTime.Add(-time.Duration(c.Settings.FreezeTime) * time.Second)
+}
+
+// FrozenAt 排行榜在now时刻是否处于封榜状态，结果全部揭晓后不再封榜
+func (c *Contest) FrozenAt(now time.Time) bool {
+	return c.Settings.FreezeTime > 0 && !c.ScoreboardUnfrozen && !now.Before(c.FreezeStart())
+}
+
+// HasProblem 题目是否属于竞赛
+func (c *Contest) HasProblem(problemID primitive.ObjectID) bool {
+	for _, id := range c.ProblemIDs {
+		if id == problemID {
+			return true
+		}
+	}
+	return false
+}
+
+// Role 角色，保存在数据库中的角色覆盖配置文件和内置的同名角色
+type Role struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Name        string             `bson:"name" json:"name"`
+	DisplayName string             `bson:"display_name" json:"display_name"`
+	Description string             `bson:"description" json:"description"`
+	Permissions []string           `bson:"permissions" json:"permissions"`
+	BuiltIn     bool               `bson:"-" json:"built_in"` // 内置角色不可删除
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Course 课程班级，对应一个学期的一个教学班
+// 教师、助教和学生按成员列表关联课程，教师和助教只能查看所教课程学生的提交、排名和统计
+type Course struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`             // 课程名称，如 Java程序设计
+	ClassName   string             `bson:"class_name" json:"class_name"` // 班级名称，按班级筛选用户时匹配
+	Term        string             `bson:"term" json:"term"`             // 学期，如 2026-2027-1
+	Description string             `bson:"description" json:"description"`
+	StartDate   time.Time          `bson:"start_date" json:"start_date"`
+	EndDate     time.Time          `bson:"end_date" json:"end_date"` // 结课后不能再用邀请码加入
+	// 成员，同一用户在一门课程中只有一种身份
+	TeacherIDs []primitive.ObjectID `bson:"teacher_ids" json:"teacher_ids"`
+	TAIDs      []primitive.ObjectID `bson:"ta_ids" json:"ta_ids"`
+	StudentIDs []primitive.ObjectID `bson:"student_ids" json:"-"`
+	// JoinCode 学生自行加入课程的邀请码，为空时只能由教师添加
+	JoinCode  string             `bson:"join_code,omitempty" json:"join_code,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// MemberRole 用户在课程中的身份(CourseRole*)，不是课程成员时为空
+func (c *Course) MemberRole(userID primitive.ObjectID) string {
+	for _, member := range []struct {
+		role string
+		ids  []primitive.ObjectID
+	}{
+		{CourseRoleTeacher, c.TeacherIDs},
+		{CourseRoleTA, c.TAIDs},
+		{CourseRoleStudent, c.StudentIDs},
+	} {
+		for _, id := range member.ids {
+			if id == userID {
+				return member.role
+			}
+		}
+	}
+	return ""
+}
+
+// IsStaff 用户是否是课程的教师或助教
+func (c *Course) IsStaff(userID primitive.ObjectID) bool {
+	role := c.MemberRole(userID)
+	return role == CourseRoleTeacher || role == CourseRoleTA
+}
+
+// Assignment 课程作业
+// 开放时间到截止时间之间的提交正常计分，截止时间到关闭时间之间的提交按迟交扣分，其余提交不计分
+type Assignment struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CourseID    primitive.ObjectID  `bson:"course_id" json:"course_id"`
+	Title       string              `bson:"title" json:"title"`
+	Description string              `bson:"description" json:"description"`
+	Problems    []AssignmentProblem `bson:"problems" json:"problems"`
+	OpenTime    time.Time           `bson:"open_time" json:"open_time"`
+	DueTime     time.Time           `bson:"due_time" json:"due_time"`
+	CloseTime   time.Time           `bson:"close_time" json:"close_time"` // 等于截止时间时不接受迟交
+	// LatePenalty 迟交扣分曲线，按after_hours升序排列；为空时迟交不扣分
+	LatePenalty []LatePenaltyStep `bson:"late_penalty" json:"late_penalty"`
+	ScoringMode string            `bson:"scoring_mode" json:"scoring_mode"` // AssignmentScoring*
+	// Extensions 个别学生的延期，学生只能看到自己的延期
+	Extensions []AssignmentExtension `bson:"extensions" json:"extensions"`
+	CreatedBy  primitive.ObjectID    `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+// AssignmentProblem 作业题目及其分值
+type AssignmentProblem struct {
+	ProblemID primitive.ObjectID `bson:"problem_id" json:"problem_id"`
+	Points    int                `bson:"points" json:"points"`
+}
+
+// LatePenaltyStep 迟交超过after_hours小时后扣除percent%的得分
+type LatePenaltyStep struct {
+	AfterHours float64 `bson:"after_hours" json:"after_hours"`
+	Percent    int     `bson:"percent" json:"percent"`
+}
+
+// AssignmentExtension 学生的延期截止时间和关闭时间
+type AssignmentExtension struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	DueTime   time.Time          `bson:"due_time" json:"due_time"`
+	CloseTime time.Time          `bson:"close_time" json:"close_time"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	GrantedBy primitive.ObjectID `bson:"granted_by" json:"granted_by"`
+}
+
+// DeadlinesFor 用户的截止时间和关闭时间，有延期时取延期的时间
+func (a *Assignment) DeadlinesFor(userID primitive.ObjectID) (dueTime, closeTime time.Time) {
+	for _, extension := range a.Extensions {
+		if extension.UserID == userID {
+			return extension.DueTime, extension.CloseTime
+		}
+	}
+	return a.DueTime, a.CloseTime
+}
+
+// LatestClose 所有学生中最晚的关闭时间
+func (a *Assignment) LatestClose() time.Time {
+	latest := a.CloseTime
+	for _, extension := range a.Extensions {
+		if extension.CloseTime.After(latest) {
+			latest = extension.CloseTime
+		}
+	}
+	return latest
+}
+
+// LatePenaltyPercent 迟交late时长时扣除的得分百分比，取最后一个超过的阶梯
+func (a *Assignment) LatePenaltyPercent(late time.Duration) int {
+	percent := 0
+	for _, step := range a.LatePenalty {
+		if late.Hours() > step.AfterHours {
+			percent = step.Percent
+		}
+	}
+	return percent
+}
+
+// HasProblem 题目是否属于作业
+func (a *Assignment) HasProblem(problemID primitive.ObjectID) bool {
+	for _, item := range a.Problems {
+		if item.ProblemID == problemID {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusAt 用户在now时刻看到的作业状态
+func (a *Assignment) StatusAt(userID primitive.ObjectID, now time.Time) string {
+	dueTime, closeTime := a.DeadlinesFor(userID)
+	switch {
+	case now.Before(a.OpenTime):
+		return AssignmentStatusUpcoming
+	case now.Before(dueTime):
+		return AssignmentStatusOpen
+	case now.Before(closeTime):
+		return AssignmentStatusLate
+	default:
+		return AssignmentStatusClosed
+	}
 }
 
 // 提交状态常量
@@ -136,6 +431,7 @@ const (
 	StatusJudging             = "JUDGING"
 	StatusAccepted            = "ACCEPTED"
 	StatusWrongAnswer         = "WRONG_ANSWER"
+	StatusPresentationError   = "PRESENTATION_ERROR"
 	StatusTimeLimitExceeded   = "TIME_LIMIT_EXCEEDED"
 	StatusMemoryLimitExceeded = "MEMORY_LIMIT_EXCEEDED"
 	StatusRuntimeError        = "RUNTIME_ERROR"
@@ -143,15 +439,80 @@ const (
 	StatusSystemError         = "SYSTEM_ERROR"
 	StatusDangerousSyscall    = "DANGEROUS_SYSCALL"
 	StatusOutputLimitExceeded = "OUTPUT_LIMIT_EXCEEDED"
+	StatusPartialAccepted     = "PARTIAL_ACCEPTED" // 子任务题目或特殊判题部分得分
+	StatusSkipped             = "SKIPPED"          // 测试用例或子任务未评测
+)
+
+// 测试用例运行策略
+const (
+	RunPolicyRunAll        = "run_all"         // 运行全部测试用例(OI赛制)
+	RunPolicyStopOnFailure = "stop_on_failure" // 第一个未通过的用例后停止(ICPC赛制)
+)
+
+// 子任务计分策略
+const (
+	SubtaskPolicyAllOrNothing = "all_or_nothing" // 全部通过得满分，否则0分
+	SubtaskPolicyMin          = "min"            // 按得分率最低的用例计分
+	SubtaskPolicySum          = "sum"            // 按各用例得分(特殊判题可部分得分)的占比计分
+)
+
+// 输出比对模式
+const (
+	CompareExact           = "exact"            // 逐字节比对
+	CompareLines           = "lines"            // 忽略每行行末空白和末尾空行
+	CompareTokens          = "tokens"           // 按空白分隔的单词比对
+	CompareCaseInsensitive = "case_insensitive" // 同lines，忽略大小写
+	CompareFloat           = "float"            // 同tokens，数值按绝对或相对误差比较
 )
 
 // 用户角色常量
 const (
 	RoleStudent = "student"
+	RoleTA      = "ta" // 助教
 	RoleTeacher = "teacher"
 	RoleAdmin   = "admin"
 )
 
+// 课程成员身份常量，与用户角色相互独立：教师账号也可以作为助教加入其他课程
+const (
+	CourseRoleTeacher = "teacher"
+	CourseRoleTA      = "ta"
+	CourseRoleStudent = "student"
+)
+
+// 作业计分方式常量
+const (
+	AssignmentScoringBest = "best" // 取得分最高的提交
+	AssignmentScoringLast = "last" // 取最后一次提交
+)
+
+// 作业状态常量
+const (
+	AssignmentStatusUpcoming = "upcoming" // 未开放
+	AssignmentStatusOpen     = "open"     // 截止前
+	AssignmentStatusLate     = "late"     // 截止后、关闭前，提交按迟交扣分
+	AssignmentStatusClosed   = "closed"
+)
+
+// 竞赛类型常量
+const (
+	ContestTypePublic = "public" // 所有用户可报名
+	ContestTypeClass  = "class"  // 仅指定班级的学生可报名
+)
+
+// 竞赛赛制常量
+const (
+	ContestRuleICPC = "icpc" // 按通过题数和罚时排名
+	ContestRuleOI   = "oi"   // 按各题最高得分之和排名
+)
+
+// 竞赛状态常量
+const (
+	ContestStatusUpcoming = "upcoming"
+	ContestStatusRunning  = "running"
+	ContestStatusEnded    = "ended"
+)
+
 // 题目难度常量
 const (
 	DifficultyEasy   = "easy"
@@ -159,7 +520,11 @@ const (
 	DifficultyHard   = "hard"
 )
 
-// 编程语言常量
+// 编程语言常量，可用语言以判题语言注册表为准
 const (
-	LanguageJava = "java"
+	LanguageJava    = "java"
+	LanguageC       = "c"
+	LanguageCpp     = "cpp"
+	LanguagePython3 = "python3"
+	LanguageGo      = "go"
 )
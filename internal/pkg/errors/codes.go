@@ -2,7 +2,7 @@ package errors
 
 // 错误码定义 - 校园Java-OJ系统统一错误码
 // 采用5位数字编码：AABBB
-// AA: 模块代码 (10:通用, 20:用户, 30:题目, 40:提交, 50:判题, 60:管理)
+// AA: 模块代码 (10:通用, 20:用户, 30:题目, 40:提交, 50:判题, 60:管理, 70:竞赛, 80:课程)
 // BBB: 具体错误代码 (001-999)
 
 const (
@@ -37,6 +37,15 @@ const (
 	PROFILE_UPDATE_FAILED     = 20015 // 用户信息更新失败
 	INSUFFICIENT_PERMISSION   = 20016 // 权限不足
 	USER_STATS_ERROR          = 20017 // 用户统计信息错误
+	SESSION_NOT_FOUND         = 20018 // 会话不存在
+	SSO_PROVIDER_NOT_FOUND    = 20019 // 统一身份认证不存在
+	SSO_LOGIN_FAILED          = 20020 // 统一身份认证登录失败
+	SSO_ACCOUNT_NOT_FOUND     = 20021 // 统一身份认证账号未开通
+	SSO_ACCOUNT_UNVERIFIED    = 20022 // 学号对应的账号未核实，不能通过统一身份认证登录
+	USER_IMPORT_INVALID       = 20023 // 批量导入的数据有误
+	RESET_TOKEN_INVALID       = 20024 // 密码重置链接无效或已过期
+	PASSWORD_CHANGE_REQUIRED  = 20025 // 需要先修改密码
+	LOGIN_LOCKED              = 20026 // 登录失败次数过多，暂时锁定
 
 	// ========== 题目模块错误码 (30000-30999) ==========
 	PROBLEM_NOT_FOUND      = 30001 // 题目不存在
@@ -61,6 +70,7 @@ const (
 	SUBMISSION_ACCESS_DENIED  = 40008 // 提交访问被拒绝
 	SUBMISSION_LIMIT_EXCEEDED = 40009 // 提交次数超限
 	SUBMISSION_TOO_FREQUENT   = 40010 // 提交过于频繁
+	SUBMISSION_JUDGING        = 40011 // 提交正在判题
 
 	// ========== 判题模块错误码 (50000-50999) ==========
 	JUDGE_SYSTEM_ERROR        = 50001 // 判题系统错误
@@ -88,6 +98,10 @@ const (
 	BACKUP_FAILED           = 60007 // 备份失败
 	RESTORE_FAILED          = 60008 // 恢复失败
 	SYSTEM_STATS_ERROR      = 60009 // 系统统计错误
+	ROLE_NOT_FOUND          = 60010 // 角色不存在
+	ROLE_ALREADY_EXISTS     = 60011 // 角色已存在
+	ROLE_IN_USE             = 60012 // 角色仍有用户使用
+	ROLE_READONLY           = 60013 // 角色不可修改
 
 	// ========== 竞赛模块错误码 (70000-70999) ==========
 	CONTEST_NOT_FOUND           = 70001 // 竞赛不存在
@@ -95,6 +109,17 @@ const (
 	CONTEST_ENDED               = 70003 // 竞赛已结束
 	CONTEST_ACCESS_DENIED       = 70004 // 竞赛访问被拒绝
 	CONTEST_REGISTRATION_FAILED = 70005 // 竞赛报名失败
+	CONTEST_NOT_ENDED           = 70006 // 竞赛未结束
+	SCOREBOARD_NOT_FROZEN       = 70007 // 排行榜未封榜
+
+	// ========== 课程模块错误码 (80000-80999) ==========
+	COURSE_NOT_FOUND     = 80001 // 课程不存在
+	COURSE_ACCESS_DENIED = 80002 // 课程访问被拒绝
+	JOIN_CODE_INVALID    = 80003 // 邀请码无效
+	COURSE_ENDED         = 80004 // 课程已结课
+	ASSIGNMENT_NOT_FOUND = 80005 // 作业不存在
+	ASSIGNMENT_NOT_OPEN  = 80006 // 作业尚未开放
+	ASSIGNMENT_CLOSED    = 80007 // 作业已关闭
 )
 
 // 错误码到消息的映射
@@ -130,6 +155,15 @@ var errorMessages = map[int]string{
 	PROFILE_UPDATE_FAILED:     "用户信息更新失败",
 	INSUFFICIENT_PERMISSION:   "权限不足",
 	USER_STATS_ERROR:          "用户统计信息获取失败",
+	SESSION_NOT_FOUND:         "会话不存在或已注销",
+	SSO_PROVIDER_NOT_FOUND:    "未配置该统一身份认证",
+	SSO_LOGIN_FAILED:          "统一身份认证登录失败",
+	SSO_ACCOUNT_NOT_FOUND:     "账号未开通，请联系管理员",
+	SSO_ACCOUNT_UNVERIFIED:    "该学号已被未核实的账号使用，请联系管理员",
+	USER_IMPORT_INVALID:       "导入数据有误，请修改后重新导入",
+	RESET_TOKEN_INVALID:       "重置链接无效或已过期，请重新找回密码",
+	PASSWORD_CHANGE_REQUIRED:  "密码已被重置，请先修改密码",
+	LOGIN_LOCKED:              "登录失败次数过多，请稍后重试",
 
 	// 题目模块
 	PROBLEM_NOT_FOUND:      "题目不存在",
@@ -154,6 +188,7 @@ var errorMessages = map[int]string{
 	SUBMISSION_ACCESS_DENIED:  "提交记录访问被拒绝",
 	SUBMISSION_LIMIT_EXCEEDED: "提交次数超过限制",
 	SUBMISSION_TOO_FREQUENT:   "提交过于频繁，请稍后重试",
+	SUBMISSION_JUDGING:        "提交正在判题，请判题完成后再重新判题",
 
 	// 判题模块
 	JUDGE_SYSTEM_ERROR:        "判题系统错误",
@@ -181,6 +216,10 @@ var errorMessages = map[int]string{
 	BACKUP_FAILED:           "备份失败",
 	RESTORE_FAILED:          "恢复失败",
 	SYSTEM_STATS_ERROR:      "系统统计信息获取失败",
+	ROLE_NOT_FOUND:          "角色不存在",
+	ROLE_ALREADY_EXISTS:     "角色已存在",
+	ROLE_IN_USE:             "仍有用户属于该角色，请先修改这些用户的角色",
+	ROLE_READONLY:           "该角色不可修改",
 
 	// 竞赛模块
 	CONTEST_NOT_FOUND:           "竞赛不存在",
@@ -188,6 +227,17 @@ var errorMessages = map[int]string{
 	CONTEST_ENDED:               "竞赛已结束",
 	CONTEST_ACCESS_DENIED:       "竞赛访问被拒绝",
 	CONTEST_REGISTRATION_FAILED: "竞赛报名失败",
+	CONTEST_NOT_ENDED:           "竞赛尚未结束",
+	SCOREBOARD_NOT_FROZEN:       "排行榜未封榜",
+
+	// 课程模块
+	COURSE_NOT_FOUND:     "课程不存在",
+	COURSE_ACCESS_DENIED: "课程访问被拒绝",
+	JOIN_CODE_INVALID:    "邀请码无效或已停用",
+	COURSE_ENDED:         "课程已结课",
+	ASSIGNMENT_NOT_FOUND: "作业不存在",
+	ASSIGNMENT_NOT_OPEN:  "作业尚未开放",
+	ASSIGNMENT_CLOSED:    "作业已关闭，不再接受提交",
 }
 
 // GetErrorMessage 根据错误码获取错误消息
@@ -227,3 +277,8 @@ func IsAdminError(code int) bool {
 func IsContestError(code int) bool {
 	return code >= 70000 && code < 71000
 }
+
+// IsCourseError 判断是否为课程模块错误
+func IsCourseError(code int) bool {
+	return code >= 80000 && code < 81000
+}
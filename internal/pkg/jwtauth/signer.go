@@ -0,0 +1,282 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// defaultKeyID 只配置jwt.secret时HMAC密钥的ID
+const defaultKeyID = "default"
+
+// Claims JWT载荷，RegisteredClaims.ID为token ID(jti)，用于撤销单个token
+type Claims struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // 登录会话ID，会话注销后该会话签发的token全部失效
+	// PasswordChange 用户需要修改密码，修改后刷新token清除
+	PasswordChange bool `json:"pwd_change,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// key 签名或校验密钥，signKey为空时只用于校验
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// Signer JWT签名与校验，按token头部的kid选择校验密钥
+// 轮换密钥时把新密钥设为signing_key，旧密钥保留到其签发的token全部过期
+type Signer struct {
+	issuer  string
+	signing *key
+	keys    map[string]*key
+	order   []string // 配置顺序，JWKS按此顺序输出
+}
+
+// NewSigner 根据配置创建签名器，未配置keys时使用jwt.secret作为HS256密钥
+func NewSigner(cfg config.JWTConfig) (*Signer, error) {
+	keyConfigs := cfg.Keys
+	signingKey := cfg.SigningKey
+	if len(keyConfigs) == 0 {
+		keyConfigs = []config.JWTKeyConfig{{ID: defaultKeyID, Algorithm: AlgHS256, Secret: cfg.Secret}}
+		signingKey = defaultKeyID
+	}
+
+	s := &Signer{issuer: cfg.Issuer, keys: make(map[string]*key, len(keyConfigs))}
+	for _, kc := range keyConfigs {
+		if kc.ID == "" {
+			return nil, errors.New("JWT密钥缺少id")
+		}
+		if _, ok := s.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("JWT密钥id重复: %s", kc.ID)
+		}
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("加载JWT密钥%s失败: %w", kc.ID, err)
+		}
+		s.keys[kc.ID] = k
+		s.order = append(s.order, kc.ID)
+
+		if s.signing == nil && k.signKey != nil && (signingKey == "" || signingKey == kc.ID) {
+			s.signing = k
+		}
+	}
+	if s.signing == nil {
+		if signingKey != "" {
+			return nil, fmt.Errorf("签名密钥%s不存在或缺少私钥", signingKey)
+		}
+		return nil, errors.New("没有可用于签名的JWT密钥")
+	}
+	return s, nil
+}
+
+func loadKey(kc config.JWTKeyConfig) (*key, error) {
+	k := &key{id: kc.ID}
+	switch kc.Algorithm {
+	case AlgHS256:
+		if kc.Secret == "" {
+			return nil, errors.New("HS256密钥为空")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(kc.Secret)
+		k.verifyKey = k.signKey
+
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = &private.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = private.(ed25519.PrivateKey).Public()
+		} else if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", kc.Algorithm)
+	}
+
+	if k.verifyKey == nil {
+		return nil, errors.New("需要配置private_key_file或public_key_file")
+	}
+	return k, nil
+}
+
+// Generate 生成访问token，返回token和载荷(包含token ID和过期时间)
+func (s *Signer) Generate(user *model.User, sessionID string, expire time.Duration) (string, *Claims, error) {
+	tokenID, err := utils.RandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	claims := &Claims{
+		UserID:         user.ID.Hex(),
+		Username:       user.Username,
+		Role:           user.Role,
+		SessionID:      sessionID,
+		PasswordChange: user.MustChangePassword,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			Subject:   user.ID.Hex(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	token.Header["kid"] = s.signing.id
+	signed, err := token.SignedString(s.signing.signKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// Parse 校验并解析token，kid未知、算法与密钥不符或签发者不符时返回错误
+func (s *Signer) Parse(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("未知的签名密钥: %q", kid)
+		}
+		// 算法必须与密钥一致，防止用公钥作为HMAC密钥伪造token
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, errors.New("token签名方法错误")
+		}
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token无效")
+	}
+	if s.issuer != "" && !claims.VerifyIssuer(s.issuer, true) {
+		return nil, errors.New("token签发者错误")
+	}
+	return claims, nil
+}
+
+// JWK 公钥，格式见RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA模数
+	E   string `json:"e,omitempty"`   // RSA指数
+	Crv string `json:"crv,omitempty"` // EdDSA曲线
+	X   string `json:"x,omitempty"`   // EdDSA公钥
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回全部非对称密钥的公钥，HMAC密钥不公开
+func (s *Signer) JWKS() *JWKS {
+	set := &JWKS{Keys: []JWK{}}
+	for _, id := range s.order {
+		k := s.keys[id]
+		switch public := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: id,
+				Use: "sig",
+				Alg: AlgRS256,
+				N:   encode(public.N.Bytes()),
+				E:   encode(bigEndian(public.E)),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: id,
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Crv: "Ed25519",
+				X:   encode(public),
+			})
+		}
+	}
+	return set
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// bigEndian RSA公钥指数的大端字节，去掉前导0
+func bigEndian(n int) []byte {
+	var buf []byte
+	for ; n > 0; n >>= 8 {
+		buf = append([]byte{byte(n)}, buf...)
+	}
+	return buf
+}
@@ -0,0 +1,28 @@
+package pwdpolicy
+
+// commonPasswords 内置的常见弱密码(小写)，取自公开泄露数据中使用最多的密码
+// 比较时忽略大小写，并会去掉密码末尾的数字和符号再比较一次
+var commonPasswords = []string{
+	// 数字
+	"123456", "1234567", "12345678", "123456789", "1234567890", "0123456789",
+	"111111", "11111111", "000000", "00000000", "666666", "66666666", "888888", "88888888",
+	"123123", "123123123", "112233", "121212", "123321", "654321", "987654321",
+	"147258", "147258369", "159357", "159753", "258369", "321321", "520520",
+	"5201314", "1314520", "7758521", "7758258", "123654", "147852", "147852369",
+	"11223344", "12341234", "12344321", "13579", "24680", "02468", "246810",
+	// 键盘序列
+	"qwerty", "qwertyui", "qwertyuiop", "asdfgh", "asdfghjk", "asdfghjkl",
+	"zxcvbn", "zxcvbnm", "qazwsx", "qazwsxedc", "1qaz2wsx", "1qaz2wsx3edc",
+	"1q2w3e4r", "1q2w3e4r5t", "1q2w3e", "q1w2e3r4", "qweasd", "qweasdzxc",
+	"asdasd", "zaq12wsx", "!qaz2wsx", "qwe123", "asd123", "zxc123",
+	// 单词
+	"password", "passw0rd", "p@ssw0rd", "p@ssword", "admin", "administrator",
+	"root", "toor", "welcome", "login", "master", "letmein", "monkey", "dragon",
+	"football", "baseball", "basketball", "superman", "batman", "iloveyou",
+	"sunshine", "princess", "shadow", "michael", "jordan", "charlie", "freedom",
+	"whatever", "trustno1", "starwars", "computer", "internet", "secret",
+	"abc123", "abcd1234", "abc12345", "a123456", "a12345678", "aa123456",
+	"qq123456", "woaini", "woaini1314", "woaini520", "wangyu", "zhang", "wang",
+	"test", "test123", "guest", "changeme", "default", "student", "teacher",
+	"school", "java", "javaoj", "oj123456", "zhku", "zhku123",
+}
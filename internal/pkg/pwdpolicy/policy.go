@@ -0,0 +1,275 @@
+// Package pwdpolicy 密码强度策略：长度、字符种类、常见弱密码和个人信息相似度
+package pwdpolicy
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"zhku-oj/internal/config"
+)
+
+// maxLength bcrypt只使用前72字节，更长的部分不起作用
+const maxLength = 72
+
+// 生成密码的字符集，去掉了容易混淆的0/O、1/l/I
+var generateClasses = []string{
+	"abcdefghijkmnpqrstuvwxyz",
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"23456789",
+	"!@#$%&*+-=?",
+}
+
+// generateAttempts 生成的密码不符合策略(如碰巧与个人信息相似)时重新生成的次数
+const generateAttempts = 10
+
+// Policy 密码策略
+type Policy struct {
+	minLength     int
+	minClasses    int
+	checkPersonal bool
+	banned        map[string]struct{}
+}
+
+// New 根据配置创建密码策略，BannedFile读取失败时返回错误
+func New(cfg config.PasswordPolicyConfig) (*Policy, error) {
+	p := &Policy{
+		minLength:     cfg.MinLength,
+		minClasses:    cfg.MinClasses,
+		checkPersonal: cfg.CheckPersonal,
+		banned:        make(map[string]struct{}, len(commonPasswords)+len(cfg.BannedPasswords)),
+	}
+	if p.minLength <= 0 {
+		p.minLength = 8
+	}
+	if p.minClasses > 4 {
+		p.minClasses = 4
+	}
+
+	for _, password := range commonPasswords {
+		p.banned[password] = struct{}{}
+	}
+	for _, password := range cfg.BannedPasswords {
+		p.ban(password)
+	}
+	if cfg.BannedFile != "" {
+		f, err := os.Open(cfg.BannedFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取禁止密码列表失败: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			p.ban(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取禁止密码列表失败: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *Policy) ban(password string) {
+	if password = strings.ToLower(strings.TrimSpace(password)); password != "" {
+		p.banned[password] = struct{}{}
+	}
+}
+
+// Validate 检查密码是否符合策略，personal为用户名、学号、邮箱等不能出现在密码中的个人信息
+// 不符合时返回的错误列出全部原因，可直接展示给用户
+func (p *Policy) Validate(password string, personal ...string) error {
+	var problems []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		problems = append(problems, fmt.Sprintf("长度不能少于%d位", p.minLength))
+	}
+	if len(password) > maxLength {
+		problems = append(problems, fmt.Sprintf("长度不能超过%d个字节", maxLength))
+	}
+	if classes := countClasses(password); classes < p.minClasses {
+		problems = append(problems, fmt.Sprintf("需包含小写字母、大写字母、数字、符号中的至少%d种", p.minClasses))
+	}
+
+	lower := strings.ToLower(password)
+	if p.isBanned(lower) {
+		problems = append(problems, "过于常见，容易被猜到")
+	} else if isRepeated(lower) || isSequential(lower) {
+		problems = append(problems, "不能是重复或连续的字符")
+	}
+	if p.checkPersonal {
+		for _, info := range personal {
+			if similar(lower, strings.ToLower(strings.TrimSpace(info))) {
+				problems = append(problems, "不能包含用户名、学号、邮箱或与其过于相似")
+				break
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("密码" + strings.Join(problems, "；"))
+}
+
+// Generate 生成符合策略的随机密码，用于初始密码和管理员重置的临时密码
+// 长度取n和策略最短长度中的较大者，包含策略要求的各种字符；personal同Validate
+func (p *Policy) Generate(n int, personal ...string) (string, error) {
+	if n < p.minLength {
+		n = p.minLength
+	}
+	// 至少包含小写字母、大写字母和数字，策略要求4种时再加符号
+	classes := generateClasses[:3]
+	if p.minClasses > 3 {
+		classes = generateClasses
+	}
+	if n < len(classes) {
+		n = len(classes)
+	}
+	if n > maxLength {
+		return "", fmt.Errorf("密码长度不能超过%d", maxLength)
+	}
+	alphabet := strings.Join(classes, "")
+
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		buf := make([]byte, n)
+		for i := range buf {
+			// 前几位依次取自各种字符，保证每种至少一个，最后整体打乱
+			set := alphabet
+			if i < len(classes) {
+				set = classes[i]
+			}
+			c, err := randomIndex(len(set))
+			if err != nil {
+				return "", err
+			}
+			buf[i] = set[c]
+		}
+		for i := len(buf) - 1; i > 0; i-- {
+			j, err := randomIndex(i + 1)
+			if err != nil {
+				return "", err
+			}
+			buf[i], buf[j] = buf[j], buf[i]
+		}
+		if password := string(buf); p.Validate(password, personal...) == nil {
+			return password, nil
+		}
+	}
+	return "", errors.New("无法生成符合密码策略的密码")
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// isBanned 密码本身或去掉末尾数字、符号后在禁止列表中，如 Password123!
+func (p *Policy) isBanned(lower string) bool {
+	if _, ok := p.banned[lower]; ok {
+		return true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	if utf8.RuneCountInString(base) < 4 || base == lower {
+		return false
+	}
+	_, ok := p.banned[base]
+	return ok
+}
+
+// countClasses 包含的字符种类数
+func countClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// isRepeated 全部由同一个字符组成，如 aaaaaaaa
+func isRepeated(password string) bool {
+	first, _ := utf8.DecodeRuneInString(password)
+	return strings.Trim(password, string(first)) == ""
+}
+
+// isSequential 整体为递增或递减的连续字符，如 12345678、hgfedcba
+func isSequential(password string) bool {
+	runes := []rune(password)
+	if len(runes) < 3 {
+		return false
+	}
+	step := runes[1] - runes[0]
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(runes); i++ {
+		if runes[i]-runes[i-1] != step {
+			return false
+		}
+	}
+	return true
+}
+
+// similar 密码包含个人信息(正序或倒序)、被个人信息包含，或只差两个字符以内
+// 邮箱只比较@之前的部分；少于3个字符的信息不比较
+func similar(password, info string) bool {
+	if at := strings.IndexByte(info, '@'); at >= 0 {
+		info = info[:at]
+	}
+	if utf8.RuneCountInString(info) < 3 {
+		return false
+	}
+	if strings.Contains(password, info) || strings.Contains(info, password) ||
+		strings.Contains(reverse(password), info) {
+		return true
+	}
+	return distance(password, info) <= 2
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// distance 编辑距离(插入、删除、替换各计1)
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
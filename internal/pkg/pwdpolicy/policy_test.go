@@ -0,0 +1,150 @@
+package pwdpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"zhku-oj/internal/config"
+)
+
+func newPolicy(t *testing.T, cfg config.PasswordPolicyConfig) *Policy {
+	t.Helper()
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("创建密码策略失败: %v", err)
+	}
+	return p
+}
+
+func TestGenerate(t *testing.T) {
+	personal := []string{"zhangsan", "2021001001", "zhangsan@example.com"}
+	tests := []struct {
+		name        string
+		cfg         config.PasswordPolicyConfig
+		n           int
+		wantLength  int
+		wantClasses int
+	}{
+		{name: "default policy", n: 10, wantLength: 10, wantClasses: 3},
+		{name: "policy longer than requested", cfg: config.PasswordPolicyConfig{MinLength: 16}, n: 10, wantLength: 16, wantClasses: 3},
+		{name: "all classes", cfg: config.PasswordPolicyConfig{MinClasses: 4, CheckPersonal: true}, n: 10, wantLength: 10, wantClasses: 4},
+		{name: "shorter than classes", cfg: config.PasswordPolicyConfig{MinLength: 1, MinClasses: 4}, n: 2, wantLength: 4, wantClasses: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPolicy(t, tt.cfg)
+			seen := make(map[string]bool)
+			for i := 0; i < 50; i++ {
+				password, err := p.Generate(tt.n, personal...)
+				if err != nil {
+					t.Fatalf("生成密码失败: %v", err)
+				}
+				if utf8.RuneCountInString(password) != tt.wantLength {
+					t.Fatalf("密码%q长度 = %d, 期望 %d", password, utf8.RuneCountInString(password), tt.wantLength)
+				}
+				if classes := countClasses(password); classes < tt.wantClasses {
+					t.Fatalf("密码%q包含%d种字符, 期望至少%d种", password, classes, tt.wantClasses)
+				}
+				if strings.ContainsAny(password, "0O1lI") {
+					t.Fatalf("密码%q包含容易混淆的字符", password)
+				}
+				if err := p.Validate(password, personal...); err != nil {
+					t.Fatalf("生成的密码%q不符合策略: %v", password, err)
+				}
+				seen[password] = true
+			}
+			if len(seen) < 45 {
+				t.Errorf("50次生成只有%d个不同的密码", len(seen))
+			}
+		})
+	}
+}
+
+func TestGenerateTooLong(t *testing.T) {
+	p := newPolicy(t, config.PasswordPolicyConfig{MinLength: 100})
+	if password, err := p.Generate(10); err == nil {
+		t.Errorf("Generate = %q, 期望超过72字节时返回error", password)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	personal := []string{"zhangsan", "2021001001", "lisi@example.com", "li"}
+	const (
+		tooShort   = "长度不能少于8位"
+		tooLong    = "长度不能超过72个字节"
+		twoClasses = "需包含小写字母、大写字母、数字、符号中的至少2种"
+		common     = "过于常见，容易被猜到"
+		pattern    = "不能是重复或连续的字符"
+		personally = "不能包含用户名、学号、邮箱或与其过于相似"
+	)
+	tests := []struct {
+		name     string
+		cfg      config.PasswordPolicyConfig
+		password string
+		want     []string // 期望的全部原因，为空表示通过
+	}{
+		{name: "valid", cfg: config.PasswordPolicyConfig{MinClasses: 2}, password: "Tr0ub4dor&3"},
+		{name: "too short", password: "Ab1!xyz", want: []string{tooShort}},
+		{name: "min length counts runes", cfg: config.PasswordPolicyConfig{MinLength: 4}, password: "密码安全"},
+		{name: "too long", password: strings.Repeat("Ab1!", 19), want: []string{tooLong}},
+		{name: "72 bytes", password: strings.Repeat("密码", 12)},
+		{name: "too few classes", cfg: config.PasswordPolicyConfig{MinClasses: 2}, password: "correcthorse", want: []string{twoClasses}},
+		{name: "classes capped at 4", cfg: config.PasswordPolicyConfig{MinClasses: 9}, password: "Tr0ub4dor&3"},
+		{name: "common", password: "password", want: []string{common}},
+		{name: "common ignores case", password: "PassW0rd", want: []string{common}},
+		{name: "common with suffix", password: "Password123!", want: []string{common}},
+		{name: "suffix on short base", password: "zhku2024!", want: []string{common}},
+		{name: "base shorter than 4", cfg: config.PasswordPolicyConfig{BannedPasswords: []string{"abc"}}, password: "abc12345!"},
+		{name: "configured banned", cfg: config.PasswordPolicyConfig{BannedPasswords: []string{" ZhkuOJ-Lab "}}, password: "zhkuoj-lab", want: []string{common}},
+		{name: "repeated", password: "zzzzzzzzz", want: []string{pattern}},
+		{name: "ascending", password: "lmnopqrs", want: []string{pattern}},
+		{name: "descending", password: "HGFEDCBA", want: []string{pattern}},
+		{name: "personal not checked", password: "Zhangsan2024"},
+		{name: "contains username", cfg: config.PasswordPolicyConfig{CheckPersonal: true}, password: "Zhangsan2024", want: []string{personally}},
+		{name: "contains student id", cfg: config.PasswordPolicyConfig{CheckPersonal: true}, password: "x2021001001", want: []string{personally}},
+		{name: "reversed email name", cfg: config.PasswordPolicyConfig{CheckPersonal: true}, password: "!isil-xyz", want: []string{personally}},
+		{name: "close to username", cfg: config.PasswordPolicyConfig{CheckPersonal: true}, password: "zhangshan", want: []string{personally}},
+		{name: "short info ignored", cfg: config.PasswordPolicyConfig{CheckPersonal: true}, password: "lion-king7"},
+		{name: "all problems", cfg: config.PasswordPolicyConfig{MinClasses: 2, CheckPersonal: true}, password: "zhang",
+			want: []string{tooShort, twoClasses, common, personally}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newPolicy(t, tt.cfg).Validate(tt.password, personal...)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate(%q) = %v, 期望通过", tt.password, err)
+				}
+				return
+			}
+			if want := "密码" + strings.Join(tt.want, "；"); err == nil || err.Error() != want {
+				t.Errorf("Validate(%q) = %v, 期望 %s", tt.password, err, want)
+			}
+		})
+	}
+}
+
+func TestNewBannedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banned.txt")
+	if err := os.WriteFile(path, []byte("Spring-Festival\n\n  mooncake99  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := newPolicy(t, config.PasswordPolicyConfig{BannedFile: path})
+	for _, password := range []string{"spring-festival", "MoonCake99", "Spring-Festival2024!"} {
+		if err := p.Validate(password); err == nil {
+			t.Errorf("Validate(%q) = nil, 期望命中禁止密码列表", password)
+		}
+	}
+	if err := p.Validate("autumn-festival"); err != nil {
+		t.Errorf("Validate(%q) = %v, 期望通过", "autumn-festival", err)
+	}
+
+	if _, err := New(config.PasswordPolicyConfig{BannedFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Error("禁止密码列表文件不存在时应返回error")
+	}
+}
@@ -0,0 +1,138 @@
+// Package sheet 读写CSV和XLSX表格，用于用户名单等数据的批量导入导出
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 支持的表格格式
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// formulaPrefixes 以这些字符开头的单元格会被Excel等软件当作公式执行
+const formulaPrefixes = "=+-@\t\r"
+
+// utf8BOM Excel打开不带BOM的UTF-8 CSV时中文会乱码
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FormatFromName 根据文件扩展名判断格式
+func FormatFromName(name string) (string, bool) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")) {
+	case FormatCSV:
+		return FormatCSV, true
+	case FormatXLSX:
+		return FormatXLSX, true
+	}
+	return "", false
+}
+
+// ContentType 下载文件的MIME类型
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read 读取表格的全部行，XLSX只读取第一个工作表
+// 单元格去掉首尾空白，末尾的空行会被丢弃
+func Read(data []byte, format string) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(data)
+	case FormatXLSX:
+		rows, err = readXLSX(data)
+	default:
+		return nil, fmt.Errorf("不支持的表格格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+	for len(rows) > 0 && isBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+// Write 写出表格，CSV带BOM以便Excel直接打开
+// 可能被当作公式的单元格前加单引号，避免导出的用户输入(如姓名、标题)在打开表格时执行
+func Write(w io.Writer, format string, rows [][]string) error {
+	rows = escapeFormulas(rows)
+	switch format {
+	case FormatCSV:
+		if _, err := w.Write(utf8BOM); err != nil {
+			return err
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("写入CSV失败: %w", err)
+		}
+		return nil
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return fmt.Errorf("不支持的表格格式: %s", format)
+}
+
+// escapeFormulas 返回转义后的副本，不修改调用方的数据
+func escapeFormulas(rows [][]string) [][]string {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, cell := range row {
+			if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+				cell = "'" + cell
+			}
+			escaped[i][j] = cell
+		}
+	}
+	return escaped
+}
+
+// readCSV 兼容Excel另存的CSV：带BOM的UTF-8，或中文系统默认的GB18030
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if !utf8.Valid(data) {
+		decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("CSV编码无法识别，请使用UTF-8编码: %w", err)
+		}
+		data = decoded
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析CSV失败: %w", err)
+	}
+	return rows, nil
+}
+
+// isBlank 整行没有内容
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if cell != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,304 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// TestRoundTrip 写出的表格读回后内容不变，末尾的空单元格和空行除外(公式字符的转义见TestWriteEscapesFormulas)
+func TestRoundTrip(t *testing.T) {
+	wide := make([]string, 30) // 超过26列，列名为AA起
+	for i := range wide {
+		wide[i] = "c" + strconv.Itoa(i)
+	}
+	tests := []struct {
+		name string
+		rows [][]string
+		want [][]string // 为nil时与rows相同
+	}{
+		{
+			name: "chinese header and leading zeros",
+			rows: [][]string{
+				{"学号", "姓名", "班级"},
+				{"0020210001", "张三", "计算机科学与技术2021-1班"},
+				{"2.021e9", "李四", ""},
+			},
+			want: [][]string{
+				{"学号", "姓名", "班级"},
+				{"0020210001", "张三", "计算机科学与技术2021-1班"},
+				{"2.021e9", "李四"},
+			},
+		},
+		{
+			name: "escaped characters",
+			rows: [][]string{{`<a & "b">`, "x'y", "多行\n文本", "逗号,分隔"}},
+		},
+		{
+			name: "empty cells and rows",
+			rows: [][]string{
+				{"a", "", "c"},
+				{"", ""}, // CSV中完全为空的行会被跳过，中间的空行写为只有分隔符的行
+				{"", "", "", "d"},
+				{"", ""},
+				{},
+			},
+			want: [][]string{
+				{"a", "", "c"},
+				nil,
+				{"", "", "", "d"},
+			},
+		},
+		{
+			name: "wide row",
+			rows: [][]string{wide},
+		},
+	}
+
+	for _, format := range []string{FormatXLSX, FormatCSV} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, format, tt.rows); err != nil {
+					t.Fatalf("写出表格失败: %v", err)
+				}
+				got, err := Read(buf.Bytes(), format)
+				if err != nil {
+					t.Fatalf("读取表格失败: %v", err)
+				}
+				want := tt.want
+				if want == nil {
+					want = tt.rows
+				}
+				if !sameRows(got, want) {
+					t.Errorf("读回的表格 = %q, 期望 %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+// sameRows 比较表格内容；CSV中空行和末尾空单元格会保留，按去掉末尾空单元格后比较
+func sameRows(got, want [][]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !reflect.DeepEqual(trimRow(got[i]), trimRow(want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func trimRow(row []string) []string {
+	for len(row) > 0 && row[len(row)-1] == "" {
+		row = row[:len(row)-1]
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	return row
+}
+
+// buildXLSX 按给定的工作表和共享字符串生成XLSX，模拟Excel等工具保存的文件
+func buildXLSX(t *testing.T, sheetData, sharedStrings string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRelationships + `">` +
+			`<sheets><sheet name="名单" sheetId="1" r:id="rId3"/><sheet name="Sheet2" sheetId="2" r:id="rId4"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId4" Type="` + nsRelationships + `/worksheet" Target="worksheets/sheet2.xml"/>` +
+			`<Relationship Id="rId3" Type="` + nsRelationships + `/worksheet" Target="/xl/worksheets/roster.xml"/>` +
+			`</Relationships>`,
+		"xl/worksheets/roster.xml": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<worksheet xmlns="` + nsMain + `"><sheetData>` + sheetData + `</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="` + nsMain + `"><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>第二个工作表</t></is></c></row></sheetData></worksheet>`,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = `<?xml version="1.0" encoding="UTF-8"?><sst xmlns="` + nsMain + `">` + sharedStrings + `</sst>`
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("生成XLSX失败: %v", err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("生成XLSX失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name          string
+		sheetData     string
+		sharedStrings string
+		want          [][]string
+		wantErr       bool
+	}{
+		{
+			name: "shared strings",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="s"><v>3</v></c></row>`,
+			sharedStrings: `<si><t>学号</t></si><si><t>姓名</t></si><si><t xml:space="preserve"> 0020210001 </t></si>` +
+				`<si><r><t>张</t></r><r><rPr><b/></rPr><t>三</t></r></si>`,
+			want: [][]string{{"学号", "姓名"}, {"0020210001", "张三"}},
+		},
+		{
+			name: "inline strings",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t>学号</t></is></c><c r="B1" t="inlineStr"><is><r><t>姓</t></r><r><t>名</t></r></is></c></row>` +
+				`<row r="2"><c r="A2" t="inlineStr"><is><t>2021001001</t></is></c><c r="B2" t="inlineStr"/></row>`,
+			want: [][]string{{"学号", "姓名"}, {"2021001001", ""}},
+		},
+		{
+			// 空单元格没有值或不写入文件，空行不写入文件
+			name: "empty cells and skipped rows",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1"/><c r="D1" t="s"><v>0</v></c></row>` +
+				`<row r="4"><c r="C4" t="s"><v>0</v></c></row>` +
+				`<row r="5"><c r="A5" s="1"/></row>` +
+				`<row r="6"/>`,
+			sharedStrings: `<si><t>x</t></si>`,
+			want:          [][]string{{"x", "", "", "x"}, nil, nil, {"", "", "x"}},
+		},
+		{
+			name:      "numbers and booleans",
+			sheetData: `<row r="1"><c r="A1"><v>2.021001001E9</v></c><c r="B1" t="n"><v>3.5</v></c><c r="C1" t="b"><v>1</v></c><c r="D1" t="str"><v>公式结果</v></c></row>`,
+			want:      [][]string{{"2021001001", "3.5", "TRUE", "公式结果"}},
+		},
+		{
+			// 部分工具省略单元格引用和行号
+			name:      "cells without references",
+			sheetData: `<row><c t="inlineStr"><is><t>a</t></is></c><c t="inlineStr"><is><t>b</t></is></c></row><row><c><v>1</v></c></row>`,
+			want:      [][]string{{"a", "b"}, {"1"}},
+		},
+		{
+			name:      "missing shared string",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>5</v></c></row>`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(buildXLSX(t, tt.sheetData, tt.sharedStrings), FormatXLSX)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Read = %q, 期望返回error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("读取XLSX失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, err := Read([]byte("not a zip"), FormatXLSX); err == nil {
+		t.Error("读取非XLSX文件应返回error")
+	}
+	if _, err := Read([]byte("a,b"), "xls"); err == nil {
+		t.Error("不支持的格式应返回error")
+	}
+	if err := Write(&bytes.Buffer{}, "xls", nil); err == nil {
+		t.Error("不支持的格式应返回error")
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	gbk, err := simplifiedchinese.GB18030.NewEncoder().String("学号,姓名\r\n2021001001,张三\r\n")
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{name: "utf8 with bom", data: "\xEF\xBB\xBF学号,姓名\n2021001001,张三\n", want: [][]string{{"学号", "姓名"}, {"2021001001", "张三"}}},
+		{name: "gb18030", data: gbk, want: [][]string{{"学号", "姓名"}, {"2021001001", "张三"}}},
+		{name: "ragged rows and trailing blank rows", data: "a, b ,c\nd\n,,\n\n", want: [][]string{{"a", "b", "c"}, {"d"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read([]byte(tt.data), FormatCSV)
+			if err != nil {
+				t.Fatalf("读取CSV失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteCSVBOM 写出的CSV带BOM
+func TestWriteCSVBOM(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, [][]string{{"学号"}}); err != nil {
+		t.Fatalf("写出CSV失败: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\xEF\xBB\xBF学号") {
+		t.Errorf("CSV = %q, 期望以BOM开头", buf.String())
+	}
+}
+
+// TestWriteEscapesFormulas 以公式字符开头的单元格写出时加单引号，调用方的数据不变
+func TestWriteEscapesFormulas(t *testing.T) {
+	rows := [][]string{
+		{"=HYPERLINK(\"http://evil\",\"点击\")", "+1+1", "-2+3", "@SUM(A1)", "\tcmd", "\rcmd"},
+		{"张三", "a=b", "98.5", "", "'=已转义"},
+	}
+	want := [][]string{
+		{"'=HYPERLINK(\"http://evil\",\"点击\")", "'+1+1", "'-2+3", "'@SUM(A1)", "'\tcmd", "'\rcmd"},
+		{"张三", "a=b", "98.5", "", "'=已转义"},
+	}
+
+	for _, format := range []string{FormatXLSX, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, rows); err != nil {
+				t.Fatalf("写出表格失败: %v", err)
+			}
+			got, err := Read(buf.Bytes(), format)
+			if err != nil {
+				t.Fatalf("读取表格失败: %v", err)
+			}
+			if !sameRows(got, want) {
+				t.Errorf("读回的表格 = %q, 期望 %q", got, want)
+			}
+			if rows[0][0] != "=HYPERLINK(\"http://evil\",\"点击\")" {
+				t.Errorf("调用方的数据被修改: %q", rows[0][0])
+			}
+		})
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 255: "IV"} {
+		if got := columnName(col); got != name {
+			t.Errorf("columnName(%d) = %s, 期望 %s", col, got, name)
+		}
+		if got := columnIndex(name + "12"); got != col {
+			t.Errorf("columnIndex(%s12) = %d, 期望 %d", name, got, col)
+		}
+	}
+}
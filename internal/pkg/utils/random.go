@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+)
+
+// passwordAlphabet 初始密码字符集，去掉了容易混淆的0/O、1/l/I
+const passwordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+
+// RandomToken 生成n字节的随机串，用于token ID、会话ID和刷新token
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// RandomPassword 生成n位随机初始密码，用于批量导入等场景
+func RandomPassword(n int) (string, error) {
+	buf := make([]byte, n)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range buf {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = passwordAlphabet[idx.Int64()]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,356 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/pkg/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// 重连与关闭参数
+const (
+	amqpHeartbeat      = 10 * time.Second
+	amqpReconnectDelay = 3 * time.Second
+	amqpDrainTimeout   = 30 * time.Second
+	amqpAttemptsHeader = "x-attempts"
+)
+
+var errBrokerClosed = errors.New("消息队列连接已关闭")
+
+// amqpBroker RabbitMQ队列后端
+// 拓扑：topic交换机按队列名路由到持久化队列；队列配置死信交换机，
+// 被拒绝且不重入队的消息路由到 {name}.dlq
+// 连接断开后自动重连，未确认的消息由RabbitMQ重新投递
+type amqpBroker struct {
+	url      string
+	vhost    string
+	exchange string
+
+	mu     sync.Mutex
+	conn   *amqp.Connection
+	closed bool
+
+	pubMu sync.Mutex
+	pubCh *amqp.Channel
+}
+
+func newAMQPBroker(cfg config.RabbitMQConfig) (*amqpBroker, error) {
+	u := url.URL{
+		Scheme: "amqp",
+		User:   url.UserPassword(cfg.Username, cfg.Password),
+		Host:   fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+	}
+	b := &amqpBroker{
+		url:      u.String(),
+		vhost:    cfg.VHost,
+		exchange: cfg.Exchange,
+	}
+	if _, err := b.connection(); err != nil {
+		return nil, fmt.Errorf("连接RabbitMQ失败: %w", err)
+	}
+	return b, nil
+}
+
+// deadLetterExchange 死信交换机名称
+func (b *amqpBroker) deadLetterExchange() string {
+	return b.exchange + ".dlx"
+}
+
+// connection 获取可用连接，连接已断开时重新连接并声明拓扑
+func (b *amqpBroker) connection() (*amqp.Connection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errBrokerClosed
+	}
+	if b.conn != nil && !b.conn.IsClosed() {
+		return b.conn, nil
+	}
+
+	conn, err := amqp.DialConfig(b.url, amqp.Config{
+		Vhost:     b.vhost,
+		Heartbeat: amqpHeartbeat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := b.declareTopology(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("声明队列拓扑失败: %w", err)
+	}
+
+	b.conn = conn
+	return conn, nil
+}
+
+// declareTopology 声明交换机、队列和死信队列（幂等）
+func (b *amqpBroker) declareTopology(conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := ch.ExchangeDeclare(b.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return err
+	}
+	dlx := b.deadLetterExchange()
+	if err := ch.ExchangeDeclare(dlx, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	for _, name := range allQueues {
+		args := amqp.Table{
+			"x-dead-letter-exchange":    dlx,
+			"x-dead-letter-routing-key": deadLetterQueue(name),
+		}
+		if _, err := ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+			return err
+		}
+		if err := ch.QueueBind(name, name, b.exchange, false, nil); err != nil {
+			return err
+		}
+
+		dlq := deadLetterQueue(name)
+		if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+			return err
+		}
+		if err := ch.QueueBind(dlq, dlq, dlx, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishChannel 获取开启了发布确认的通道（调用方需持有pubMu）
+func (b *amqpBroker) publishChannel() (*amqp.Channel, error) {
+	if b.pubCh != nil && !b.pubCh.IsClosed() {
+		return b.pubCh, nil
+	}
+
+	conn, err := b.connection()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	b.pubCh = ch
+	return ch, nil
+}
+
+// Publish 发布持久化消息并等待broker确认
+func (b *amqpBroker) Publish(ctx context.Context, queue string, msg *Message) error {
+	publishing := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.ID,
+		Timestamp:    msg.Timestamp,
+		Headers:      amqp.Table{amqpAttemptsHeader: int32(msg.Attempts)},
+		Body:         msg.Body,
+	}
+
+	b.pubMu.Lock()
+	ch, err := b.publishChannel()
+	if err != nil {
+		b.pubMu.Unlock()
+		return err
+	}
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, b.exchange, queue, true, false, publishing)
+	b.pubMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("RabbitMQ拒绝了消息")
+	}
+	return nil
+}
+
+// Consume 订阅指定队列，连接断开后自动重新订阅
+func (b *amqpBroker) Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	if prefetch <= 0 {
+		return nil, fmt.Errorf("prefetch必须大于0")
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for ctx.Err() == nil {
+			err := b.consumeSession(ctx, queue, prefetch, out)
+			if err == nil || errors.Is(err, errBrokerClosed) {
+				return
+			}
+			logger.Warn("RabbitMQ消费中断，准备重连", "queue", queue, "error", err)
+			select {
+			case <-time.After(amqpReconnectDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// consumeSession 在一个通道上消费，直到ctx取消(返回nil)或通道断开(返回错误)
+// ctx取消时先停止接收新消息，等待已投递的消息确认完毕后再关闭通道
+func (b *amqpBroker) consumeSession(ctx context.Context, queue string, prefetch int, out chan<- Delivery) error {
+	conn, err := b.connection()
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		return err
+	}
+	tag := fmt.Sprintf("%s-%d", queue, time.Now().UnixNano())
+	deliveries, err := ch.Consume(queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	var inflight sync.WaitGroup
+	drain := func() {
+		ch.Cancel(tag, false)
+		done := make(chan struct{})
+		go func() {
+			inflight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(amqpDrainTimeout):
+			logger.Warn("等待消息确认超时，未确认的消息将由RabbitMQ重新投递", "queue", queue)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			drain()
+			return nil
+		case amqpErr := <-closed:
+			if amqpErr == nil {
+				return fmt.Errorf("通道已关闭")
+			}
+			return amqpErr
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("投递通道已关闭")
+			}
+			inflight.Add(1)
+			delivery := &amqpDelivery{raw: d, msg: toMessage(d), done: inflight.Done}
+			select {
+			case out <- delivery:
+			case <-ctx.Done():
+				// 未交给消费者的消息直接重新入队
+				delivery.Nack(true)
+				drain()
+				return nil
+			}
+		}
+	}
+}
+
+// toMessage 将RabbitMQ投递转换为队列消息
+func toMessage(d amqp.Delivery) *Message {
+	attempts := 0
+	switch v := d.Headers[amqpAttemptsHeader].(type) {
+	case int32:
+		attempts = int(v)
+	case int64:
+		attempts = int(v)
+	case int:
+		attempts = v
+	}
+	return &Message{
+		ID:        d.MessageId,
+		Body:      d.Body,
+		Attempts:  attempts,
+		Timestamp: d.Timestamp,
+	}
+}
+
+// Len 队列中等待消费的消息数
+func (b *amqpBroker) Len(ctx context.Context, queue string) (int64, error) {
+	conn, err := b.connection()
+	if err != nil {
+		return 0, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(queue, true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int64(q.Messages), nil
+}
+
+// Close 关闭连接
+func (b *amqpBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.conn != nil && !b.conn.IsClosed() {
+		return b.conn.Close()
+	}
+	return nil
+}
+
+// amqpDelivery RabbitMQ的一次投递
+type amqpDelivery struct {
+	raw  amqp.Delivery
+	msg  *Message
+	done func()
+	once sync.Once
+}
+
+func (d *amqpDelivery) Message() *Message {
+	return d.msg
+}
+
+func (d *amqpDelivery) Redelivered() bool {
+	return d.raw.Redelivered
+}
+
+func (d *amqpDelivery) Ack() error {
+	defer d.once.Do(d.done)
+	return d.raw.Ack(false)
+}
+
+func (d *amqpDelivery) Nack(requeue bool) error {
+	defer d.once.Do(d.done)
+	return d.raw.Nack(false, requeue)
+}
@@ -0,0 +1,350 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	os.Exit(m.Run())
+}
+
+// backend 测试用的队列后端及其死信队列长度
+type backend struct {
+	broker    broker
+	deadCount func(t *testing.T, queue string) int64
+}
+
+// testBackends 内存后端和基于miniredis的Redis后端
+func testBackends(ackTimeout time.Duration) map[string]func(t *testing.T) backend {
+	return map[string]func(t *testing.T) backend{
+		"memory": func(t *testing.T) backend {
+			b := newMemoryBroker()
+			return backend{
+				broker: b,
+				deadCount: func(t *testing.T, queue string) int64 {
+					n, _ := b.Len(context.Background(), deadLetterQueue(queue))
+					return n
+				},
+			}
+		},
+		"redis": func(t *testing.T) backend {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			b := newRedisBroker(client, config.RabbitMQConfig{KeyPrefix: "test:", AckTimeout: ackTimeout})
+			return backend{
+				broker: b,
+				deadCount: func(t *testing.T, queue string) int64 {
+					n, err := client.LLen(context.Background(), deadLetterQueue(b.pendingKey(queue))).Result()
+					if err != nil {
+						t.Fatalf("读取死信队列失败: %v", err)
+					}
+					return n
+				},
+			}
+		},
+	}
+}
+
+func publishText(t *testing.T, b broker, queue, id string) {
+	t.Helper()
+	body, _ := json.Marshal(id)
+	if err := b.Publish(context.Background(), queue, &Message{ID: id, Body: body, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("发布消息失败: %v", err)
+	}
+}
+
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-deliveries:
+		if !ok {
+			t.Fatal("投递通道已关闭")
+		}
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待投递超时")
+	}
+	return nil
+}
+
+func expectNone(t *testing.T, deliveries <-chan Delivery) {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		t.Fatalf("不应再收到投递, 收到%s", d.Message().ID)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func waitLen(t *testing.T, b broker, queue string, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n, err := b.Len(context.Background(), queue)
+		if err != nil {
+			t.Fatalf("读取队列长度失败: %v", err)
+		}
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("队列长度 = %d, 期望 %d", n, want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestBrokerAckNack(t *testing.T) {
+	tests := []struct {
+		name string
+		// settle 确认或拒绝第一次投递
+		settle         func(d Delivery) error
+		wantRedeliver  bool
+		wantDeadLetter int64
+	}{
+		{name: "ack", settle: func(d Delivery) error { return d.Ack() }},
+		{name: "nack requeue", settle: func(d Delivery) error { return d.Nack(true) }, wantRedeliver: true},
+		{name: "nack dead letter", settle: func(d Delivery) error { return d.Nack(false) }, wantDeadLetter: 1},
+	}
+
+	for backendName, newBackend := range testBackends(time.Minute) {
+		for _, tt := range tests {
+			t.Run(backendName+"/"+tt.name, func(t *testing.T) {
+				be := newBackend(t)
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				deliveries, err := be.broker.Consume(ctx, "tasks", 1)
+				if err != nil {
+					t.Fatalf("订阅失败: %v", err)
+				}
+				publishText(t, be.broker, "tasks", "m1")
+
+				d := receive(t, deliveries)
+				if d.Redelivered() {
+					t.Error("首次投递不应标记为重新投递")
+				}
+				if err := tt.settle(d); err != nil {
+					t.Fatalf("确认失败: %v", err)
+				}
+				if err := d.Ack(); !errors.Is(err, errSettled) {
+					t.Errorf("重复确认应返回errSettled, 得到%v", err)
+				}
+
+				if tt.wantRedeliver {
+					again := receive(t, deliveries)
+					if again.Message().ID != "m1" || !again.Redelivered() {
+						t.Errorf("重新投递 = %s redelivered=%v", again.Message().ID, again.Redelivered())
+					}
+					again.Ack()
+				} else {
+					expectNone(t, deliveries)
+				}
+				if got := be.deadCount(t, "tasks"); got != tt.wantDeadLetter {
+					t.Errorf("死信数 = %d, 期望 %d", got, tt.wantDeadLetter)
+				}
+			})
+		}
+	}
+}
+
+// TestBrokerPrefetch 未确认的投递数不超过prefetch，确认后才投递下一条
+func TestBrokerPrefetch(t *testing.T) {
+	for backendName, newBackend := range testBackends(time.Minute) {
+		t.Run(backendName, func(t *testing.T) {
+			be := newBackend(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			deliveries, err := be.broker.Consume(ctx, "tasks", 1)
+			if err != nil {
+				t.Fatalf("订阅失败: %v", err)
+			}
+			publishText(t, be.broker, "tasks", "m1")
+			publishText(t, be.broker, "tasks", "m2")
+
+			first := receive(t, deliveries)
+			expectNone(t, deliveries)
+			first.Ack()
+			if second := receive(t, deliveries); second.Message().ID != "m2" {
+				t.Errorf("第二条投递 = %s, 期望 m2", second.Message().ID)
+			}
+		})
+	}
+}
+
+// TestBrokerRequeueOnStop 消费者退出时未确认的消息重新入队，下一个消费者收到时标记为重新投递
+func TestBrokerRequeueOnStop(t *testing.T) {
+	for backendName, newBackend := range testBackends(time.Minute) {
+		t.Run(backendName, func(t *testing.T) {
+			be := newBackend(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			deliveries, err := be.broker.Consume(ctx, "tasks", 1)
+			if err != nil {
+				t.Fatalf("订阅失败: %v", err)
+			}
+			publishText(t, be.broker, "tasks", "m1")
+			d := receive(t, deliveries)
+
+			cancel()
+			for range deliveries {
+			}
+			waitLen(t, be.broker, "tasks", 1)
+			if err := d.Ack(); !errors.Is(err, errSettled) {
+				t.Errorf("退出后确认应返回errSettled, 得到%v", err)
+			}
+
+			ctx2, cancel2 := context.WithCancel(context.Background())
+			defer cancel2()
+			deliveries2, err := be.broker.Consume(ctx2, "tasks", 1)
+			if err != nil {
+				t.Fatalf("订阅失败: %v", err)
+			}
+			again := receive(t, deliveries2)
+			if again.Message().ID != "m1" || !again.Redelivered() {
+				t.Errorf("重新投递 = %s redelivered=%v", again.Message().ID, again.Redelivered())
+			}
+		})
+	}
+}
+
+// TestRedisBrokerReclaim 消费者出队后崩溃，消息留在processing中，超过确认截止时间后由其他消费者回收
+func TestRedisBrokerReclaim(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	b := newRedisBroker(client, config.RabbitMQConfig{KeyPrefix: "test:", AckTimeout: 200 * time.Millisecond})
+
+	publishText(t, b, "tasks", "m1")
+	ctx := context.Background()
+	if err := client.RPopLPush(ctx, b.pendingKey("tasks"), b.processingKey("tasks")).Err(); err != nil {
+		t.Fatalf("模拟出队失败: %v", err)
+	}
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	deliveries, err := b.Consume(consumeCtx, "tasks", 1)
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	d := receive(t, deliveries)
+	if d.Message().ID != "m1" {
+		t.Errorf("回收的消息 = %s, 期望 m1", d.Message().ID)
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatalf("确认失败: %v", err)
+	}
+	if n, _ := client.LLen(ctx, b.processingKey("tasks")).Result(); n != 0 {
+		t.Errorf("确认后processing中还有%d条消息", n)
+	}
+}
+
+// TestConsumerRetry 处理失败的消息带着attempts+1重新发布，超过最大重试次数后进入死信队列
+func TestConsumerRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int32 // 前failures次处理失败
+		wantCalls      int32
+		wantDeadLetter int64
+	}{
+		{name: "succeeds first time", failures: 0, wantCalls: 1},
+		{name: "succeeds after retry", failures: 2, wantCalls: 3},
+		{name: "exceeds max retries", failures: 10, wantCalls: 3, wantDeadLetter: 1},
+	}
+
+	for backendName, newBackend := range testBackends(time.Minute) {
+		for _, tt := range tests {
+			t.Run(backendName+"/"+tt.name, func(t *testing.T) {
+				be := newBackend(t)
+				c := &consumer{broker: be.broker, prefetch: 1, maxRetries: 2, laneWeights: laneWeights(nil)}
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				var calls int32
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					c.ConsumeStatsUpdates(ctx, func(ctx context.Context, update *StatsUpdate) error {
+						if atomic.AddInt32(&calls, 1) <= tt.failures {
+							return errors.New("处理失败")
+						}
+						return nil
+					})
+				}()
+
+				p := &producer{broker: be.broker}
+				if err := p.PublishStatsUpdate(ctx, &StatsUpdate{Status: "ACCEPTED"}); err != nil {
+					t.Fatalf("发布失败: %v", err)
+				}
+
+				deadline := time.Now().Add(5 * time.Second)
+				for atomic.LoadInt32(&calls) < tt.wantCalls && time.Now().Before(deadline) {
+					time.Sleep(20 * time.Millisecond)
+				}
+				time.Sleep(200 * time.Millisecond)
+				cancel()
+				<-done
+
+				if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+					t.Errorf("处理次数 = %d, 期望 %d", got, tt.wantCalls)
+				}
+				if got := be.deadCount(t, QueueStatsUpdates); got != tt.wantDeadLetter {
+					t.Errorf("死信数 = %d, 期望 %d", got, tt.wantDeadLetter)
+				}
+			})
+		}
+	}
+}
+
+// TestConsumerMalformed 无法反序列化的消息直接进入死信队列，不重试
+func TestConsumerMalformed(t *testing.T) {
+	for backendName, newBackend := range testBackends(time.Minute) {
+		t.Run(backendName, func(t *testing.T) {
+			be := newBackend(t)
+			c := &consumer{broker: be.broker, prefetch: 1, maxRetries: 3}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var calls int32
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.ConsumeNotifications(ctx, func(ctx context.Context, notification *Notification) error {
+					atomic.AddInt32(&calls, 1)
+					return nil
+				})
+			}()
+			if err := be.broker.Publish(ctx, QueueNotifications, &Message{ID: "bad", Body: json.RawMessage(`[1,2]`)}); err != nil {
+				t.Fatalf("发布失败: %v", err)
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for be.deadCount(t, QueueNotifications) == 0 && time.Now().Before(deadline) {
+				time.Sleep(20 * time.Millisecond)
+			}
+			cancel()
+			<-done
+
+			if got := atomic.LoadInt32(&calls); got != 0 {
+				t.Errorf("格式错误的消息不应调用处理函数, 调用了%d次", got)
+			}
+			if got := be.deadCount(t, QueueNotifications); got != 1 {
+				t.Errorf("死信数 = %d, 期望 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"zhku-oj/internal/pkg/logger"
+)
+
+// consumer 消息消费者实现
+type consumer struct {
+	broker      broker
+	prefetch    int
+	maxRetries  int
+	laneWeights []int // 与Lanes顺序一致
+
+	mu      sync.Mutex
+	cancels []context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// ConsumeJudgeTasks 消费判题任务，按通道权重加权轮询
+func (c *consumer) ConsumeJudgeTasks(ctx context.Context, handler JudgeTaskHandler) error {
+	queues := make([]string, len(Lanes))
+	for i, lane := range Lanes {
+		queues[i] = JudgeTaskQueue(lane)
+	}
+	return c.consumeWeighted(ctx, queues, c.laneWeights, func(ctx context.Context, body []byte) error {
+		var task JudgeTask
+		if err := json.Unmarshal(body, &task); err != nil {
+			return errMalformed{err}
+		}
+		return handler(ctx, &task)
+	})
+}
+
+// ConsumeJudgeResults 消费判题结果
+func (c *consumer) ConsumeJudgeResults(ctx context.Context, handler JudgeResultHandler) error {
+	return c.consume(ctx, QueueJudgeResults, func(ctx context.Context, body []byte) error {
+		var result JudgeResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return errMalformed{err}
+		}
+		return handler(ctx, &result)
+	})
+}
+
+// ConsumeStatsUpdates 消费统计更新
+func (c *consumer) ConsumeStatsUpdates(ctx context.Context, handler StatsUpdateHandler) error {
+	return c.consume(ctx, QueueStatsUpdates, func(ctx context.Context, body []byte) error {
+		var update StatsUpdate
+		if err := json.Unmarshal(body, &update); err != nil {
+			return errMalformed{err}
+		}
+		return handler(ctx, &update)
+	})
+}
+
+// ConsumeNotifications 消费用户通知
+func (c *consumer) ConsumeNotifications(ctx context.Context, handler NotificationHandler) error {
+	return c.consume(ctx, QueueNotifications, func(ctx context.Context, body []byte) error {
+		var notification Notification
+		if err := json.Unmarshal(body, &notification); err != nil {
+			return errMalformed{err}
+		}
+		return handler(ctx, &notification)
+	})
+}
+
+// Close 停止所有消费循环并关闭后端连接
+// 已投递但未确认的消息由后端重新入队
+func (c *consumer) Close() error {
+	c.mu.Lock()
+	for _, cancel := range c.cancels {
+		cancel()
+	}
+	c.cancels = nil
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	return c.broker.Close()
+}
+
+// consume 通用消费循环
+// 每条投递在独立的goroutine中处理，并发数受prefetch限制
+func (c *consumer) consume(parent context.Context, queue string, handle func(ctx context.Context, body []byte) error) error {
+	ctx, cancel := context.WithCancel(parent)
+	c.mu.Lock()
+	c.cancels = append(c.cancels, cancel)
+	c.mu.Unlock()
+	defer cancel()
+
+	deliveries, err := c.broker.Consume(ctx, queue, c.prefetch)
+	if err != nil {
+		return fmt.Errorf("订阅队列%s失败: %w", queue, err)
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("队列%s的投递通道已关闭", queue)
+			}
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				c.dispatch(ctx, queue, d, handle)
+			}()
+		}
+	}
+}
+
+// consumeWeighted 加权消费多个队列
+// 所有队列共享prefetch个并发名额。每个队列预先取出一条消息等待调度，
+// 名额空出时在已有消息的队列之间按权重平滑轮询选择：各队列都有积压时按权重比例处理，
+// 空闲队列的份额让给其他队列
+func (c *consumer) consumeWeighted(parent context.Context, queues []string, weights []int, handle func(ctx context.Context, body []byte) error) error {
+	ctx, cancel := context.WithCancel(parent)
+	c.mu.Lock()
+	c.cancels = append(c.cancels, cancel)
+	c.mu.Unlock()
+	defer cancel()
+
+	sources := make([]<-chan Delivery, len(queues))
+	for i, queue := range queues {
+		// 每个队列多预取一条，用作等待调度的消息
+		deliveries, err := c.broker.Consume(ctx, queue, c.prefetch+1)
+		if err != nil {
+			return fmt.Errorf("订阅队列%s失败: %w", queue, err)
+		}
+		sources[i] = deliveries
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+
+	// heads 每个队列已取出、等待调度的一条消息；退出时重新入队
+	heads := make([]Delivery, len(queues))
+	defer func() {
+		for i, d := range heads {
+			if d != nil {
+				c.nack(queues[i], d, true)
+			}
+		}
+	}()
+
+	slots := make(chan struct{}, c.prefetch)
+	picker := newWeightedPicker(weights)
+	ready := make([]bool, len(queues))
+
+	// select分支: [0,len(queues))为各队列接收, 之后依次为并发名额和ctx
+	cases := make([]reflect.SelectCase, len(queues)+2)
+	slotCase, doneCase := len(queues), len(queues)+1
+	cases[doneCase] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+
+	for {
+		// 先非阻塞地补齐各队列的待调度消息，避免已到达的消息因select随机选择而错过本轮调度
+		for i, src := range sources {
+			if src == nil || heads[i] != nil {
+				continue
+			}
+			select {
+			case d, ok := <-src:
+				if !ok {
+					sources[i] = nil
+					continue
+				}
+				heads[i] = d
+			default:
+			}
+		}
+
+		open, pending := 0, false
+		for i, src := range sources {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			if src != nil {
+				open++
+			}
+			if heads[i] != nil {
+				pending = true
+			} else if src != nil {
+				cases[i].Chan = reflect.ValueOf(src)
+			}
+		}
+		if open == 0 && !pending {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("队列%v的投递通道已关闭", queues)
+		}
+		cases[slotCase] = reflect.SelectCase{Dir: reflect.SelectSend}
+		if pending {
+			cases[slotCase].Chan = reflect.ValueOf(slots)
+			cases[slotCase].Send = reflect.ValueOf(struct{}{})
+		}
+
+		chosen, value, ok := reflect.Select(cases)
+		switch {
+		case chosen == doneCase:
+			return nil
+		case chosen == slotCase:
+			for i := range heads {
+				ready[i] = heads[i] != nil
+			}
+			i := picker.pick(ready)
+			d, queue := heads[i], queues[i]
+			heads[i] = nil
+
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				defer func() { <-slots }()
+				c.dispatch(ctx, queue, d, handle)
+			}()
+		case !ok:
+			sources[chosen] = nil
+		default:
+			heads[chosen] = value.Interface().(Delivery)
+		}
+	}
+}
+
+// dispatch 处理单条投递并确认
+// 处理成功: Ack
+// 消息格式错误: 直接进入死信队列
+// 消费者正在退出: 重新入队，交给其他消费者
+// 处理失败: 未超过最大重试次数时重新发布(attempts+1)，否则进入死信队列
+func (c *consumer) dispatch(ctx context.Context, queue string, d Delivery, handle func(ctx context.Context, body []byte) error) {
+	msg := d.Message()
+	err := safeHandle(ctx, handle, msg.Body)
+	if err == nil {
+		if ackErr := d.Ack(); ackErr != nil && !errors.Is(ackErr, errSettled) {
+			logger.Error("消息确认失败", "queue", queue, "message_id", msg.ID, "error", ackErr)
+		}
+		return
+	}
+
+	if _, ok := err.(errMalformed); ok {
+		logger.Error("消息格式错误，转入死信队列", "queue", queue, "message_id", msg.ID, "error", err)
+		c.nack(queue, d, false)
+		return
+	}
+
+	if ctx.Err() != nil {
+		logger.Warn("消费者退出，消息重新入队", "queue", queue, "message_id", msg.ID)
+		c.nack(queue, d, true)
+		return
+	}
+
+	if msg.Attempts >= c.maxRetries {
+		logger.Error("消息处理失败且超过最大重试次数，转入死信队列",
+			"queue", queue, "message_id", msg.ID, "attempts", msg.Attempts, "error", err)
+		c.nack(queue, d, false)
+		return
+	}
+
+	logger.Warn("消息处理失败，重新投递",
+		"queue", queue, "message_id", msg.ID, "attempts", msg.Attempts, "error", err)
+	retry := &Message{
+		ID:        msg.ID,
+		Body:      msg.Body,
+		Attempts:  msg.Attempts + 1,
+		Timestamp: msg.Timestamp,
+	}
+	// 先发布重试消息再确认原消息，发布失败时原消息重新入队，保证不丢失
+	if pubErr := c.broker.Publish(context.Background(), queue, retry); pubErr != nil {
+		logger.Error("重新发布消息失败", "queue", queue, "message_id", msg.ID, "error", pubErr)
+		c.nack(queue, d, true)
+		return
+	}
+	if ackErr := d.Ack(); ackErr != nil {
+		logger.Error("消息确认失败", "queue", queue, "message_id", msg.ID, "error", ackErr)
+	}
+}
+
+// nack 拒绝消息并记录错误
+func (c *consumer) nack(queue string, d Delivery, requeue bool) {
+	if err := d.Nack(requeue); err != nil && !errors.Is(err, errSettled) {
+		logger.Error("消息拒绝失败", "queue", queue, "message_id", d.Message().ID, "error", err)
+	}
+}
+
+// safeHandle 调用处理函数并将panic转换为错误
+func safeHandle(ctx context.Context, handle func(ctx context.Context, body []byte) error, body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("消息处理panic: %v", r)
+		}
+	}()
+	return handle(ctx, body)
+}
+
+// errMalformed 消息无法反序列化
+type errMalformed struct {
+	err error
+}
+
+func (e errMalformed) Error() string {
+	return fmt.Sprintf("消息格式错误: %v", e.err)
+}
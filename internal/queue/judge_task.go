@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JudgeTask 判题任务
+// 由Web服务在提交代码后发布，判题服务消费
+type JudgeTask struct {
+	SubmissionID primitive.ObjectID  `json:"submission_id"`
+	ProblemID    primitive.ObjectID  `json:"problem_id"`
+	UserID       primitive.ObjectID  `json:"user_id"`
+	ContestID    *primitive.ObjectID `json:"contest_id,omitempty"` // 竞赛提交所属竞赛
+	Code         string              `json:"code"`
+	Language     string              `json:"language"`
+	Lane         string              `json:"lane"`     // 判题通道，见Lane*常量
+	Priority     int                 `json:"priority"` // 1-10，默认由通道决定
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// JudgeResult 判题结果
+// 由判题服务在判题完成后发布
+type JudgeResult struct {
+	SubmissionID primitive.ObjectID  `json:"submission_id"`
+	ProblemID    primitive.ObjectID  `json:"problem_id"`
+	UserID       primitive.ObjectID  `json:"user_id"`
+	ContestID    *primitive.ObjectID `json:"contest_id,omitempty"`
+	Language     string              `json:"language"`
+	Status       string              `json:"status"`
+	Score        int                 `json:"score"`
+	TimeUsed     int                 `json:"time_used"`   // 毫秒
+	MemoryUsed   int                 `json:"memory_used"` // KB
+	CompileInfo  model.CompileInfo   `json:"compile_info"`
+	TestResults  []model.TestResult  `json:"test_results"`
+	// SubtaskResults 子任务结果，题目未设置子任务时为空
+	SubtaskResults []model.SubtaskResult `json:"subtask_results,omitempty"`
+	JudgedAt       time.Time             `json:"judged_at"`
+}
+
+// JudgeTaskHandler 判题任务处理函数
+type JudgeTaskHandler func(ctx context.Context, task *JudgeTask) error
+
+// JudgeResultHandler 判题结果处理函数
+type JudgeResultHandler func(ctx context.Context, result *JudgeResult) error
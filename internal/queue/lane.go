@@ -0,0 +1,98 @@
+package queue
+
+// 判题任务通道
+// 每个通道对应一个独立队列，判题机按权重轮询各通道，
+// 高优先级通道获得更多判题机会，低优先级通道也不会被饿死
+const (
+	LaneContest  = "contest"  // 竞赛/考试
+	LaneHomework = "homework" // 作业
+	LanePractice = "practice" // 日常练习
+	LaneRejudge  = "rejudge"  // 管理员重判
+)
+
+// Lanes 全部判题通道，按优先级从高到低排列
+var Lanes = []string{LaneContest, LaneHomework, LanePractice, LaneRejudge}
+
+// defaultLaneWeights 默认通道权重，各通道都有积压时按权重比例分配判题机会
+var defaultLaneWeights = map[string]int{
+	LaneContest:  8,
+	LaneHomework: 4,
+	LanePractice: 2,
+	LaneRejudge:  1,
+}
+
+// lanePriorities 通道对应的judge_queue优先级(1-10, 10最高)
+var lanePriorities = map[string]int{
+	LaneContest:  10,
+	LaneHomework: 8,
+	LanePractice: 5,
+	LaneRejudge:  2,
+}
+
+// NormalizeLane 规范化通道名称，未知通道归入日常练习
+func NormalizeLane(lane string) string {
+	if _, ok := lanePriorities[lane]; ok {
+		return lane
+	}
+	return LanePractice
+}
+
+// LanePriority 通道对应的优先级
+func LanePriority(lane string) int {
+	return lanePriorities[NormalizeLane(lane)]
+}
+
+// JudgeTaskQueue 通道对应的判题任务队列名称
+func JudgeTaskQueue(lane string) string {
+	return QueueJudgeTasks + "." + NormalizeLane(lane)
+}
+
+// LaneWeight 通道的生效权重，未配置或配置非法时使用默认权重
+func LaneWeight(configured map[string]int, lane string) int {
+	if w, ok := configured[lane]; ok && w > 0 {
+		return w
+	}
+	return defaultLaneWeights[lane]
+}
+
+// laneWeights 按Lanes顺序返回通道权重
+func laneWeights(configured map[string]int) []int {
+	weights := make([]int, len(Lanes))
+	for i, lane := range Lanes {
+		weights[i] = LaneWeight(configured, lane)
+	}
+	return weights
+}
+
+// weightedPicker 平滑加权轮询
+// 只在有消息的通道之间分配，空闲通道不占用份额
+type weightedPicker struct {
+	weights []int
+	current []int
+}
+
+func newWeightedPicker(weights []int) *weightedPicker {
+	return &weightedPicker{
+		weights: weights,
+		current: make([]int, len(weights)),
+	}
+}
+
+// pick 从ready为true的通道中选出下一个，没有可选通道时返回-1
+func (p *weightedPicker) pick(ready []bool) int {
+	best, total := -1, 0
+	for i, ok := range ready {
+		if !ok {
+			continue
+		}
+		p.current[i] += p.weights[i]
+		total += p.weights[i]
+		if best < 0 || p.current[i] > p.current[best] {
+			best = i
+		}
+	}
+	if best >= 0 {
+		p.current[best] -= total
+	}
+	return best
+}
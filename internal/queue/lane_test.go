@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWeightedPicker(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		ready   []bool
+		rounds  int
+		want    []int // 各通道被选中的次数
+	}{
+		{
+			name:    "all lanes backlogged",
+			weights: []int{8, 4, 2, 1},
+			ready:   []bool{true, true, true, true},
+			rounds:  15,
+			want:    []int{8, 4, 2, 1},
+		},
+		{
+			name:    "idle contest lane yields its share",
+			weights: []int{8, 4, 2, 1},
+			ready:   []bool{false, true, true, true},
+			rounds:  14,
+			want:    []int{0, 8, 4, 2},
+		},
+		{
+			name:    "only rejudge lane",
+			weights: []int{8, 4, 2, 1},
+			ready:   []bool{false, false, false, true},
+			rounds:  5,
+			want:    []int{0, 0, 0, 5},
+		},
+		{
+			name:    "equal weights",
+			weights: []int{1, 1, 1, 1},
+			ready:   []bool{true, true, true, true},
+			rounds:  8,
+			want:    []int{2, 2, 2, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picker := newWeightedPicker(tt.weights)
+			got := make([]int, len(tt.weights))
+			for i := 0; i < tt.rounds; i++ {
+				lane := picker.pick(tt.ready)
+				if lane < 0 || !tt.ready[lane] {
+					t.Fatalf("第%d次选中了不可选的通道%d", i+1, lane)
+				}
+				got[lane]++
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("选中次数 = %v, 期望 %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	if got := newWeightedPicker([]int{1, 2}).pick([]bool{false, false}); got != -1 {
+		t.Errorf("没有可选通道时应返回-1, 得到%d", got)
+	}
+}
+
+// TestWeightedPickerSmooth 平滑轮询不会连续选中高权重通道直到用完份额
+func TestWeightedPickerSmooth(t *testing.T) {
+	picker := newWeightedPicker([]int{2, 1})
+	ready := []bool{true, true}
+	var order []int
+	for i := 0; i < 6; i++ {
+		order = append(order, picker.pick(ready))
+	}
+	want := []int{0, 1, 0, 0, 1, 0}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("选择顺序 = %v, 期望 %v", order, want)
+		}
+	}
+}
+
+func TestLaneWeights(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured map[string]int
+		want       []int
+	}{
+		{name: "defaults", configured: nil, want: []int{8, 4, 2, 1}},
+		{name: "override", configured: map[string]int{LaneRejudge: 3, LanePractice: 5}, want: []int{8, 4, 5, 3}},
+		{name: "invalid falls back", configured: map[string]int{LaneContest: 0, LaneHomework: -2}, want: []int{8, 4, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := laneWeights(tt.configured)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("通道权重 = %v, 期望 %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestConsumeJudgeTasksWeighted 各通道都有积压时按权重比例处理判题任务
+func TestConsumeJudgeTasksWeighted(t *testing.T) {
+	b := newMemoryBroker()
+	p := &producer{broker: b}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const perLane = 30
+	for _, lane := range Lanes {
+		for i := 0; i < perLane; i++ {
+			if err := p.PublishJudgeTask(ctx, &JudgeTask{Lane: lane}); err != nil {
+				t.Fatalf("发布判题任务失败: %v", err)
+			}
+		}
+	}
+
+	const rounds = 30
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{})
+	c := &consumer{broker: b, prefetch: 1, maxRetries: 3, laneWeights: laneWeights(nil)}
+	go c.ConsumeJudgeTasks(ctx, func(ctx context.Context, task *JudgeTask) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, task.Lane)
+		if len(order) == rounds {
+			close(done)
+		}
+		// 处理慢于投递，保证每次调度时各通道都已有待调度的消息
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("等待判题任务处理超时")
+	}
+
+	mu.Lock()
+	counts := make(map[string]int)
+	for _, lane := range order[:rounds] {
+		counts[lane]++
+	}
+	mu.Unlock()
+
+	// 30次调度按8:4:2:1分配为16:8:4:2，首轮调度时部分通道的消息可能尚未到达，允许1次偏差
+	want := map[string]int{LaneContest: 16, LaneHomework: 8, LanePractice: 4, LaneRejudge: 2}
+	for lane, n := range want {
+		if diff := counts[lane] - n; diff < -1 || diff > 1 {
+			t.Errorf("通道%s处理了%d个任务, 期望约%d个 (全部: %v)", lane, counts[lane], n, counts)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var (
+	memoryBrokersMu sync.Mutex
+	memoryBrokers   = make(map[string]*memoryBroker)
+)
+
+// sharedMemoryBroker 获取进程内共享的内存队列
+// 同一进程内相同vhost的生产者和消费者共享队列，适用于单进程部署和测试
+func sharedMemoryBroker(vhost string) *memoryBroker {
+	memoryBrokersMu.Lock()
+	defer memoryBrokersMu.Unlock()
+
+	b, ok := memoryBrokers[vhost]
+	if !ok {
+		b = newMemoryBroker()
+		memoryBrokers[vhost] = b
+	}
+	return b
+}
+
+// memoryBroker 进程内队列后端
+// 语义与RabbitMQ保持一致：未确认的消息在消费者退出时重新入队，拒绝且不重入队的消息进入死信队列
+type memoryBroker struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+}
+
+// memoryQueue 单个内存队列
+type memoryQueue struct {
+	pending []*memoryEntry
+	notify  chan struct{} // 有新消息时唤醒等待的消费者
+}
+
+// memoryEntry 队列中的消息及其投递次数
+type memoryEntry struct {
+	msg        *Message
+	deliveries int
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{queues: make(map[string]*memoryQueue)}
+}
+
+// queue 获取队列，不存在时创建（调用方需持有锁）
+func (b *memoryBroker) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{notify: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// push 将消息加入队列，front为true时插入队头（用于重新入队）
+func (b *memoryBroker) push(name string, entry *memoryEntry, front bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(name)
+	if front {
+		q.pending = append([]*memoryEntry{entry}, q.pending...)
+	} else {
+		q.pending = append(q.pending, entry)
+	}
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+// pop 取出队头消息，队列为空时返回等待通道
+func (b *memoryBroker) pop(name string) (*memoryEntry, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(name)
+	if len(q.pending) == 0 {
+		return nil, q.notify
+	}
+	entry := q.pending[0]
+	q.pending = q.pending[1:]
+	entry.deliveries++
+	return entry, nil
+}
+
+// Publish 发布消息到指定队列
+func (b *memoryBroker) Publish(ctx context.Context, queue string, msg *Message) error {
+	copied := *msg
+	b.push(queue, &memoryEntry{msg: &copied}, false)
+	return nil
+}
+
+// Consume 订阅指定队列
+func (b *memoryBroker) Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	if prefetch <= 0 {
+		return nil, fmt.Errorf("prefetch必须大于0")
+	}
+
+	out := make(chan Delivery)
+	slots := make(chan struct{}, prefetch)
+
+	var mu sync.Mutex
+	unacked := make(map[*memoryDelivery]struct{})
+
+	go func() {
+		defer close(out)
+		defer func() {
+			// 消费者退出：未确认的消息重新入队
+			mu.Lock()
+			defer mu.Unlock()
+			for d := range unacked {
+				d.settle(func() { b.push(queue, d.entry, true) })
+			}
+		}()
+
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			var entry *memoryEntry
+			for entry == nil {
+				var wait <-chan struct{}
+				entry, wait = b.pop(queue)
+				if entry != nil {
+					break
+				}
+				select {
+				case <-wait:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			d := &memoryDelivery{broker: b, queue: queue, entry: entry}
+			d.release = func() {
+				mu.Lock()
+				delete(unacked, d)
+				mu.Unlock()
+				<-slots
+			}
+			mu.Lock()
+			unacked[d] = struct{}{}
+			mu.Unlock()
+
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Len 队列中等待消费的消息数
+func (b *memoryBroker) Len(ctx context.Context, queue string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.queue(queue).pending)), nil
+}
+
+// Close 进程内队列由进程内所有生产者和消费者共享，不做关闭
+func (b *memoryBroker) Close() error {
+	return nil
+}
+
+// memoryDelivery 内存队列的一次投递
+type memoryDelivery struct {
+	broker  *memoryBroker
+	queue   string
+	entry   *memoryEntry
+	release func()
+
+	mu      sync.Mutex
+	settled bool
+}
+
+func (d *memoryDelivery) Message() *Message {
+	return d.entry.msg
+}
+
+func (d *memoryDelivery) Redelivered() bool {
+	return d.entry.deliveries > 1
+}
+
+func (d *memoryDelivery) Ack() error {
+	if !d.settle(nil) {
+		return errSettled
+	}
+	return nil
+}
+
+func (d *memoryDelivery) Nack(requeue bool) error {
+	ok := d.settle(func() {
+		if requeue {
+			d.broker.push(d.queue, d.entry, true)
+		} else {
+			d.broker.push(deadLetterQueue(d.queue), &memoryEntry{msg: d.entry.msg}, false)
+		}
+	})
+	if !ok {
+		return errSettled
+	}
+	return nil
+}
+
+// settle 结束投递，每条投递只能结束一次
+func (d *memoryDelivery) settle(action func()) bool {
+	d.mu.Lock()
+	if d.settled {
+		d.mu.Unlock()
+		return false
+	}
+	d.settled = true
+	d.mu.Unlock()
+
+	if action != nil {
+		action()
+	}
+	if d.release != nil {
+		go d.release()
+	}
+	return true
+}
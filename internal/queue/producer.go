@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// producer 消息生产者实现
+type producer struct {
+	broker broker
+}
+
+// PublishJudgeTask 发布判题任务到所属通道的队列
+func (p *producer) PublishJudgeTask(ctx context.Context, task *JudgeTask) error {
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	task.Lane = NormalizeLane(task.Lane)
+	if task.Priority == 0 {
+		task.Priority = LanePriority(task.Lane)
+	}
+	return p.publish(ctx, JudgeTaskQueue(task.Lane), task)
+}
+
+// PublishJudgeResult 发布判题结果
+func (p *producer) PublishJudgeResult(ctx context.Context, result *JudgeResult) error {
+	return p.publish(ctx, QueueJudgeResults, result)
+}
+
+// PublishStatsUpdate 发布统计更新
+func (p *producer) PublishStatsUpdate(ctx context.Context, update *StatsUpdate) error {
+	return p.publish(ctx, QueueStatsUpdates, update)
+}
+
+// PublishNotification 发布用户通知
+func (p *producer) PublishNotification(ctx context.Context, notification *Notification) error {
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	return p.publish(ctx, QueueNotifications, notification)
+}
+
+// QueueLength 获取队列中等待消费的消息数
+func (p *producer) QueueLength(ctx context.Context, queue string) (int64, error) {
+	return p.broker.Len(ctx, queue)
+}
+
+// Close 关闭生产者
+func (p *producer) Close() error {
+	return p.broker.Close()
+}
+
+// publish 序列化并发布消息
+func (p *producer) publish(ctx context.Context, queue string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	msg := &Message{
+		ID:        primitive.NewObjectID().Hex(),
+		Body:      body,
+		Timestamp: time.Now(),
+	}
+	if err := p.broker.Publish(ctx, queue, msg); err != nil {
+		return fmt.Errorf("发布消息到%s失败: %w", queue, err)
+	}
+	return nil
+}
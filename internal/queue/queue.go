@@ -0,0 +1,224 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 队列名称
+// RabbitMQ后端中同时作为topic交换机的路由键
+// 判题任务按通道拆分为 judge.tasks.{lane}，见JudgeTaskQueue
+const (
+	QueueJudgeTasks    = "judge.tasks"
+	QueueJudgeResults  = "judge.results"
+	QueueStatsUpdates  = "stats.updates"
+	QueueNotifications = "notifications"
+)
+
+// 队列驱动类型
+const (
+	DriverRabbitMQ = "rabbitmq"
+	DriverRedis    = "redis"
+	DriverMemory   = "memory"
+)
+
+// 默认参数
+const (
+	defaultExchange   = "oj.topic"
+	defaultPrefetch   = 4
+	defaultMaxRetries = 3
+	defaultAckTimeout = 5 * time.Minute
+	deadLetterSuffix  = ".dlq"
+)
+
+// allQueues 系统使用的全部队列
+var allQueues = []string{
+	JudgeTaskQueue(LaneContest),
+	JudgeTaskQueue(LaneHomework),
+	JudgeTaskQueue(LanePractice),
+	JudgeTaskQueue(LaneRejudge),
+	QueueJudgeResults,
+	QueueStatsUpdates,
+	QueueNotifications,
+}
+
+// Message 队列消息
+// Body为业务消息的JSON，Attempts记录因处理失败被重新投递的次数
+type Message struct {
+	ID        string          `json:"id"`
+	Body      json.RawMessage `json:"body"`
+	Attempts  int             `json:"attempts"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// errSettled 投递已确认或已拒绝
+// 消费者退出时后端会将未确认的消息重新入队，此后处理协程再确认会得到该错误
+var errSettled = errors.New("消息已确认或已拒绝")
+
+// Delivery 一次消息投递
+// 消费者必须调用Ack或Nack之一，否则该消息会在连接断开或确认超时后重新投递
+type Delivery interface {
+	// Message 投递的消息
+	Message() *Message
+	// Redelivered 是否为重新投递(上一个消费者未确认就退出)
+	Redelivered() bool
+	// Ack 确认消息已处理完成
+	Ack() error
+	// Nack 拒绝消息，requeue为true时重新入队，否则进入死信队列
+	Nack(requeue bool) error
+}
+
+// broker 消息队列后端
+// 屏蔽RabbitMQ、Redis列表和进程内队列之间的差异
+type broker interface {
+	// Publish 发布消息到指定队列
+	Publish(ctx context.Context, queue string, msg *Message) error
+	// Consume 订阅指定队列，未确认的投递数不超过prefetch
+	// ctx取消后停止投递，并将已投递但未确认的消息重新入队
+	Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error)
+	// Len 队列中等待消费的消息数
+	Len(ctx context.Context, queue string) (int64, error)
+	// Close 关闭后端连接
+	Close() error
+}
+
+// Producer 消息生产者
+type Producer interface {
+	// PublishJudgeTask 发布判题任务
+	PublishJudgeTask(ctx context.Context, task *JudgeTask) error
+
+	// PublishJudgeResult 发布判题结果
+	PublishJudgeResult(ctx context.Context, result *JudgeResult) error
+
+	// PublishStatsUpdate 发布统计更新
+	PublishStatsUpdate(ctx context.Context, update *StatsUpdate) error
+
+	// PublishNotification 发布用户通知
+	PublishNotification(ctx context.Context, notification *Notification) error
+
+	// QueueLength 获取队列中等待消费的消息数
+	QueueLength(ctx context.Context, queue string) (int64, error)
+
+	// Close 关闭生产者
+	Close() error
+}
+
+// Consumer 消息消费者
+// Consume*方法会阻塞直到ctx取消；处理函数返回错误时消息会被重新投递，
+// 超过最大重试次数后进入死信队列
+type Consumer interface {
+	// ConsumeJudgeTasks 消费判题任务
+	// 同时订阅所有通道，按通道权重加权轮询，总并发数不超过prefetch
+	ConsumeJudgeTasks(ctx context.Context, handler JudgeTaskHandler) error
+
+	// ConsumeJudgeResults 消费判题结果
+	ConsumeJudgeResults(ctx context.Context, handler JudgeResultHandler) error
+
+	// ConsumeStatsUpdates 消费统计更新
+	ConsumeStatsUpdates(ctx context.Context, handler StatsUpdateHandler) error
+
+	// ConsumeNotifications 消费用户通知
+	ConsumeNotifications(ctx context.Context, handler NotificationHandler) error
+
+	// Close 关闭消费者
+	Close() error
+}
+
+// options 构造选项
+type options struct {
+	redisClient *redis.Client
+}
+
+// Option 生产者/消费者构造选项
+type Option func(*options)
+
+// WithRedisClient 指定redis后端使用的Redis客户端
+func WithRedisClient(client *redis.Client) Option {
+	return func(o *options) {
+		o.redisClient = client
+	}
+}
+
+// NewProducer 创建消息生产者
+func NewProducer(cfg config.RabbitMQConfig, opts ...Option) (Producer, error) {
+	cfg = withDefaults(cfg)
+	b, err := newBroker(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &producer{broker: b}, nil
+}
+
+// NewConsumer 创建消息消费者
+func NewConsumer(cfg config.RabbitMQConfig, opts ...Option) (Consumer, error) {
+	cfg = withDefaults(cfg)
+	b, err := newBroker(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &consumer{
+		broker:      b,
+		prefetch:    cfg.Prefetch,
+		maxRetries:  cfg.MaxRetries,
+		laneWeights: laneWeights(cfg.LaneWeights),
+	}, nil
+}
+
+// newBroker 根据配置创建队列后端
+func newBroker(cfg config.RabbitMQConfig, opts ...Option) (broker, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	switch cfg.Driver {
+	case DriverRabbitMQ:
+		return newAMQPBroker(cfg)
+	case DriverRedis:
+		if o.redisClient == nil {
+			return nil, fmt.Errorf("redis队列后端需要Redis客户端")
+		}
+		return newRedisBroker(o.redisClient, cfg), nil
+	case DriverMemory:
+		return sharedMemoryBroker(cfg.VHost), nil
+	default:
+		return nil, fmt.Errorf("不支持的队列驱动: %s", cfg.Driver)
+	}
+}
+
+// withDefaults 填充未配置的参数
+func withDefaults(cfg config.RabbitMQConfig) config.RabbitMQConfig {
+	if cfg.Driver == "" {
+		cfg.Driver = DriverRabbitMQ
+	}
+	if cfg.VHost == "" {
+		cfg.VHost = "/"
+	}
+	if cfg.Exchange == "" {
+		cfg.Exchange = defaultExchange
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = defaultPrefetch
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = defaultAckTimeout
+	}
+	return cfg
+}
+
+// deadLetterQueue 死信队列名称
+func deadLetterQueue(queue string) string {
+	return queue + deadLetterSuffix
+}
@@ -0,0 +1,354 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisBroker 基于Redis列表的队列后端
+// 采用可靠队列模式：
+//   - {prefix}queue:{name}             待消费列表(LPUSH入队, BRPOPLPUSH出队)
+//   - {prefix}queue:{name}:processing  已投递未确认的消息
+//   - {prefix}queue:{name}:deadline    未确认消息的确认截止时间(ZSET)
+//   - {prefix}queue:{name}:deliveries  消息投递次数(HASH)，用于判断是否为重新投递
+//   - {prefix}queue:{name}.dlq         死信列表
+//
+// 消费者存活期间会定期延长其未确认消息的截止时间；
+// 消费者崩溃后截止时间到期，消息由任意消费者的回收协程放回待消费列表
+type redisBroker struct {
+	client     *redis.Client
+	prefix     string
+	ackTimeout time.Duration
+}
+
+// requeueScript 将消息从processing移回待消费列表
+// 放在队尾(RPUSH)，即下一个出队；processing中已不存在时说明已被确认或回收，不做处理
+var requeueScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if removed > 0 then
+	redis.call('RPUSH', KEYS[3], ARGV[1])
+end
+return removed
+`)
+
+// ackScript 将消息从processing中移除，deadLetter非空时放入死信列表
+var ackScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[2])
+if removed > 0 and ARGV[3] ~= '' then
+	redis.call('LPUSH', ARGV[3], ARGV[1])
+end
+return removed
+`)
+
+func newRedisBroker(client *redis.Client, cfg config.RabbitMQConfig) *redisBroker {
+	return &redisBroker{
+		client:     client,
+		prefix:     cfg.KeyPrefix,
+		ackTimeout: cfg.AckTimeout,
+	}
+}
+
+func (b *redisBroker) pendingKey(queue string) string {
+	return b.prefix + "queue:" + queue
+}
+
+func (b *redisBroker) processingKey(queue string) string {
+	return b.pendingKey(queue) + ":processing"
+}
+
+func (b *redisBroker) deadlineKey(queue string) string {
+	return b.pendingKey(queue) + ":deadline"
+}
+
+func (b *redisBroker) deliveriesKey(queue string) string {
+	return b.pendingKey(queue) + ":deliveries"
+}
+
+// Publish 发布消息到指定队列
+func (b *redisBroker) Publish(ctx context.Context, queue string, msg *Message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+	return b.client.LPush(ctx, b.pendingKey(queue), raw).Err()
+}
+
+// Consume 订阅指定队列
+func (b *redisBroker) Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	if prefetch <= 0 {
+		return nil, fmt.Errorf("prefetch必须大于0")
+	}
+
+	out := make(chan Delivery)
+	slots := make(chan struct{}, prefetch)
+
+	var mu sync.Mutex
+	unacked := make(map[*redisDelivery]struct{})
+
+	// 续期协程：延长本消费者未确认消息的截止时间；回收协程：回收已过期的消息
+	go b.keepAlive(ctx, queue, &mu, unacked)
+	go b.reclaim(ctx, queue)
+
+	go func() {
+		defer close(out)
+		defer func() {
+			// 消费者正常退出：未确认的消息立即重新入队
+			mu.Lock()
+			pending := make([]*redisDelivery, 0, len(unacked))
+			for d := range unacked {
+				pending = append(pending, d)
+			}
+			mu.Unlock()
+			for _, d := range pending {
+				d.settle(func() error { return b.requeue(context.Background(), queue, d.raw) })
+			}
+		}()
+
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			raw, err := b.client.BRPopLPush(ctx, b.pendingKey(queue), b.processingKey(queue), time.Second).Result()
+			if err != nil {
+				<-slots
+				if err == redis.Nil {
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Error("从Redis队列读取消息失败", "queue", queue, "error", err)
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			d, err := b.newDelivery(ctx, queue, raw)
+			if err != nil {
+				logger.Error("解析Redis队列消息失败，转入死信队列", "queue", queue, "error", err)
+				b.ackScriptRun(context.Background(), queue, raw, "", deadLetterQueue(b.pendingKey(queue)))
+				<-slots
+				continue
+			}
+			d.release = func() {
+				mu.Lock()
+				delete(unacked, d)
+				mu.Unlock()
+				<-slots
+			}
+			mu.Lock()
+			unacked[d] = struct{}{}
+			mu.Unlock()
+
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// newDelivery 记录截止时间和投递次数并构造投递
+func (b *redisBroker) newDelivery(ctx context.Context, queue, raw string) (*redisDelivery, error) {
+	var msg Message
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, err
+	}
+
+	deadline := float64(time.Now().Add(b.ackTimeout).UnixMilli())
+	pipe := b.client.TxPipeline()
+	pipe.ZAdd(ctx, b.deadlineKey(queue), &redis.Z{Score: deadline, Member: raw})
+	count := pipe.HIncrBy(ctx, b.deliveriesKey(queue), msg.ID, 1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("记录消息投递信息失败", "queue", queue, "message_id", msg.ID, "error", err)
+	}
+
+	return &redisDelivery{
+		broker:      b,
+		queue:       queue,
+		raw:         raw,
+		msg:         &msg,
+		redelivered: count.Val() > 1,
+	}, nil
+}
+
+// keepAlive 定期延长本消费者未确认消息的截止时间
+func (b *redisBroker) keepAlive(ctx context.Context, queue string, mu *sync.Mutex, unacked map[*redisDelivery]struct{}) {
+	ticker := time.NewTicker(b.ackTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mu.Lock()
+			members := make([]*redis.Z, 0, len(unacked))
+			deadline := float64(time.Now().Add(b.ackTimeout).UnixMilli())
+			for d := range unacked {
+				members = append(members, &redis.Z{Score: deadline, Member: d.raw})
+			}
+			mu.Unlock()
+			if len(members) == 0 {
+				continue
+			}
+			if err := b.client.ZAddXX(ctx, b.deadlineKey(queue), members...).Err(); err != nil && ctx.Err() == nil {
+				logger.Warn("延长消息确认截止时间失败", "queue", queue, "error", err)
+			}
+		}
+	}
+}
+
+// reclaim 回收截止时间已过的消息（其消费者已崩溃）
+func (b *redisBroker) reclaim(ctx context.Context, queue string) {
+	interval := b.ackTimeout / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.trackOrphans(ctx, queue)
+
+			expired, err := b.client.ZRangeByScore(ctx, b.deadlineKey(queue), &redis.ZRangeBy{
+				Min: "-inf",
+				Max: fmt.Sprintf("%d", time.Now().UnixMilli()),
+			}).Result()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Warn("查询超时未确认消息失败", "queue", queue, "error", err)
+				}
+				continue
+			}
+			for _, raw := range expired {
+				if err := b.requeue(ctx, queue, raw); err != nil {
+					logger.Warn("回收超时未确认消息失败", "queue", queue, "error", err)
+					continue
+				}
+				logger.Warn("消息确认超时，已重新入队", "queue", queue)
+			}
+		}
+	}
+}
+
+// trackOrphans 为processing中没有截止时间的消息补充截止时间
+// 消费者在出队后、记录截止时间前崩溃时，消息会留在processing中无人回收
+func (b *redisBroker) trackOrphans(ctx context.Context, queue string) {
+	raws, err := b.client.LRange(ctx, b.processingKey(queue), 0, -1).Result()
+	if err != nil || len(raws) == 0 {
+		return
+	}
+	deadline := float64(time.Now().Add(b.ackTimeout).UnixMilli())
+	members := make([]*redis.Z, 0, len(raws))
+	for _, raw := range raws {
+		members = append(members, &redis.Z{Score: deadline, Member: raw})
+	}
+	if err := b.client.ZAddNX(ctx, b.deadlineKey(queue), members...).Err(); err != nil && ctx.Err() == nil {
+		logger.Warn("补充消息确认截止时间失败", "queue", queue, "error", err)
+	}
+}
+
+// requeue 将未确认的消息放回待消费列表
+func (b *redisBroker) requeue(ctx context.Context, queue, raw string) error {
+	return requeueScript.Run(ctx, b.client,
+		[]string{b.processingKey(queue), b.deadlineKey(queue), b.pendingKey(queue)},
+		raw,
+	).Err()
+}
+
+// ackScriptRun 从processing中移除消息，deadLetterKey非空时同时写入死信列表
+func (b *redisBroker) ackScriptRun(ctx context.Context, queue, raw, messageID, deadLetterKey string) error {
+	return ackScript.Run(ctx, b.client,
+		[]string{b.processingKey(queue), b.deadlineKey(queue), b.deliveriesKey(queue)},
+		raw, messageID, deadLetterKey,
+	).Err()
+}
+
+// Len 队列中等待消费的消息数
+func (b *redisBroker) Len(ctx context.Context, queue string) (int64, error) {
+	return b.client.LLen(ctx, b.pendingKey(queue)).Result()
+}
+
+// Close Redis客户端由调用方管理，这里不关闭
+func (b *redisBroker) Close() error {
+	return nil
+}
+
+// redisDelivery Redis队列的一次投递
+type redisDelivery struct {
+	broker      *redisBroker
+	queue       string
+	raw         string
+	msg         *Message
+	redelivered bool
+	release     func()
+
+	mu      sync.Mutex
+	settled bool
+}
+
+func (d *redisDelivery) Message() *Message {
+	return d.msg
+}
+
+func (d *redisDelivery) Redelivered() bool {
+	return d.redelivered
+}
+
+func (d *redisDelivery) Ack() error {
+	return d.settle(func() error {
+		return d.broker.ackScriptRun(context.Background(), d.queue, d.raw, d.msg.ID, "")
+	})
+}
+
+func (d *redisDelivery) Nack(requeue bool) error {
+	return d.settle(func() error {
+		if requeue {
+			return d.broker.requeue(context.Background(), d.queue, d.raw)
+		}
+		return d.broker.ackScriptRun(context.Background(), d.queue, d.raw, d.msg.ID,
+			deadLetterQueue(d.broker.pendingKey(d.queue)))
+	})
+}
+
+// settle 结束投递，每条投递只能结束一次
+func (d *redisDelivery) settle(action func() error) error {
+	d.mu.Lock()
+	if d.settled {
+		d.mu.Unlock()
+		return errSettled
+	}
+	d.settled = true
+	d.mu.Unlock()
+
+	err := action()
+	if d.release != nil {
+		go d.release()
+	}
+	return err
+}
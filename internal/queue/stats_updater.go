@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 通知类型
+const (
+	NotificationSubmissionResult = "submission_result"
+	NotificationRankingUpdate    = "ranking_update"
+	NotificationSystemNotice     = "system_notice"
+)
+
+// StatsUpdate 统计更新消息
+// 判题完成后发布，由异步任务处理器更新用户和题目统计
+type StatsUpdate struct {
+	SubmissionID primitive.ObjectID  `json:"submission_id"`
+	UserID       primitive.ObjectID  `json:"user_id"`
+	ProblemID    primitive.ObjectID  `json:"problem_id"`
+	ContestID    *primitive.ObjectID `json:"contest_id,omitempty"` // 竞赛提交时同时更新竞赛排行榜
+	Language     string              `json:"language"`
+	Status       string              `json:"status"`
+	Score        int                 `json:"score"`
+	TimeUsed     int                 `json:"time_used"`   // 毫秒
+	MemoryUsed   int                 `json:"memory_used"` // KB
+	JudgedAt     time.Time           `json:"judged_at"`
+}
+
+// Notification 用户通知消息
+type Notification struct {
+	Type      string                 `json:"type"`
+	UserID    primitive.ObjectID     `json:"user_id"`
+	Title     string                 `json:"title"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// StatsUpdateHandler 统计更新处理函数
+type StatsUpdateHandler func(ctx context.Context, update *StatsUpdate) error
+
+// NotificationHandler 通知处理函数
+type NotificationHandler func(ctx context.Context, notification *Notification) error
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DuplicateGuard 拒绝一段时间内重复提交完全相同的代码
+type DuplicateGuard struct {
+	client *redis.Client
+	window time.Duration
+}
+
+// NewDuplicateGuard 创建重复提交检查，window为0时不检查
+func NewDuplicateGuard(client *redis.Client, window time.Duration) *DuplicateGuard {
+	return &DuplicateGuard{client: client, window: window}
+}
+
+func duplicateKey(userID string, parts []string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		// 分隔各部分，避免不同拆分得到相同的摘要
+		fmt.Fprintf(hash, "%d:%s;", len(part), part)
+	}
+	return fmt.Sprintf("submission:dedup:%s:%s", userID, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// Acquire 记录用户的一次提交，parts为题目、竞赛、语言和代码等决定提交是否相同的内容
+// 相同内容在window内已提交过时返回false和需要等待的时间
+func (g *DuplicateGuard) Acquire(ctx context.Context, userID string, parts ...string) (bool, time.Duration, error) {
+	if g.window <= 0 {
+		return true, 0, nil
+	}
+	key := duplicateKey(userID, parts)
+	ok, err := g.client.SetNX(ctx, key, time.Now().UnixMilli(), g.window).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("检查重复提交失败: %w", err)
+	}
+	if ok {
+		return true, 0, nil
+	}
+
+	ttl, err := g.client.PTTL(ctx, key).Result()
+	if err != nil || ttl < 0 {
+		ttl = g.window
+	}
+	return false, ttl, nil
+}
+
+// Release 提交未被受理时撤销记录，允许立即重新提交
+func (g *DuplicateGuard) Release(ctx context.Context, userID string, parts ...string) error {
+	if g.window <= 0 {
+		return nil
+	}
+	if err := g.client.Del(ctx, duplicateKey(userID, parts)).Err(); err != nil {
+		return fmt.Errorf("撤销重复提交记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// slidingWindowScript 滑动窗口限流
+// 有序集合记录窗口内每次请求的时间(毫秒)，未超过上限时记录本次请求；
+// 返回 {是否允许, 剩余次数, 需要等待的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local wait = window
+if oldest[2] then
+	wait = tonumber(oldest[2]) + window - now
+end
+return {0, 0, wait}
+`)
+
+// Result 限流检查结果
+type Result struct {
+	Allowed    bool
+	Remaining  int           // 窗口内剩余可用次数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+}
+
+// Limiter 基于Redis的滑动窗口限流器，多个服务实例共享计数
+type Limiter struct {
+	client *redis.Client
+}
+
+// NewLimiter 创建限流器
+func NewLimiter(client *redis.Client) *Limiter {
+	return &Limiter{client: client}
+}
+
+// Allow 检查key在window内的请求数是否超过limit，未超过时计入本次请求
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	now := time.Now().UnixMilli()
+	// 多个实例同一毫秒内的请求需要不同的成员
+	member := primitive.NewObjectID().Hex()
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{"ratelimit:" + key},
+		now, window.Milliseconds(), limit, member,
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("限流检查失败: %w", err)
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		requests      int
+		wantAllowed   int
+		wantRemaining []int // 各次被允许的请求之后的剩余次数
+	}{
+		{name: "under limit", limit: 5, requests: 3, wantAllowed: 3, wantRemaining: []int{4, 3, 2}},
+		{name: "exactly limit", limit: 3, requests: 3, wantAllowed: 3, wantRemaining: []int{2, 1, 0}},
+		{name: "over limit", limit: 2, requests: 5, wantAllowed: 2, wantRemaining: []int{1, 0}},
+		{name: "single request", limit: 1, requests: 3, wantAllowed: 1, wantRemaining: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newTestClient(t)
+			limiter := NewLimiter(client)
+			ctx := context.Background()
+
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				result, err := limiter.Allow(ctx, "submit:user:1", tt.limit, time.Minute)
+				if err != nil {
+					t.Fatalf("限流检查失败: %v", err)
+				}
+				if !result.Allowed {
+					if result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+						t.Errorf("RetryAfter = %v, 期望在(0, 1m]内", result.RetryAfter)
+					}
+					continue
+				}
+				if result.Remaining != tt.wantRemaining[allowed] {
+					t.Errorf("第%d次请求剩余次数 = %d, 期望 %d", i+1, result.Remaining, tt.wantRemaining[allowed])
+				}
+				allowed++
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("允许%d次请求, 期望%d次", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+// TestLimiterSlidingWindow 最早的请求移出窗口后才允许新的请求，RetryAfter为到那时需要等待的时间
+func TestLimiterSlidingWindow(t *testing.T) {
+	_, client := newTestClient(t)
+	limiter := NewLimiter(client)
+	ctx := context.Background()
+	const window = 300 * time.Millisecond
+
+	first := time.Now()
+	for i := 0; i < 2; i++ {
+		if result, err := limiter.Allow(ctx, "k", 2, window); err != nil || !result.Allowed {
+			t.Fatalf("第%d次请求 = %+v, %v, 期望允许", i+1, result, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	result, err := limiter.Allow(ctx, "k", 2, window)
+	if err != nil || result.Allowed {
+		t.Fatalf("窗口内第3次请求 = %+v, %v, 期望拒绝", result, err)
+	}
+	// 需要等到第一次请求移出窗口
+	if want := window - time.Since(first); result.RetryAfter > want+20*time.Millisecond || result.RetryAfter < want-50*time.Millisecond {
+		t.Errorf("RetryAfter = %v, 期望约%v", result.RetryAfter, want)
+	}
+
+	time.Sleep(result.RetryAfter + 10*time.Millisecond)
+	result, err = limiter.Allow(ctx, "k", 2, window)
+	if err != nil || !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("第一次请求移出窗口后 = %+v, %v, 期望允许且剩余0次(第二次请求仍在窗口内)", result, err)
+	}
+
+	// 不同的key分别计数
+	if result, err := limiter.Allow(ctx, "other", 2, window); err != nil || !result.Allowed {
+		t.Errorf("其他key的请求 = %+v, %v, 期望允许", result, err)
+	}
+}
+
+func TestLimiterRedisUnavailable(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Close()
+	if _, err := NewLimiter(client).Allow(context.Background(), "k", 1, time.Minute); err == nil {
+		t.Error("Redis不可用时应返回error")
+	}
+}
+
+func TestDuplicateGuard(t *testing.T) {
+	server, client := newTestClient(t)
+	guard := NewDuplicateGuard(client, 10*time.Second)
+	ctx := context.Background()
+	submission := []string{"problem1", "", "java", "class Main {}"}
+
+	ok, _, err := guard.Acquire(ctx, "user1", submission...)
+	if err != nil || !ok {
+		t.Fatalf("首次提交 = %v, %v, 期望允许", ok, err)
+	}
+
+	ok, wait, err := guard.Acquire(ctx, "user1", submission...)
+	if err != nil || ok {
+		t.Fatalf("重复提交 = %v, %v, 期望拒绝", ok, err)
+	}
+	if wait <= 0 || wait > 10*time.Second {
+		t.Errorf("等待时间 = %v, 期望在(0, 10s]内", wait)
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		parts  []string
+	}{
+		{name: "other user", userID: "user2", parts: submission},
+		{name: "other problem", userID: "user1", parts: []string{"problem2", "", "java", "class Main {}"}},
+		{name: "other language", userID: "user1", parts: []string{"problem1", "", "cpp", "class Main {}"}},
+		{name: "changed code", userID: "user1", parts: []string{"problem1", "", "java", "class Main { }"}},
+		// 各部分拼接相同但拆分不同
+		{name: "shifted parts", userID: "user1", parts: []string{"problem1", "java", "", "class Main {}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, _, err := guard.Acquire(ctx, tt.userID, tt.parts...); err != nil || !ok {
+				t.Errorf("Acquire = %v, %v, 期望允许", ok, err)
+			}
+		})
+	}
+
+	// 撤销后可以立即重新提交
+	if err := guard.Release(ctx, "user1", submission...); err != nil {
+		t.Fatalf("撤销失败: %v", err)
+	}
+	if ok, _, err := guard.Acquire(ctx, "user1", submission...); err != nil || !ok {
+		t.Errorf("撤销后重新提交 = %v, %v, 期望允许", ok, err)
+	}
+
+	// 窗口过后可以重新提交
+	server.FastForward(11 * time.Second)
+	if ok, _, err := guard.Acquire(ctx, "user1", submission...); err != nil || !ok {
+		t.Errorf("窗口过后重新提交 = %v, %v, 期望允许", ok, err)
+	}
+}
+
+func TestDuplicateGuardDisabled(t *testing.T) {
+	_, client := newTestClient(t)
+	guard := NewDuplicateGuard(client, 0)
+	for i := 0; i < 3; i++ {
+		if ok, _, err := guard.Acquire(context.Background(), "user1", "same"); err != nil || !ok {
+			t.Fatalf("未开启时第%d次提交 = %v, %v, 期望允许", i+1, ok, err)
+		}
+	}
+}
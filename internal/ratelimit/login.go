@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// lockLevelTTL 锁定次数的保留时间，期间再次锁定时长翻倍
+const lockLevelTTL = 24 * time.Hour
+
+// loginFailureScript 记录一次登录失败
+// 失败次数达到阈值时清零计数、锁定次数加一，并按 base*2^(锁定次数-1) 锁定(不超过上限)；
+// KEYS: 失败计数, 锁定, 锁定次数; ARGV: 阈值, 统计时间(毫秒), 首次锁定时长(毫秒), 锁定上限(毫秒), 锁定次数保留时间(毫秒)
+// 返回 {失败次数, 锁定时长(毫秒)，未锁定为0}
+var loginFailureScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if count < tonumber(ARGV[1]) then
+	return {count, 0}
+end
+redis.call('DEL', KEYS[1])
+local level = redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[5])
+local duration = tonumber(ARGV[3]) * 2 ^ (level - 1)
+if duration > tonumber(ARGV[4]) then
+	duration = tonumber(ARGV[4])
+end
+duration = math.floor(duration)
+redis.call('SET', KEYS[2], level, 'PX', duration)
+return {count, duration}
+`)
+
+// LoginGuard 登录失败锁定，按账号和IP分别计数，多个服务实例共享
+// 账号连续失败达到阈值后锁定该账号，同一IP失败过多时锁定该IP；每次锁定的时长在前一次的基础上翻倍
+type LoginGuard struct {
+	client *redis.Client
+	cfg    config.LoginLockoutConfig
+}
+
+// NewLoginGuard 创建登录失败锁定，未配置的阈值和时长使用默认值
+func NewLoginGuard(client *redis.Client, cfg config.LoginLockoutConfig) *LoginGuard {
+	if cfg.AccountThreshold <= 0 {
+		cfg.AccountThreshold = 5
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.LockDuration <= 0 {
+		cfg.LockDuration = time.Minute
+	}
+	if cfg.MaxLockDuration < cfg.LockDuration {
+		cfg.MaxLockDuration = cfg.LockDuration
+	}
+	return &LoginGuard{client: client, cfg: cfg}
+}
+
+// 账号按小写用户名计数，不存在的用户名同样计数和锁定，避免通过响应区分账号是否存在
+func accountKeys(account string) []string {
+	account = strings.ToLower(strings.TrimSpace(account))
+	return []string{"login:fail:account:" + account, "login:lock:account:" + account, "login:level:account:" + account}
+}
+
+func ipKeys(ip string) []string {
+	return []string{"login:fail:ip:" + ip, "login:lock:ip:" + ip, "login:level:ip:" + ip}
+}
+
+// Locked 账号或IP被锁定时返回剩余的锁定时间，未锁定时返回0
+func (g *LoginGuard) Locked(ctx context.Context, account, ip string) (time.Duration, error) {
+	if !g.cfg.Enabled {
+		return 0, nil
+	}
+	var accountTTL, ipTTL *redis.DurationCmd
+	_, err := g.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		accountTTL = pipe.PTTL(ctx, accountKeys(account)[1])
+		ipTTL = pipe.PTTL(ctx, ipKeys(ip)[1])
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("检查登录锁定失败: %w", err)
+	}
+	// 键不存在时PTTL为负数
+	return max(accountTTL.Val(), ipTTL.Val(), 0), nil
+}
+
+// Fail 记录一次登录失败，达到阈值时锁定，返回本次触发的锁定时长(账号和IP中较长者)
+func (g *LoginGuard) Fail(ctx context.Context, account, ip string) (time.Duration, error) {
+	if !g.cfg.Enabled {
+		return 0, nil
+	}
+	locked, err := g.fail(ctx, accountKeys(account), g.cfg.AccountThreshold)
+	if err != nil {
+		return 0, err
+	}
+	if g.cfg.IPThreshold > 0 {
+		ipLocked, err := g.fail(ctx, ipKeys(ip), g.cfg.IPThreshold)
+		if err != nil {
+			return 0, err
+		}
+		locked = max(locked, ipLocked)
+	}
+	return locked, nil
+}
+
+func (g *LoginGuard) fail(ctx context.Context, keys []string, threshold int) (time.Duration, error) {
+	values, err := loginFailureScript.Run(ctx, g.client, keys,
+		threshold, g.cfg.Window.Milliseconds(), g.cfg.LockDuration.Milliseconds(),
+		g.cfg.MaxLockDuration.Milliseconds(), lockLevelTTL.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("记录登录失败次数失败: %w", err)
+	}
+	return time.Duration(values[1]) * time.Millisecond, nil
+}
+
+// Succeed 登录成功后清零账号的失败次数；IP可能是多人共用的出口地址，不清零
+func (g *LoginGuard) Succeed(ctx context.Context, account string) error {
+	if !g.cfg.Enabled {
+		return nil
+	}
+	if err := g.client.Del(ctx, accountKeys(account)[0]).Err(); err != nil {
+		return fmt.Errorf("清除登录失败次数失败: %w", err)
+	}
+	return nil
+}
+
+// UnlockAccount 解除账号锁定，并清除失败次数和锁定次数，返回解除前是否处于锁定状态
+func (g *LoginGuard) UnlockAccount(ctx context.Context, account string) (bool, error) {
+	return g.unlock(ctx, accountKeys(account))
+}
+
+// UnlockIP 解除IP锁定，并清除失败次数和锁定次数，返回解除前是否处于锁定状态
+func (g *LoginGuard) UnlockIP(ctx context.Context, ip string) (bool, error) {
+	return g.unlock(ctx, ipKeys(ip))
+}
+
+func (g *LoginGuard) unlock(ctx context.Context, keys []string) (bool, error) {
+	var lock *redis.IntCmd
+	_, err := g.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		lock = pipe.Del(ctx, keys[1])
+		pipe.Del(ctx, keys[0], keys[2])
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("解除登录锁定失败: %w", err)
+	}
+	return lock.Val() > 0, nil
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+)
+
+type loginAttempt struct {
+	account string
+	ip      string
+}
+
+// attempts n次相同账号和IP的登录
+func attempts(n int, account, ip string) []loginAttempt {
+	list := make([]loginAttempt, n)
+	for i := range list {
+		list[i] = loginAttempt{account: account, ip: ip}
+	}
+	return list
+}
+
+func TestLoginGuardFail(t *testing.T) {
+	enabled := func(cfg config.LoginLockoutConfig) config.LoginLockoutConfig {
+		cfg.Enabled = true
+		return cfg
+	}
+	tests := []struct {
+		name       string
+		cfg        config.LoginLockoutConfig
+		failures   []loginAttempt
+		wantLocks  []time.Duration // 每次失败返回的锁定时长
+		wantLocked time.Duration   // 之后alice从10.0.0.1登录的剩余锁定时间
+	}{
+		{
+			name:       "defaults",
+			cfg:        enabled(config.LoginLockoutConfig{}),
+			failures:   attempts(6, "alice", "10.0.0.1"),
+			wantLocks:  []time.Duration{0, 0, 0, 0, time.Minute, 0},
+			wantLocked: time.Minute,
+		},
+		{
+			name:       "lock doubles",
+			cfg:        enabled(config.LoginLockoutConfig{AccountThreshold: 2, LockDuration: time.Minute, MaxLockDuration: time.Hour}),
+			failures:   attempts(6, "alice", "10.0.0.1"),
+			wantLocks:  []time.Duration{0, time.Minute, 0, 2 * time.Minute, 0, 4 * time.Minute},
+			wantLocked: 4 * time.Minute,
+		},
+		{
+			name:       "lock capped",
+			cfg:        enabled(config.LoginLockoutConfig{AccountThreshold: 1, LockDuration: time.Minute, MaxLockDuration: 3 * time.Minute}),
+			failures:   attempts(4, "alice", "10.0.0.1"),
+			wantLocks:  []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute},
+			wantLocked: 3 * time.Minute,
+		},
+		{
+			name:       "max below base",
+			cfg:        enabled(config.LoginLockoutConfig{AccountThreshold: 1, LockDuration: 2 * time.Minute, MaxLockDuration: time.Minute}),
+			failures:   attempts(2, "alice", "10.0.0.1"),
+			wantLocks:  []time.Duration{2 * time.Minute, 2 * time.Minute},
+			wantLocked: 2 * time.Minute,
+		},
+		{
+			// 用户名不区分大小写，前后空格忽略
+			name: "account case insensitive",
+			cfg:  enabled(config.LoginLockoutConfig{AccountThreshold: 2}),
+			failures: []loginAttempt{
+				{account: "Alice", ip: "10.0.0.2"},
+				{account: " alice ", ip: "10.0.0.3"},
+			},
+			wantLocks:  []time.Duration{0, time.Minute},
+			wantLocked: time.Minute,
+		},
+		{
+			name: "accounts counted separately",
+			cfg:  enabled(config.LoginLockoutConfig{AccountThreshold: 2}),
+			failures: []loginAttempt{
+				{account: "alice", ip: "10.0.0.1"},
+				{account: "bob", ip: "10.0.0.1"},
+			},
+			wantLocks: []time.Duration{0, 0},
+		},
+		{
+			// 同一IP尝试不同账号时按IP锁定，锁定对该IP上的所有账号生效
+			name: "ip threshold",
+			cfg:  enabled(config.LoginLockoutConfig{IPThreshold: 3, LockDuration: 5 * time.Minute, MaxLockDuration: time.Hour}),
+			failures: []loginAttempt{
+				{account: "bob", ip: "10.0.0.1"},
+				{account: "carol", ip: "10.0.0.1"},
+				{account: "dave", ip: "10.0.0.1"},
+			},
+			wantLocks:  []time.Duration{0, 0, 5 * time.Minute},
+			wantLocked: 5 * time.Minute,
+		},
+		{
+			name:      "ip not counted without threshold",
+			cfg:       enabled(config.LoginLockoutConfig{AccountThreshold: 2}),
+			failures:  []loginAttempt{{account: "bob", ip: "10.0.0.1"}, {account: "carol", ip: "10.0.0.1"}, {account: "dave", ip: "10.0.0.1"}},
+			wantLocks: []time.Duration{0, 0, 0},
+		},
+		{
+			name:      "disabled",
+			cfg:       config.LoginLockoutConfig{AccountThreshold: 1, IPThreshold: 1},
+			failures:  attempts(3, "alice", "10.0.0.1"),
+			wantLocks: []time.Duration{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newTestClient(t)
+			guard := NewLoginGuard(client, tt.cfg)
+			ctx := context.Background()
+
+			var locks []time.Duration
+			for _, attempt := range tt.failures {
+				locked, err := guard.Fail(ctx, attempt.account, attempt.ip)
+				if err != nil {
+					t.Fatalf("记录登录失败出错: %v", err)
+				}
+				locks = append(locks, locked)
+			}
+			if !reflect.DeepEqual(locks, tt.wantLocks) {
+				t.Errorf("锁定时长 = %v, 期望 %v", locks, tt.wantLocks)
+			}
+
+			locked, err := guard.Locked(ctx, "alice", "10.0.0.1")
+			if err != nil {
+				t.Fatalf("检查登录锁定出错: %v", err)
+			}
+			if locked != tt.wantLocked {
+				t.Errorf("剩余锁定时间 = %v, 期望 %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+// TestLoginGuardReset 登录成功只清零账号的失败次数；锁定到期后失败次数重新计算；
+// 解除锁定同时清除锁定次数，下次锁定恢复为首次锁定时长
+func TestLoginGuardReset(t *testing.T) {
+	server, client := newTestClient(t)
+	guard := NewLoginGuard(client, config.LoginLockoutConfig{
+		Enabled: true, AccountThreshold: 2, IPThreshold: 3, LockDuration: time.Minute, MaxLockDuration: time.Hour,
+	})
+	ctx := context.Background()
+	fail := func(account string, want time.Duration) {
+		t.Helper()
+		if locked, err := guard.Fail(ctx, account, "10.0.0.1"); err != nil || locked != want {
+			t.Fatalf("%s登录失败 = %v, %v, 期望锁定%v", account, locked, err, want)
+		}
+	}
+
+	fail("alice", 0)
+	if err := guard.Succeed(ctx, "alice"); err != nil {
+		t.Fatalf("清零失败次数出错: %v", err)
+	}
+	fail("alice", 0)
+	// IP的失败次数不随登录成功清零，第3次失败时锁定IP
+	fail("alice", time.Minute)
+
+	server.FastForward(time.Minute)
+	if locked, err := guard.Locked(ctx, "alice", "10.0.0.1"); err != nil || locked != 0 {
+		t.Fatalf("锁定到期后剩余锁定时间 = %v, %v, 期望0", locked, err)
+	}
+	fail("alice", 0)
+	fail("alice", 2*time.Minute)
+
+	unlocked, err := guard.UnlockAccount(ctx, " Alice ")
+	if err != nil || !unlocked {
+		t.Fatalf("解除账号锁定 = %v, %v, 期望解除前处于锁定", unlocked, err)
+	}
+	if unlocked, err := guard.UnlockAccount(ctx, "alice"); err != nil || unlocked {
+		t.Errorf("再次解除账号锁定 = %v, %v, 期望解除前未锁定", unlocked, err)
+	}
+	// 解除IP锁定同样清除失败次数，IP从未锁定过时返回false
+	if unlocked, err := guard.UnlockIP(ctx, "10.0.0.1"); err != nil || unlocked {
+		t.Errorf("解除IP锁定 = %v, %v, 期望解除前未锁定", unlocked, err)
+	}
+	fail("alice", 0)
+	fail("alice", time.Minute)
+	if locked, err := guard.Locked(ctx, "bob", "10.0.0.1"); err != nil || locked != 0 {
+		t.Errorf("其他账号剩余锁定时间 = %v, %v, 期望0", locked, err)
+	}
+}
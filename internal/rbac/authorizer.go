@@ -0,0 +1,227 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+)
+
+// builtInRoles 内置角色及默认权限，按权限从低到高排列
+// 教师默认只能修改自己的题目、竞赛和任教的课程，删除题目需要管理员授予problem:delete:*
+// 助教不能管理课程，作为课程成员可以查看所教课程学生的提交和统计
+var builtInRoles = []model.Role{
+	{Name: model.RoleStudent, DisplayName: "学生", Permissions: []string{}},
+	{Name: model.RoleTA, DisplayName: "助教", Permissions: []string{
+		ProblemViewHidden, SubmissionViewClass, JudgeQueueView,
+	}},
+	{Name: model.RoleTeacher, DisplayName: "教师", Permissions: []string{
+		ProblemViewHidden, ProblemCreate, ProblemEditOwn, ProblemImport,
+		SubmissionViewClass, ContestCreate, ContestManageOwn, CourseCreate, CourseManageOwn, JudgeQueueView,
+	}},
+	{Name: model.RoleAdmin, DisplayName: "管理员", Permissions: []string{Wildcard}},
+}
+
+// roleNamePattern 角色名只能包含小写字母、数字、下划线和连字符
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// ValidRoleName 角色名是否合法
+func ValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// RoleLoader 读取数据库中保存的角色
+type RoleLoader func(ctx context.Context) ([]*model.Role, error)
+
+// Authorizer 角色到权限集合的映射
+// 权限依次取内置默认值、配置文件和数据库中的角色，后者覆盖前者；admin始终拥有全部权限
+type Authorizer struct {
+	base     map[string]*model.Role // 内置角色和配置文件中的角色
+	loader   RoleLoader
+	interval time.Duration
+
+	mu    sync.RWMutex
+	roles map[string]*model.Role
+	sets  map[string]Set
+}
+
+// NewAuthorizer 创建权限映射，loader为nil时只使用内置角色和配置文件
+func NewAuthorizer(cfg config.RBACConfig, loader RoleLoader) (*Authorizer, error) {
+	base := make(map[string]*model.Role, len(builtInRoles)+len(cfg.Roles))
+	for i := range builtInRoles {
+		role := builtInRoles[i]
+		role.BuiltIn = true
+		base[role.Name] = &role
+	}
+
+	names := make([]string, 0, len(cfg.Roles))
+	for name := range cfg.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rc := cfg.Roles[name]
+		if !ValidRoleName(name) {
+			return nil, fmt.Errorf("角色名不合法: %s", name)
+		}
+		if name == model.RoleAdmin {
+			return nil, fmt.Errorf("admin角色拥有全部权限，不能在配置中修改")
+		}
+		for _, perm := range rc.Permissions {
+			if !Valid(perm) {
+				return nil, fmt.Errorf("角色%s的权限不存在: %s", name, perm)
+			}
+		}
+
+		role, ok := base[name]
+		if !ok {
+			role = &model.Role{Name: name, DisplayName: name}
+			base[name] = role
+		}
+		if rc.DisplayName != "" {
+			role.DisplayName = rc.DisplayName
+		}
+		if rc.Permissions != nil {
+			role.Permissions = append([]string{}, rc.Permissions...)
+		}
+	}
+
+	interval := cfg.ReloadInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	a := &Authorizer{base: base, loader: loader, interval: interval}
+	a.apply(nil)
+	return a, nil
+}
+
+// Reload 重新读取数据库中的角色
+func (a *Authorizer) Reload(ctx context.Context) error {
+	if a.loader == nil {
+		return nil
+	}
+	stored, err := a.loader(ctx)
+	if err != nil {
+		return fmt.Errorf("加载角色失败: %w", err)
+	}
+	a.apply(stored)
+	return nil
+}
+
+// Run 定期重新加载角色，使其他实例通过管理接口修改的角色生效，直到ctx结束
+func (a *Authorizer) Run(ctx context.Context) {
+	if a.loader == nil {
+		return
+	}
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Reload(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("重新加载角色失败，继续使用上次加载的角色", "error", err)
+			}
+		}
+	}
+}
+
+// apply 合并数据库中的角色并重建权限集合；数据库中的权限名称不合法时忽略该权限
+func (a *Authorizer) apply(stored []*model.Role) {
+	roles := make(map[string]*model.Role, len(a.base)+len(stored))
+	for name, role := range a.base {
+		r := *role
+		roles[name] = &r
+	}
+	for _, s := range stored {
+		if s.Name == model.RoleAdmin {
+			continue
+		}
+		r := *s
+		r.Permissions = make([]string, 0, len(s.Permissions))
+		for _, perm := range s.Permissions {
+			if Valid(perm) {
+				r.Permissions = append(r.Permissions, perm)
+			} else {
+				logger.Warn("忽略角色中不存在的权限", "role", s.Name, "permission", perm)
+			}
+		}
+		if base, ok := a.base[s.Name]; ok {
+			r.BuiltIn = base.BuiltIn
+			if r.DisplayName == "" {
+				r.DisplayName = base.DisplayName
+			}
+		}
+		roles[s.Name] = &r
+	}
+
+	sets := make(map[string]Set, len(roles))
+	for name, role := range roles {
+		sets[name] = NewSet(role.Permissions...)
+	}
+	sets[model.RoleAdmin] = NewSet(Wildcard)
+
+	a.mu.Lock()
+	a.roles = roles
+	a.sets = sets
+	a.mu.Unlock()
+}
+
+// Permissions 角色的权限集合，不存在的角色没有任何权限；返回的集合不可修改
+func (a *Authorizer) Permissions(role string) Set {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if set, ok := a.sets[role]; ok {
+		return set
+	}
+	return Set{}
+}
+
+// Role 获取角色
+func (a *Authorizer) Role(name string) (*model.Role, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	role, ok := a.roles[name]
+	if !ok {
+		return nil, false
+	}
+	r := *role
+	return &r, true
+}
+
+// Roles 全部角色，内置角色在前，其余按名称排序
+func (a *Authorizer) Roles() []*model.Role {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	roles := make([]*model.Role, 0, len(a.roles))
+	for _, builtIn := range builtInRoles {
+		r := *a.roles[builtIn.Name]
+		roles = append(roles, &r)
+	}
+	var custom []string
+	for name, role := range a.roles {
+		if !role.BuiltIn {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	for _, name := range custom {
+		r := *a.roles[name]
+		roles = append(roles, &r)
+	}
+	return roles
+}
+
+// Configured 角色是否在内置角色或配置文件中定义，这类角色从数据库删除后仍然存在
+func (a *Authorizer) Configured(name string) bool {
+	_, ok := a.base[name]
+	return ok
+}
@@ -0,0 +1,140 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	os.Exit(m.Run())
+}
+
+func TestNewAuthorizerInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles map[string]config.RoleConfig
+	}{
+		{name: "invalid role name", roles: map[string]config.RoleConfig{"Grader": {}}},
+		{name: "admin", roles: map[string]config.RoleConfig{model.RoleAdmin: {Permissions: []string{ProblemCreate}}}},
+		{name: "unknown permission", roles: map[string]config.RoleConfig{"grader": {Permissions: []string{"problem:publish"}}}},
+		{name: "unknown wildcard", roles: map[string]config.RoleConfig{"grader": {Permissions: []string{"blog:*"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthorizer(config.RBACConfig{Roles: tt.roles}, nil); err == nil {
+				t.Error("NewAuthorizer 期望返回error")
+			}
+		})
+	}
+}
+
+// TestAuthorizerPermissions 权限依次取内置角色、配置文件和数据库，后者覆盖前者；admin始终拥有全部权限
+func TestAuthorizerPermissions(t *testing.T) {
+	cfg := config.RBACConfig{Roles: map[string]config.RoleConfig{
+		model.RoleTeacher: {Permissions: []string{"problem:*", ContestCreate}},
+		"grader":          {DisplayName: "评分员", Permissions: []string{"submission:*"}},
+		model.RoleTA:      {DisplayName: "课程助教"}, // 未配置权限时保留默认权限
+	}}
+	var stored []*model.Role
+	authorizer, err := NewAuthorizer(cfg, func(ctx context.Context) ([]*model.Role, error) {
+		return stored, nil
+	})
+	if err != nil {
+		t.Fatalf("创建权限映射失败: %v", err)
+	}
+
+	type check struct {
+		role string
+		perm string
+		want bool
+	}
+	checks := func(t *testing.T, list []check) {
+		t.Helper()
+		for _, c := range list {
+			if got := authorizer.Permissions(c.role).Has(c.perm); got != c.want {
+				t.Errorf("角色%s拥有%s = %v, 期望 %v", c.role, c.perm, got, c.want)
+			}
+		}
+	}
+
+	t.Run("config", func(t *testing.T) {
+		checks(t, []check{
+			{model.RoleStudent, ProblemViewHidden, false},
+			{model.RoleTA, SubmissionViewClass, true},
+			{model.RoleTeacher, ProblemDeleteAny, true},
+			{model.RoleTeacher, ContestCreate, true},
+			{model.RoleTeacher, CourseCreate, false},
+			{"grader", SubmissionRejudge, true},
+			{"grader", ProblemViewHidden, false},
+			{model.RoleAdmin, RoleManage, true},
+			{"unknown", ProblemCreate, false},
+		})
+		if ta, _ := authorizer.Role(model.RoleTA); ta.DisplayName != "课程助教" || !ta.BuiltIn {
+			t.Errorf("助教角色 = %+v, 期望使用配置的显示名称且仍为内置角色", ta)
+		}
+	})
+
+	stored = []*model.Role{
+		{Name: model.RoleTeacher, Permissions: []string{"course:*", "problem:publish"}},
+		{Name: "grader", Permissions: []string{SubmissionViewAny}},
+		{Name: model.RoleAdmin, Permissions: []string{}},
+		{Name: "auditor", DisplayName: "审计员", Permissions: []string{"judge:*", "blog:*"}},
+	}
+	if err := authorizer.Reload(context.Background()); err != nil {
+		t.Fatalf("重新加载角色失败: %v", err)
+	}
+
+	t.Run("stored", func(t *testing.T) {
+		checks(t, []check{
+			{model.RoleTeacher, CourseManageAny, true},
+			{model.RoleTeacher, ProblemCreate, false},
+			{"grader", SubmissionViewAny, true},
+			{"grader", SubmissionRejudge, false},
+			{model.RoleAdmin, SystemManage, true},
+			{"auditor", JudgeQueueView, true},
+		})
+		if teacher, _ := authorizer.Role(model.RoleTeacher); teacher.DisplayName != "教师" || !teacher.BuiltIn ||
+			len(teacher.Permissions) != 1 {
+			t.Errorf("教师角色 = %+v, 期望保留内置显示名称并忽略不存在的权限", teacher)
+		}
+		if auditor, _ := authorizer.Role("auditor"); auditor.BuiltIn || authorizer.Configured("auditor") {
+			t.Error("数据库中新增的角色不是内置角色")
+		}
+	})
+
+	// 数据库中删除角色后恢复配置文件中的权限
+	stored = nil
+	if err := authorizer.Reload(context.Background()); err != nil {
+		t.Fatalf("重新加载角色失败: %v", err)
+	}
+	t.Run("deleted", func(t *testing.T) {
+		checks(t, []check{
+			{model.RoleTeacher, ProblemDeleteAny, true},
+			{model.RoleTeacher, CourseManageAny, false},
+			{"auditor", JudgeQueueView, false},
+		})
+	})
+}
+
+func TestAuthorizerReloadFailed(t *testing.T) {
+	authorizer, err := NewAuthorizer(config.RBACConfig{}, func(ctx context.Context) ([]*model.Role, error) {
+		return nil, errors.New("mongo unavailable")
+	})
+	if err != nil {
+		t.Fatalf("创建权限映射失败: %v", err)
+	}
+	if err := authorizer.Reload(context.Background()); err == nil {
+		t.Error("加载角色失败时应返回error")
+	}
+	if !authorizer.Permissions(model.RoleTeacher).Has(ProblemCreate) {
+		t.Error("加载失败时应继续使用内置角色")
+	}
+}
@@ -0,0 +1,34 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrResourceNotFound 检查所有者时资源不存在
+var ErrResourceNotFound = errors.New("资源不存在")
+
+// OwnerLookup 查询资源的创建者，资源不存在时返回ErrResourceNotFound
+type OwnerLookup func(ctx context.Context, id string) (primitive.ObjectID, error)
+
+// ProblemOwner 按题目的CreatedBy判断所有者
+func ProblemOwner(problemRepo interfaces.ProblemRepository) OwnerLookup {
+	return func(ctx context.Context, id string) (primitive.ObjectID, error) {
+		problemID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return primitive.NilObjectID, ErrResourceNotFound
+		}
+		problem, err := problemRepo.GetByID(ctx, problemID)
+		if err != nil {
+			if errors.Is(err, interfaces.ErrProblemNotFound) {
+				return primitive.NilObjectID, ErrResourceNotFound
+			}
+			return primitive.NilObjectID, err
+		}
+		return problem.CreatedBy, nil
+	}
+}
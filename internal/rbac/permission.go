@@ -0,0 +1,138 @@
+// Package rbac 基于角色的权限控制：权限名称、角色到权限集合的映射和资源所有者检查
+package rbac
+
+import (
+	"sort"
+	"strings"
+)
+
+// 权限名称，格式为 资源:操作[:范围]；own只允许操作自己创建(课程为自己任教)的资源，any允许操作全部资源，class限于所教课程的学生
+const (
+	ProblemViewHidden = "problem:view:hidden" // 查看和提交未公开的题目
+	ProblemCreate     = "problem:create"
+	ProblemEditOwn    = "problem:edit:own"
+	ProblemEditAny    = "problem:edit:any"
+	ProblemDeleteOwn  = "problem:delete:own"
+	ProblemDeleteAny  = "problem:delete:any"
+	ProblemImport     = "problem:import"
+
+	SubmissionViewClass = "submission:view:class"
+	SubmissionViewAny   = "submission:view:any"
+	SubmissionRejudge   = "submission:rejudge"
+
+	ContestCreate    = "contest:create"
+	ContestManageOwn = "contest:manage:own" // 修改、删除竞赛和滚榜
+	ContestManageAny = "contest:manage:any"
+
+	CourseCreate    = "course:create"
+	CourseManageOwn = "course:manage:own" // 修改、删除课程，管理成员和邀请码
+	CourseManageAny = "course:manage:any"
+
+	JudgeQueueView = "judge:queue:view"
+
+	UserManage   = "user:manage"   // 创建、修改、停用、导入导出用户，重置密码和解除锁定
+	RoleManage   = "role:manage"   // 管理角色和修改用户的角色
+	SystemManage = "system:manage" // 管理员仪表板和系统状态
+)
+
+// Wildcard 全部权限
+const Wildcard = "*"
+
+// Permission 权限说明
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// catalog 全部权限，按资源分组排列
+var catalog = []Permission{
+	{ProblemViewHidden, "查看和提交未公开的题目"},
+	{ProblemCreate, "创建题目"},
+	{ProblemEditOwn, "修改自己创建的题目"},
+	{ProblemEditAny, "修改任意题目"},
+	{ProblemDeleteOwn, "删除自己创建的题目"},
+	{ProblemDeleteAny, "删除任意题目"},
+	{ProblemImport, "批量导入题目"},
+	{SubmissionViewClass, "查看所教课程学生的提交"},
+	{SubmissionViewAny, "查看任意提交"},
+	{SubmissionRejudge, "重新判题"},
+	{ContestCreate, "创建竞赛"},
+	{ContestManageOwn, "管理自己创建的竞赛"},
+	{ContestManageAny, "管理任意竞赛"},
+	{CourseCreate, "创建课程"},
+	{CourseManageOwn, "管理自己任教的课程"},
+	{CourseManageAny, "管理任意课程"},
+	{JudgeQueueView, "查看判题队列"},
+	{UserManage, "管理用户"},
+	{RoleManage, "管理角色和用户角色"},
+	{SystemManage, "查看系统状态"},
+}
+
+// Catalog 全部权限及说明
+func Catalog() []Permission {
+	return append([]Permission(nil), catalog...)
+}
+
+// Valid 是否为已知权限，或能匹配到已知权限的通配符("*"、"problem:*"、"problem:edit:*")
+func Valid(name string) bool {
+	if name == Wildcard {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(name, ":"+Wildcard)
+	for _, p := range catalog {
+		if p.Name == name || wildcard && strings.HasPrefix(p.Name, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Set 权限集合，可包含通配符
+type Set map[string]bool
+
+// NewSet 创建权限集合
+func NewSet(perms ...string) Set {
+	s := make(Set, len(perms))
+	for _, p := range perms {
+		s[p] = true
+	}
+	return s
+}
+
+// Has 是否拥有权限，"*"匹配全部权限，"problem:*"匹配以"problem:"开头的权限
+func (s Set) Has(perm string) bool {
+	if s[perm] || s[Wildcard] {
+		return true
+	}
+	for i := 0; i < len(perm); i++ {
+		if perm[i] == ':' && s[perm[:i+1]+Wildcard] {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAny 是否拥有其中任一权限
+func (s Set) HasAny(perms ...string) bool {
+	for _, p := range perms {
+		if s.Has(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage 对资源的操作权限：拥有anyPerm，或拥有ownPerm且是资源的创建者
+func (s Set) CanManage(ownPerm, anyPerm string, isOwner bool) bool {
+	return s.Has(anyPerm) || isOwner && s.Has(ownPerm)
+}
+
+// List 集合中的权限，按名称排序
+func (s Set) List() []string {
+	perms := make([]string, 0, len(s))
+	for p := range s {
+		perms = append(perms, p)
+	}
+	sort.Strings(perms)
+	return perms
+}
@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetHas(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms []string
+		perm  string
+		want  bool
+	}{
+		{name: "exact", perms: []string{ProblemCreate}, perm: ProblemCreate, want: true},
+		{name: "missing", perms: []string{ProblemCreate}, perm: ProblemImport},
+		{name: "empty set", perm: ProblemCreate},
+		{name: "all", perms: []string{Wildcard}, perm: SystemManage, want: true},
+		{name: "resource wildcard", perms: []string{"problem:*"}, perm: ProblemEditAny, want: true},
+		{name: "resource wildcard two parts", perms: []string{"problem:*"}, perm: ProblemCreate, want: true},
+		{name: "action wildcard", perms: []string{"problem:edit:*"}, perm: ProblemEditOwn, want: true},
+		{name: "action wildcard other action", perms: []string{"problem:edit:*"}, perm: ProblemDeleteOwn},
+		{name: "action wildcard without scope", perms: []string{"problem:edit:*"}, perm: "problem:edit"},
+		{name: "other resource", perms: []string{"problem:*"}, perm: ContestCreate},
+		{name: "resource name prefix", perms: []string{"course:*"}, perm: "courses:create"},
+		{name: "wildcard without colon", perms: []string{"problem*"}, perm: ProblemCreate},
+		{name: "scope is not a wildcard", perms: []string{ProblemEditAny}, perm: ProblemEditOwn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSet(tt.perms...).Has(tt.perm); got != tt.want {
+				t.Errorf("%v.Has(%q) = %v, 期望 %v", tt.perms, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCanManage(t *testing.T) {
+	tests := []struct {
+		name    string
+		perms   []string
+		isOwner bool
+		want    bool
+	}{
+		{name: "any permission", perms: []string{ContestManageAny}, want: true},
+		{name: "own permission as owner", perms: []string{ContestManageOwn}, isOwner: true, want: true},
+		{name: "own permission not owner", perms: []string{ContestManageOwn}},
+		{name: "owner without permission", isOwner: true},
+		{name: "wildcard not owner", perms: []string{"contest:manage:*"}, want: true},
+		{name: "admin", perms: []string{Wildcard}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSet(tt.perms...).CanManage(ContestManageOwn, ContestManageAny, tt.isOwner); got != tt.want {
+				t.Errorf("CanManage = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if set := NewSet(SubmissionViewClass); !set.HasAny(SubmissionViewAny, SubmissionViewClass) || set.HasAny(SubmissionViewAny, SubmissionRejudge) {
+		t.Error("HasAny 应在拥有其中任一权限时返回true")
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: Wildcard, want: true},
+		{name: ProblemEditOwn, want: true},
+		{name: "problem:*", want: true},
+		{name: "problem:edit:*", want: true},
+		{name: "judge:queue:*", want: true},
+		{name: "problem:view"},
+		{name: "problem:publish:*"},
+		{name: "blog:*"},
+		{name: "prob:*"},
+		{name: "problem*"},
+		{name: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.name); got != tt.want {
+				t.Errorf("Valid(%q) = %v, 期望 %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetList(t *testing.T) {
+	want := []string{"*", ContestCreate, ProblemCreate}
+	if got := NewSet(ProblemCreate, Wildcard, ContestCreate, ProblemCreate).List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package realtime
+
+import (
+	"time"
+
+	"zhku-oj/internal/model"
+)
+
+// 事件类型，对应md/2.md中的WebSocket消息类型
+const (
+	EventJudgeProgress    = "judge_progress"    // 判题进度：排队、编译中、运行中
+	EventCompileResult    = "compile_result"    // 编译结果
+	EventTestCaseResult   = "test_case_result"  // 单个测试用例结果
+	EventSubmissionResult = "submission_result" // 最终结果
+)
+
+// StageQueued 判题进度中的排队阶段，其余阶段见model.JudgeStage*
+const StageQueued = "QUEUED"
+
+// Event 推送给提交用户的事件
+// 通过Redis发布订阅分发到所有Web服务实例，由持有该用户连接的实例推送
+type Event struct {
+	Type   string      `json:"type"`
+	UserID string      `json:"user_id"`
+	Data   interface{} `json:"data"`
+}
+
+// JudgeProgress 判题进度
+type JudgeProgress struct {
+	SubmissionID    string    `json:"submission_id"`
+	Stage           string    `json:"stage"` // QUEUED, COMPILING, RUNNING
+	CurrentTestCase int       `json:"current_test_case"`
+	TotalTestCases  int       `json:"total_test_cases"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// CompileResult 编译结果
+type CompileResult struct {
+	SubmissionID  string    `json:"submission_id"`
+	CompileStatus string    `json:"compile_status"` // SUCCESS, FAILED
+	TimeUsed      int64     `json:"time_used"`      // 纳秒
+	MemoryUsed    int64     `json:"memory_used"`    // 字节
+	Message       string    `json:"message"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// TestCaseResult 单个测试用例结果
+// 不包含输入和输出，避免泄露非公开测试数据
+type TestCaseResult struct {
+	SubmissionID   string    `json:"submission_id"`
+	TestCaseID     string    `json:"test_case_id"`
+	TestCaseIndex  int       `json:"test_case_index"`  // 在题目中的序号，从1开始
+	CompletedCases int       `json:"completed_cases"`  // 已完成的用例数，用例并发运行时与序号不一致
+	TotalTestCases int       `json:"total_test_cases"` // 用例总数
+	Status         string    `json:"status"`
+	Score          int       `json:"score"`
+	TimeUsed       int       `json:"time_used"`   // 毫秒
+	MemoryUsed     int       `json:"memory_used"` // KB
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// SubmissionResult 最终结果
+type SubmissionResult struct {
+	SubmissionID string             `json:"submission_id"`
+	Status       string             `json:"status"`
+	Score        int                `json:"score"`
+	TimeUsed     int                `json:"time_used"`   // 毫秒
+	MemoryUsed   int                `json:"memory_used"` // KB
+	CompileInfo  *model.CompileInfo `json:"compile_info,omitempty"`
+	JudgedAt     time.Time          `json:"judged_at"`
+}
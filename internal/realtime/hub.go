@@ -0,0 +1,146 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// subscriberBuffer 每个连接缓冲的事件数，客户端读取过慢时丢弃新事件
+const subscriberBuffer = 64
+
+// Message 推送给客户端的消息
+type Message struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// envelope Redis频道中的事件
+type envelope struct {
+	Type   string          `json:"type"`
+	UserID string          `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Hub 本实例的事件分发中心
+// 每个Web服务实例订阅一次Redis频道，再按用户ID分发到本实例的WebSocket和SSE连接
+type Hub struct {
+	client      *redis.Client
+	mu          sync.RWMutex
+	subscribers map[string]map[chan Message]struct{}
+	closed      bool
+}
+
+// NewHub 创建事件分发中心
+func NewHub(client *redis.Client) *Hub {
+	return &Hub{
+		client:      client,
+		subscribers: make(map[string]map[chan Message]struct{}),
+	}
+}
+
+// Run 订阅Redis频道并分发事件，直到ctx取消；订阅断开时自动重连
+// 退出时关闭所有订阅的事件通道，使长连接随服务一起结束
+func (h *Hub) Run(ctx context.Context) {
+	defer h.closeAll()
+	for {
+		h.consume(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for userID, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(h.subscribers, userID)
+	}
+}
+
+func (h *Hub) consume(ctx context.Context) {
+	pubsub := h.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			logger.Error("订阅判题事件失败", "error", err)
+		}
+		return
+	}
+	logger.Info("已订阅判题事件", "channel", channel)
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				logger.Warn("判题事件订阅已断开", "channel", channel)
+				return
+			}
+			h.dispatch([]byte(msg.Payload))
+		}
+	}
+}
+
+func (h *Hub) dispatch(payload []byte) {
+	var event envelope
+	if err := json.Unmarshal(payload, &event); err != nil {
+		logger.Warn("解析判题事件失败", "error", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- Message{Type: event.Type, Data: event.Data}:
+		default:
+			// 不阻塞其他连接，客户端可通过提交详情接口获取最终结果
+			logger.Warn("事件缓冲已满，丢弃事件", "user_id", event.UserID, "type", event.Type)
+		}
+	}
+}
+
+// Subscribe 订阅用户的事件，返回事件通道和取消订阅函数；服务关闭时事件通道被关闭
+func (h *Hub) Subscribe(userID string) (<-chan Message, func()) {
+	ch := make(chan Message, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Message]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
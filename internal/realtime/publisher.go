@@ -0,0 +1,40 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// channel 判题事件的Redis发布订阅频道，所有Web服务实例订阅同一频道
+const channel = "events:submission"
+
+// Publisher 判题事件发布接口
+type Publisher interface {
+	// Publish 向提交用户推送事件，没有在线连接时事件被丢弃
+	Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) error
+}
+
+type redisPublisher struct {
+	client *redis.Client
+}
+
+// NewRedisPublisher 创建基于Redis发布订阅的事件发布器
+func NewRedisPublisher(client *redis.Client) Publisher {
+	return &redisPublisher{client: client}
+}
+
+// Publish 发布事件
+func (p *redisPublisher) Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) error {
+	raw, err := json.Marshal(Event{Type: eventType, UserID: userID.Hex(), Data: data})
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %w", err)
+	}
+	if err := p.client.Publish(ctx, channel, raw).Err(); err != nil {
+		return fmt.Errorf("发布事件失败: %w", err)
+	}
+	return nil
+}
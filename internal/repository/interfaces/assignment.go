@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrAssignmentNotFound 作业不存在
+var ErrAssignmentNotFound = errors.New("作业不存在")
+
+// AssignmentRepository 作业数据访问接口
+type AssignmentRepository interface {
+	// Create 创建作业
+	Create(ctx context.Context, assignment *model.Assignment) error
+
+	// GetByID 根据ID获取作业，包含全部延期；不存在时返回ErrAssignmentNotFound
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Assignment, error)
+
+	// Update 更新作业信息、题目、时间和扣分规则，延期不变；不存在时返回ErrAssignmentNotFound
+	Update(ctx context.Context, assignment *model.Assignment) error
+
+	// Delete 删除作业，不存在时返回ErrAssignmentNotFound
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// DeleteByCourse 删除课程的全部作业，返回删除的数量
+	DeleteByCourse(ctx context.Context, courseID primitive.ObjectID) (int64, error)
+
+	// List 分页查询作业列表，按截止时间倒序；filters支持 course_id、opened_before(开放时间不晚于该时间)
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Assignment, int64, error)
+
+	// SetExtension 设置学生的延期，已有延期时替换；不存在时返回ErrAssignmentNotFound
+	SetExtension(ctx context.Context, id primitive.ObjectID, extension model.AssignmentExtension) error
+
+	// RemoveExtension 取消学生的延期，返回是否存在该延期；作业不存在时返回ErrAssignmentNotFound
+	RemoveExtension(ctx context.Context, id, userID primitive.ObjectID) (bool, error)
+}
@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrContestNotFound 竞赛不存在
+var ErrContestNotFound = errors.New("竞赛不存在")
+
+// ContestRepository 竞赛数据访问接口
+type ContestRepository interface {
+	// Create 创建竞赛
+	Create(ctx context.Context, contest *model.Contest) error
+
+	// GetByID 根据ID获取竞赛，包含报名用户；不存在时返回ErrContestNotFound
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Contest, error)
+
+	// Update 更新竞赛信息、题目和设置，报名用户不变
+	Update(ctx context.Context, contest *model.Contest) error
+
+	// Delete 删除竞赛
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// List 分页查询竞赛列表，按开始时间倒序，不包含报名用户
+	// filters支持type、created_by、keyword，以及status(upcoming/running/ended，按当前时间计算)
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Contest, int64, error)
+
+	// AddParticipant 报名竞赛，重复报名不报错
+	AddParticipant(ctx context.Context, contestID, userID primitive.ObjectID) error
+
+	// IsParticipant 用户是否已报名竞赛
+	IsParticipant(ctx context.Context, contestID, userID primitive.ObjectID) (bool, error)
+
+	// SetScoreboardUnfrozen 设置封榜结果是否已全部揭晓
+	SetScoreboardUnfrozen(ctx context.Context, id primitive.ObjectID, unfrozen bool) error
+}
@@ -0,0 +1,50 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrCourseNotFound 课程不存在
+	ErrCourseNotFound = errors.New("课程不存在")
+	// ErrJoinCodeExists 邀请码与其他课程重复
+	ErrJoinCodeExists = errors.New("邀请码已被使用")
+)
+
+// CourseRepository 课程数据访问接口
+type CourseRepository interface {
+	// Create 创建课程，邀请码冲突时返回ErrJoinCodeExists
+	Create(ctx context.Context, course *model.Course) error
+
+	// GetByID 根据ID获取课程，包含成员；不存在时返回ErrCourseNotFound
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Course, error)
+
+	// GetByJoinCode 根据邀请码获取课程，不存在时返回ErrCourseNotFound
+	GetByJoinCode(ctx context.Context, code string) (*model.Course, error)
+
+	// Update 更新课程信息和邀请码，成员不变；邀请码冲突时返回ErrJoinCodeExists
+	Update(ctx context.Context, course *model.Course) error
+
+	// Delete 删除课程
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// List 分页查询课程列表，按开课时间倒序
+	// filters支持member(课程成员的用户ID)、term、class_name、keyword(课程名或班级名)
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Course, int64, error)
+
+	// AddMembers 以指定身份(model.CourseRole*)加入课程，已有其他身份的成员改为该身份，重复加入不报错
+	AddMembers(ctx context.Context, id primitive.ObjectID, role string, userIDs []primitive.ObjectID) error
+
+	// RemoveMember 将用户移出课程，用户不是成员时不报错
+	RemoveMember(ctx context.Context, id, userID primitive.ObjectID) error
+
+	// StudentIDsByClass 班级名称为className的全部课程的学生，不重复
+	StudentIDsByClass(ctx context.Context, className string) ([]primitive.ObjectID, error)
+
+	// Teaches staffID是否是studentID所在的某门课程的教师或助教
+	Teaches(ctx context.Context, staffID, studentID primitive.ObjectID) (bool, error)
+}
@@ -0,0 +1,56 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JudgeTaskRepository 判题任务状态数据访问接口 (judge_queue集合)
+// 任务状态流转：PENDING -> PROCESSING -> COMPLETED / FAILED，
+// 失败重试时 PROCESSING -> PENDING，重新判题时任意状态 -> PENDING
+type JudgeTaskRepository interface {
+	// Create 创建判题任务，同一提交的任务已存在时不做修改
+	Create(ctx context.Context, task *model.JudgeTask) error
+
+	// Reset 重新判题时将任务重置为PENDING，通道、优先级和入队时间取自task，清除重试次数、心跳和错误信息
+	// 任务不存在(提交早于judge_queue)时创建；正由判题机处理的任务被收回，该判题机在下次心跳时停止判题
+	Reset(ctx context.Context, task *model.JudgeTask) error
+
+	// GetBySubmissionID 根据提交ID获取判题任务
+	GetBySubmissionID(ctx context.Context, submissionID primitive.ObjectID) (*model.JudgeTask, error)
+
+	// Acquire 判题机领取PENDING任务，转为PROCESSING并分配给judgeID
+	// 任务已结束或正由其他判题机处理时返回nil（心跳超时的任务由ListStale回收）
+	Acquire(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (*model.JudgeTask, error)
+
+	// Heartbeat 刷新心跳和进度，任务已不属于judgeID时返回false
+	Heartbeat(ctx context.Context, submissionID primitive.ObjectID, judgeID string, progress model.JudgeProgress) (bool, error)
+
+	// Complete 标记任务完成，任务已不属于judgeID时返回false
+	Complete(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (bool, error)
+
+	// Release 判题机退出时归还任务，任务回到PENDING且不计入重试次数
+	Release(ctx context.Context, submissionID primitive.ObjectID, judgeID string) error
+
+	// RecordFailure 记录判题失败
+	// retryAt非空时重试次数+1并回到PENDING，到期后重新发布；否则标记为FAILED
+	// 任务已不属于judgeID时返回false
+	RecordFailure(ctx context.Context, submissionID primitive.ObjectID, judgeID string, info model.JudgeErrorInfo, retryAt *time.Time) (bool, error)
+
+	// ListStale 查询心跳早于staleBefore的PROCESSING任务
+	ListStale(ctx context.Context, staleBefore time.Time, limit int) ([]*model.JudgeTask, error)
+
+	// ClaimDueRetry 领取一个重试时间已到的PENDING任务用于重新发布
+	// 领取后next_retry_at被推迟到leaseUntil，重新发布失败或调度器崩溃时会被再次领取；无到期任务时返回nil
+	ClaimDueRetry(ctx context.Context, now, leaseUntil time.Time) (*model.JudgeTask, error)
+
+	// ClearRetry 重新发布成功后清除重试时间
+	ClearRetry(ctx context.Context, submissionID primitive.ObjectID, leaseUntil time.Time) error
+
+	// LaneStats 按判题通道统计任务数和排队时间(since之后开始判题的任务)
+	LaneStats(ctx context.Context, since time.Time) ([]*model.JudgeLaneStats, error)
+}
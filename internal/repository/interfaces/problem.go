@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrProblemNotFound 题目不存在
+var ErrProblemNotFound = errors.New("题目不存在")
+
+// ProblemRepository 题目数据访问接口
+type ProblemRepository interface {
+	// Create 创建题目
+	Create(ctx context.Context, problem *model.Problem) error
+
+	// GetByID 根据ID获取题目，不存在时返回ErrProblemNotFound
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Problem, error)
+
+	// Update 更新题目内容、测试用例和判题约束(不包括统计信息)，不存在时返回ErrProblemNotFound
+	Update(ctx context.Context, problem *model.Problem) error
+
+	// Delete 删除题目，不存在时返回ErrProblemNotFound
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// List 分页查询题目列表，filters支持 difficulty、is_public、tag、created_by、keyword
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Problem, int64, error)
+
+	// UpdateStats 更新题目统计信息
+	UpdateStats(ctx context.Context, problemID primitive.ObjectID, stats model.ProblemStats) error
+}
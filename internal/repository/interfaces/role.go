@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+)
+
+// ErrRoleNotFound 角色不存在
+var ErrRoleNotFound = errors.New("角色不存在")
+
+// RoleRepository 角色数据访问接口
+type RoleRepository interface {
+	// List 获取全部保存在数据库中的角色，按名称排序
+	List(ctx context.Context) ([]*model.Role, error)
+
+	// Upsert 按名称创建或更新角色
+	Upsert(ctx context.Context, role *model.Role) error
+
+	// Delete 按名称删除角色，不存在时返回ErrRoleNotFound
+	Delete(ctx context.Context, name string) error
+}
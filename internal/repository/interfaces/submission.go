@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrStatusTransition 提交状态不允许从当前状态转换到目标状态
+var ErrStatusTransition = errors.New("提交状态不允许回退")
+
+// SubmissionRepository 提交记录数据访问接口
+// 状态流转：PENDING -> JUDGING -> 判题结果，重试时 JUDGING -> PENDING；
+// 已有判题结果的提交只能回到PENDING(重新判题)，SYSTEM_ERROR可以重新进入判题。
+// 不允许的转换返回ErrStatusTransition，状态检查与更新在同一次写操作中完成
+type SubmissionRepository interface {
+	// Create 创建提交记录，状态默认为PENDING，code_length由代码计算
+	Create(ctx context.Context, submission *model.Submission) error
+
+	// GetByID 根据ID获取提交记录
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Submission, error)
+
+	// Delete 删除提交记录
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// List 分页查询提交记录，按提交时间倒序；filters支持 user_id、problem_id、contest_id、assignment_id、status、language，
+	// 以及按提交时间筛选的submitted_from(包含)、submitted_to(不包含)；user_id等字段可以传入{"$in": [...]}
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Submission, int64, error)
+
+	// Count 统计符合条件的提交记录数，filters同List
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+
+	// UpdateStatus 更新提交状态
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error
+
+	// MarkRejudge 将提交重置为PENDING以重新判题，提交正在判题(PENDING或JUDGING)时返回ErrStatusTransition
+	MarkRejudge(ctx context.Context, id primitive.ObjectID) error
+
+	// UpdateResult 写入判题结果(状态、得分、资源使用、编译信息和测试结果)
+	UpdateResult(ctx context.Context, submission *model.Submission) error
+}
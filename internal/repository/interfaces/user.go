@@ -2,19 +2,33 @@ package interfaces
 
 import (
 	"context"
+	"errors"
 	"zhku-oj/internal/model"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrUserNotFound 用户不存在
+var ErrUserNotFound = errors.New("用户不存在")
+
+// 用户名、邮箱、学号的唯一索引冲突，ExistsBy*检查之后并发创建或修改用户时返回
+var (
+	ErrUsernameExists  = errors.New("用户名已存在")
+	ErrEmailExists     = errors.New("邮箱已存在")
+	ErrStudentIDExists = errors.New("学号已存在")
+)
+
 // UserRepository 用户数据访问接口 (类似Spring的@Repository)
 type UserRepository interface {
-	// Create 创建用户 (类似Spring的save方法)
+	// Create 创建用户 (类似Spring的save方法)，唯一字段冲突时返回ErrUsernameExists等错误
 	Create(ctx context.Context, user *model.User) error
 
-	// GetByID 根据ID获取用户 (类似Spring的findById)
+	// GetByID 根据ID获取用户 (类似Spring的findById)，不存在时返回ErrUserNotFound
 	GetByID(ctx context.Context, id primitive.ObjectID) (*model.User, error)
 
+	// GetByIDs 批量获取用户，不包含密码；不存在的用户不返回
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error)
+
 	// GetByUsername 根据用户名获取用户 (类似Spring的findByUsername)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 
@@ -24,16 +38,18 @@ type UserRepository interface {
 	// GetByEmail 根据邮箱获取用户 (类似Spring的findByEmail)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 
-	// Update 更新用户 (类似Spring的save方法)
+	// Update 更新用户 (类似Spring的save方法)，唯一字段冲突时返回ErrUsernameExists等错误
 	Update(ctx context.Context, user *model.User) error
 
-	// UpdatePassword 更新密码
-	UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error
+	// UpdatePassword 更新密码，mustChange为true时要求用户下次登录后修改密码
+	UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string, mustChange bool) error
 
 	// Delete 删除用户 (类似Spring的deleteById)
 	Delete(ctx context.Context, id primitive.ObjectID) error
 
 	// List 分页查询用户列表 (类似Spring的findAll with Pageable)
+	// filters支持role、class、grade、is_active、keyword；同时指定class_members(用户ID列表)时，
+	// class匹配班级字段为该班级或在class_members中的用户
 	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.User, int64, error)
 
 	// UpdateStats 更新用户统计信息
@@ -0,0 +1,196 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 作业仓储层
+type assignmentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAssignmentRepository 创建作业仓储
+func NewAssignmentRepository(client *mongo.Client, database string) interfaces.AssignmentRepository {
+	return &assignmentRepository{
+		collection: client.Database(database).Collection("assignments"),
+	}
+}
+
+// Create 创建作业
+func (r *assignmentRepository) Create(ctx context.Context, assignment *model.Assignment) error {
+	assignment.CreatedAt = time.Now()
+	assignment.UpdatedAt = assignment.CreatedAt
+	if assignment.LatePenalty == nil {
+		assignment.LatePenalty = []model.LatePenaltyStep{}
+	}
+	if assignment.Extensions == nil {
+		assignment.Extensions = []model.AssignmentExtension{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, assignment)
+	if err != nil {
+		return fmt.Errorf("创建作业失败: %w", err)
+	}
+
+	assignment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取作业
+func (r *assignmentRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Assignment, error) {
+	var assignment model.Assignment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&assignment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, interfaces.ErrAssignmentNotFound
+		}
+		return nil, fmt.Errorf("查询作业失败: %w", err)
+	}
+	return &assignment, nil
+}
+
+// Update 更新作业，延期由SetExtension和RemoveExtension单独维护
+func (r *assignmentRepository) Update(ctx context.Context, assignment *model.Assignment) error {
+	assignment.UpdatedAt = time.Now()
+	if assignment.LatePenalty == nil {
+		assignment.LatePenalty = []model.LatePenaltyStep{}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"title":        assignment.Title,
+			"description":  assignment.Description,
+			"problems":     assignment.Problems,
+			"open_time":    assignment.OpenTime,
+			"due_time":     assignment.DueTime,
+			"close_time":   assignment.CloseTime,
+			"late_penalty": assignment.LatePenalty,
+			"scoring_mode": assignment.ScoringMode,
+			"updated_at":   assignment.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": assignment.ID}, update)
+	if err != nil {
+		return fmt.Errorf("更新作业失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrAssignmentNotFound
+	}
+	return nil
+}
+
+// Delete 删除作业
+func (r *assignmentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("删除作业失败: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrAssignmentNotFound
+	}
+	return nil
+}
+
+// DeleteByCourse 删除课程的全部作业
+func (r *assignmentRepository) DeleteByCourse(ctx context.Context, courseID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"course_id": courseID})
+	if err != nil {
+		return 0, fmt.Errorf("删除课程作业失败: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// List 分页查询作业列表，按截止时间倒序
+func (r *assignmentRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Assignment, int64, error) {
+	filter := bson.M{}
+	for key, value := range filters {
+		switch key {
+		case "course_id":
+			filter["course_id"] = value
+		case "opened_before":
+			filter["open_time"] = bson.M{"$lte": value}
+		}
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "due_time", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询作业列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var assignments []*model.Assignment
+	if err = cursor.All(ctx, &assignments); err != nil {
+		return nil, 0, fmt.Errorf("解析作业数据失败: %w", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计作业总数失败: %w", err)
+	}
+
+	return assignments, total, nil
+}
+
+// SetExtension 设置学生的延期
+// 先移除该学生原有的延期再追加，两步之间读到的作业可能暂时没有该学生的延期
+func (r *assignmentRepository) SetExtension(ctx context.Context, id primitive.ObjectID, extension model.AssignmentExtension) error {
+	if _, err := r.RemoveExtension(ctx, id, extension.UserID); err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$push": bson.M{"extensions": extension},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("设置延期失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrAssignmentNotFound
+	}
+	return nil
+}
+
+// RemoveExtension 取消学生的延期
+func (r *assignmentRepository) RemoveExtension(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	update := bson.M{
+		"$pull": bson.M{"extensions": bson.M{"user_id": userID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "extensions.user_id": userID}, update)
+	if err != nil {
+		return false, fmt.Errorf("取消延期失败: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	// 未匹配时区分作业不存在和该学生没有延期
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("查询作业失败: %w", err)
+	}
+	if count == 0 {
+		return false, interfaces.ErrAssignmentNotFound
+	}
+	return false, nil
+}
@@ -0,0 +1,197 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 竞赛仓储层
+type contestRepository struct {
+	collection *mongo.Collection
+}
+
+// NewContestRepository 创建竞赛仓储
+func NewContestRepository(client *mongo.Client, database string) interfaces.ContestRepository {
+	return &contestRepository{
+		collection: client.Database(database).Collection("contests"),
+	}
+}
+
+// Create 创建竞赛
+func (r *contestRepository) Create(ctx context.Context, contest *model.Contest) error {
+	contest.CreatedAt = time.Now()
+	contest.UpdatedAt = contest.CreatedAt
+	if contest.ProblemIDs == nil {
+		contest.ProblemIDs = []primitive.ObjectID{}
+	}
+	if contest.ParticipantIDs == nil {
+		contest.ParticipantIDs = []primitive.ObjectID{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, contest)
+	if err != nil {
+		return fmt.Errorf("创建竞赛失败: %w", err)
+	}
+
+	contest.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取竞赛
+func (r *contestRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Contest, error) {
+	var contest model.Contest
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&contest)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, interfaces.ErrContestNotFound
+		}
+		return nil, fmt.Errorf("查询竞赛失败: %w", err)
+	}
+	return &contest, nil
+}
+
+// Update 更新竞赛信息
+func (r *contestRepository) Update(ctx context.Context, contest *model.Contest) error {
+	contest.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"title":       contest.Title,
+			"description": contest.Description,
+			"type":        contest.Type,
+			"classes":     contest.Classes,
+			"problem_ids": contest.ProblemIDs,
+			"settings":    contest.Settings,
+			"start_time":  contest.StartTime,
+			"end_time":    contest.EndTime,
+			"updated_at":  contest.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": contest.ID}, update)
+	if err != nil {
+		return fmt.Errorf("更新竞赛失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrContestNotFound
+	}
+	return nil
+}
+
+// Delete 删除竞赛
+func (r *contestRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("删除竞赛失败: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrContestNotFound
+	}
+	return nil
+}
+
+// List 分页查询竞赛列表，按开始时间倒序
+func (r *contestRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Contest, int64, error) {
+	filter := bson.M{}
+	now := time.Now()
+	for key, value := range filters {
+		switch key {
+		case "type", "created_by":
+			filter[key] = value
+		case "status":
+			switch value {
+			case model.ContestStatusUpcoming:
+				filter["start_time"] = bson.M{"$gt": now}
+			case model.ContestStatusRunning:
+				filter["start_time"] = bson.M{"$lte": now}
+				filter["end_time"] = bson.M{"$gt": now}
+			case model.ContestStatusEnded:
+				filter["end_time"] = bson.M{"$lte": now}
+			}
+		case "keyword": // 标题关键词搜索
+			if keyword, ok := value.(string); ok && keyword != "" {
+				filter["title"] = bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}
+			}
+		}
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "start_time", Value: -1}}).
+		SetProjection(bson.M{"participant_ids": 0})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询竞赛列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var contests []*model.Contest
+	if err = cursor.All(ctx, &contests); err != nil {
+		return nil, 0, fmt.Errorf("解析竞赛数据失败: %w", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计竞赛总数失败: %w", err)
+	}
+
+	return contests, total, nil
+}
+
+// AddParticipant 报名竞赛
+func (r *contestRepository) AddParticipant(ctx context.Context, contestID, userID primitive.ObjectID) error {
+	update := bson.M{
+		"$addToSet": bson.M{"participant_ids": userID},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": contestID}, update)
+	if err != nil {
+		return fmt.Errorf("报名竞赛失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrContestNotFound
+	}
+	return nil
+}
+
+// IsParticipant 用户是否已报名竞赛
+func (r *contestRepository) IsParticipant(ctx context.Context, contestID, userID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": contestID, "participant_ids": userID})
+	if err != nil {
+		return false, fmt.Errorf("查询竞赛报名失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// SetScoreboardUnfrozen 设置封榜结果是否已全部揭晓
+func (r *contestRepository) SetScoreboardUnfrozen(ctx context.Context, id primitive.ObjectID, unfrozen bool) error {
+	update := bson.M{
+		"$set": bson.M{
+			"scoreboard_unfrozen": unfrozen,
+			"updated_at":          time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("更新竞赛封榜状态失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrContestNotFound
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// courseMemberFields 课程成员身份对应的字段
+var courseMemberFields = map[string]string{
+	model.CourseRoleTeacher: "teacher_ids",
+	model.CourseRoleTA:      "ta_ids",
+	model.CourseRoleStudent: "student_ids",
+}
+
+// 课程仓储层
+type courseRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCourseRepository 创建课程仓储
+func NewCourseRepository(client *mongo.Client, database string) interfaces.CourseRepository {
+	return &courseRepository{
+		collection: client.Database(database).Collection("courses"),
+	}
+}
+
+// Create 创建课程
+func (r *courseRepository) Create(ctx context.Context, course *model.Course) error {
+	course.CreatedAt = time.Now()
+	course.UpdatedAt = course.CreatedAt
+	if course.TeacherIDs == nil {
+		course.TeacherIDs = []primitive.ObjectID{}
+	}
+	if course.TAIDs == nil {
+		course.TAIDs = []primitive.ObjectID{}
+	}
+	if course.StudentIDs == nil {
+		course.StudentIDs = []primitive.ObjectID{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, course)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return interfaces.ErrJoinCodeExists
+		}
+		return fmt.Errorf("创建课程失败: %w", err)
+	}
+
+	course.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取课程
+func (r *courseRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Course, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByJoinCode 根据邀请码获取课程
+func (r *courseRepository) GetByJoinCode(ctx context.Context, code string) (*model.Course, error) {
+	return r.findOne(ctx, bson.M{"join_code": code})
+}
+
+func (r *courseRepository) findOne(ctx context.Context, filter bson.M) (*model.Course, error) {
+	var course model.Course
+	err := r.collection.FindOne(ctx, filter).Decode(&course)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, interfaces.ErrCourseNotFound
+		}
+		return nil, fmt.Errorf("查询课程失败: %w", err)
+	}
+	return &course, nil
+}
+
+// Update 更新课程信息和邀请码
+// 邀请码的唯一索引是稀疏索引，停用邀请码时删除该字段而不是置空
+func (r *courseRepository) Update(ctx context.Context, course *model.Course) error {
+	course.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"name":        course.Name,
+		"class_name":  course.ClassName,
+		"term":        course.Term,
+		"description": course.Description,
+		"start_date":  course.StartDate,
+		"end_date":    course.EndDate,
+		"updated_at":  course.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if course.JoinCode != "" {
+		set["join_code"] = course.JoinCode
+	} else {
+		update["$unset"] = bson.M{"join_code": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": course.ID}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return interfaces.ErrJoinCodeExists
+		}
+		return fmt.Errorf("更新课程失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrCourseNotFound
+	}
+	return nil
+}
+
+// Delete 删除课程
+func (r *courseRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("删除课程失败: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrCourseNotFound
+	}
+	return nil
+}
+
+// List 分页查询课程列表，按开课时间倒序
+func (r *courseRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Course, int64, error) {
+	var conditions []bson.M
+	for key, value := range filters {
+		switch key {
+		case "term", "class_name":
+			conditions = append(conditions, bson.M{key: value})
+		case "member":
+			conditions = append(conditions, bson.M{"$or": bson.A{
+				bson.M{"teacher_ids": value},
+				bson.M{"ta_ids": value},
+				bson.M{"student_ids": value},
+			}})
+		case "keyword": // 课程名或班级名关键词搜索
+			if keyword, ok := value.(string); ok && keyword != "" {
+				pattern := bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}
+				conditions = append(conditions, bson.M{"$or": bson.A{
+					bson.M{"name": pattern},
+					bson.M{"class_name": pattern},
+				}})
+			}
+		}
+	}
+	filter := bson.M{}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "start_date", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询课程列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var courses []*model.Course
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, 0, fmt.Errorf("解析课程数据失败: %w", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计课程总数失败: %w", err)
+	}
+
+	return courses, total, nil
+}
+
+// AddMembers 以指定身份加入课程，同时从其他身份中移除
+func (r *courseRepository) AddMembers(ctx context.Context, id primitive.ObjectID, role string, userIDs []primitive.ObjectID) error {
+	field, ok := courseMemberFields[role]
+	if !ok {
+		return fmt.Errorf("未知的课程成员身份: %s", role)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	pull := bson.M{}
+	for other, otherField := range courseMemberFields {
+		if other != role {
+			pull[otherField] = bson.M{"$in": userIDs}
+		}
+	}
+	update := bson.M{
+		"$addToSet": bson.M{field: bson.M{"$each": userIDs}},
+		"$pull":     pull,
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("添加课程成员失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrCourseNotFound
+	}
+	return nil
+}
+
+// RemoveMember 将用户移出课程
+func (r *courseRepository) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) error {
+	update := bson.M{
+		"$pull": bson.M{"teacher_ids": userID, "ta_ids": userID, "student_ids": userID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("移除课程成员失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrCourseNotFound
+	}
+	return nil
+}
+
+// StudentIDsByClass 班级名称为className的全部课程的学生
+func (r *courseRepository) StudentIDsByClass(ctx context.Context, className string) ([]primitive.ObjectID, error) {
+	// 对数组字段distinct时按元素去重
+	values, err := r.collection.Distinct(ctx, "student_ids", bson.M{"class_name": className})
+	if err != nil {
+		return nil, fmt.Errorf("查询班级学生失败: %w", err)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Teaches staffID是否是studentID所在的某门课程的教师或助教
+func (r *courseRepository) Teaches(ctx context.Context, staffID, studentID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"student_ids": studentID,
+		"$or": bson.A{
+			bson.M{"teacher_ids": staffID},
+			bson.M{"ta_ids": staffID},
+		},
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("查询任课关系失败: %w", err)
+	}
+	return count > 0, nil
+}
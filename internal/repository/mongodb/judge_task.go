@@ -0,0 +1,351 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 判题任务仓储层
+type judgeTaskRepository struct {
+	collection *mongo.Collection
+}
+
+// NewJudgeTaskRepository 创建判题任务仓储
+func NewJudgeTaskRepository(client *mongo.Client, database string) interfaces.JudgeTaskRepository {
+	return &judgeTaskRepository{
+		collection: client.Database(database).Collection("judge_queue"),
+	}
+}
+
+// Create 创建判题任务，同一提交的任务已存在时不做修改
+func (r *judgeTaskRepository) Create(ctx context.Context, task *model.JudgeTask) error {
+	now := time.Now()
+	if task.Status == "" {
+		task.Status = model.JudgeTaskPending
+	}
+	// created_at记录任务入队时间，用于统计排队时长；未指定时取当前时间
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	task.UpdatedAt = now
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"submission_id":  task.SubmissionID,
+			"status":         task.Status,
+			"lane":           task.Lane,
+			"priority":       task.Priority,
+			"retry_count":    0,
+			"assigned_judge": "",
+			"progress":       task.Progress,
+			"created_at":     task.CreatedAt,
+			"updated_at":     task.UpdatedAt,
+		},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"submission_id": task.SubmissionID}, update, opts)
+	if err != nil {
+		return fmt.Errorf("创建判题任务失败: %w", err)
+	}
+	if id, ok := result.UpsertedID.(primitive.ObjectID); ok {
+		task.ID = id
+	}
+	return nil
+}
+
+// Reset 将任务重置为PENDING，用于重新判题
+func (r *judgeTaskRepository) Reset(ctx context.Context, task *model.JudgeTask) error {
+	now := time.Now()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	task.Status = model.JudgeTaskPending
+	task.UpdatedAt = now
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":         task.Status,
+			"lane":           task.Lane,
+			"priority":       task.Priority,
+			"retry_count":    0,
+			"assigned_judge": "",
+			"progress":       model.JudgeProgress{},
+			"created_at":     task.CreatedAt,
+			"updated_at":     task.UpdatedAt,
+		},
+		"$unset": bson.M{"heartbeat": "", "next_retry_at": "", "error_info": ""},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"submission_id": task.SubmissionID}, update, opts); err != nil {
+		return fmt.Errorf("重置判题任务失败: %w", err)
+	}
+	return nil
+}
+
+// GetBySubmissionID 根据提交ID获取判题任务
+func (r *judgeTaskRepository) GetBySubmissionID(ctx context.Context, submissionID primitive.ObjectID) (*model.JudgeTask, error) {
+	var task model.JudgeTask
+	err := r.collection.FindOne(ctx, bson.M{"submission_id": submissionID}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("判题任务不存在")
+		}
+		return nil, fmt.Errorf("查询判题任务失败: %w", err)
+	}
+	return &task, nil
+}
+
+// Acquire 判题机领取PENDING任务
+func (r *judgeTaskRepository) Acquire(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (*model.JudgeTask, error) {
+	now := time.Now()
+	filter := bson.M{
+		"submission_id": submissionID,
+		"status":        model.JudgeTaskPending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":         model.JudgeTaskProcessing,
+			"assigned_judge": judgeID,
+			"heartbeat":      now,
+			"progress": model.JudgeProgress{
+				Stage:     model.JudgeStageCompiling,
+				StartTime: &now,
+			},
+			"updated_at": now,
+		},
+		"$unset": bson.M{"next_retry_at": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var task model.JudgeTask
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("领取判题任务失败: %w", err)
+	}
+	return &task, nil
+}
+
+// Heartbeat 刷新心跳和进度
+func (r *judgeTaskRepository) Heartbeat(ctx context.Context, submissionID primitive.ObjectID, judgeID string, progress model.JudgeProgress) (bool, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"heartbeat":                  now,
+			"progress.stage":             progress.Stage,
+			"progress.current_test_case": progress.CurrentTestCase,
+			"progress.total_test_cases":  progress.TotalTestCases,
+			"updated_at":                 now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, r.ownedBy(submissionID, judgeID), update)
+	if err != nil {
+		return false, fmt.Errorf("更新判题心跳失败: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Complete 标记任务完成
+func (r *judgeTaskRepository) Complete(ctx context.Context, submissionID primitive.ObjectID, judgeID string) (bool, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":         model.JudgeTaskCompleted,
+			"progress.stage": model.JudgeStageCompleted,
+			"updated_at":     now,
+		},
+		"$unset": bson.M{"heartbeat": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, r.ownedBy(submissionID, judgeID), update)
+	if err != nil {
+		return false, fmt.Errorf("更新判题任务状态失败: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release 归还任务
+func (r *judgeTaskRepository) Release(ctx context.Context, submissionID primitive.ObjectID, judgeID string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":         model.JudgeTaskPending,
+			"assigned_judge": "",
+			"updated_at":     time.Now(),
+		},
+		"$unset": bson.M{"heartbeat": ""},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, r.ownedBy(submissionID, judgeID), update)
+	if err != nil {
+		return fmt.Errorf("归还判题任务失败: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure 记录判题失败
+func (r *judgeTaskRepository) RecordFailure(ctx context.Context, submissionID primitive.ObjectID, judgeID string, info model.JudgeErrorInfo, retryAt *time.Time) (bool, error) {
+	set := bson.M{
+		"error_info.error_type":    info.ErrorType,
+		"error_info.error_message": info.ErrorMessage,
+		"assigned_judge":           "",
+		"updated_at":               time.Now(),
+	}
+	inc := bson.M{"error_info.error_count": 1}
+	if retryAt != nil {
+		set["status"] = model.JudgeTaskPending
+		set["next_retry_at"] = *retryAt
+		inc["retry_count"] = 1
+	} else {
+		set["status"] = model.JudgeTaskFailed
+	}
+	update := bson.M{
+		"$set":   set,
+		"$inc":   inc,
+		"$unset": bson.M{"heartbeat": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, r.ownedBy(submissionID, judgeID), update)
+	if err != nil {
+		return false, fmt.Errorf("记录判题失败信息失败: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ListStale 查询心跳超时的任务
+func (r *judgeTaskRepository) ListStale(ctx context.Context, staleBefore time.Time, limit int) ([]*model.JudgeTask, error) {
+	filter := bson.M{
+		"status":    model.JudgeTaskProcessing,
+		"heartbeat": bson.M{"$lt": staleBefore},
+	}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "heartbeat", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询超时判题任务失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*model.JudgeTask
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("解析判题任务数据失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// ClaimDueRetry 领取到期的重试任务
+func (r *judgeTaskRepository) ClaimDueRetry(ctx context.Context, now, leaseUntil time.Time) (*model.JudgeTask, error) {
+	filter := bson.M{
+		"status":        model.JudgeTaskPending,
+		"next_retry_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"next_retry_at": leaseUntil.Truncate(time.Millisecond), // MongoDB时间精度为毫秒，与ClearRetry保持一致
+			"updated_at":    now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "next_retry_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var task model.JudgeTask
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("领取重试任务失败: %w", err)
+	}
+	return &task, nil
+}
+
+// ClearRetry 清除重试时间
+func (r *judgeTaskRepository) ClearRetry(ctx context.Context, submissionID primitive.ObjectID, leaseUntil time.Time) error {
+	filter := bson.M{
+		"submission_id": submissionID,
+		"status":        model.JudgeTaskPending,
+		"next_retry_at": leaseUntil.Truncate(time.Millisecond),
+	}
+	update := bson.M{
+		"$unset": bson.M{"next_retry_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("清除重试时间失败: %w", err)
+	}
+	return nil
+}
+
+// LaneStats 按判题通道统计任务数和排队时间
+func (r *judgeTaskRepository) LaneStats(ctx context.Context, since time.Time) ([]*model.JudgeLaneStats, error) {
+	started := bson.M{"$gte": bson.A{"$progress.start_time", since}}
+	wait := bson.M{"$cond": bson.A{
+		started,
+		bson.M{"$subtract": bson.A{"$progress.start_time", "$created_at"}},
+		nil,
+	}}
+	countIf := func(cond interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"status": bson.M{"$in": bson.A{model.JudgeTaskPending, model.JudgeTaskProcessing}}},
+			bson.M{"progress.start_time": bson.M{"$gte": since}},
+		}}}},
+		// 没有lane字段的历史任务归入日常练习通道
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"$ifNull": bson.A{"$lane", "practice"}},
+			"pending":     countIf(bson.M{"$eq": bson.A{"$status", model.JudgeTaskPending}}),
+			"processing":  countIf(bson.M{"$eq": bson.A{"$status", model.JudgeTaskProcessing}}),
+			"started":     countIf(started),
+			"avg_wait_ms": bson.M{"$avg": wait},
+			"max_wait_ms": bson.M{"$max": wait},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"pending":     1,
+			"processing":  1,
+			"started":     1,
+			"avg_wait_ms": bson.M{"$toLong": bson.M{"$ifNull": bson.A{"$avg_wait_ms", 0}}},
+			"max_wait_ms": bson.M{"$toLong": bson.M{"$ifNull": bson.A{"$max_wait_ms", 0}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("统计判题通道失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stats []*model.JudgeLaneStats
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("解析判题通道统计失败: %w", err)
+	}
+	return stats, nil
+}
+
+// ownedBy 由指定判题机处理中的任务
+func (r *judgeTaskRepository) ownedBy(submissionID primitive.ObjectID, judgeID string) bson.M {
+	return bson.M{
+		"submission_id":  submissionID,
+		"status":         model.JudgeTaskProcessing,
+		"assigned_judge": judgeID,
+	}
+}
@@ -0,0 +1,163 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 题目仓储层
+type problemRepository struct {
+	collection *mongo.Collection
+}
+
+// NewProblemRepository 创建题目仓储
+func NewProblemRepository(client *mongo.Client, database string) interfaces.ProblemRepository {
+	return &problemRepository{
+		collection: client.Database(database).Collection("problems"),
+	}
+}
+
+// Create 创建题目
+func (r *problemRepository) Create(ctx context.Context, problem *model.Problem) error {
+	problem.CreatedAt = time.Now()
+	problem.UpdatedAt = problem.CreatedAt
+
+	result, err := r.collection.InsertOne(ctx, problem)
+	if err != nil {
+		return fmt.Errorf("创建题目失败: %w", err)
+	}
+
+	problem.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取题目
+func (r *problemRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Problem, error) {
+	var problem model.Problem
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&problem)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, interfaces.ErrProblemNotFound
+		}
+		return nil, fmt.Errorf("查询题目失败: %w", err)
+	}
+	return &problem, nil
+}
+
+// Update 更新题目内容、测试用例和判题约束，统计信息由UpdateStats维护
+func (r *problemRepository) Update(ctx context.Context, problem *model.Problem) error {
+	problem.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"title":         problem.Title,
+			"description":   problem.Description,
+			"input_format":  problem.InputFormat,
+			"output_format": problem.OutputFormat,
+			"sample_input":  problem.SampleInput,
+			"sample_output": problem.SampleOutput,
+			"time_limit":    problem.TimeLimit,
+			"memory_limit":  problem.MemoryLimit,
+			"difficulty":    problem.Difficulty,
+			"tags":          problem.Tags,
+			"test_cases":    problem.TestCases,
+			"subtasks":      problem.Subtasks,
+			"constraints":   problem.Constraints,
+			"is_public":     problem.IsPublic,
+			"updated_at":    problem.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": problem.ID}, update)
+	if err != nil {
+		return fmt.Errorf("更新题目失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return interfaces.ErrProblemNotFound
+	}
+	return nil
+}
+
+// Delete 删除题目
+func (r *problemRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("删除题目失败: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrProblemNotFound
+	}
+	return nil
+}
+
+// List 分页查询题目列表，按创建时间倒序
+func (r *problemRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Problem, int64, error) {
+	filter := bson.M{}
+	for key, value := range filters {
+		switch key {
+		case "difficulty":
+			filter["difficulty"] = value
+		case "is_public":
+			filter["is_public"] = value
+		case "tag":
+			filter["tags"] = value
+		case "created_by":
+			filter["created_by"] = value
+		case "keyword": // 标题关键词搜索
+			if keyword, ok := value.(string); ok && keyword != "" {
+				filter["title"] = bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}
+			}
+		}
+	}
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询题目列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var problems []*model.Problem
+	if err = cursor.All(ctx, &problems); err != nil {
+		return nil, 0, fmt.Errorf("解析题目数据失败: %w", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计题目总数失败: %w", err)
+	}
+
+	return problems, total, nil
+}
+
+// UpdateStats 更新题目统计信息
+func (r *problemRepository) UpdateStats(ctx context.Context, problemID primitive.ObjectID, stats model.ProblemStats) error {
+	update := bson.M{
+		"$set": bson.M{
+			"stats":      stats,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": problemID}, update)
+	if err != nil {
+		return fmt.Errorf("更新题目统计失败: %w", err)
+	}
+	return nil
+}
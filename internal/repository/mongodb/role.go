@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 角色仓储层
+type roleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRoleRepository 创建角色仓储
+func NewRoleRepository(client *mongo.Client, database string) interfaces.RoleRepository {
+	return &roleRepository{
+		collection: client.Database(database).Collection("roles"),
+	}
+}
+
+// List 获取全部角色
+func (r *roleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("查询角色列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var roles []*model.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, fmt.Errorf("解析角色列表失败: %w", err)
+	}
+	return roles, nil
+}
+
+// Upsert 按名称创建或更新角色，创建时间只在首次创建时设置
+func (r *roleRepository) Upsert(ctx context.Context, role *model.Role) error {
+	now := time.Now()
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"display_name": role.DisplayName,
+			"description":  role.Description,
+			"permissions":  role.Permissions,
+			"updated_at":   now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved model.Role
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": role.Name}, update, opts).Decode(&saved); err != nil {
+		return fmt.Errorf("保存角色失败: %w", err)
+	}
+
+	role.ID = saved.ID
+	role.CreatedAt = saved.CreatedAt
+	role.UpdatedAt = saved.UpdatedAt
+	return nil
+}
+
+// Delete 按名称删除角色
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return fmt.Errorf("删除角色失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return interfaces.ErrRoleNotFound
+	}
+	return nil
+}
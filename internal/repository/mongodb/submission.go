@@ -0,0 +1,224 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 提交记录仓储层
+type submissionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSubmissionRepository 创建提交记录仓储
+func NewSubmissionRepository(client *mongo.Client, database string) interfaces.SubmissionRepository {
+	return &submissionRepository{
+		collection: client.Database(database).Collection("submissions"),
+	}
+}
+
+// Create 创建提交记录
+func (r *submissionRepository) Create(ctx context.Context, submission *model.Submission) error {
+	submission.CodeLength = utf8.RuneCountInString(submission.Code)
+	if submission.Status == "" {
+		submission.Status = model.StatusPending
+	}
+	if submission.SubmittedAt.IsZero() {
+		submission.SubmittedAt = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, submission)
+	if err != nil {
+		return fmt.Errorf("创建提交记录失败: %w", err)
+	}
+
+	submission.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取提交记录
+func (r *submissionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Submission, error) {
+	var submission model.Submission
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&submission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("提交记录不存在")
+		}
+		return nil, fmt.Errorf("查询提交记录失败: %w", err)
+	}
+	return &submission, nil
+}
+
+// Delete 删除提交记录
+func (r *submissionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("删除提交记录失败: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("提交记录不存在")
+	}
+	return nil
+}
+
+// List 分页查询提交记录，按提交时间倒序
+func (r *submissionRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Submission, int64, error) {
+	filter := submissionFilter(filters)
+
+	skip := (page - 1) * pageSize
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "submitted_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询提交列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var submissions []*model.Submission
+	if err = cursor.All(ctx, &submissions); err != nil {
+		return nil, 0, fmt.Errorf("解析提交数据失败: %w", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计提交总数失败: %w", err)
+	}
+
+	return submissions, total, nil
+}
+
+// Count 统计符合条件的提交记录数
+func (r *submissionRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	total, err := r.collection.CountDocuments(ctx, submissionFilter(filters))
+	if err != nil {
+		return 0, fmt.Errorf("统计提交总数失败: %w", err)
+	}
+	return total, nil
+}
+
+// submissionFilter 将查询条件转换为MongoDB过滤条件，忽略不支持的字段
+func submissionFilter(filters map[string]interface{}) bson.M {
+	filter := bson.M{}
+	for key, value := range filters {
+		switch key {
+		case "user_id", "problem_id", "contest_id", "assignment_id", "status", "language":
+			filter[key] = value
+		case "submitted_from": // 提交时间范围，包含起点不包含终点
+			addTimeBound(filter, "$gte", value)
+		case "submitted_to":
+			addTimeBound(filter, "$lt", value)
+		}
+	}
+	return filter
+}
+
+// addTimeBound 在提交时间条件中加入一个边界
+func addTimeBound(filter bson.M, op string, value interface{}) {
+	bound, ok := filter["submitted_at"].(bson.M)
+	if !ok {
+		bound = bson.M{}
+		filter["submitted_at"] = bound
+	}
+	bound[op] = value
+}
+
+// UpdateStatus 更新提交状态，不允许的状态转换返回ErrStatusTransition
+func (r *submissionRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	update := bson.M{
+		"$set": bson.M{"status": status},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, transitionFilter(id, status), update)
+	if err != nil {
+		return fmt.Errorf("更新提交状态失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return r.transitionError(ctx, id, status)
+	}
+	return nil
+}
+
+// MarkRejudge 将提交重置为PENDING以重新判题，提交正在判题(PENDING或JUDGING)时返回ErrStatusTransition
+// 状态检查与更新在同一次写操作中完成，并发的重新判题只有一个成功
+func (r *submissionRepository) MarkRejudge(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$nin": []string{model.StatusPending, model.StatusJudging}},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": model.StatusPending},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("更新提交状态失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return r.transitionError(ctx, id, model.StatusPending)
+	}
+	return nil
+}
+
+// UpdateResult 写入判题结果，不允许的状态转换返回ErrStatusTransition
+func (r *submissionRepository) UpdateResult(ctx context.Context, submission *model.Submission) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":          submission.Status,
+			"score":           submission.Score,
+			"time_used":       submission.TimeUsed,
+			"memory_used":     submission.MemoryUsed,
+			"compile_info":    submission.CompileInfo,
+			"test_results":    submission.TestResults,
+			"subtask_results": submission.SubtaskResults,
+			"judged_at":       submission.JudgedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, transitionFilter(submission.ID, submission.Status), update)
+	if err != nil {
+		return fmt.Errorf("更新判题结果失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return r.transitionError(ctx, submission.ID, submission.Status)
+	}
+	return nil
+}
+
+// transitionFilter 允许转换到status的提交记录条件
+// 回到PENDING(重试、重新判题)不限制当前状态；其余状态只能由PENDING、JUDGING或SYSTEM_ERROR转换而来
+func transitionFilter(id primitive.ObjectID, status string) bson.M {
+	filter := bson.M{"_id": id}
+	if status != model.StatusPending {
+		filter["status"] = bson.M{"$in": []string{model.StatusPending, model.StatusJudging, model.StatusSystemError}}
+	}
+	return filter
+}
+
+// transitionError 更新未匹配时区分提交不存在和状态不允许转换
+func (r *submissionRepository) transitionError(ctx context.Context, id primitive.ObjectID, status string) error {
+	var current struct {
+		Status string `bson:"status"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&current)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("提交记录不存在")
+		}
+		return fmt.Errorf("查询提交状态失败: %w", err)
+	}
+	return fmt.Errorf("%w: %s -> %s", interfaces.ErrStatusTransition, current.Status, status)
+}
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 	"zhku-oj/internal/model"
 	"zhku-oj/internal/repository/interfaces"
@@ -32,6 +33,9 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 
 	result, err := r.collection.InsertOne(ctx, user)
 	if err != nil {
+		if dupErr := duplicateUserError(err); dupErr != nil {
+			return dupErr
+		}
 		return fmt.Errorf("创建用户失败: %w", err)
 	}
 
@@ -45,13 +49,33 @@ func (r *userRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*m
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("用户不存在")
+			return nil, interfaces.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 	return &user, nil
 }
 
+// GetByIDs 批量获取用户，不包含密码
+func (r *userRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error) {
+	if len(ids) == 0 {
+		return []*model.User{}, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"password": 0})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []*model.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("解析用户数据失败: %w", err)
+	}
+	return users, nil
+}
+
 // GetByUsername 根据用户名获取用户 (类似Spring的findByUsername)
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
@@ -97,31 +121,55 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 
 	update := bson.M{
 		"$set": bson.M{
-			"username":   user.Username,
-			"email":      user.Email,
-			"real_name":  user.RealName,
-			"role":       user.Role,
-			"class":      user.Class,
-			"grade":      user.Grade,
-			"avatar":     user.Avatar,
-			"is_active":  user.IsActive,
-			"updated_at": user.UpdatedAt,
+			"username":            user.Username,
+			"email":               user.Email,
+			"real_name":           user.RealName,
+			"role":                user.Role,
+			"class":               user.Class,
+			"grade":               user.Grade,
+			"avatar":              user.Avatar,
+			"is_active":           user.IsActive,
+			"updated_at":          user.UpdatedAt,
+			"student_id_verified": user.StudentIDVerified,
 		},
 	}
 
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
 	if err != nil {
+		if dupErr := duplicateUserError(err); dupErr != nil {
+			return dupErr
+		}
 		return fmt.Errorf("更新用户失败: %w", err)
 	}
 	return nil
 }
 
+// duplicateUserError 将唯一索引冲突转换为对应字段的错误，其他错误返回nil
+func duplicateUserError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	// 冲突的索引名出现在错误信息中，如 "index: username_1 dup key"
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "username_1"):
+		return interfaces.ErrUsernameExists
+	case strings.Contains(msg, "email_1"):
+		return interfaces.ErrEmailExists
+	case strings.Contains(msg, "student_id_1"):
+		return interfaces.ErrStudentIDExists
+	default:
+		return nil
+	}
+}
+
 // UpdatePassword 更新密码
-func (r *userRepository) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error {
+func (r *userRepository) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string, mustChange bool) error {
 	update := bson.M{
 		"$set": bson.M{
-			"password":   hashedPassword,
-			"updated_at": time.Now(),
+			"password":             hashedPassword,
+			"must_change_password": mustChange,
+			"updated_at":           time.Now(),
 		},
 	}
 
@@ -153,8 +201,15 @@ func (r *userRepository) List(ctx context.Context, page, pageSize int, filters m
 		switch key {
 		case "role":
 			filter["role"] = value
-		case "class":
-			filter["class"] = value
+		case "class": // 用户填写的班级，或加入了该班级的课程
+			if members, ok := filters["class_members"].([]primitive.ObjectID); ok && len(members) > 0 {
+				filter["$and"] = []bson.M{{"$or": []bson.M{
+					{"class": value},
+					{"_id": bson.M{"$in": members}},
+				}}}
+			} else {
+				filter["class"] = value
+			}
 		case "grade":
 			filter["grade"] = value
 		case "is_active":
@@ -177,7 +232,7 @@ func (r *userRepository) List(ctx context.Context, page, pageSize int, filters m
 	opts := options.Find().
 		SetSkip(int64(skip)).
 		SetLimit(int64(pageSize)).
-		SetSort(bson.D{{"created_at", -1}}) // 按创建时间倒序
+		SetSort(bson.D{{Key: "created_at", Value: -1}}) // 按创建时间倒序
 
 	// 执行查询
 	cursor, err := r.collection.Find(ctx, filter, opts)
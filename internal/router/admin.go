@@ -2,123 +2,172 @@ package router
 
 import (
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
 // setupAdminRoutes 设置管理员相关路由
-// 系统管理、用户管理、数据统计等功能
+// 系统管理、用户管理、角色管理、数据统计等功能，每个接口按所需权限检查
 func (rm *RouterManager) setupAdminRoutes(v1 *gin.RouterGroup) {
 	adminGroup := v1.Group("/admin")
-	adminGroup.Use(middleware.AuthRequired(), middleware.RoleRequired("admin")) // 管理员权限
+	adminGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS))
 	{
-		// ========== 系统管理 ==========
+		// ========== 系统管理（system:manage） ==========
 
 		// 管理员仪表板
 		// GET /api/v1/admin/dashboard
 		// 响应码: 0-成功, 10004-权限不足
-		adminGroup.GET("/dashboard", rm.adminHandler.Dashboard)
+		adminGroup.GET("/dashboard", middleware.Require(rbac.SystemManage), rm.adminHandler.Dashboard)
 
 		// 系统状态监控
 		// GET /api/v1/admin/system/status
 		// 响应码: 0-成功, 10004-权限不足
-		adminGroup.GET("/system/status", rm.adminHandler.SystemStatus)
+		adminGroup.GET("/system/status", middleware.Require(rbac.SystemManage), rm.adminHandler.SystemStatus)
 
 		// 系统配置管理
 		// GET /api/v1/admin/system/config
 		// PUT /api/v1/admin/system/config
 		// 响应码: 0-成功, 10004-权限不足, 10002-参数错误
-		// adminGroup.GET("/system/config", rm.adminHandler.GetSystemConfig)
-		// adminGroup.PUT("/system/config", rm.adminHandler.UpdateSystemConfig)
+		// adminGroup.GET("/system/config", middleware.Require(rbac.SystemManage), rm.adminHandler.GetSystemConfig)
+		// adminGroup.PUT("/system/config", middleware.Require(rbac.SystemManage), rm.adminHandler.UpdateSystemConfig)
 
-		// ========== 用户管理 CRUD ==========
+		// ========== 用户管理 CRUD（user:manage） ==========
 
 		// 创建用户
 		// POST /api/v1/admin/users
-		// 响应码: 0-成功, 10002-参数错误, 20002-用户已存在
-		adminGroup.POST("/users", rm.userHandler.CreateUser)
+		// 响应码: 0-成功, 10002-参数错误, 20002-用户已存在, 20007-密码强度不足
+		adminGroup.POST("/users", middleware.Require(rbac.UserManage), rm.userHandler.CreateUser)
 
 		// 获取用户列表（管理员视图）
 		// GET /api/v1/admin/users?page=1&page_size=20&role=student&keyword=张三&is_active=true
 		// 响应码: 0-成功, 10002-参数错误
-		adminGroup.GET("/users", rm.userHandler.ListUsers)
+		adminGroup.GET("/users", middleware.Require(rbac.UserManage), rm.userHandler.ListUsers)
 
 		// 更新用户信息
 		// PUT /api/v1/admin/users/{id}
 		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
-		adminGroup.PUT("/users/:id", rm.userHandler.UpdateUser)
+		adminGroup.PUT("/users/:id", middleware.Require(rbac.UserManage), rm.userHandler.UpdateUser)
 
 		// 删除用户
 		// DELETE /api/v1/admin/users/{id}
 		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
-		adminGroup.DELETE("/users/:id", rm.userHandler.DeleteUser)
+		adminGroup.DELETE("/users/:id", middleware.Require(rbac.UserManage), rm.userHandler.DeleteUser)
 
 		// 激活用户
 		// PUT /api/v1/admin/users/{id}/activate
 		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
-		adminGroup.PUT("/users/:id/activate", rm.userHandler.ActivateUser)
+		adminGroup.PUT("/users/:id/activate", middleware.Require(rbac.UserManage), rm.userHandler.ActivateUser)
 
 		// 停用用户
 		// PUT /api/v1/admin/users/{id}/deactivate
 		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
-		adminGroup.PUT("/users/:id/deactivate", rm.userHandler.DeactivateUser)
+		adminGroup.PUT("/users/:id/deactivate", middleware.Require(rbac.UserManage), rm.userHandler.DeactivateUser)
 
-		// 批量导入用户
+		// 批量导入用户(CSV/XLSX)，dry_run=true时只返回逐行校验结果
 		// POST /api/v1/admin/users/import
+		// 响应码: 0-成功, 10002-参数错误, 20023-导入数据有误
+		adminGroup.POST("/users/import", middleware.Require(rbac.UserManage), rm.adminHandler.ImportUsers)
+
+		// 导出用户(CSV/XLSX)，筛选条件与用户列表相同
+		// GET /api/v1/admin/users/export?format=xlsx&role=student&class=计科1班
 		// 响应码: 0-成功, 10002-参数错误
-		// adminGroup.POST("/users/import", rm.adminHandler.ImportUsers)
+		adminGroup.GET("/users/export", middleware.Require(rbac.UserManage), rm.adminHandler.ExportUsers)
 
-		// 重置用户密码
+		// 重置用户密码，未指定密码时随机生成，用户登录后必须先修改密码
 		// PUT /api/v1/admin/users/{id}/reset-password
+		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在, 20007-密码强度不足
+		adminGroup.PUT("/users/:id/reset-password", middleware.Require(rbac.UserManage), rm.adminHandler.ResetUserPassword)
+
+		// 解除用户的登录失败锁定
+		// PUT /api/v1/admin/users/{id}/unlock
 		// 响应码: 0-成功, 10002-参数错误, 20001-用户不存在
-		// adminGroup.PUT("/users/:id/reset-password", rm.adminHandler.ResetUserPassword)
+		adminGroup.PUT("/users/:id/unlock", middleware.Require(rbac.UserManage), rm.adminHandler.UnlockUser)
+
+		// 解除IP的登录失败锁定
+		// PUT /api/v1/admin/ips/{ip}/unlock
+		// 响应码: 0-成功, 10002-参数错误
+		adminGroup.PUT("/ips/:ip/unlock", middleware.Require(rbac.UserManage), rm.adminHandler.UnlockIP)
+
+		// ========== 角色权限管理（role:manage） ==========
+
+		// 获取全部权限及说明
+		// GET /api/v1/admin/permissions
+		// 响应码: 0-成功, 10004-权限不足
+		adminGroup.GET("/permissions", middleware.Require(rbac.RoleManage), rm.adminHandler.ListPermissions)
+
+		// 获取全部角色及其权限和用户数
+		// GET /api/v1/admin/roles
+		// 响应码: 0-成功, 10004-权限不足
+		adminGroup.GET("/roles", middleware.Require(rbac.RoleManage), rm.adminHandler.ListRoles)
+
+		// 创建自定义角色
+		// POST /api/v1/admin/roles
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 60011-角色已存在
+		adminGroup.POST("/roles", middleware.Require(rbac.RoleManage), rm.adminHandler.CreateRole)
+
+		// 修改角色权限(admin除外)
+		// PUT /api/v1/admin/roles/{name}
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 60010-角色不存在, 60013-角色不可修改
+		adminGroup.PUT("/roles/:name", middleware.Require(rbac.RoleManage), rm.adminHandler.UpdateRole)
+
+		// 删除自定义角色；内置角色和配置文件中的角色恢复默认权限
+		// DELETE /api/v1/admin/roles/{name}
+		// 响应码: 0-成功, 10004-权限不足, 60010-角色不存在, 60012-角色仍有用户, 60013-角色不可修改
+		adminGroup.DELETE("/roles/:name", middleware.Require(rbac.RoleManage), rm.adminHandler.DeleteRole)
+
+		// 修改用户角色，用户需要重新登录
+		// PUT /api/v1/admin/users/{id}/role
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 20001-用户不存在, 60010-角色不存在
+		adminGroup.PUT("/users/:id/role", middleware.Require(rbac.RoleManage), rm.adminHandler.AssignRole)
 
-		// ========== 题目管理 ==========
+		// ========== 题目管理（problem:edit:any） ==========
 
 		// 获取所有题目（管理员视图）
 		// GET /api/v1/admin/problems?page=1&page_size=20&is_public=false
 		// 响应码: 0-成功, 10002-参数错误
-		// adminGroup.GET("/problems", rm.adminHandler.ListAllProblems)
+		// adminGroup.GET("/problems", middleware.Require(rbac.ProblemEditAny), rm.adminHandler.ListAllProblems)
 
 		// 题目审核
 		// PUT /api/v1/admin/problems/{id}/approve
 		// PUT /api/v1/admin/problems/{id}/reject
 		// 响应码: 0-成功, 10002-参数错误, 30001-题目不存在
-		// adminGroup.PUT("/problems/:id/approve", rm.adminHandler.ApproveProblem)
-		// adminGroup.PUT("/problems/:id/reject", rm.adminHandler.RejectProblem)
+		// adminGroup.PUT("/problems/:id/approve", middleware.Require(rbac.ProblemEditAny), rm.adminHandler.ApproveProblem)
+		// adminGroup.PUT("/problems/:id/reject", middleware.Require(rbac.ProblemEditAny), rm.adminHandler.RejectProblem)
 
-		// ========== 系统数据统计 ==========
+		// ========== 系统数据统计（system:manage） ==========
 
 		// 用户统计
 		// GET /api/v1/admin/stats/users
 		// 响应码: 0-成功
-		// adminGroup.GET("/stats/users", rm.adminHandler.GetUserStats)
+		// adminGroup.GET("/stats/users", middleware.Require(rbac.SystemManage), rm.adminHandler.GetUserStats)
 
 		// 题目统计
 		// GET /api/v1/admin/stats/problems
 		// 响应码: 0-成功
-		// adminGroup.GET("/stats/problems", rm.adminHandler.GetProblemStats)
+		// adminGroup.GET("/stats/problems", middleware.Require(rbac.SystemManage), rm.adminHandler.GetProblemStats)
 
 		// 提交统计
 		// GET /api/v1/admin/stats/submissions
 		// 响应码: 0-成功
-		// adminGroup.GET("/stats/submissions", rm.adminHandler.GetSubmissionStats)
+		// adminGroup.GET("/stats/submissions", middleware.Require(rbac.SystemManage), rm.adminHandler.GetSubmissionStats)
 
 		// 判题系统统计
 		// GET /api/v1/admin/stats/judge
 		// 响应码: 0-成功
-		// adminGroup.GET("/stats/judge", rm.adminHandler.GetJudgeStats)
+		// adminGroup.GET("/stats/judge", middleware.Require(rbac.SystemManage), rm.adminHandler.GetJudgeStats)
 
-		// ========== 系统日志 ==========
+		// ========== 系统日志（system:manage） ==========
 
 		// 获取系统日志
 		// GET /api/v1/admin/logs?level=error&start_time=2024-01-01&end_time=2024-01-31
 		// 响应码: 0-成功, 10002-参数错误
-		// adminGroup.GET("/logs", rm.adminHandler.GetSystemLogs)
+		// adminGroup.GET("/logs", middleware.Require(rbac.SystemManage), rm.adminHandler.GetSystemLogs)
 
 		// 获取操作审计日志
 		// GET /api/v1/admin/audit-logs?user_id=xxx&action=create&resource=user
 		// 响应码: 0-成功, 10002-参数错误
-		// adminGroup.GET("/audit-logs", rm.adminHandler.GetAuditLogs)
+		// adminGroup.GET("/audit-logs", middleware.Require(rbac.SystemManage), rm.adminHandler.GetAuditLogs)
 	}
 }
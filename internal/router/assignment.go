@@ -0,0 +1,66 @@
+package router
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAssignmentRoutes 设置作业相关路由
+// 作业的创建和列表在课程路由下(/courses/{id}/assignments)，这里是单个作业的查看、管理、延期和成绩册
+func (rm *RouterManager) setupAssignmentRoutes(v1 *gin.RouterGroup) {
+	assignmentGroup := v1.Group("/assignments")
+	assignmentGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS)) // 所有作业接口都需要认证
+	{
+		// 获取作业详情（课程成员；学生在开放前不可见，同时返回本人的成绩）
+		// GET /api/v1/assignments/{id}
+		// 响应码: 0-成功, 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在, 80006-作业尚未开放
+		assignmentGroup.GET("/:id", rm.courseHandler.GetAssignment)
+
+		// 成绩册（课程的教师、助教，或course:manage:any）
+		// GET /api/v1/assignments/{id}/gradebook
+		// 响应码: 0-成功, 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在
+		assignmentGroup.GET("/:id/gradebook", rm.courseHandler.GetGradebook)
+
+		// 导出成绩册，默认为教务系统成绩上传格式，detail=true时导出各题得分
+		// GET /api/v1/assignments/{id}/gradebook/export?format=csv&detail=false
+		// 响应码: 0-成功(文件), 10002-参数错误, 80002-课程访问被拒绝, 80005-作业不存在
+		assignmentGroup.GET("/:id/gradebook/export", rm.courseHandler.ExportGradebook)
+
+		// ========== 作业管理接口 ==========
+
+		// 更新作业
+		// PUT /api/v1/assignments/{id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 80005-作业不存在
+		assignmentGroup.PUT("/:id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.UpdateAssignment)
+
+		// 删除作业
+		// DELETE /api/v1/assignments/{id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80005-作业不存在
+		assignmentGroup.DELETE("/:id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.DeleteAssignment)
+
+		// 为学生设置延期，已有延期时替换
+		// PUT /api/v1/assignments/{id}/extensions
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80005-作业不存在
+		assignmentGroup.PUT("/:id/extensions",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.SetExtension)
+
+		// 取消学生的延期
+		// DELETE /api/v1/assignments/{id}/extensions/{user_id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 10005-该学生没有延期, 80005-作业不存在
+		assignmentGroup.DELETE("/:id/extensions/:user_id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.RemoveExtension)
+	}
+}
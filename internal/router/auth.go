@@ -2,40 +2,82 @@ package router
 
 import (
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
 
 // setupAuthRoutes 设置认证相关路由
-// 用户注册、登录、登出等认证功能
+// 用户注册、登录(含统一身份认证)、登出、刷新token、找回密码和登录会话管理
 func (rm *RouterManager) setupAuthRoutes(v1 *gin.RouterGroup) {
 	authGroup := v1.Group("/auth")
+	authGroup.Use(rm.rateLimit("auth", errors.TOO_MANY_REQUESTS)) // 按IP限流，防止暴力破解和批量注册
 	{
 		// 用户注册
 		// POST /api/v1/auth/register
-		// 响应码: 0-成功, 10002-参数错误, 20002-用户已存在
+		// 响应码: 0-成功, 10002-参数错误, 20002-用户已存在, 20007-密码强度不足
 		authGroup.POST("/register", rm.authHandler.Register)
 
-		// 用户登录
+		// 用户登录，返回访问token和刷新token；连续失败过多时账号或IP被暂时锁定
 		// POST /api/v1/auth/login
-		// 响应码: 0-成功, 10002-参数错误, 20010-登录失败
+		// 响应码: 0-成功, 10002-参数错误, 20008-用户已被禁用, 20010-登录失败, 20026-登录已锁定
 		authGroup.POST("/login", rm.authHandler.Login)
 
-		// 用户登出（需要认证）
+		// 用户登出（需要认证），注销当前会话
 		// POST /api/v1/auth/logout
 		// 响应码: 0-成功, 10003-未授权
 		authGroup.POST("/logout", middleware.AuthRequired(), rm.authHandler.Logout)
 
-		// 刷新Token（需要认证）
+		// 刷新Token，访问token过期后使用刷新token换取，刷新token随之轮换
 		// POST /api/v1/auth/refresh
-		// 响应码: 0-成功, 10003-未授权, 10009-Token无效
-		// authGroup.POST("/refresh", middleware.AuthRequired(), rm.authHandler.RefreshToken)
+		// 响应码: 0-成功, 10002-参数错误, 10009-Token无效, 20008-用户已被禁用
+		authGroup.POST("/refresh", rm.authHandler.RefreshToken)
+
+		// 我的登录会话（需要认证）
+		// GET /api/v1/auth/sessions
+		// 响应码: 0-成功, 10003-未授权
+		authGroup.GET("/sessions", middleware.AuthRequired(), rm.authHandler.ListSessions)
+
+		// 注销指定会话（需要认证）
+		// DELETE /api/v1/auth/sessions/:id
+		// 响应码: 0-成功, 10003-未授权, 20018-会话不存在
+		authGroup.DELETE("/sessions/:id", middleware.AuthRequired(), rm.authHandler.RevokeSession)
+
+		// 注销其他设备的会话（需要认证）
+		// DELETE /api/v1/auth/sessions
+		// 响应码: 0-成功, 10003-未授权
+		authGroup.DELETE("/sessions", middleware.AuthRequired(), rm.authHandler.RevokeOtherSessions)
+
+		// 统一身份认证入口列表
+		// GET /api/v1/auth/sso/providers
+		// 响应码: 0-成功
+		authGroup.GET("/sso/providers", rm.authHandler.SSOProviders)
+
+		// 跳转到统一身份认证登录页
+		// GET /api/v1/auth/sso/:provider/login
+		// 响应码: 302-跳转, 10002-参数错误, 20019-统一身份认证不存在
+		authGroup.GET("/sso/:provider/login", rm.authHandler.SSOLogin)
+
+		// 统一身份认证回调
+		// GET /api/v1/auth/sso/:provider/callback
+		// 响应码: 0-成功, 20008-用户已被禁用, 20019-统一身份认证不存在, 20020-登录失败, 20021-账号未开通
+		authGroup.GET("/sso/:provider/callback", rm.authHandler.SSOCallback)
 
 		// 修改密码（需要认证）
 		// PUT /api/v1/auth/password
-		// 响应码: 0-成功, 10002-参数错误, 20013-旧密码不正确
+		// 响应码: 0-成功, 10002-参数错误, 20007-密码强度不足, 20013-旧密码不正确
 		authGroup.PUT("/password", middleware.AuthRequired(), rm.userHandler.ChangePassword)
 
+		// 找回密码，向注册邮箱发送重置链接(无论邮箱是否注册都返回成功)
+		// POST /api/v1/auth/password/forgot
+		// 响应码: 0-成功, 10002-参数错误
+		authGroup.POST("/password/forgot", rm.authHandler.ForgotPassword)
+
+		// 通过重置链接设置新密码，链接只能使用一次
+		// POST /api/v1/auth/password/reset
+		// 响应码: 0-成功, 10002-参数错误, 20007-密码强度不足, 20008-用户已被禁用, 20024-重置链接无效
+		authGroup.POST("/password/reset", rm.authHandler.ResetPassword)
+
 		// 验证Token状态
 		// GET /api/v1/auth/verify
 		// 响应码: 0-成功, 10003-未授权, 10009-Token无效
@@ -48,9 +90,10 @@ func (rm *RouterManager) setupAuthRoutes(v1 *gin.RouterGroup) {
 				"code":    0,
 				"message": "Token有效",
 				"data": gin.H{
-					"user_id":  userID,
-					"username": username,
-					"role":     role,
+					"user_id":     userID,
+					"username":    username,
+					"role":        role,
+					"permissions": middleware.GetPermissions(c).List(),
 				},
 			})
 		})
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupContestRoutes 设置竞赛相关路由
+// 竞赛浏览、报名、管理等功能；竞赛提交通过 POST /api/v1/submissions 携带contest_id
+func (rm *RouterManager) setupContestRoutes(v1 *gin.RouterGroup) {
+	contestGroup := v1.Group("/contests")
+	contestGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS)) // 所有竞赛接口都需要认证
+	{
+		// ========== 竞赛查询与报名 ==========
+
+		// 获取竞赛列表
+		// GET /api/v1/contests?page=1&page_size=20&type=class&status=running&keyword=周赛
+		// 响应码: 0-成功, 10002-参数错误
+		contestGroup.GET("", rm.contestHandler.ListContests)
+
+		// 获取竞赛详情（竞赛开始前不包含题目）
+		// GET /api/v1/contests/{id}
+		// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在
+		contestGroup.GET("/:id", rm.contestHandler.GetContest)
+
+		// 获取竞赛题目
+		// GET /api/v1/contests/{id}/problems
+		// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70002-竞赛未开始, 70004-竞赛访问被拒绝
+		contestGroup.GET("/:id/problems", rm.contestHandler.ListProblems)
+
+		// 报名竞赛（班级竞赛仅限指定班级）
+		// POST /api/v1/contests/{id}/register
+		// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70003-竞赛已结束, 70004-竞赛访问被拒绝
+		contestGroup.POST("/:id/register", rm.contestHandler.Register)
+
+		// 获取竞赛排行榜（封榜后普通用户获取封榜视图）
+		// GET /api/v1/contests/{id}/scoreboard?frozen=true
+		// 响应码: 0-成功, 10002-参数错误, 70001-竞赛不存在, 70002-竞赛未开始
+		contestGroup.GET("/:id/scoreboard", rm.contestHandler.GetScoreboard)
+
+		// ========== 竞赛管理接口 ==========
+
+		// 创建竞赛
+		// POST /api/v1/contests
+		// 权限: contest:create
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在
+		contestGroup.POST("",
+			middleware.Require(rbac.ContestCreate),
+			rm.contestHandler.CreateContest)
+
+		// 更新竞赛
+		// PUT /api/v1/contests/{id}
+		// 权限: contest:manage:any, 或contest:manage:own且是竞赛创建者
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 70001-竞赛不存在
+		contestGroup.PUT("/:id",
+			middleware.Require(rbac.ContestManageOwn, rbac.ContestManageAny),
+			rm.contestHandler.UpdateContest)
+
+		// 删除竞赛
+		// DELETE /api/v1/contests/{id}
+		// 权限: contest:manage:any, 或contest:manage:own且是竞赛创建者
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在
+		contestGroup.DELETE("/:id",
+			middleware.Require(rbac.ContestManageOwn, rbac.ContestManageAny),
+			rm.contestHandler.DeleteContest)
+
+		// 滚榜：揭晓下一个封榜结果（竞赛结束后）
+		// POST /api/v1/contests/{id}/scoreboard/resolve
+		// 权限: contest:manage:any, 或contest:manage:own且是竞赛创建者
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在, 70006-竞赛未结束, 70007-排行榜未封榜
+		contestGroup.POST("/:id/scoreboard/resolve",
+			middleware.Require(rbac.ContestManageOwn, rbac.ContestManageAny),
+			rm.contestHandler.ResolveStep)
+
+		// 揭晓全部封榜结果（竞赛结束后）
+		// POST /api/v1/contests/{id}/scoreboard/unfreeze
+		// 权限: contest:manage:any, 或contest:manage:own且是竞赛创建者
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 70001-竞赛不存在, 70006-竞赛未结束, 70007-排行榜未封榜
+		contestGroup.POST("/:id/scoreboard/unfreeze",
+			middleware.Require(rbac.ContestManageOwn, rbac.ContestManageAny),
+			rm.contestHandler.Unfreeze)
+	}
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupCourseRoutes 设置课程相关路由
+// 课程班级、成员和邀请码管理，以及教师和助教查看所教课程学生的提交、排名和统计
+func (rm *RouterManager) setupCourseRoutes(v1 *gin.RouterGroup) {
+	courseGroup := v1.Group("/courses")
+	courseGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS)) // 所有课程接口都需要认证
+	{
+		// ========== 课程查询与加入 ==========
+
+		// 获取自己所在的课程（all=true时获取全部课程，需要course:manage:any）
+		// GET /api/v1/courses?page=1&page_size=20&term=2026-2027-1&keyword=Java
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足
+		courseGroup.GET("", rm.courseHandler.ListCourses)
+
+		// 通过邀请码加入课程（以学生身份）
+		// POST /api/v1/courses/join
+		// 响应码: 0-成功, 10002-参数错误, 80003-邀请码无效, 80004-课程已结课
+		courseGroup.POST("/join", rm.courseHandler.JoinCourse)
+
+		// 获取课程详情（课程成员；邀请码只对教师和助教可见）
+		// GET /api/v1/courses/{id}
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id", rm.courseHandler.GetCourse)
+
+		// ========== 教师和助教查询（课程的教师、助教，或course:manage:any） ==========
+
+		// 获取课程成员
+		// GET /api/v1/courses/{id}/members?role=student
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id/members", rm.courseHandler.ListMembers)
+
+		// 获取课程学生的提交（还需要submission:view:class，或拥有submission:view:any）
+		// GET /api/v1/courses/{id}/submissions?page=1&page_size=20&user_id=xxx&problem_id=xxx&status=ACCEPTED
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id/submissions", rm.courseHandler.ListSubmissions)
+
+		// 课程学生排名（按解题数、通过数）
+		// GET /api/v1/courses/{id}/ranking
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id/ranking", rm.courseHandler.GetRanking)
+
+		// 课程统计
+		// GET /api/v1/courses/{id}/stats
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id/stats", rm.courseHandler.GetStats)
+
+		// ========== 课程作业 ==========
+
+		// 获取课程的作业（课程成员；学生只能看到已开放的作业）
+		// GET /api/v1/courses/{id}/assignments?page=1&page_size=20
+		// 响应码: 0-成功, 10002-参数错误, 80001-课程不存在, 80002-课程访问被拒绝
+		courseGroup.GET("/:id/assignments", rm.courseHandler.ListAssignments)
+
+		// 创建作业
+		// POST /api/v1/courses/{id}/assignments
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在, 80001-课程不存在
+		courseGroup.POST("/:id/assignments",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.CreateAssignment)
+
+		// ========== 课程管理接口 ==========
+
+		// 创建课程，创建者作为课程的教师
+		// POST /api/v1/courses
+		// 权限: course:create
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足
+		courseGroup.POST("",
+			middleware.Require(rbac.CourseCreate),
+			rm.courseHandler.CreateCourse)
+
+		// 更新课程信息
+		// PUT /api/v1/courses/{id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+		courseGroup.PUT("/:id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.UpdateCourse)
+
+		// 删除课程
+		// DELETE /api/v1/courses/{id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+		courseGroup.DELETE("/:id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.DeleteCourse)
+
+		// 生成新的邀请码或停用邀请码
+		// PUT /api/v1/courses/{id}/join-code
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+		courseGroup.PUT("/:id/join-code",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.SetJoinCode)
+
+		// 按学号添加课程成员（学生、助教或教师）
+		// POST /api/v1/courses/{id}/members
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 80001-课程不存在
+		courseGroup.POST("/:id/members",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.AddMembers)
+
+		// 将用户移出课程
+		// DELETE /api/v1/courses/{id}/members/{user_id}
+		// 权限: course:manage:any, 或course:manage:own且是课程的教师
+		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 20001-用户不是课程成员, 80001-课程不存在
+		courseGroup.DELETE("/:id/members/:user_id",
+			middleware.Require(rbac.CourseManageOwn, rbac.CourseManageAny),
+			rm.courseHandler.RemoveMember)
+	}
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupEventRoutes 设置判题事件推送路由
+// 判题机通过Redis发布事件，任一Web服务实例都可以推送给提交用户
+// 浏览器的WebSocket和EventSource无法设置请求头，这两个接口额外接受access_token查询参数
+func (rm *RouterManager) setupEventRoutes(v1 *gin.RouterGroup) {
+	streamGroup := v1.Group("")
+	streamGroup.Use(middleware.TokenFromQuery(), middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS))
+	{
+		// WebSocket连接获取实时判题状态
+		// GET /api/v1/ws?access_token=xxx (升级为WebSocket)
+		// 消息类型: judge_progress, compile_result, test_case_result, submission_result
+		// 响应码: 401-未认证
+		streamGroup.GET("/ws", rm.eventHandler.WebSocket)
+
+		// SSE获取实时判题状态，事件类型同WebSocket
+		// GET /api/v1/submissions/events?access_token=xxx
+		// 响应码: 401-未认证
+		streamGroup.GET("/submissions/events", rm.eventHandler.Stream)
+	}
+}
@@ -2,6 +2,8 @@ package router
 
 import (
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,7 +12,7 @@ import (
 // 题目浏览、搜索、管理等功能
 func (rm *RouterManager) setupProblemRoutes(v1 *gin.RouterGroup) {
 	problemGroup := v1.Group("/problems")
-	problemGroup.Use(middleware.AuthRequired()) // 所有题目接口都需要认证
+	problemGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS)) // 所有题目接口都需要认证
 	{
 		// ========== 题目查询接口 ==========
 
@@ -34,38 +36,38 @@ func (rm *RouterManager) setupProblemRoutes(v1 *gin.RouterGroup) {
 		// 响应码: 0-成功, 10002-参数错误, 30001-题目不存在
 		// problemGroup.GET("/:id/submissions", rm.submissionHandler.GetProblemSubmissions)
 
-		// ========== 题目管理接口（教师/管理员权限） ==========
+		// ========== 题目管理接口 ==========
 
 		// 创建题目
 		// POST /api/v1/problems
-		// 权限: teacher, admin
+		// 权限: problem:create
 		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30002-题目已存在
 		problemGroup.POST("",
-			middleware.RoleRequired("teacher", "admin"),
+			middleware.Require(rbac.ProblemCreate),
 			rm.problemHandler.CreateProblem)
 
 		// 更新题目
 		// PUT /api/v1/problems/{id}
-		// 权限: teacher, admin
+		// 权限: problem:edit:any, 或problem:edit:own且是题目创建者
 		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在
 		problemGroup.PUT("/:id",
-			middleware.RoleRequired("teacher", "admin"),
+			middleware.RequireOwner(rm.problemOwner, rbac.ProblemEditOwn, rbac.ProblemEditAny),
 			rm.problemHandler.UpdateProblem)
 
 		// 删除题目
 		// DELETE /api/v1/problems/{id}
-		// 权限: admin
+		// 权限: problem:delete:any, 或problem:delete:own且是题目创建者
 		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足, 30001-题目不存在
 		problemGroup.DELETE("/:id",
-			middleware.RoleRequired("admin"),
+			middleware.RequireOwner(rm.problemOwner, rbac.ProblemDeleteOwn, rbac.ProblemDeleteAny),
 			rm.problemHandler.DeleteProblem)
 
 		// 批量导入题目
 		// POST /api/v1/problems/import
-		// 权限: teacher, admin
+		// 权限: problem:import
 		// 响应码: 0-成功, 10002-参数错误, 10004-权限不足
 		// problemGroup.POST("/import",
-		// 	middleware.RoleRequired("teacher", "admin"),
+		// 	middleware.Require(rbac.ProblemImport),
 		// 	rm.problemHandler.ImportProblems)
 
 		// 题目标签管理
@@ -1,12 +1,18 @@
 package router
 
 import (
+	"zhku-oj/internal/config"
 	"zhku-oj/internal/handler/admin"
 	"zhku-oj/internal/handler/auth"
+	"zhku-oj/internal/handler/contest"
+	"zhku-oj/internal/handler/course"
+	"zhku-oj/internal/handler/event"
 	"zhku-oj/internal/handler/problem"
 	"zhku-oj/internal/handler/submission"
 	"zhku-oj/internal/handler/user"
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/ratelimit"
+	"zhku-oj/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,7 +24,17 @@ type RouterManager struct {
 	userHandler       *user.UserHandler
 	problemHandler    *problem.ProblemHandler
 	submissionHandler *submission.SubmissionHandler
+	contestHandler    *contest.ContestHandler
+	courseHandler     *course.CourseHandler
+	eventHandler      *event.EventHandler
 	adminHandler      *admin.AdminHandler
+
+	// 限流
+	rateLimiter  *ratelimit.Limiter
+	rateLimitCfg config.RateLimitConfig
+
+	// 资源所有者查询，用于own范围的权限检查
+	problemOwner rbac.OwnerLookup
 }
 
 // NewRouterManager 创建路由管理器
@@ -27,15 +43,36 @@ func NewRouterManager(
 	userHandler *user.UserHandler,
 	problemHandler *problem.ProblemHandler,
 	submissionHandler *submission.SubmissionHandler,
+	contestHandler *contest.ContestHandler,
+	courseHandler *course.CourseHandler,
+	eventHandler *event.EventHandler,
 	adminHandler *admin.AdminHandler,
+	rateLimiter *ratelimit.Limiter,
+	rateLimitCfg config.RateLimitConfig,
+	problemOwner rbac.OwnerLookup,
 ) *RouterManager {
 	return &RouterManager{
 		authHandler:       authHandler,
 		userHandler:       userHandler,
 		problemHandler:    problemHandler,
 		submissionHandler: submissionHandler,
+		contestHandler:    contestHandler,
+		courseHandler:     courseHandler,
+		eventHandler:      eventHandler,
 		adminHandler:      adminHandler,
+		rateLimiter:       rateLimiter,
+		rateLimitCfg:      rateLimitCfg,
+		problemOwner:      problemOwner,
+	}
+}
+
+// rateLimit 路由组限流中间件，未开启限流或未配置该路由组时不限流
+func (rm *RouterManager) rateLimit(name string, errCode int) gin.HandlerFunc {
+	policy, ok := rm.rateLimitCfg.Policy(name)
+	if !ok {
+		return func(c *gin.Context) { c.Next() }
 	}
+	return middleware.RateLimit(rm.rateLimiter, name, policy, errCode)
 }
 
 // SetupRoutes 设置所有路由
@@ -48,6 +85,10 @@ func (rm *RouterManager) SetupRoutes(router *gin.Engine) {
 	// 健康检查路由
 	rm.setupHealthRoutes(router)
 
+	// JWT签名公钥，供其他服务校验token
+	// GET /.well-known/jwks.json
+	router.GET("/.well-known/jwks.json", rm.authHandler.JWKS)
+
 	// API路由组
 	v1 := router.Group("/api/v1")
 	{
@@ -63,6 +104,18 @@ func (rm *RouterManager) SetupRoutes(router *gin.Engine) {
 		// 提交相关路由
 		rm.setupSubmissionRoutes(v1)
 
+		// 竞赛相关路由
+		rm.setupContestRoutes(v1)
+
+		// 课程相关路由
+		rm.setupCourseRoutes(v1)
+
+		// 课程作业相关路由
+		rm.setupAssignmentRoutes(v1)
+
+		// 判题事件推送路由
+		rm.setupEventRoutes(v1)
+
 		// 管理员路由
 		rm.setupAdminRoutes(v1)
 	}
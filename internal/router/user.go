@@ -2,6 +2,7 @@ package router
 
 import (
 	"zhku-oj/internal/middleware"
+	"zhku-oj/internal/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,7 +11,7 @@ import (
 // 用户信息管理、个人资料等功能
 func (rm *RouterManager) setupUserRoutes(v1 *gin.RouterGroup) {
 	userGroup := v1.Group("/users")
-	userGroup.Use(middleware.AuthRequired()) // 所有用户接口都需要认证
+	userGroup.Use(middleware.AuthRequired(), rm.rateLimit("default", errors.TOO_MANY_REQUESTS)) // 所有用户接口都需要认证
 	{
 		// ========== 当前用户相关接口 ==========
 
@@ -0,0 +1,210 @@
+package scoreboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// cacheTTL 排行榜缓存有效期，每次写入时刷新；过期后从提交记录重建
+const cacheTTL = 7 * 24 * time.Hour
+
+// builtField 单元格哈希中标记已从提交记录完整构建的字段
+// 值为构建时的竞赛时间和赛制，竞赛设置修改后需要重建
+const builtField = "_built"
+
+// saveCellScript 写入单元格，已缓存的单元格版本更新时不覆盖
+var saveCellScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if current then
+	local version = cjson.decode(current)['version']
+	if version and tonumber(version) > tonumber(ARGV[2]) then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// Cache 排行榜Redis缓存
+// 每个竞赛的单元格存放在一个哈希中，字段为 用户ID:题目ID
+type Cache struct {
+	client *redis.Client
+}
+
+// NewCache 创建排行榜缓存
+func NewCache(client *redis.Client) *Cache {
+	return &Cache{client: client}
+}
+
+func cellsKey(contestID string) string {
+	return fmt.Sprintf("scoreboard:%s:cells", contestID)
+}
+
+func participantsKey(contestID string) string {
+	return fmt.Sprintf("scoreboard:%s:participants", contestID)
+}
+
+func revealedKey(contestID string) string {
+	return fmt.Sprintf("scoreboard:%s:revealed", contestID)
+}
+
+// buildVersion 影响单元格计算的竞赛设置
+func buildVersion(contest *model.Contest) string {
+	return fmt.Sprintf("%d:%d:%d:%s",
+		contest.StartTime.UnixMilli(), contest.EndTime.UnixMilli(), contest.Settings.FreezeTime, contest.Settings.Rule)
+}
+
+// Built 排行榜是否已按当前竞赛设置完整构建
+func (c *Cache) Built(ctx context.Context, contest *model.Contest) (bool, error) {
+	version, err := c.client.HGet(ctx, cellsKey(contest.ID.Hex()), builtField).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取排行榜缓存失败: %w", err)
+	}
+	return version == buildVersion(contest), nil
+}
+
+// Cells 读取竞赛的全部单元格
+func (c *Cache) Cells(ctx context.Context, contestID string) ([]Cell, error) {
+	values, err := c.client.HGetAll(ctx, cellsKey(contestID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取排行榜缓存失败: %w", err)
+	}
+
+	cells := make([]Cell, 0, len(values))
+	for field, value := range values {
+		if field == builtField {
+			continue
+		}
+		var cell Cell
+		if err := json.Unmarshal([]byte(value), &cell); err != nil {
+			return nil, fmt.Errorf("解析排行榜缓存失败: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+// SaveCell 写入单元格，缓存中的版本更新时忽略
+func (c *Cache) SaveCell(ctx context.Context, contestID string, cell Cell) error {
+	raw, err := json.Marshal(cell)
+	if err != nil {
+		return fmt.Errorf("序列化排行榜单元格失败: %w", err)
+	}
+	err = saveCellScript.Run(ctx, c.client, []string{cellsKey(contestID)},
+		cell.Key(), cell.Version, raw, int(cacheTTL.Seconds()),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("写入排行榜缓存失败: %w", err)
+	}
+	return nil
+}
+
+// SaveAll 写入从提交记录构建的全部单元格，并标记排行榜已构建
+func (c *Cache) SaveAll(ctx context.Context, contest *model.Contest, cells []Cell) error {
+	contestID := contest.ID.Hex()
+	for _, cell := range cells {
+		if err := c.SaveCell(ctx, contestID, cell); err != nil {
+			return err
+		}
+	}
+
+	key := cellsKey(contestID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, builtField, buildVersion(contest))
+		pipe.Expire(ctx, key, cacheTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入排行榜缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Participants 读取缓存的用户信息，按用户ID索引
+func (c *Cache) Participants(ctx context.Context, contestID string) (map[string]Participant, error) {
+	values, err := c.client.HGetAll(ctx, participantsKey(contestID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取排行榜用户缓存失败: %w", err)
+	}
+
+	participants := make(map[string]Participant, len(values))
+	for userID, value := range values {
+		var participant Participant
+		if err := json.Unmarshal([]byte(value), &participant); err != nil {
+			return nil, fmt.Errorf("解析排行榜用户缓存失败: %w", err)
+		}
+		participants[userID] = participant
+	}
+	return participants, nil
+}
+
+// SaveParticipants 缓存用户信息
+func (c *Cache) SaveParticipants(ctx context.Context, contestID string, participants []Participant) error {
+	if len(participants) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(participants))
+	for _, participant := range participants {
+		raw, err := json.Marshal(participant)
+		if err != nil {
+			return fmt.Errorf("序列化排行榜用户失败: %w", err)
+		}
+		values[participant.UserID] = raw
+	}
+
+	key := participantsKey(contestID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, values)
+		pipe.Expire(ctx, key, cacheTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入排行榜用户缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Revealed 滚榜已揭晓的单元格
+func (c *Cache) Revealed(ctx context.Context, contestID string) (map[string]bool, error) {
+	keys, err := c.client.SMembers(ctx, revealedKey(contestID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取滚榜进度失败: %w", err)
+	}
+	revealed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		revealed[key] = true
+	}
+	return revealed, nil
+}
+
+// Reveal 记录滚榜揭晓的单元格
+func (c *Cache) Reveal(ctx context.Context, contestID, cellKey string) error {
+	key := revealedKey(contestID)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, cellKey)
+		pipe.Expire(ctx, key, cacheTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入滚榜进度失败: %w", err)
+	}
+	return nil
+}
+
+// ClearRevealed 清除滚榜进度，全部揭晓后由竞赛记录标记
+func (c *Cache) ClearRevealed(ctx context.Context, contestID string) error {
+	if err := c.client.Del(ctx, revealedKey(contestID)).Err(); err != nil {
+		return fmt.Errorf("清除滚榜进度失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package scoreboard
+
+import (
+	"sort"
+	"time"
+
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Result 用户在一道题上的提交结果
+type Result struct {
+	Attempts   int   `json:"attempts"`               // ICPC为通过前计入罚时的提交数，OI为已评测的提交数
+	Pending    int   `json:"pending"`                // 等待评测或封榜后提交、结果未公布的提交数
+	Solved     bool  `json:"solved"`                 // 是否通过
+	SolvedAtMs int64 `json:"solved_at_ms,omitempty"` // 通过时距竞赛开始的毫秒数
+	Score      int   `json:"score"`                  // 最高得分，仅OI
+}
+
+// Cell 用户在一道题上的提交汇总，排行榜按单元格增量更新
+// Visible只包含封榜前的提交，封榜后的提交计入Visible.Pending；Final包含全部提交
+type Cell struct {
+	UserID    string `json:"user_id"`
+	ProblemID string `json:"problem_id"`
+	Visible   Result `json:"visible"`
+	Final     Result `json:"final"`
+	// Version 参与计算的提交中最晚的提交或评测时间(毫秒)，用于丢弃过期的并发更新
+	Version int64 `json:"version"`
+}
+
+// CellKey 单元格标识
+func CellKey(userID, problemID string) string {
+	return userID + ":" + problemID
+}
+
+// Key 单元格标识
+func (c *Cell) Key() string {
+	return CellKey(c.UserID, c.ProblemID)
+}
+
+// Frozen 单元格是否有封榜后提交的结果未公布
+func (c *Cell) Frozen() bool {
+	return c.Visible != c.Final
+}
+
+// NewCell 根据用户在一道题上的全部竞赛提交计算单元格
+func NewCell(contest *model.Contest, userID, problemID primitive.ObjectID, submissions []*model.Submission) Cell {
+	sorted := make([]*model.Submission, len(submissions))
+	copy(sorted, submissions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].SubmittedAt.Before(sorted[j].SubmittedAt)
+	})
+
+	cell := Cell{UserID: userID.Hex(), ProblemID: problemID.Hex()}
+	rule := contest.Settings.Rule
+	freezing := contest.Settings.FreezeTime > 0
+	freezeStart := contest.FreezeStart()
+	for _, submission := range sorted {
+		cell.Version = max(cell.Version, submission.SubmittedAt.UnixMilli())
+		if submission.JudgedAt != nil {
+			cell.Version = max(cell.Version, submission.JudgedAt.UnixMilli())
+		}
+
+		elapsed := submission.SubmittedAt.Sub(contest.StartTime).Milliseconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		cell.Final.add(rule, submission.Status, submission.Score, elapsed)
+		if freezing && !submission.SubmittedAt.Before(freezeStart) {
+			// 封榜后的提交只显示提交次数；ICPC已通过的题目不再显示
+			if rule == model.ContestRuleOI || !cell.Visible.Solved {
+				cell.Visible.Pending++
+			}
+			continue
+		}
+		cell.Visible.add(rule, submission.Status, submission.Score, elapsed)
+	}
+	return cell
+}
+
+// add 按提交时间顺序累加一次提交
+func (r *Result) add(rule, status string, score int, elapsedMs int64) {
+	icpc := rule != model.ContestRuleOI
+	switch status {
+	case model.StatusPending, model.StatusJudging:
+		if !icpc || !r.Solved {
+			r.Pending++
+		}
+		return
+	case model.StatusCompileError, model.StatusSystemError:
+		// 编译错误和系统错误不计入提交次数
+		return
+	}
+
+	if icpc {
+		if r.Solved {
+			return
+		}
+		if status == model.StatusAccepted {
+			r.Solved = true
+			r.SolvedAtMs = elapsedMs
+			return
+		}
+		r.Attempts++
+		return
+	}
+
+	r.Attempts++
+	if score > r.Score {
+		r.Score = score
+	}
+	if status == model.StatusAccepted && !r.Solved {
+		r.Solved = true
+		r.SolvedAtMs = elapsedMs
+	}
+}
+
+// Participant 排行榜中的用户信息
+type Participant struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	RealName string `json:"real_name"`
+	Class    string `json:"class"`
+}
+
+// ProblemResult 排行榜中用户在一道题上的结果
+type ProblemResult struct {
+	ProblemID  string `json:"problem_id"`
+	Attempts   int    `json:"attempts"`
+	Pending    int    `json:"pending"`
+	Solved     bool   `json:"solved"`
+	SolvedAt   int64  `json:"solved_at,omitempty"` // 通过时距竞赛开始的秒数
+	Score      int    `json:"score"`
+	FirstBlood bool   `json:"first_blood"` // 该题第一个通过
+	solvedAtMs int64
+}
+
+// Row 排行榜中的一行
+type Row struct {
+	Rank int `json:"rank"`
+	Participant
+	Solved   int              `json:"solved"`  // 通过题数
+	Penalty  int64            `json:"penalty"` // 罚时(秒)，仅ICPC
+	Score    int              `json:"score"`   // 总分，仅OI
+	Problems []*ProblemResult `json:"problems"`
+}
+
+// Standings 竞赛排行榜
+type Standings struct {
+	ContestID   string     `json:"contest_id"`
+	Rule        string     `json:"rule"`
+	Frozen      bool       `json:"frozen"` // 是否为封榜视图
+	FreezeStart *time.Time `json:"freeze_start,omitempty"`
+	ProblemIDs  []string   `json:"problem_ids"`
+	Rows        []*Row     `json:"rows"`
+}
+
+// Build 计算排行榜
+// revealed返回true的单元格显示最终结果，否则显示封榜视图；报名但没有提交的用户也会列出
+func Build(contest *model.Contest, participants []Participant, cells []Cell, revealed func(*Cell) bool) *Standings {
+	standings := &Standings{
+		ContestID:  contest.ID.Hex(),
+		Rule:       contest.Settings.Rule,
+		ProblemIDs: make([]string, len(contest.ProblemIDs)),
+		Rows:       make([]*Row, 0, len(participants)),
+	}
+	if contest.Settings.FreezeTime > 0 {
+		freezeStart := contest.FreezeStart()
+		standings.FreezeStart = &freezeStart
+	}
+	problemIndex := make(map[string]int, len(contest.ProblemIDs))
+	for i, problemID := range contest.ProblemIDs {
+		standings.ProblemIDs[i] = problemID.Hex()
+		problemIndex[problemID.Hex()] = i
+	}
+
+	rows := make(map[string]*Row, len(participants))
+	addRow := func(participant Participant) *Row {
+		row := &Row{Participant: participant, Problems: make([]*ProblemResult, len(standings.ProblemIDs))}
+		for i, problemID := range standings.ProblemIDs {
+			row.Problems[i] = &ProblemResult{ProblemID: problemID}
+		}
+		rows[participant.UserID] = row
+		standings.Rows = append(standings.Rows, row)
+		return row
+	}
+	for _, participant := range participants {
+		if _, ok := rows[participant.UserID]; !ok {
+			addRow(participant)
+		}
+	}
+
+	for i := range cells {
+		cell := &cells[i]
+		index, ok := problemIndex[cell.ProblemID]
+		if !ok {
+			continue // 已从竞赛中移除的题目
+		}
+		row, ok := rows[cell.UserID]
+		if !ok {
+			row = addRow(Participant{UserID: cell.UserID})
+		}
+		result := cell.Visible
+		if revealed(cell) {
+			result = cell.Final
+		}
+		*row.Problems[index] = ProblemResult{
+			ProblemID:  cell.ProblemID,
+			Attempts:   result.Attempts,
+			Pending:    result.Pending,
+			Solved:     result.Solved,
+			SolvedAt:   result.SolvedAtMs / 1000,
+			Score:      result.Score,
+			solvedAtMs: result.SolvedAtMs,
+		}
+	}
+
+	oi := contest.Settings.Rule == model.ContestRuleOI
+	penaltyTime := int64(contest.Settings.PenaltyTime)
+	for _, row := range standings.Rows {
+		for _, problem := range row.Problems {
+			row.Score += problem.Score
+			if !problem.Solved {
+				continue
+			}
+			row.Solved++
+			if !oi {
+				row.Penalty += problem.SolvedAt + int64(problem.Attempts)*penaltyTime
+			}
+		}
+	}
+	markFirstBlood(standings)
+	rank(standings, oi)
+	return standings
+}
+
+// markFirstBlood 标记每道题最早通过的用户，同时通过的都标记
+func markFirstBlood(standings *Standings) {
+	for i := range standings.ProblemIDs {
+		first := int64(-1)
+		for _, row := range standings.Rows {
+			problem := row.Problems[i]
+			if problem.Solved && (first < 0 || problem.solvedAtMs < first) {
+				first = problem.solvedAtMs
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		for _, row := range standings.Rows {
+			problem := row.Problems[i]
+			problem.FirstBlood = problem.Solved && problem.solvedAtMs == first
+		}
+	}
+}
+
+// rank 排序并计算名次，成绩相同的用户名次相同
+// ICPC按通过题数降序、罚时升序；OI按总分降序
+func rank(standings *Standings, oi bool) {
+	less := func(a, b *Row) bool {
+		if oi {
+			return a.Score > b.Score
+		}
+		if a.Solved != b.Solved {
+			return a.Solved > b.Solved
+		}
+		return a.Penalty < b.Penalty
+	}
+	sort.SliceStable(standings.Rows, func(i, j int) bool {
+		a, b := standings.Rows[i], standings.Rows[j]
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.UserID < b.UserID
+	})
+	for i, row := range standings.Rows {
+		if i > 0 && !less(standings.Rows[i-1], row) {
+			row.Rank = standings.Rows[i-1].Rank
+			continue
+		}
+		row.Rank = i + 1
+	}
+}
+
+// Row 获取用户所在行
+func (s *Standings) Row(userID string) *Row {
+	for _, row := range s.Rows {
+		if row.UserID == userID {
+			return row
+		}
+	}
+	return nil
+}
+
+// NextReveal 滚榜时下一个揭晓的单元格
+// 从排名最后的用户开始，揭晓其题号最小的封榜单元格；全部揭晓后返回nil
+func NextReveal(standings *Standings, cells []Cell, revealed func(*Cell) bool) *Cell {
+	byKey := make(map[string]*Cell, len(cells))
+	for i := range cells {
+		byKey[cells[i].Key()] = &cells[i]
+	}
+	for i := len(standings.Rows) - 1; i >= 0; i-- {
+		row := standings.Rows[i]
+		for _, problem := range row.Problems {
+			cell, ok := byKey[CellKey(row.UserID, problem.ProblemID)]
+			if ok && cell.Frozen() && !revealed(cell) {
+				return cell
+			}
+		}
+	}
+	return nil
+}
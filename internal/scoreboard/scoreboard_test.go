@@ -0,0 +1,352 @@
+package scoreboard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var contestStart = time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+// newContest 5小时的竞赛，freeze为结束前封榜的时长
+func newContest(rule string, freeze time.Duration, problems int) *model.Contest {
+	contest := &model.Contest{
+		ID:        primitive.NewObjectID(),
+		StartTime: contestStart,
+		EndTime:   contestStart.Add(5 * time.Hour),
+		Settings:  model.ContestSettings{Rule: rule, PenaltyTime: 1200, FreezeTime: int(freeze / time.Second)},
+	}
+	for i := 0; i < problems; i++ {
+		contest.ProblemIDs = append(contest.ProblemIDs, primitive.NewObjectID())
+	}
+	return contest
+}
+
+// submission 竞赛开始后at提交的提交记录
+func submission(at time.Duration, status string, score int) *model.Submission {
+	return &model.Submission{Status: status, Score: score, SubmittedAt: contestStart.Add(at)}
+}
+
+func TestNewCell(t *testing.T) {
+	const (
+		ac  = model.StatusAccepted
+		wa  = model.StatusWrongAnswer
+		tle = model.StatusTimeLimitExceeded
+		ce  = model.StatusCompileError
+	)
+	ms := func(d time.Duration) int64 { return d.Milliseconds() }
+
+	tests := []struct {
+		name        string
+		rule        string
+		freeze      time.Duration
+		submissions []*model.Submission
+		wantVisible Result
+		wantFinal   Result
+	}{
+		{
+			name: "icpc wrong then accepted", rule: model.ContestRuleICPC, freeze: time.Hour,
+			// 乱序传入，按提交时间计算；编译错误和通过后的提交不计
+			submissions: []*model.Submission{
+				submission(40*time.Minute, wa, 0),
+				submission(10*time.Minute, wa, 0),
+				submission(30*time.Minute, ac, 0),
+				submission(15*time.Minute, ce, 0),
+			},
+			wantVisible: Result{Attempts: 1, Solved: true, SolvedAtMs: ms(30 * time.Minute)},
+			wantFinal:   Result{Attempts: 1, Solved: true, SolvedAtMs: ms(30 * time.Minute)},
+		},
+		{
+			name: "icpc pending", rule: model.ContestRuleICPC, freeze: time.Hour,
+			submissions: []*model.Submission{submission(10*time.Minute, wa, 0), submission(20*time.Minute, model.StatusJudging, 0)},
+			wantVisible: Result{Attempts: 1, Pending: 1},
+			wantFinal:   Result{Attempts: 1, Pending: 1},
+		},
+		{
+			name: "icpc accepted after freeze", rule: model.ContestRuleICPC, freeze: time.Hour,
+			submissions: []*model.Submission{submission(3*time.Hour, wa, 0), submission(4*time.Hour+10*time.Minute, ac, 0)},
+			wantVisible: Result{Attempts: 1, Pending: 1},
+			wantFinal:   Result{Attempts: 1, Solved: true, SolvedAtMs: ms(4*time.Hour + 10*time.Minute)},
+		},
+		{
+			name: "icpc submitted exactly at freeze start", rule: model.ContestRuleICPC, freeze: time.Hour,
+			submissions: []*model.Submission{submission(4*time.Hour, ac, 0)},
+			wantVisible: Result{Pending: 1},
+			wantFinal:   Result{Solved: true, SolvedAtMs: ms(4 * time.Hour)},
+		},
+		{
+			// 封榜前已通过的题目，封榜后的提交不显示
+			name: "icpc solved before freeze", rule: model.ContestRuleICPC, freeze: time.Hour,
+			submissions: []*model.Submission{submission(time.Hour, ac, 0), submission(4*time.Hour+30*time.Minute, wa, 0)},
+			wantVisible: Result{Solved: true, SolvedAtMs: ms(time.Hour)},
+			wantFinal:   Result{Solved: true, SolvedAtMs: ms(time.Hour)},
+		},
+		{
+			name: "icpc without freeze", rule: model.ContestRuleICPC,
+			submissions: []*model.Submission{submission(4*time.Hour+30*time.Minute, ac, 0)},
+			wantVisible: Result{Solved: true, SolvedAtMs: ms(4*time.Hour + 30*time.Minute)},
+			wantFinal:   Result{Solved: true, SolvedAtMs: ms(4*time.Hour + 30*time.Minute)},
+		},
+		{
+			name: "submitted before start", rule: model.ContestRuleICPC,
+			submissions: []*model.Submission{submission(-time.Minute, ac, 0)},
+			wantVisible: Result{Solved: true},
+			wantFinal:   Result{Solved: true},
+		},
+		{
+			name: "oi best score", rule: model.ContestRuleOI, freeze: time.Hour,
+			submissions: []*model.Submission{submission(10*time.Minute, wa, 30), submission(20*time.Minute, wa, 60), submission(30*time.Minute, tle, 40)},
+			wantVisible: Result{Attempts: 3, Score: 60},
+			wantFinal:   Result{Attempts: 3, Score: 60},
+		},
+		{
+			name: "oi accepted then lower score", rule: model.ContestRuleOI, freeze: time.Hour,
+			submissions: []*model.Submission{submission(time.Hour, ac, 100), submission(2*time.Hour, wa, 20), submission(3*time.Hour, ce, 0)},
+			wantVisible: Result{Attempts: 2, Score: 100, Solved: true, SolvedAtMs: ms(time.Hour)},
+			wantFinal:   Result{Attempts: 2, Score: 100, Solved: true, SolvedAtMs: ms(time.Hour)},
+		},
+		{
+			name: "oi after freeze", rule: model.ContestRuleOI, freeze: time.Hour,
+			submissions: []*model.Submission{submission(time.Hour, wa, 50), submission(4*time.Hour+30*time.Minute, ac, 100)},
+			wantVisible: Result{Attempts: 1, Score: 50, Pending: 1},
+			wantFinal:   Result{Attempts: 2, Score: 100, Solved: true, SolvedAtMs: ms(4*time.Hour + 30*time.Minute)},
+		},
+		{
+			name: "oi pending after accepted", rule: model.ContestRuleOI,
+			submissions: []*model.Submission{submission(time.Hour, ac, 100), submission(2*time.Hour, model.StatusPending, 0)},
+			wantVisible: Result{Attempts: 1, Score: 100, Solved: true, SolvedAtMs: ms(time.Hour), Pending: 1},
+			wantFinal:   Result{Attempts: 1, Score: 100, Solved: true, SolvedAtMs: ms(time.Hour), Pending: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contest := newContest(tt.rule, tt.freeze, 1)
+			userID := primitive.NewObjectID()
+			cell := NewCell(contest, userID, contest.ProblemIDs[0], tt.submissions)
+			if cell.Visible != tt.wantVisible {
+				t.Errorf("封榜视图 = %+v, 期望 %+v", cell.Visible, tt.wantVisible)
+			}
+			if cell.Final != tt.wantFinal {
+				t.Errorf("最终结果 = %+v, 期望 %+v", cell.Final, tt.wantFinal)
+			}
+			if cell.Frozen() != (tt.wantVisible != tt.wantFinal) {
+				t.Errorf("Frozen = %v", cell.Frozen())
+			}
+			if cell.UserID != userID.Hex() || cell.ProblemID != contest.ProblemIDs[0].Hex() {
+				t.Errorf("单元格 = %s, 期望 %s", cell.Key(), CellKey(userID.Hex(), contest.ProblemIDs[0].Hex()))
+			}
+		})
+	}
+}
+
+func TestNewCellVersion(t *testing.T) {
+	contest := newContest(model.ContestRuleICPC, 0, 1)
+	judgedAt := contestStart.Add(2 * time.Hour)
+	judged := submission(time.Hour, model.StatusWrongAnswer, 0)
+	judged.JudgedAt = &judgedAt
+	cell := NewCell(contest, primitive.NewObjectID(), contest.ProblemIDs[0],
+		[]*model.Submission{judged, submission(90*time.Minute, model.StatusPending, 0)})
+	if cell.Version != judgedAt.UnixMilli() {
+		t.Errorf("Version = %d, 期望最晚的评测时间 %d", cell.Version, judgedAt.UnixMilli())
+	}
+}
+
+// row 排行榜中用户的名次和成绩
+type row struct {
+	user    string
+	rank    int
+	solved  int
+	penalty int64
+	score   int
+}
+
+func rowsOf(standings *Standings) []row {
+	var rows []row
+	for _, r := range standings.Rows {
+		rows = append(rows, row{user: r.UserID, rank: r.Rank, solved: r.Solved, penalty: r.Penalty, score: r.Score})
+	}
+	return rows
+}
+
+func revealAll(*Cell) bool { return true }
+
+func TestBuild(t *testing.T) {
+	minutes := func(m int) int64 { return int64(m) * 60000 }
+	solved := func(attempts, atMinute int) Result {
+		return Result{Attempts: attempts, Solved: true, SolvedAtMs: minutes(atMinute)}
+	}
+	participants := []Participant{
+		{UserID: "u1", Username: "alice"}, {UserID: "u2", Username: "bob"}, {UserID: "u3", Username: "carol"},
+		{UserID: "u4", Username: "dave"}, {UserID: "u5", Username: "eve"}, // eve报名但没有提交
+	}
+
+	tests := []struct {
+		name       string
+		rule       string
+		cells      func(p []string) []Cell // p为题目ID
+		want       []row
+		firstBlood map[int]string // 题目下标 -> 该题一血的用户
+	}{
+		{
+			// 罚时 = 通过时间 + 通过前错误次数 × 20分钟
+			name: "icpc",
+			rule: model.ContestRuleICPC,
+			cells: func(p []string) []Cell {
+				return []Cell{
+					{UserID: "u1", ProblemID: p[0], Final: solved(1, 10)}, // 600 + 1200
+					{UserID: "u1", ProblemID: p[1], Final: solved(0, 50)}, // 3000
+					{UserID: "u2", ProblemID: p[0], Final: solved(0, 20)}, // 1200
+					{UserID: "u2", ProblemID: p[2], Final: solved(0, 60)}, // 3600
+					{UserID: "u3", ProblemID: p[0], Final: solved(0, 5)},
+					{UserID: "u3", ProblemID: p[1], Final: Result{Attempts: 3}},
+					{UserID: "u4", ProblemID: p[2], Final: Result{Attempts: 2, Pending: 1}},
+					{UserID: "u6", ProblemID: p[2], Final: solved(0, 100)},                        // 未报名的用户(如管理员)同样列出
+					{UserID: "u1", ProblemID: primitive.NewObjectID().Hex(), Final: solved(0, 1)}, // 已移除的题目
+				}
+			},
+			want: []row{
+				{user: "u1", rank: 1, solved: 2, penalty: 4800},
+				{user: "u2", rank: 1, solved: 2, penalty: 4800},
+				{user: "u3", rank: 3, solved: 1, penalty: 300},
+				{user: "u6", rank: 4, solved: 1, penalty: 6000},
+				{user: "u4", rank: 5},
+				{user: "u5", rank: 5},
+			},
+			firstBlood: map[int]string{0: "u3", 1: "u1", 2: "u2"},
+		},
+		{
+			name: "oi",
+			rule: model.ContestRuleOI,
+			cells: func(p []string) []Cell {
+				return []Cell{
+					{UserID: "u1", ProblemID: p[0], Final: Result{Attempts: 1, Score: 100, Solved: true, SolvedAtMs: minutes(30)}},
+					{UserID: "u1", ProblemID: p[1], Final: Result{Attempts: 2, Score: 50}},
+					{UserID: "u2", ProblemID: p[1], Final: Result{Attempts: 1, Score: 100, Solved: true, SolvedAtMs: minutes(30)}},
+					{UserID: "u2", ProblemID: p[2], Final: Result{Attempts: 4, Score: 50}},
+					{UserID: "u3", ProblemID: p[2], Final: Result{Attempts: 1, Score: 90}},
+					{UserID: "u4", ProblemID: p[0], Final: Result{Attempts: 1, Score: 100, Solved: true, SolvedAtMs: minutes(40)}},
+				}
+			},
+			want: []row{
+				{user: "u1", rank: 1, solved: 1, score: 150},
+				{user: "u2", rank: 1, solved: 1, score: 150},
+				{user: "u4", rank: 3, solved: 1, score: 100},
+				{user: "u3", rank: 4, score: 90},
+				{user: "u5", rank: 5},
+			},
+			firstBlood: map[int]string{0: "u1", 1: "u2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contest := newContest(tt.rule, 0, 3)
+			problemIDs := make([]string, len(contest.ProblemIDs))
+			for i, id := range contest.ProblemIDs {
+				problemIDs[i] = id.Hex()
+			}
+			cells := tt.cells(problemIDs)
+			for i := range cells {
+				cells[i].Visible = cells[i].Final
+			}
+
+			standings := Build(contest, participants, cells, revealAll)
+			if got := rowsOf(standings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("排行榜 = %+v, 期望 %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(standings.ProblemIDs, problemIDs) {
+				t.Errorf("题目 = %v, 期望 %v", standings.ProblemIDs, problemIDs)
+			}
+			for _, r := range standings.Rows {
+				if len(r.Problems) != len(problemIDs) {
+					t.Fatalf("%s的题目结果%d个, 期望%d个", r.UserID, len(r.Problems), len(problemIDs))
+				}
+				for i, problem := range r.Problems {
+					want := tt.firstBlood[i] == r.UserID
+					if problem.FirstBlood != want {
+						t.Errorf("%s第%d题一血 = %v, 期望 %v", r.UserID, i+1, problem.FirstBlood, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestResolver 封榜后按名次从后往前逐个揭晓单元格，揭晓完毕后与最终排行榜一致
+func TestResolver(t *testing.T) {
+	contest := newContest(model.ContestRuleICPC, time.Hour, 2)
+	p1, p2 := contest.ProblemIDs[0], contest.ProblemIDs[1]
+	users := map[string]primitive.ObjectID{"alice": primitive.NewObjectID(), "bob": primitive.NewObjectID(), "carol": primitive.NewObjectID()}
+	var participants []Participant
+	for name, id := range users {
+		participants = append(participants, Participant{UserID: id.Hex(), Username: name})
+	}
+	cells := []Cell{
+		NewCell(contest, users["alice"], p1, []*model.Submission{submission(time.Hour, model.StatusAccepted, 0)}),
+		NewCell(contest, users["alice"], p2, []*model.Submission{submission(4*time.Hour+30*time.Minute, model.StatusAccepted, 0)}),
+		NewCell(contest, users["bob"], p1, []*model.Submission{submission(2*time.Hour, model.StatusAccepted, 0)}),
+		NewCell(contest, users["bob"], p2, []*model.Submission{
+			submission(3*time.Hour, model.StatusWrongAnswer, 0),
+			submission(4*time.Hour+20*time.Minute, model.StatusAccepted, 0),
+		}),
+		NewCell(contest, users["carol"], p1, []*model.Submission{submission(4*time.Hour+10*time.Minute, model.StatusWrongAnswer, 0)}),
+	}
+	names := make(map[string]string, len(users))
+	for name, id := range users {
+		names[id.Hex()] = name
+	}
+	order := func(standings *Standings) []string {
+		var list []string
+		for _, r := range standings.Rows {
+			list = append(list, names[r.UserID])
+		}
+		return list
+	}
+
+	revealedKeys := make(map[string]bool)
+	revealed := func(cell *Cell) bool { return revealedKeys[cell.Key()] }
+	standings := Build(contest, participants, cells, revealed)
+	if got := order(standings); !reflect.DeepEqual(got, []string{"alice", "bob", "carol"}) {
+		t.Fatalf("封榜排行榜 = %v", got)
+	}
+	if r := standings.Row(users["bob"].Hex()); r.Solved != 1 || r.Problems[1].Pending != 1 || r.Problems[1].Attempts != 1 {
+		t.Errorf("封榜时bob = %+v, 期望通过1题、第2题显示1次未公布的提交", r)
+	}
+
+	// 每次揭晓后重新排名：carol揭晓后仍是0题；bob揭晓后升到第一；最后揭晓alice
+	steps := []struct {
+		user  string
+		order []string
+	}{
+		{user: "carol", order: []string{"alice", "bob", "carol"}},
+		{user: "bob", order: []string{"bob", "alice", "carol"}},
+		{user: "alice", order: []string{"alice", "bob", "carol"}},
+	}
+	for _, step := range steps {
+		cell := NextReveal(standings, cells, revealed)
+		if cell == nil || names[cell.UserID] != step.user {
+			t.Fatalf("揭晓 = %+v, 期望揭晓%s", cell, step.user)
+		}
+		revealedKeys[cell.Key()] = true
+		standings = Build(contest, participants, cells, revealed)
+		if got := order(standings); !reflect.DeepEqual(got, step.order) {
+			t.Errorf("揭晓%s后排行榜 = %v, 期望 %v", step.user, got, step.order)
+		}
+	}
+	if cell := NextReveal(standings, cells, revealed); cell != nil {
+		t.Errorf("全部揭晓后 NextReveal = %+v, 期望nil", cell)
+	}
+
+	final := Build(contest, participants, cells, revealAll)
+	if !reflect.DeepEqual(rowsOf(standings), rowsOf(final)) {
+		t.Errorf("揭晓完毕的排行榜 = %+v, 期望与最终排行榜 %+v 一致", rowsOf(standings), rowsOf(final))
+	}
+	if r := final.Row(users["alice"].Hex()); r.Solved != 2 || r.Penalty != 3600+16200 {
+		t.Errorf("alice = %+v, 期望通过2题、罚时19800", r)
+	}
+}
@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"math"
+	"sort"
+
+	"zhku-oj/internal/model"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// gradeStudent 计算一名学生各题的得分和总分
+// submissions为该学生的提交，只计开放时间到该学生关闭时间之间的提交；截止后的提交按迟交扣分曲线扣分。
+// 判题中的提交只计数；编译错误和系统错误与竞赛排行榜一致，不计入提交次数也不参与计分
+func gradeStudent(assignment *model.Assignment, fullScores map[primitive.ObjectID]int, userID primitive.ObjectID, submissions []*model.Submission) ([]serviceInterface.ProblemGrade, float64) {
+	dueTime, closeTime := assignment.DeadlinesFor(userID)
+	sort.Slice(submissions, func(i, j int) bool {
+		return submissions[i].SubmittedAt.Before(submissions[j].SubmittedAt)
+	})
+
+	grades := make([]serviceInterface.ProblemGrade, 0, len(assignment.Problems))
+	total := 0.0
+	for _, item := range assignment.Problems {
+		grade := serviceInterface.ProblemGrade{ProblemID: item.ProblemID}
+		var counted *model.Submission
+		for _, submission := range submissions {
+			if submission.ProblemID != item.ProblemID ||
+				submission.SubmittedAt.Before(assignment.OpenTime) || !submission.SubmittedAt.Before(closeTime) {
+				continue
+			}
+			switch submission.Status {
+			case model.StatusPending, model.StatusJudging:
+				grade.Pending++
+				continue
+			case model.StatusCompileError, model.StatusSystemError:
+				continue
+			}
+			grade.Attempts++
+
+			penalty := 0
+			if submission.SubmittedAt.After(dueTime) {
+				penalty = assignment.LatePenaltyPercent(submission.SubmittedAt.Sub(dueTime))
+			}
+			points := earnedPoints(item.Points, fullScores[item.ProblemID], submission) * float64(100-penalty) / 100
+			// best取得分最高的提交，同分取较早的；last取最后一次
+			if counted == nil || assignment.ScoringMode == model.AssignmentScoringLast || points > grade.Points {
+				counted = submission
+				grade.Points = points
+				grade.Penalty = penalty
+			}
+		}
+		if counted != nil {
+			grade.SubmissionID = &counted.ID
+			grade.SubmittedAt = &counted.SubmittedAt
+		}
+		grade.Points = roundPoints(grade.Points)
+		total += grade.Points
+		grades = append(grades, grade)
+	}
+	return grades, roundPoints(total)
+}
+
+// earnedPoints 提交按得分率折算的分值；题目没有设置分数(满分为0)时按是否通过计分
+func earnedPoints(points, fullScore int, submission *model.Submission) float64 {
+	if fullScore <= 0 {
+		if submission.Status == model.StatusAccepted {
+			return float64(points)
+		}
+		return 0
+	}
+	ratio := math.Min(float64(submission.Score)/float64(fullScore), 1)
+	return float64(points) * ratio
+}
+
+// roundPoints 分数保留两位小数
+func roundPoints(points float64) float64 {
+	return math.Round(points*100) / 100
+}
@@ -0,0 +1,205 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/rbac"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGradeStudent(t *testing.T) {
+	open := time.Date(2026, 9, 14, 8, 0, 0, 0, time.UTC)
+	due := open.Add(7 * 24 * time.Hour)
+	student, extended := primitive.NewObjectID(), primitive.NewObjectID()
+	problemID, unscored := primitive.NewObjectID(), primitive.NewObjectID()
+	fullScores := map[primitive.ObjectID]int{problemID: 50} // unscored没有设置测试用例分数
+
+	// submit 相对截止时间提交的提交记录
+	submit := func(problem primitive.ObjectID, offset time.Duration, status string, score int) *model.Submission {
+		return &model.Submission{ID: primitive.NewObjectID(), ProblemID: problem, Status: status, Score: score, SubmittedAt: due.Add(offset)}
+	}
+
+	tests := []struct {
+		name        string
+		mode        string
+		userID      primitive.ObjectID
+		problemID   primitive.ObjectID
+		submissions []*model.Submission
+		want        serviceInterface.ProblemGrade // 只比较Points、Penalty、Attempts、Pending
+		wantCounted int                           // 计分的提交在submissions中的下标，-1表示没有
+	}{
+		{
+			name: "partial score before due", problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, -time.Hour, model.StatusWrongAnswer, 20)},
+			want:        serviceInterface.ProblemGrade{Points: 24, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "late within first step", problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, time.Hour, model.StatusAccepted, 50)},
+			want:        serviceInterface.ProblemGrade{Points: 54, Penalty: 10, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "late beyond second step", problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, 25*time.Hour, model.StatusAccepted, 50)},
+			want:        serviceInterface.ProblemGrade{Points: 42, Penalty: 30, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "exactly at due is not late", problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, 0, model.StatusAccepted, 50)},
+			want:        serviceInterface.ProblemGrade{Points: 60, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "best prefers on-time partial over penalized full", problemID: problemID,
+			submissions: []*model.Submission{
+				submit(problemID, -time.Hour, model.StatusWrongAnswer, 48),
+				submit(problemID, 30*time.Hour, model.StatusAccepted, 50),
+			},
+			want: serviceInterface.ProblemGrade{Points: 57.6, Attempts: 2}, wantCounted: 0,
+		},
+		{
+			name: "last takes the final submission", mode: model.AssignmentScoringLast, problemID: problemID,
+			submissions: []*model.Submission{
+				submit(problemID, -2*time.Hour, model.StatusAccepted, 50),
+				submit(problemID, 2*time.Hour, model.StatusWrongAnswer, 10),
+			},
+			want: serviceInterface.ProblemGrade{Points: 10.8, Penalty: 10, Attempts: 2}, wantCounted: 1,
+		},
+		{
+			name: "pending and compile errors are not graded", problemID: problemID,
+			submissions: []*model.Submission{
+				submit(problemID, -3*time.Hour, model.StatusCompileError, 0),
+				submit(problemID, -2*time.Hour, model.StatusSystemError, 0),
+				submit(problemID, -time.Hour, model.StatusJudging, 0),
+			},
+			want: serviceInterface.ProblemGrade{Pending: 1}, wantCounted: -1,
+		},
+		{
+			name: "outside open and close", problemID: problemID,
+			submissions: []*model.Submission{
+				submit(problemID, -8*24*time.Hour, model.StatusAccepted, 50),
+				submit(problemID, 48*time.Hour, model.StatusAccepted, 50),
+			},
+			wantCounted: -1,
+		},
+		{
+			name: "extension moves due and close", userID: extended, problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, 60*time.Hour, model.StatusAccepted, 50)},
+			want:        serviceInterface.ProblemGrade{Points: 60, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "extension late penalty from extended due", userID: extended, problemID: problemID,
+			submissions: []*model.Submission{submit(problemID, 73*time.Hour, model.StatusAccepted, 50)},
+			want:        serviceInterface.ProblemGrade{Points: 54, Penalty: 10, Attempts: 1}, wantCounted: 0,
+		},
+		{
+			name: "unscored problem graded by acceptance", problemID: unscored,
+			submissions: []*model.Submission{
+				submit(unscored, -2*time.Hour, model.StatusAccepted, 0),
+				submit(unscored, -time.Hour, model.StatusWrongAnswer, 0),
+			},
+			want: serviceInterface.ProblemGrade{Points: 40, Attempts: 2}, wantCounted: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := tt.mode
+			if mode == "" {
+				mode = model.AssignmentScoringBest
+			}
+			userID := tt.userID
+			if userID.IsZero() {
+				userID = student
+			}
+			assignment := &model.Assignment{
+				Problems:  []model.AssignmentProblem{{ProblemID: problemID, Points: 60}, {ProblemID: unscored, Points: 40}},
+				OpenTime:  open,
+				DueTime:   due,
+				CloseTime: due.Add(36 * time.Hour),
+				// 迟交24小时内扣10%，超过24小时扣30%
+				LatePenalty: []model.LatePenaltyStep{{AfterHours: 0, Percent: 10}, {AfterHours: 24, Percent: 30}},
+				ScoringMode: mode,
+				Extensions: []model.AssignmentExtension{{UserID: extended,
+					DueTime: due.Add(72 * time.Hour), CloseTime: due.Add(96 * time.Hour)}},
+			}
+
+			grades, total := gradeStudent(assignment, fullScores, userID, tt.submissions)
+			var got serviceInterface.ProblemGrade
+			for _, grade := range grades {
+				if grade.ProblemID == tt.problemID {
+					got = grade
+				} else if grade.Points != 0 || grade.Attempts != 0 {
+					t.Errorf("其他题目得分 = %+v, 期望没有提交", grade)
+				}
+			}
+			if got.Points != tt.want.Points || got.Penalty != tt.want.Penalty ||
+				got.Attempts != tt.want.Attempts || got.Pending != tt.want.Pending {
+				t.Errorf("得分 = %+v, 期望 %+v", got, tt.want)
+			}
+			if total != tt.want.Points {
+				t.Errorf("总分 = %v, 期望 %v", total, tt.want.Points)
+			}
+			if tt.wantCounted < 0 {
+				if got.SubmissionID != nil {
+					t.Errorf("计分的提交 = %s, 期望没有", got.SubmissionID.Hex())
+				}
+			} else if got.SubmissionID == nil || *got.SubmissionID != tt.submissions[tt.wantCounted].ID {
+				t.Errorf("计分的提交 = %v, 期望第%d个提交", got.SubmissionID, tt.wantCounted)
+			}
+		})
+	}
+}
+
+// TestGradebookAssignmentSubmissions 成绩只计提交到本作业的提交，同一题目的练习提交和其他作业的提交不计
+func TestGradebookAssignmentSubmissions(t *testing.T) {
+	now := time.Now()
+	teacher := primitive.NewObjectID()
+	student := &model.User{StudentID: "2021001001", Username: "zhangsan", RealName: "张三"}
+	users := newFakeUserRepo(student)
+	problem := &model.Problem{Title: "两数之和", IsPublic: true}
+	problems := newFakeProblemRepo(problem)
+	course := &model.Course{TeacherIDs: []primitive.ObjectID{teacher}, StudentIDs: []primitive.ObjectID{student.ID}}
+	courses := newFakeCourseRepo(course)
+	newAssignment := func() *model.Assignment {
+		return &model.Assignment{
+			CourseID:    course.ID,
+			Problems:    []model.AssignmentProblem{{ProblemID: problem.ID, Points: 100}},
+			OpenTime:    now.Add(-2 * time.Hour),
+			DueTime:     now.Add(time.Hour),
+			CloseTime:   now.Add(time.Hour),
+			ScoringMode: model.AssignmentScoringBest,
+		}
+	}
+	week3, week4 := newAssignment(), newAssignment()
+	assignments := newFakeAssignmentRepo(week3, week4)
+
+	submit := func(assignmentID *primitive.ObjectID, status string) *model.Submission {
+		return &model.Submission{UserID: student.ID, ProblemID: problem.ID, AssignmentID: assignmentID,
+			Status: status, SubmittedAt: now.Add(-time.Hour)}
+	}
+	counted := submit(&week3.ID, model.StatusWrongAnswer)
+	submissions := newFakeSubmissionRepo(
+		counted,
+		submit(nil, model.StatusAccepted),       // 练习提交
+		submit(&week4.ID, model.StatusAccepted), // 其他作业的提交
+	)
+
+	service := NewAssignmentService(assignments, courses, problems, users, submissions)
+	gradebook, err := service.GetGradebook(context.Background(), week3.ID,
+		serviceInterface.Viewer{UserID: teacher, Role: model.RoleTeacher, Permissions: rbac.NewSet()})
+	if err != nil {
+		t.Fatalf("查询成绩册失败: %v", err)
+	}
+	if len(gradebook.Students) != 1 {
+		t.Fatalf("成绩册学生 = %d, 期望1", len(gradebook.Students))
+	}
+	grade := gradebook.Students[0].Problems[0]
+	if grade.Attempts != 1 || grade.Points != 0 || grade.SubmissionID == nil || *grade.SubmissionID != counted.ID {
+		t.Errorf("得分 = %+v, 期望只计提交到本作业的一次提交", grade)
+	}
+}
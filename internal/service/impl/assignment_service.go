@@ -0,0 +1,553 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAssignmentPoints = 100 // 未设置分值的作业题目按100分计
+	maxLatePenaltySteps     = 20
+)
+
+// assignmentService 作业服务实现
+type assignmentService struct {
+	assignmentRepo repoInterface.AssignmentRepository
+	courseRepo     repoInterface.CourseRepository
+	problemRepo    repoInterface.ProblemRepository
+	userRepo       repoInterface.UserRepository
+	submissionRepo repoInterface.SubmissionRepository
+}
+
+// NewAssignmentService 创建作业服务实例
+func NewAssignmentService(
+	assignmentRepo repoInterface.AssignmentRepository,
+	courseRepo repoInterface.CourseRepository,
+	problemRepo repoInterface.ProblemRepository,
+	userRepo repoInterface.UserRepository,
+	submissionRepo repoInterface.SubmissionRepository,
+) serviceInterface.AssignmentService {
+	return &assignmentService{
+		assignmentRepo: assignmentRepo,
+		courseRepo:     courseRepo,
+		problemRepo:    problemRepo,
+		userRepo:       userRepo,
+		submissionRepo: submissionRepo,
+	}
+}
+
+// CreateAssignment 创建作业
+func (s *assignmentService) CreateAssignment(ctx context.Context, courseID primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.CreateAssignmentRequest) (*serviceInterface.AssignmentDetail, error) {
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, courseError(err)
+	}
+	if !canManageCourse(course, viewer) {
+		return nil, errors.New(errors.FORBIDDEN, "只能管理自己任教的课程")
+	}
+
+	problems, err := s.resolveProblems(ctx, req.Problems)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &model.Assignment{
+		CourseID:    course.ID,
+		Title:       req.Title,
+		Description: req.Description,
+		Problems:    problems,
+		OpenTime:    req.OpenTime,
+		DueTime:     req.DueTime,
+		CloseTime:   req.DueTime,
+		LatePenalty: req.LatePenalty,
+		ScoringMode: req.ScoringMode,
+		CreatedBy:   viewer.UserID,
+	}
+	if req.CloseTime != nil {
+		assignment.CloseTime = *req.CloseTime
+	}
+	if assignment.ScoringMode == "" {
+		assignment.ScoringMode = model.AssignmentScoringBest
+	}
+	if err := validateAssignment(assignment); err != nil {
+		return nil, err
+	}
+
+	if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	logger.Info("创建作业", "assignment_id", assignment.ID.Hex(), "course_id", course.ID.Hex(), "operator", viewer.UserID.Hex())
+	return newAssignmentDetail(assignment, viewer, true, time.Now()), nil
+}
+
+// UpdateAssignment 更新作业
+func (s *assignmentService) UpdateAssignment(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.UpdateAssignmentRequest) (*serviceInterface.AssignmentDetail, error) {
+	assignment, _, err := s.getManagedAssignment(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != "" {
+		assignment.Title = req.Title
+	}
+	if req.Description != nil {
+		assignment.Description = *req.Description
+	}
+	if req.Problems != nil {
+		problems, err := s.resolveProblems(ctx, req.Problems)
+		if err != nil {
+			return nil, err
+		}
+		assignment.Problems = problems
+	}
+	if req.OpenTime != nil {
+		assignment.OpenTime = *req.OpenTime
+	}
+	if req.DueTime != nil {
+		assignment.DueTime = *req.DueTime
+	}
+	if req.CloseTime != nil {
+		assignment.CloseTime = *req.CloseTime
+	}
+	if req.LatePenalty != nil {
+		assignment.LatePenalty = *req.LatePenalty
+	}
+	if req.ScoringMode != "" {
+		assignment.ScoringMode = req.ScoringMode
+	}
+	if err := validateAssignment(assignment); err != nil {
+		return nil, err
+	}
+
+	if err := s.assignmentRepo.Update(ctx, assignment); err != nil {
+		return nil, assignmentError(err)
+	}
+	logger.Info("更新作业", "assignment_id", id.Hex(), "operator", viewer.UserID.Hex())
+	return newAssignmentDetail(assignment, viewer, true, time.Now()), nil
+}
+
+// DeleteAssignment 删除作业
+func (s *assignmentService) DeleteAssignment(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) error {
+	if _, _, err := s.getManagedAssignment(ctx, id, viewer); err != nil {
+		return err
+	}
+	if err := s.assignmentRepo.Delete(ctx, id); err != nil {
+		return assignmentError(err)
+	}
+	logger.Info("删除作业", "assignment_id", id.Hex(), "operator", viewer.UserID.Hex())
+	return nil
+}
+
+// GetAssignment 获取作业详情，课程学生同时返回本人的成绩
+func (s *assignmentService) GetAssignment(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.AssignmentDetail, error) {
+	assignment, course, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := checkAssignmentAccess(course, viewer)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !staff && now.Before(assignment.OpenTime) {
+		return nil, errors.New(errors.ASSIGNMENT_NOT_OPEN)
+	}
+
+	detail := newAssignmentDetail(assignment, viewer, staff, now)
+	if course.MemberRole(viewer.UserID) == model.CourseRoleStudent {
+		problems, err := s.loadProblems(ctx, assignment)
+		if err != nil {
+			return nil, err
+		}
+		grades, err := s.grade(ctx, assignment, problems, []primitive.ObjectID{viewer.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if len(grades) > 0 {
+			detail.MyGrade = grades[0]
+		}
+	}
+	return detail, nil
+}
+
+// ListAssignments 分页查询课程的作业
+func (s *assignmentService) ListAssignments(ctx context.Context, courseID primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.AssignmentListRequest) (*serviceInterface.AssignmentListResponse, error) {
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, courseError(err)
+	}
+	staff, err := checkAssignmentAccess(course, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filters := map[string]interface{}{"course_id": course.ID}
+	if !staff {
+		filters["opened_before"] = now
+	}
+	assignments, total, err := s.assignmentRepo.List(ctx, req.Page, req.PageSize, filters)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	details := make([]*serviceInterface.AssignmentDetail, 0, len(assignments))
+	for _, assignment := range assignments {
+		details = append(details, newAssignmentDetail(assignment, viewer, staff, now))
+	}
+	return &serviceInterface.AssignmentListResponse{
+		Assignments: details,
+		Total:       total,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+	}, nil
+}
+
+// SetExtension 为课程学生设置延期
+func (s *assignmentService) SetExtension(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.ExtensionRequest) (*model.AssignmentExtension, error) {
+	assignment, course, err := s.getManagedAssignment(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return nil, errors.New(errors.INVALID_PARAMS, "用户ID格式错误")
+	}
+	if course.MemberRole(userID) != model.CourseRoleStudent {
+		return nil, errors.New(errors.INVALID_PARAMS, "只能为课程学生设置延期")
+	}
+
+	// 未指定关闭时间时保留作业原有的迟交时长
+	extension := model.AssignmentExtension{
+		UserID:    userID,
+		DueTime:   req.DueTime,
+		CloseTime: req.DueTime.Add(assignment.CloseTime.Sub(assignment.DueTime)),
+		Reason:    req.Reason,
+		GrantedBy: viewer.UserID,
+	}
+	if req.CloseTime != nil {
+		extension.CloseTime = *req.CloseTime
+	}
+	if !extension.DueTime.After(assignment.OpenTime) {
+		return nil, errors.New(errors.INVALID_PARAMS, "延期的截止时间必须晚于作业开放时间")
+	}
+	if extension.CloseTime.Before(extension.DueTime) {
+		return nil, errors.New(errors.INVALID_PARAMS, "关闭时间不能早于截止时间")
+	}
+
+	if err := s.assignmentRepo.SetExtension(ctx, id, extension); err != nil {
+		return nil, assignmentError(err)
+	}
+	logger.Info("设置作业延期", "assignment_id", id.Hex(), "user_id", userID.Hex(),
+		"due_time", extension.DueTime, "operator", viewer.UserID.Hex())
+	return &extension, nil
+}
+
+// RemoveExtension 取消学生的延期
+func (s *assignmentService) RemoveExtension(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, userID primitive.ObjectID) error {
+	if _, _, err := s.getManagedAssignment(ctx, id, viewer); err != nil {
+		return err
+	}
+	removed, err := s.assignmentRepo.RemoveExtension(ctx, id, userID)
+	if err != nil {
+		return assignmentError(err)
+	}
+	if !removed {
+		return errors.New(errors.NOT_FOUND, "该学生没有延期")
+	}
+	logger.Info("取消作业延期", "assignment_id", id.Hex(), "user_id", userID.Hex(), "operator", viewer.UserID.Hex())
+	return nil
+}
+
+// GetGradebook 计算课程全部学生的作业成绩
+func (s *assignmentService) GetGradebook(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.Gradebook, error) {
+	assignment, course, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isCourseStaff(course, viewer) {
+		return nil, errors.New(errors.COURSE_ACCESS_DENIED, "只有课程的教师和助教可以查看成绩册")
+	}
+
+	problems, err := s.loadProblems(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.grade(ctx, assignment, problems, course.StudentIDs)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(students, func(i, j int) bool {
+		return students[i].StudentID < students[j].StudentID
+	})
+
+	return &serviceInterface.Gradebook{
+		AssignmentID: assignment.ID,
+		Title:        assignment.Title,
+		ScoringMode:  assignment.ScoringMode,
+		MaxPoints:    maxPoints(assignment),
+		Problems:     gradebookProblems(assignment, problems),
+		Students:     students,
+	}, nil
+}
+
+// CheckSubmission 检查作业提交
+func (s *assignmentService) CheckSubmission(ctx context.Context, assignmentID, userID, problemID primitive.ObjectID) (*model.Assignment, error) {
+	assignment, course, err := s.getAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if course.MemberRole(userID) == "" {
+		return nil, errors.New(errors.COURSE_ACCESS_DENIED, "不是该课程的成员")
+	}
+	if !assignment.HasProblem(problemID) {
+		return nil, errors.New(errors.PROBLEM_NOT_FOUND, "题目不属于该作业")
+	}
+
+	// 关闭时间按本人的延期计算
+	switch assignment.StatusAt(userID, time.Now()) {
+	case model.AssignmentStatusUpcoming:
+		return nil, errors.New(errors.ASSIGNMENT_NOT_OPEN)
+	case model.AssignmentStatusClosed:
+		return nil, errors.New(errors.ASSIGNMENT_CLOSED)
+	}
+	return assignment, nil
+}
+
+// grade 计算学生的作业成绩，只读取计分时间范围内提交到该作业的提交
+func (s *assignmentService) grade(ctx context.Context, assignment *model.Assignment, problems map[primitive.ObjectID]*model.Problem, userIDs []primitive.ObjectID) ([]*serviceInterface.StudentGrade, error) {
+	if len(userIDs) == 0 {
+		return []*serviceInterface.StudentGrade{}, nil
+	}
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	problemIDs := make([]primitive.ObjectID, 0, len(assignment.Problems))
+	fullScores := make(map[primitive.ObjectID]int, len(problems))
+	for _, item := range assignment.Problems {
+		problemIDs = append(problemIDs, item.ProblemID)
+		if problem, ok := problems[item.ProblemID]; ok {
+			fullScores[item.ProblemID] = problem.FullScore()
+		}
+	}
+
+	// 同一题目可能同时出现在多个作业中，只计提交时指定了本作业的提交
+	filters := map[string]interface{}{
+		"assignment_id":  assignment.ID,
+		"user_id":        map[string]interface{}{"$in": userIDs},
+		"problem_id":     map[string]interface{}{"$in": problemIDs},
+		"submitted_from": assignment.OpenTime,
+		"submitted_to":   assignment.LatestClose(),
+	}
+	submissions, err := listAllSubmissions(ctx, s.submissionRepo, filters)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	byUser := make(map[primitive.ObjectID][]*model.Submission)
+	for _, submission := range submissions {
+		byUser[submission.UserID] = append(byUser[submission.UserID], submission)
+	}
+
+	grades := make([]*serviceInterface.StudentGrade, 0, len(users))
+	for _, user := range users {
+		dueTime, _ := assignment.DeadlinesFor(user.ID)
+		grade := &serviceInterface.StudentGrade{
+			UserID:    user.ID,
+			StudentID: user.StudentID,
+			Username:  user.Username,
+			RealName:  user.RealName,
+			Class:     user.Class,
+			DueTime:   dueTime,
+			Extended:  hasExtension(assignment, user.ID),
+		}
+		grade.Problems, grade.Total = gradeStudent(assignment, fullScores, user.ID, byUser[user.ID])
+		grades = append(grades, grade)
+	}
+	return grades, nil
+}
+
+// loadProblems 读取作业的题目，已删除的题目不在结果中
+func (s *assignmentService) loadProblems(ctx context.Context, assignment *model.Assignment) (map[primitive.ObjectID]*model.Problem, error) {
+	problems := make(map[primitive.ObjectID]*model.Problem, len(assignment.Problems))
+	for _, item := range assignment.Problems {
+		problem, err := s.problemRepo.GetByID(ctx, item.ProblemID)
+		if err != nil {
+			if stdErrors.Is(err, repoInterface.ErrProblemNotFound) {
+				continue
+			}
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		problems[item.ProblemID] = problem
+	}
+	return problems, nil
+}
+
+// resolveProblems 检查作业题目存在、已公开且不重复，未设置分值时按默认分值
+func (s *assignmentService) resolveProblems(ctx context.Context, items []serviceInterface.AssignmentProblemRequest) ([]model.AssignmentProblem, error) {
+	problems := make([]model.AssignmentProblem, 0, len(items))
+	seen := make(map[primitive.ObjectID]bool, len(items))
+	for _, item := range items {
+		problemID, err := primitive.ObjectIDFromHex(item.ProblemID)
+		if err != nil {
+			return nil, errors.New(errors.INVALID_PARAMS, "题目ID格式错误: "+item.ProblemID)
+		}
+		if seen[problemID] {
+			return nil, errors.New(errors.INVALID_PARAMS, "作业题目重复: "+item.ProblemID)
+		}
+		seen[problemID] = true
+
+		problem, err := s.problemRepo.GetByID(ctx, problemID)
+		if err != nil {
+			if stdErrors.Is(err, repoInterface.ErrProblemNotFound) {
+				return nil, errors.New(errors.PROBLEM_NOT_FOUND, "题目不存在: "+item.ProblemID)
+			}
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		// 非公开题目只能在竞赛中提交，学生无法完成
+		if !problem.IsPublic {
+			return nil, errors.New(errors.INVALID_PARAMS, fmt.Sprintf("题目「%s」未公开，学生无法提交", problem.Title))
+		}
+
+		points := item.Points
+		if points == 0 {
+			points = defaultAssignmentPoints
+		}
+		problems = append(problems, model.AssignmentProblem{ProblemID: problemID, Points: points})
+	}
+	return problems, nil
+}
+
+// getAssignment 获取作业及其所属课程
+func (s *assignmentService) getAssignment(ctx context.Context, id primitive.ObjectID) (*model.Assignment, *model.Course, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, assignmentError(err)
+	}
+	course, err := s.courseRepo.GetByID(ctx, assignment.CourseID)
+	if err != nil {
+		return nil, nil, courseError(err)
+	}
+	return assignment, course, nil
+}
+
+// getManagedAssignment 获取作业并检查当前用户是否可以管理所属课程
+func (s *assignmentService) getManagedAssignment(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Assignment, *model.Course, error) {
+	assignment, course, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !canManageCourse(course, viewer) {
+		return nil, nil, errors.New(errors.FORBIDDEN, "只能管理自己任教的课程")
+	}
+	return assignment, course, nil
+}
+
+// checkAssignmentAccess 检查当前用户是否可以查看课程的作业，返回是否是课程的教师、助教或拥有course:manage:any
+func checkAssignmentAccess(course *model.Course, viewer serviceInterface.Viewer) (bool, error) {
+	if isCourseStaff(course, viewer) {
+		return true, nil
+	}
+	if course.MemberRole(viewer.UserID) == "" {
+		return false, errors.New(errors.COURSE_ACCESS_DENIED, "不是该课程的成员")
+	}
+	return false, nil
+}
+
+// validateAssignment 检查作业时间和迟交扣分规则
+func validateAssignment(assignment *model.Assignment) error {
+	if !assignment.DueTime.After(assignment.OpenTime) {
+		return errors.New(errors.INVALID_PARAMS, "截止时间必须晚于开放时间")
+	}
+	if assignment.CloseTime.Before(assignment.DueTime) {
+		return errors.New(errors.INVALID_PARAMS, "关闭时间不能早于截止时间")
+	}
+	if assignment.ScoringMode != model.AssignmentScoringBest && assignment.ScoringMode != model.AssignmentScoringLast {
+		return errors.New(errors.INVALID_PARAMS, "计分方式只能为best或last")
+	}
+
+	if len(assignment.LatePenalty) > maxLatePenaltySteps {
+		return errors.New(errors.INVALID_PARAMS, fmt.Sprintf("迟交扣分规则最多%d条", maxLatePenaltySteps))
+	}
+	for i, step := range assignment.LatePenalty {
+		if step.AfterHours < 0 || step.Percent < 0 || step.Percent > 100 {
+			return errors.New(errors.INVALID_PARAMS, "迟交扣分规则的小时数不能为负，扣分比例在0到100之间")
+		}
+		if i > 0 {
+			prev := assignment.LatePenalty[i-1]
+			if step.AfterHours <= prev.AfterHours || step.Percent < prev.Percent {
+				return errors.New(errors.INVALID_PARAMS, "迟交扣分规则需按小时数递增，扣分比例不能减少")
+			}
+		}
+	}
+	return nil
+}
+
+// newAssignmentDetail 作业详情，非教师、助教只能看到自己的延期
+func newAssignmentDetail(assignment *model.Assignment, viewer serviceInterface.Viewer, staff bool, now time.Time) *serviceInterface.AssignmentDetail {
+	if !staff {
+		own := []model.AssignmentExtension{}
+		for _, extension := range assignment.Extensions {
+			if extension.UserID == viewer.UserID {
+				own = append(own, extension)
+			}
+		}
+		assignment.Extensions = own
+	}
+	return &serviceInterface.AssignmentDetail{
+		Assignment: assignment,
+		Status:     assignment.StatusAt(viewer.UserID, now),
+		MaxPoints:  maxPoints(assignment),
+	}
+}
+
+// gradebookProblems 成绩册的题目列表，已删除的题目标题为空
+func gradebookProblems(assignment *model.Assignment, problems map[primitive.ObjectID]*model.Problem) []serviceInterface.GradebookProblem {
+	items := make([]serviceInterface.GradebookProblem, 0, len(assignment.Problems))
+	for _, item := range assignment.Problems {
+		entry := serviceInterface.GradebookProblem{ProblemID: item.ProblemID, Points: item.Points}
+		if problem, ok := problems[item.ProblemID]; ok {
+			entry.Title = problem.Title
+		}
+		items = append(items, entry)
+	}
+	return items
+}
+
+// maxPoints 作业各题分值之和
+func maxPoints(assignment *model.Assignment) int {
+	total := 0
+	for _, problem := range assignment.Problems {
+		total += problem.Points
+	}
+	return total
+}
+
+// hasExtension 学生是否有延期
+func hasExtension(assignment *model.Assignment, userID primitive.ObjectID) bool {
+	for _, extension := range assignment.Extensions {
+		if extension.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// assignmentError 将仓储错误转换为业务错误
+func assignmentError(err error) error {
+	if stdErrors.Is(err, repoInterface.ErrAssignmentNotFound) {
+		return errors.New(errors.ASSIGNMENT_NOT_FOUND)
+	}
+	return errors.Wrap(errors.DATABASE_ERROR, err)
+}
@@ -0,0 +1,363 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/jwtauth"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/session"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authService 认证服务实现
+type authService struct {
+	userRepo repoInterface.UserRepository
+	sessions *session.Store
+	signer   *jwtauth.Signer
+	guard    *ratelimit.LoginGuard
+	policy   *pwdpolicy.Policy
+	cfg      config.JWTConfig
+}
+
+// NewAuthService 创建认证服务实例
+func NewAuthService(
+	userRepo repoInterface.UserRepository,
+	sessions *session.Store,
+	signer *jwtauth.Signer,
+	guard *ratelimit.LoginGuard,
+	policy *pwdpolicy.Policy,
+	cfg *config.Config,
+) serviceInterface.AuthService {
+	return &authService{
+		userRepo: userRepo,
+		sessions: sessions,
+		signer:   signer,
+		guard:    guard,
+		policy:   policy,
+		cfg:      cfg.JWT,
+	}
+}
+
+// Register 注册学生账号
+func (s *authService) Register(ctx context.Context, req *serviceInterface.RegisterRequest) (*model.User, error) {
+	checks := []struct {
+		exists func(context.Context, string) (bool, error)
+		value  string
+		code   int
+	}{
+		{s.userRepo.ExistsByUsername, req.Username, errors.USERNAME_ALREADY_EXISTS},
+		{s.userRepo.ExistsByEmail, req.Email, errors.EMAIL_ALREADY_EXISTS},
+		{s.userRepo.ExistsByStudentID, req.StudentID, errors.STUDENT_ID_ALREADY_EXISTS},
+	}
+	for _, check := range checks {
+		exists, err := check.exists(ctx, check.value)
+		if err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if exists {
+			return nil, errors.New(check.code)
+		}
+	}
+
+	if err := s.policy.Validate(req.Password, req.Username, req.StudentID, req.Email); err != nil {
+		return nil, errors.New(errors.PASSWORD_TOO_WEAK, err.Error())
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+
+	user := &model.User{
+		StudentID: req.StudentID,
+		Username:  req.Username,
+		Password:  string(hashedPassword),
+		Email:     req.Email,
+		RealName:  req.RealName,
+		Role:      model.RoleStudent,
+		Grade:     req.Grade,
+		IsActive:  true,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// 并发注册时唯一索引冲突
+		switch {
+		case stdErrors.Is(err, repoInterface.ErrUsernameExists):
+			return nil, errors.New(errors.USERNAME_ALREADY_EXISTS)
+		case stdErrors.Is(err, repoInterface.ErrEmailExists):
+			return nil, errors.New(errors.EMAIL_ALREADY_EXISTS)
+		case stdErrors.Is(err, repoInterface.ErrStudentIDExists):
+			return nil, errors.New(errors.STUDENT_ID_ALREADY_EXISTS)
+		}
+		return nil, errors.Wrap(errors.REGISTER_FAILED, err)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// Login 登录，创建会话并签发访问token和刷新token
+// 账号或IP因失败次数过多被锁定时，锁定期间不再校验密码
+func (s *authService) Login(ctx context.Context, req *serviceInterface.LoginRequest, client serviceInterface.ClientInfo) (*serviceInterface.TokenPair, error) {
+	locked, err := s.guard.Locked(ctx, req.Username, client.IP)
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	if locked > 0 {
+		return nil, loginLockedError(locked)
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, s.loginFailed(ctx, req.Username, client)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, s.loginFailed(ctx, req.Username, client)
+	}
+	if !user.IsActive {
+		return nil, errors.New(errors.USER_DISABLED)
+	}
+	if err := s.guard.Succeed(ctx, req.Username); err != nil {
+		logger.Warn("清除登录失败次数失败", "username", req.Username, "error", err)
+	}
+	return s.IssueSession(ctx, user, req.DeviceName, client)
+}
+
+// loginFailed 记录登录失败，达到锁定阈值时返回锁定错误
+func (s *authService) loginFailed(ctx context.Context, username string, client serviceInterface.ClientInfo) error {
+	locked, err := s.guard.Fail(ctx, username, client.IP)
+	if err != nil {
+		logger.Error("记录登录失败次数失败", "username", username, "ip", client.IP, "error", err)
+	}
+	if locked > 0 {
+		logger.Warn("登录失败次数过多，暂时锁定", "username", username, "ip", client.IP, "duration", locked.String())
+		return loginLockedError(locked)
+	}
+	return errors.New(errors.LOGIN_FAILED, "用户名或密码错误")
+}
+
+// loginLockedError 锁定错误，提示需要等待的时间(向上取整)
+func loginLockedError(wait time.Duration) error {
+	if wait < time.Minute {
+		return errors.New(errors.LOGIN_LOCKED, fmt.Sprintf("请%d秒后重试", int((wait+time.Second-1)/time.Second)))
+	}
+	return errors.New(errors.LOGIN_LOCKED, fmt.Sprintf("请%d分钟后重试", int((wait+time.Minute-1)/time.Minute)))
+}
+
+// IssueSession 为已通过认证的用户创建会话并签发token
+func (s *authService) IssueSession(ctx context.Context, user *model.User, deviceName string, client serviceInterface.ClientInfo) (*serviceInterface.TokenPair, error) {
+	sessionID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	now := time.Now()
+	sess := &session.Session{
+		ID:           sessionID,
+		UserID:       user.ID.Hex(),
+		Device:       deviceName,
+		UserAgent:    client.UserAgent,
+		LoginIP:      client.IP,
+		LastIP:       client.IP,
+		CreatedAt:    now,
+		LastActivity: now,
+	}
+	pair, err := s.issue(user, sess)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Create(ctx, sess, s.cfg.MaxSessions); err != nil {
+		return nil, errors.Wrap(errors.LOGIN_FAILED, err)
+	}
+
+	if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		logger.Warn("更新最后登录时间失败", "user_id", user.ID.Hex(), "error", err)
+	}
+	return pair, nil
+}
+
+// Refresh 使用刷新token换取新的token，刷新token随之轮换
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client serviceInterface.ClientInfo) (*serviceInterface.TokenPair, error) {
+	userID, sessionID, err := s.sessions.Lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	if sessionID == "" {
+		return nil, errors.New(errors.INVALID_TOKEN, "刷新token无效或已过期")
+	}
+	sess, err := s.sessions.Get(ctx, userID, sessionID)
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	if sess == nil {
+		return nil, errors.New(errors.INVALID_TOKEN, "会话已注销")
+	}
+
+	// 重新读取用户，角色变更和停用在刷新时生效
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New(errors.INVALID_TOKEN)
+	}
+	user, err := s.userRepo.GetByID(ctx, oid)
+	if err != nil || !user.IsActive {
+		if _, revokeErr := s.sessions.RevokeAll(ctx, userID, ""); revokeErr != nil {
+			logger.Error("注销会话失败", "user_id", userID, "error", revokeErr)
+		}
+		if err != nil {
+			return nil, errors.New(errors.INVALID_TOKEN, "用户不存在")
+		}
+		return nil, errors.New(errors.USER_DISABLED)
+	}
+
+	oldAccessID, oldAccessExpiresAt := sess.AccessTokenID, sess.AccessExpiresAt
+	sess.LastIP = client.IP
+	sess.UserAgent = client.UserAgent
+	sess.LastActivity = time.Now()
+	pair, err := s.issue(user, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求中的刷新token不是会话当前的刷新token时轮换失败
+	result, err := s.sessions.Rotate(ctx, session.HashToken(refreshToken), sess)
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	switch result {
+	case session.RotateMissing:
+		return nil, errors.New(errors.INVALID_TOKEN, "会话已注销")
+	case session.RotateReused:
+		// 旧的刷新token被再次使用，token可能已泄露，注销整个会话
+		logger.Warn("刷新token重复使用，注销会话", "user_id", userID, "session_id", sessionID, "ip", client.IP)
+		if _, err := s.sessions.Revoke(ctx, userID, sessionID); err != nil {
+			logger.Error("注销会话失败", "user_id", userID, "session_id", sessionID, "error", err)
+		}
+		return nil, errors.New(errors.INVALID_TOKEN, "刷新token已使用，会话已注销")
+	}
+
+	// 轮换前签发的访问token立即失效
+	if err := s.sessions.RevokeToken(ctx, oldAccessID, oldAccessExpiresAt); err != nil {
+		logger.Warn("撤销访问token失败", "user_id", userID, "error", err)
+	}
+	return pair, nil
+}
+
+// issue 为会话签发访问token和新的刷新token，并将token信息写入会话
+func (s *authService) issue(user *model.User, sess *session.Session) (*serviceInterface.TokenPair, error) {
+	accessToken, claims, err := s.signer.Generate(user, sess.ID, s.cfg.AccessExpire)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+
+	sess.AccessTokenID = claims.ID
+	sess.AccessExpiresAt = claims.ExpiresAt.Time
+	sess.RefreshHash = session.HashToken(refreshToken)
+	sess.ExpiresAt = time.Now().Add(s.cfg.RefreshExpire)
+
+	user.Password = ""
+	return &serviceInterface.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.cfg.AccessExpire / time.Second),
+		RefreshExpiresIn: int64(s.cfg.RefreshExpire / time.Second),
+		SessionID:        sess.ID,
+		User:             user,
+	}, nil
+}
+
+// Logout 注销当前会话
+func (s *authService) Logout(ctx context.Context, userID primitive.ObjectID, sessionID string) error {
+	if _, err := s.sessions.Revoke(ctx, userID.Hex(), sessionID); err != nil {
+		return errors.Wrap(errors.LOGOUT_FAILED, err)
+	}
+	return nil
+}
+
+// ListSessions 获取用户的登录会话，最近活动的排在前面
+func (s *authService) ListSessions(ctx context.Context, userID primitive.ObjectID, currentSessionID string) ([]*serviceInterface.SessionInfo, error) {
+	sessions, err := s.sessions.List(ctx, userID.Hex())
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+
+	infos := make([]*serviceInterface.SessionInfo, 0, len(sessions))
+	for _, sess := range sessions {
+		infos = append(infos, &serviceInterface.SessionInfo{
+			ID:           sess.ID,
+			Device:       sess.Device,
+			UserAgent:    sess.UserAgent,
+			LoginIP:      sess.LoginIP,
+			LastIP:       sess.LastIP,
+			CreatedAt:    sess.CreatedAt,
+			LastActivity: sess.LastActivity,
+			ExpiresAt:    sess.ExpiresAt,
+			Current:      sess.ID == currentSessionID,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastActivity.After(infos[j].LastActivity)
+	})
+	return infos, nil
+}
+
+// RevokeSession 注销指定会话
+func (s *authService) RevokeSession(ctx context.Context, userID primitive.ObjectID, sessionID string) error {
+	revoked, err := s.sessions.Revoke(ctx, userID.Hex(), sessionID)
+	if err != nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	if !revoked {
+		return errors.New(errors.SESSION_NOT_FOUND)
+	}
+	return nil
+}
+
+// RevokeOtherSessions 注销当前会话以外的全部会话
+func (s *authService) RevokeOtherSessions(ctx context.Context, userID primitive.ObjectID, currentSessionID string) (int, error) {
+	count, err := s.sessions.RevokeAll(ctx, userID.Hex(), currentSessionID)
+	if err != nil {
+		return count, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	return count, nil
+}
+
+// UnlockUser 按用户名解除账号锁定
+func (s *authService) UnlockUser(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, userError(err)
+	}
+	unlocked, err := s.guard.UnlockAccount(ctx, user.Username)
+	if err != nil {
+		return false, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	logger.Info("解除账号登录锁定", "user_id", userID.Hex(), "username", user.Username, "was_locked", unlocked)
+	return unlocked, nil
+}
+
+// UnlockIP 解除IP锁定
+func (s *authService) UnlockIP(ctx context.Context, ip string) (bool, error) {
+	unlocked, err := s.guard.UnlockIP(ctx, ip)
+	if err != nil {
+		return false, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	logger.Info("解除IP登录锁定", "ip", ip, "was_locked", unlocked)
+	return unlocked, nil
+}
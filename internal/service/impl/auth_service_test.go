@@ -0,0 +1,63 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/ratelimit"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUnlockUser(t *testing.T) {
+	user := &model.User{Username: "ZhangSan"}
+	tests := []struct {
+		name         string
+		failures     int
+		unknown      bool
+		wantCode     int
+		wantUnlocked bool
+	}{
+		{name: "locked", failures: 2, wantUnlocked: true},
+		{name: "not locked", failures: 1},
+		{name: "unknown user", unknown: true, wantCode: errors.USER_NOT_FOUND},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			guard := ratelimit.NewLoginGuard(client, config.LoginLockoutConfig{Enabled: true, AccountThreshold: 2})
+			users := newFakeUserRepo(user)
+			service := NewAuthService(users, nil, nil, guard, nil, &config.Config{})
+
+			ctx := context.Background()
+			for i := 0; i < tt.failures; i++ {
+				if _, err := guard.Fail(ctx, "zhangsan", "10.0.0.1"); err != nil {
+					t.Fatalf("记录登录失败出错: %v", err)
+				}
+			}
+			userID := user.ID
+			if tt.unknown {
+				userID = primitive.NewObjectID()
+			}
+
+			unlocked, err := service.UnlockUser(ctx, userID)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("解除锁定错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+			}
+			if unlocked != tt.wantUnlocked {
+				t.Errorf("解除前处于锁定 = %v, 期望 %v", unlocked, tt.wantUnlocked)
+			}
+			if locked, _ := guard.Locked(ctx, user.Username, ""); tt.wantCode == errors.SUCCESS && locked != 0 {
+				t.Errorf("解除后剩余锁定时间 = %v, 期望0", locked)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contestService 竞赛服务实现
+type contestService struct {
+	contestRepo    repoInterface.ContestRepository
+	problemRepo    repoInterface.ProblemRepository
+	submissionRepo repoInterface.SubmissionRepository
+	userRepo       repoInterface.UserRepository
+}
+
+// NewContestService 创建竞赛服务实例
+func NewContestService(
+	contestRepo repoInterface.ContestRepository,
+	problemRepo repoInterface.ProblemRepository,
+	submissionRepo repoInterface.SubmissionRepository,
+	userRepo repoInterface.UserRepository,
+) serviceInterface.ContestService {
+	return &contestService{
+		contestRepo:    contestRepo,
+		problemRepo:    problemRepo,
+		submissionRepo: submissionRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// CreateContest 创建竞赛
+func (s *contestService) CreateContest(ctx context.Context, creator serviceInterface.Viewer, req *serviceInterface.CreateContestRequest) (*model.Contest, error) {
+	problemIDs, err := s.parseProblemIDs(ctx, req.ProblemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	contest := &model.Contest{
+		Title:       req.Title,
+		Description: req.Description,
+		Type:        req.Type,
+		Classes:     req.Classes,
+		ProblemIDs:  problemIDs,
+		Settings:    req.Settings,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		CreatedBy:   creator.UserID,
+	}
+	if err := validateContest(contest); err != nil {
+		return nil, err
+	}
+
+	if err := s.contestRepo.Create(ctx, contest); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return contest, nil
+}
+
+// UpdateContest 更新竞赛
+func (s *contestService) UpdateContest(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.UpdateContestRequest) (*model.Contest, error) {
+	contest, err := s.getManagedContest(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != "" {
+		contest.Title = req.Title
+	}
+	if req.Description != nil {
+		contest.Description = *req.Description
+	}
+	if req.Type != "" {
+		contest.Type = req.Type
+	}
+	if req.Classes != nil {
+		contest.Classes = req.Classes
+	}
+	if req.ProblemIDs != nil {
+		if contest.ProblemIDs, err = s.parseProblemIDs(ctx, req.ProblemIDs); err != nil {
+			return nil, err
+		}
+	}
+	if req.Settings != nil {
+		contest.Settings = *req.Settings
+	}
+	if req.StartTime != nil {
+		contest.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		contest.EndTime = *req.EndTime
+	}
+	if err := validateContest(contest); err != nil {
+		return nil, err
+	}
+
+	if err := s.contestRepo.Update(ctx, contest); err != nil {
+		return nil, contestError(err)
+	}
+	return contest, nil
+}
+
+// DeleteContest 删除竞赛
+func (s *contestService) DeleteContest(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) error {
+	if _, err := s.getManagedContest(ctx, id, viewer); err != nil {
+		return err
+	}
+	if err := s.contestRepo.Delete(ctx, id); err != nil {
+		return contestError(err)
+	}
+	return nil
+}
+
+// GetContest 获取竞赛详情
+func (s *contestService) GetContest(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.ContestDetail, error) {
+	contest, err := s.contestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, contestError(err)
+	}
+
+	detail := newContestDetail(contest, time.Now())
+	detail.ParticipantCount = len(contest.ParticipantIDs)
+	for _, participant := range contest.ParticipantIDs {
+		if participant == viewer.UserID {
+			detail.Registered = true
+			break
+		}
+	}
+	// 竞赛开始前题目只对可管理竞赛的用户可见
+	if detail.Status == model.ContestStatusUpcoming && !canManage(contest, viewer) {
+		contest.ProblemIDs = nil
+	}
+	return detail, nil
+}
+
+// ListContests 分页查询竞赛列表
+func (s *contestService) ListContests(ctx context.Context, req *serviceInterface.ContestListRequest) (*serviceInterface.ContestListResponse, error) {
+	filters := make(map[string]interface{})
+	if req.Type != "" {
+		filters["type"] = req.Type
+	}
+	if req.Status != "" {
+		filters["status"] = req.Status
+	}
+	if req.Keyword != "" {
+		filters["keyword"] = req.Keyword
+	}
+
+	contests, total, err := s.contestRepo.List(ctx, req.Page, req.PageSize, filters)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	now := time.Now()
+	details := make([]*serviceInterface.ContestDetail, 0, len(contests))
+	for _, contest := range contests {
+		contest.ProblemIDs = nil
+		details = append(details, newContestDetail(contest, now))
+	}
+	return &serviceInterface.ContestListResponse{
+		Contests: details,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// Register 报名竞赛
+func (s *contestService) Register(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) error {
+	contest, err := s.contestRepo.GetByID(ctx, id)
+	if err != nil {
+		return contestError(err)
+	}
+	if contest.StatusAt(time.Now()) == model.ContestStatusEnded {
+		return errors.New(errors.CONTEST_ENDED)
+	}
+
+	// 班级由管理员设置，用户注册和修改个人信息时不能填写
+	if contest.Type == model.ContestTypeClass {
+		user, err := s.userRepo.GetByID(ctx, viewer.UserID)
+		if err != nil {
+			return errors.New(errors.USER_NOT_FOUND)
+		}
+		if !containsString(contest.Classes, user.Class) {
+			return errors.New(errors.CONTEST_ACCESS_DENIED, "仅限以下班级报名: "+strings.Join(contest.Classes, ", "))
+		}
+	}
+
+	if err := s.contestRepo.AddParticipant(ctx, id, viewer.UserID); err != nil {
+		return contestError(err)
+	}
+	return nil
+}
+
+// ListProblems 获取竞赛题目
+// 可管理竞赛的用户随时可见；竞赛进行中仅报名用户可见；竞赛结束后所有用户可见
+func (s *contestService) ListProblems(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) ([]*model.Problem, error) {
+	contest, err := s.contestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, contestError(err)
+	}
+
+	if !canManage(contest, viewer) {
+		switch contest.StatusAt(time.Now()) {
+		case model.ContestStatusUpcoming:
+			return nil, errors.New(errors.CONTEST_NOT_STARTED)
+		case model.ContestStatusRunning:
+			registered, err := s.contestRepo.IsParticipant(ctx, id, viewer.UserID)
+			if err != nil {
+				return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+			}
+			if !registered {
+				return nil, errors.New(errors.CONTEST_ACCESS_DENIED, "未报名竞赛")
+			}
+		}
+	}
+
+	problems := make([]*model.Problem, 0, len(contest.ProblemIDs))
+	for _, problemID := range contest.ProblemIDs {
+		problem, err := s.problemRepo.GetByID(ctx, problemID)
+		if err != nil {
+			return nil, errors.New(errors.PROBLEM_NOT_FOUND, problemID.Hex())
+		}
+		problems = append(problems, hideJudgeData(problem))
+	}
+	return problems, nil
+}
+
+// CheckSubmission 检查竞赛提交
+func (s *contestService) CheckSubmission(ctx context.Context, contestID, userID, problemID primitive.ObjectID) (*model.Contest, error) {
+	contest, err := s.contestRepo.GetByID(ctx, contestID)
+	if err != nil {
+		return nil, contestError(err)
+	}
+
+	switch contest.StatusAt(time.Now()) {
+	case model.ContestStatusUpcoming:
+		return nil, errors.New(errors.CONTEST_NOT_STARTED)
+	case model.ContestStatusEnded:
+		return nil, errors.New(errors.CONTEST_ENDED)
+	}
+	if !contest.HasProblem(problemID) {
+		return nil, errors.New(errors.PROBLEM_NOT_FOUND, "题目不属于该竞赛")
+	}
+
+	registered, err := s.contestRepo.IsParticipant(ctx, contestID, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if !registered {
+		return nil, errors.New(errors.CONTEST_ACCESS_DENIED, "未报名竞赛")
+	}
+
+	if contest.Settings.MaxSubmissions > 0 {
+		count, err := s.submissionRepo.Count(ctx, map[string]interface{}{
+			"contest_id": contestID,
+			"user_id":    userID,
+			"problem_id": problemID,
+		})
+		if err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if count >= int64(contest.Settings.MaxSubmissions) {
+			return nil, errors.New(errors.SUBMISSION_LIMIT_EXCEEDED)
+		}
+	}
+	return contest, nil
+}
+
+// getManagedContest 获取竞赛并检查当前用户是否可以管理
+func (s *contestService) getManagedContest(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Contest, error) {
+	contest, err := s.contestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, contestError(err)
+	}
+	if !canManage(contest, viewer) {
+		return nil, errors.New(errors.FORBIDDEN, "只能管理自己创建的竞赛")
+	}
+	return contest, nil
+}
+
+// parseProblemIDs 解析题目ID并检查题目存在且不重复
+func (s *contestService) parseProblemIDs(ctx context.Context, ids []string) ([]primitive.ObjectID, error) {
+	problemIDs := make([]primitive.ObjectID, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		problemID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New(errors.INVALID_PARAMS, "题目ID格式错误: "+id)
+		}
+		if seen[problemID] {
+			return nil, errors.New(errors.INVALID_PARAMS, "题目重复: "+id)
+		}
+		seen[problemID] = true
+
+		if _, err := s.problemRepo.GetByID(ctx, problemID); err != nil {
+			return nil, errors.New(errors.PROBLEM_NOT_FOUND, id)
+		}
+		problemIDs = append(problemIDs, problemID)
+	}
+	return problemIDs, nil
+}
+
+// validateContest 检查竞赛时间、类型和设置，未指定赛制时使用ICPC
+func validateContest(contest *model.Contest) error {
+	if !contest.EndTime.After(contest.StartTime) {
+		return errors.New(errors.INVALID_PARAMS, "结束时间必须晚于开始时间")
+	}
+	if contest.Type == model.ContestTypeClass && len(contest.Classes) == 0 {
+		return errors.New(errors.INVALID_PARAMS, "班级竞赛需要指定班级")
+	}
+	settings := &contest.Settings
+	if settings.PenaltyTime < 0 || settings.FreezeTime < 0 || settings.MaxSubmissions < 0 {
+		return errors.New(errors.INVALID_PARAMS, "竞赛设置不能为负数")
+	}
+	switch settings.Rule {
+	case "":
+		settings.Rule = model.ContestRuleICPC
+	case model.ContestRuleICPC, model.ContestRuleOI:
+	default:
+		return errors.New(errors.INVALID_PARAMS, "赛制只能是icpc或oi")
+	}
+	if contest.FreezeStart().Before(contest.StartTime) {
+		return errors.New(errors.INVALID_PARAMS, "封榜时长不能超过竞赛时长")
+	}
+	return nil
+}
+
+// contestError 将仓储错误转换为业务错误
+func contestError(err error) error {
+	if stdErrors.Is(err, repoInterface.ErrContestNotFound) {
+		return errors.New(errors.CONTEST_NOT_FOUND)
+	}
+	return errors.Wrap(errors.DATABASE_ERROR, err)
+}
+
+// canManage 拥有contest:manage:any，或拥有contest:manage:own的竞赛创建者可以管理竞赛
+func canManage(contest *model.Contest, viewer serviceInterface.Viewer) bool {
+	return viewer.Permissions.CanManage(rbac.ContestManageOwn, rbac.ContestManageAny, contest.CreatedBy == viewer.UserID)
+}
+
+func newContestDetail(contest *model.Contest, now time.Time) *serviceInterface.ContestDetail {
+	return &serviceInterface.ContestDetail{
+		Contest: contest,
+		Status:  contest.StatusAt(now),
+	}
+}
+
+// hideJudgeData 去掉非公开测试用例和checker源码，用于向参赛者展示题目
+func hideJudgeData(problem *model.Problem) *model.Problem {
+	publicCases := make([]model.TestCase, 0, len(problem.TestCases))
+	for _, testCase := range problem.TestCases {
+		if testCase.IsPublic {
+			publicCases = append(publicCases, testCase)
+		}
+	}
+	problem.TestCases = publicCases
+	problem.Constraints.Checker = nil
+	return problem
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,591 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"sort"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/rbac"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	joinCodeLength   = 8
+	joinCodeAttempts = 5    // 邀请码与其他课程重复时重新生成的次数
+	enrollPageSize   = 500  // 按班级加入学生时每次查询的用户数
+	maxEnrollClass   = 2000 // 按班级加入学生的上限
+)
+
+// courseService 课程服务实现
+type courseService struct {
+	courseRepo     repoInterface.CourseRepository
+	assignmentRepo repoInterface.AssignmentRepository
+	userRepo       repoInterface.UserRepository
+	submissionRepo repoInterface.SubmissionRepository
+}
+
+// NewCourseService 创建课程服务实例
+func NewCourseService(
+	courseRepo repoInterface.CourseRepository,
+	assignmentRepo repoInterface.AssignmentRepository,
+	userRepo repoInterface.UserRepository,
+	submissionRepo repoInterface.SubmissionRepository,
+) serviceInterface.CourseService {
+	return &courseService{
+		courseRepo:     courseRepo,
+		assignmentRepo: assignmentRepo,
+		userRepo:       userRepo,
+		submissionRepo: submissionRepo,
+	}
+}
+
+// CreateCourse 创建课程
+func (s *courseService) CreateCourse(ctx context.Context, creator serviceInterface.Viewer, req *serviceInterface.CreateCourseRequest) (*serviceInterface.CourseDetail, error) {
+	course := &model.Course{
+		Name:        strings.TrimSpace(req.Name),
+		ClassName:   strings.TrimSpace(req.ClassName),
+		Term:        strings.TrimSpace(req.Term),
+		Description: req.Description,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		TeacherIDs:  []primitive.ObjectID{creator.UserID},
+		CreatedBy:   creator.UserID,
+	}
+	if err := validateCourse(course); err != nil {
+		return nil, err
+	}
+
+	var students []primitive.ObjectID
+	if req.EnrollClass {
+		if course.ClassName == "" {
+			return nil, errors.New(errors.INVALID_PARAMS, "按班级加入学生需要填写班级名称")
+		}
+		var err error
+		if students, err = s.classStudents(ctx, course.ClassName); err != nil {
+			return nil, err
+		}
+		course.StudentIDs = students
+	}
+
+	// 邀请码与其他课程重复的概率很小，重复时重新生成
+	for attempt := 0; ; attempt++ {
+		if req.JoinEnabled {
+			code, err := newJoinCode()
+			if err != nil {
+				return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+			}
+			course.JoinCode = code
+		}
+		err := s.courseRepo.Create(ctx, course)
+		if err == nil {
+			break
+		}
+		if !stdErrors.Is(err, repoInterface.ErrJoinCodeExists) || attempt+1 >= joinCodeAttempts {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+	}
+
+	logger.Info("创建课程", "course_id", course.ID.Hex(), "creator", creator.UserID.Hex(), "students", len(students))
+	return newCourseDetail(course, creator), nil
+}
+
+// classStudents 班级字段为className的学生，用于把已有用户加入新课程
+func (s *courseService) classStudents(ctx context.Context, className string) ([]primitive.ObjectID, error) {
+	filters := map[string]interface{}{"class": className, "role": model.RoleStudent}
+	var ids []primitive.ObjectID
+	for page := 1; ; page++ {
+		users, total, err := s.userRepo.List(ctx, page, enrollPageSize, filters)
+		if err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if total > maxEnrollClass {
+			return nil, errors.Newf(errors.INVALID_PARAMS, "班级学生超过%d人，请按学号添加", maxEnrollClass)
+		}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		if len(users) < enrollPageSize || int64(len(ids)) >= total {
+			return ids, nil
+		}
+	}
+}
+
+// UpdateCourse 更新课程信息
+func (s *courseService) UpdateCourse(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.UpdateCourseRequest) (*serviceInterface.CourseDetail, error) {
+	course, err := s.getManagedCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		course.Name = strings.TrimSpace(req.Name)
+	}
+	if req.ClassName != nil {
+		course.ClassName = strings.TrimSpace(*req.ClassName)
+	}
+	if req.Term != "" {
+		course.Term = strings.TrimSpace(req.Term)
+	}
+	if req.Description != nil {
+		course.Description = *req.Description
+	}
+	if req.StartDate != nil {
+		course.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		course.EndDate = *req.EndDate
+	}
+	if err := validateCourse(course); err != nil {
+		return nil, err
+	}
+
+	if err := s.courseRepo.Update(ctx, course); err != nil {
+		return nil, courseError(err)
+	}
+	return newCourseDetail(course, viewer), nil
+}
+
+// DeleteCourse 删除课程及其作业
+func (s *courseService) DeleteCourse(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) error {
+	if _, err := s.getManagedCourse(ctx, id, viewer); err != nil {
+		return err
+	}
+	if err := s.courseRepo.Delete(ctx, id); err != nil {
+		return courseError(err)
+	}
+	assignments, err := s.assignmentRepo.DeleteByCourse(ctx, id)
+	if err != nil {
+		// 课程已删除，残留的作业无法再通过课程访问
+		logger.Error("删除课程作业失败", "course_id", id.Hex(), "error", err)
+	}
+	logger.Info("删除课程", "course_id", id.Hex(), "assignments", assignments, "operator", viewer.UserID.Hex())
+	return nil
+}
+
+// GetCourse 获取课程详情
+func (s *courseService) GetCourse(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.CourseDetail, error) {
+	course, err := s.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, courseError(err)
+	}
+	if course.MemberRole(viewer.UserID) == "" && !viewer.Permissions.Has(rbac.CourseManageAny) {
+		return nil, errors.New(errors.COURSE_ACCESS_DENIED, "不是该课程的成员")
+	}
+	return newCourseDetail(course, viewer), nil
+}
+
+// ListCourses 分页查询课程列表
+func (s *courseService) ListCourses(ctx context.Context, viewer serviceInterface.Viewer, req *serviceInterface.CourseListRequest) (*serviceInterface.CourseListResponse, error) {
+	filters := make(map[string]interface{})
+	if !req.All {
+		filters["member"] = viewer.UserID
+	} else if !viewer.Permissions.Has(rbac.CourseManageAny) {
+		return nil, errors.New(errors.FORBIDDEN, "查询全部课程需要"+rbac.CourseManageAny+"权限")
+	}
+	if req.Term != "" {
+		filters["term"] = req.Term
+	}
+	if req.Keyword != "" {
+		filters["keyword"] = req.Keyword
+	}
+
+	courses, total, err := s.courseRepo.List(ctx, req.Page, req.PageSize, filters)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	details := make([]*serviceInterface.CourseDetail, 0, len(courses))
+	for _, course := range courses {
+		details = append(details, newCourseDetail(course, viewer))
+	}
+	return &serviceInterface.CourseListResponse{
+		Courses:  details,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// JoinCourse 通过邀请码加入课程
+func (s *courseService) JoinCourse(ctx context.Context, viewer serviceInterface.Viewer, joinCode string) (*serviceInterface.CourseDetail, error) {
+	code := strings.ToUpper(strings.TrimSpace(joinCode))
+	if code == "" {
+		return nil, errors.New(errors.JOIN_CODE_INVALID)
+	}
+	course, err := s.courseRepo.GetByJoinCode(ctx, code)
+	if err != nil {
+		if stdErrors.Is(err, repoInterface.ErrCourseNotFound) {
+			return nil, errors.New(errors.JOIN_CODE_INVALID)
+		}
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if !course.EndDate.After(time.Now()) {
+		return nil, errors.New(errors.COURSE_ENDED)
+	}
+
+	// 已是成员时不改变身份，避免教师和助教误用邀请码后变成学生
+	switch course.MemberRole(viewer.UserID) {
+	case "":
+		if err := s.courseRepo.AddMembers(ctx, course.ID, model.CourseRoleStudent, []primitive.ObjectID{viewer.UserID}); err != nil {
+			return nil, courseError(err)
+		}
+		course.StudentIDs = append(course.StudentIDs, viewer.UserID)
+	case model.CourseRoleStudent:
+	default:
+		return nil, errors.New(errors.INVALID_PARAMS, "已是该课程的教师或助教")
+	}
+	return newCourseDetail(course, viewer), nil
+}
+
+// SetJoinCode 生成新的邀请码或停用邀请码
+func (s *courseService) SetJoinCode(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, enabled bool) (*serviceInterface.CourseDetail, error) {
+	course, err := s.getManagedCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	course.JoinCode = ""
+	for attempt := 0; ; attempt++ {
+		if enabled {
+			if course.JoinCode, err = newJoinCode(); err != nil {
+				return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+			}
+		}
+		err = s.courseRepo.Update(ctx, course)
+		if err == nil {
+			break
+		}
+		if !stdErrors.Is(err, repoInterface.ErrJoinCodeExists) || attempt+1 >= joinCodeAttempts {
+			return nil, courseError(err)
+		}
+	}
+	return newCourseDetail(course, viewer), nil
+}
+
+// ListMembers 获取课程成员
+func (s *courseService) ListMembers(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, role string) ([]*serviceInterface.CourseMember, error) {
+	course, err := s.getStaffCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []struct {
+		role string
+		ids  []primitive.ObjectID
+	}{
+		{model.CourseRoleTeacher, course.TeacherIDs},
+		{model.CourseRoleTA, course.TAIDs},
+		{model.CourseRoleStudent, course.StudentIDs},
+	}
+	var ids []primitive.ObjectID
+	roles := make(map[primitive.ObjectID]string)
+	for _, group := range groups {
+		if role != "" && role != group.role {
+			continue
+		}
+		for _, userID := range group.ids {
+			ids = append(ids, userID)
+			roles[userID] = group.role
+		}
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	members := make([]*serviceInterface.CourseMember, 0, len(users))
+	for _, user := range users {
+		members = append(members, &serviceInterface.CourseMember{User: user, CourseRole: roles[user.ID]})
+	}
+	// 教师、助教在前，学生按学号排列
+	order := map[string]int{model.CourseRoleTeacher: 0, model.CourseRoleTA: 1, model.CourseRoleStudent: 2}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CourseRole != members[j].CourseRole {
+			return order[members[i].CourseRole] < order[members[j].CourseRole]
+		}
+		return members[i].StudentID < members[j].StudentID
+	})
+	return members, nil
+}
+
+// AddMembers 按学号添加课程成员
+func (s *courseService) AddMembers(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.AddCourseMembersRequest) (*serviceInterface.AddCourseMembersResult, error) {
+	course, err := s.getManagedCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &serviceInterface.AddCourseMembersResult{NotFound: []string{}}
+	var userIDs []primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool)
+	for _, studentID := range req.StudentIDs {
+		studentID = strings.TrimSpace(studentID)
+		if studentID == "" {
+			continue
+		}
+		user, err := s.userRepo.GetByStudentID(ctx, studentID)
+		if err != nil {
+			result.NotFound = append(result.NotFound, studentID)
+			continue
+		}
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+		if user.ID == viewer.UserID && req.Role != model.CourseRoleTeacher && course.MemberRole(viewer.UserID) == model.CourseRoleTeacher {
+			return nil, errors.New(errors.INVALID_PARAMS, "不能修改自己在课程中的身份")
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	if err := s.courseRepo.AddMembers(ctx, id, req.Role, userIDs); err != nil {
+		return nil, courseError(err)
+	}
+	result.Added = len(userIDs)
+	logger.Info("添加课程成员", "course_id", id.Hex(), "role", req.Role, "added", result.Added, "not_found", len(result.NotFound))
+	return result, nil
+}
+
+// RemoveMember 将用户移出课程
+func (s *courseService) RemoveMember(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, userID primitive.ObjectID) error {
+	course, err := s.getManagedCourse(ctx, id, viewer)
+	if err != nil {
+		return err
+	}
+	role := course.MemberRole(userID)
+	if role == "" {
+		return errors.New(errors.USER_NOT_FOUND, "用户不是该课程的成员")
+	}
+	if role == model.CourseRoleTeacher && len(course.TeacherIDs) <= 1 {
+		return errors.New(errors.INVALID_PARAMS, "课程至少需要一名教师")
+	}
+
+	if err := s.courseRepo.RemoveMember(ctx, id, userID); err != nil {
+		return courseError(err)
+	}
+	return nil
+}
+
+// ListSubmissions 分页查询课程学生的提交
+func (s *courseService) ListSubmissions(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer, req *serviceInterface.CourseSubmissionListRequest) ([]*model.Submission, int64, error) {
+	course, err := s.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, courseError(err)
+	}
+	if !viewer.Permissions.Has(rbac.SubmissionViewAny) &&
+		!(course.IsStaff(viewer.UserID) && viewer.Permissions.Has(rbac.SubmissionViewClass)) {
+		return nil, 0, errors.New(errors.COURSE_ACCESS_DENIED, "只能查看所教课程学生的提交")
+	}
+	if len(course.StudentIDs) == 0 {
+		return []*model.Submission{}, 0, nil
+	}
+
+	filter := map[string]interface{}{
+		"user_id": map[string]interface{}{"$in": course.StudentIDs},
+	}
+	if req.UserID != "" {
+		userID, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return nil, 0, errors.New(errors.INVALID_PARAMS, "user_id格式错误")
+		}
+		if course.MemberRole(userID) != model.CourseRoleStudent {
+			return nil, 0, errors.New(errors.INVALID_PARAMS, "用户不是该课程的学生")
+		}
+		filter["user_id"] = userID
+	}
+	if req.ProblemID != "" {
+		problemID, err := primitive.ObjectIDFromHex(req.ProblemID)
+		if err != nil {
+			return nil, 0, errors.New(errors.INVALID_PARAMS, "problem_id格式错误")
+		}
+		filter["problem_id"] = problemID
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Language != "" {
+		filter["language"] = req.Language
+	}
+
+	submissions, total, err := s.submissionRepo.List(ctx, req.Page, req.PageSize, filter)
+	if err != nil {
+		return nil, 0, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return submissions, total, nil
+}
+
+// GetRanking 课程学生按解题数、通过数排名，提交数少者在前；并列时名次相同
+func (s *courseService) GetRanking(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) ([]*serviceInterface.CourseRankItem, error) {
+	course, err := s.getStaffCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.userRepo.GetByIDs(ctx, course.StudentIDs)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	items := make([]*serviceInterface.CourseRankItem, 0, len(students))
+	for _, user := range students {
+		items = append(items, &serviceInterface.CourseRankItem{
+			UserID:           user.ID,
+			StudentID:        user.StudentID,
+			Username:         user.Username,
+			RealName:         user.RealName,
+			ProblemsSolved:   user.Stats.ProblemsSolved,
+			AcceptedCount:    user.Stats.AcceptedCount,
+			TotalSubmissions: user.Stats.TotalSubmissions,
+		})
+	}
+	less := func(a, b *serviceInterface.CourseRankItem) bool {
+		if a.ProblemsSolved != b.ProblemsSolved {
+			return a.ProblemsSolved > b.ProblemsSolved
+		}
+		if a.AcceptedCount != b.AcceptedCount {
+			return a.AcceptedCount > b.AcceptedCount
+		}
+		return a.TotalSubmissions < b.TotalSubmissions
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if less(items[i], items[j]) != less(items[j], items[i]) {
+			return less(items[i], items[j])
+		}
+		return items[i].StudentID < items[j].StudentID
+	})
+	for i, item := range items {
+		item.Rank = i + 1
+		if i > 0 && !less(items[i-1], item) {
+			item.Rank = items[i-1].Rank
+		}
+	}
+	return items, nil
+}
+
+// GetStats 课程统计
+func (s *courseService) GetStats(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.CourseStats, error) {
+	course, err := s.getStaffCourse(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.userRepo.GetByIDs(ctx, course.StudentIDs)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	stats := &serviceInterface.CourseStats{
+		StudentCount: len(course.StudentIDs),
+		TeacherCount: len(course.TeacherIDs),
+		TACount:      len(course.TAIDs),
+	}
+	solved := 0
+	for _, user := range students {
+		if user.Stats.TotalSubmissions > 0 {
+			stats.ActiveStudents++
+		}
+		solved += user.Stats.ProblemsSolved
+	}
+	if len(students) > 0 {
+		stats.AverageSolved = float64(solved) / float64(len(students))
+	}
+
+	if len(course.StudentIDs) > 0 {
+		filter := map[string]interface{}{
+			"user_id":        map[string]interface{}{"$in": course.StudentIDs},
+			"submitted_from": course.StartDate,
+			"submitted_to":   course.EndDate,
+		}
+		if stats.TermSubmissions, err = s.submissionRepo.Count(ctx, filter); err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		filter["status"] = model.StatusAccepted
+		if stats.TermAccepted, err = s.submissionRepo.Count(ctx, filter); err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+	}
+	return stats, nil
+}
+
+// getManagedCourse 获取课程并检查当前用户是否可以管理
+func (s *courseService) getManagedCourse(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Course, error) {
+	course, err := s.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, courseError(err)
+	}
+	if !canManageCourse(course, viewer) {
+		return nil, errors.New(errors.FORBIDDEN, "只能管理自己任教的课程")
+	}
+	return course, nil
+}
+
+// getStaffCourse 获取课程并检查当前用户是否是课程的教师、助教，或可以管理全部课程
+func (s *courseService) getStaffCourse(ctx context.Context, id primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Course, error) {
+	course, err := s.courseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, courseError(err)
+	}
+	if !isCourseStaff(course, viewer) {
+		return nil, errors.New(errors.COURSE_ACCESS_DENIED, "只有课程的教师和助教可以查看")
+	}
+	return course, nil
+}
+
+// canManageCourse 拥有course:manage:any，或拥有course:manage:own的课程教师可以管理课程
+func canManageCourse(course *model.Course, viewer serviceInterface.Viewer) bool {
+	isTeacher := course.MemberRole(viewer.UserID) == model.CourseRoleTeacher
+	return viewer.Permissions.CanManage(rbac.CourseManageOwn, rbac.CourseManageAny, isTeacher)
+}
+
+// isCourseStaff 课程的教师、助教，或拥有course:manage:any
+func isCourseStaff(course *model.Course, viewer serviceInterface.Viewer) bool {
+	return course.IsStaff(viewer.UserID) || viewer.Permissions.Has(rbac.CourseManageAny)
+}
+
+// validateCourse 检查课程起止日期
+func validateCourse(course *model.Course) error {
+	if course.Name == "" || course.Term == "" {
+		return errors.New(errors.INVALID_PARAMS, "课程名称和学期不能为空")
+	}
+	if !course.EndDate.After(course.StartDate) {
+		return errors.New(errors.INVALID_PARAMS, "结课日期必须晚于开课日期")
+	}
+	return nil
+}
+
+// courseError 将仓储错误转换为业务错误
+func courseError(err error) error {
+	if stdErrors.Is(err, repoInterface.ErrCourseNotFound) {
+		return errors.New(errors.COURSE_NOT_FOUND)
+	}
+	return errors.Wrap(errors.DATABASE_ERROR, err)
+}
+
+// newJoinCode 生成邀请码，只含大写字母和数字，便于口头或投影告知学生
+func newJoinCode() (string, error) {
+	code, err := utils.RandomPassword(joinCodeLength)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(code), nil
+}
+
+// newCourseDetail 课程详情，邀请码只对课程的教师、助教和可管理全部课程的用户可见
+func newCourseDetail(course *model.Course, viewer serviceInterface.Viewer) *serviceInterface.CourseDetail {
+	detail := &serviceInterface.CourseDetail{
+		Course:       course,
+		StudentCount: len(course.StudentIDs),
+		MyRole:       course.MemberRole(viewer.UserID),
+	}
+	if !isCourseStaff(course, viewer) {
+		course.JoinCode = ""
+	}
+	return detail
+}
@@ -0,0 +1,476 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 测试用的内存仓储和消息队列，只实现被测服务用到的方法；
+// 嵌入的接口为nil，调用未实现的方法会panic，便于发现测试覆盖不到的依赖
+
+func TestMain(m *testing.M) {
+	logger.Init(config.LoggingConfig{Level: "fatal", Output: "stdout"})
+	os.Exit(m.Run())
+}
+
+// fakeSubmissionRepo 内存提交记录仓储
+type fakeSubmissionRepo struct {
+	repoInterface.SubmissionRepository
+
+	mu          sync.Mutex
+	submissions map[primitive.ObjectID]*model.Submission
+}
+
+func newFakeSubmissionRepo(submissions ...*model.Submission) *fakeSubmissionRepo {
+	r := &fakeSubmissionRepo{submissions: make(map[primitive.ObjectID]*model.Submission)}
+	for _, submission := range submissions {
+		r.add(submission)
+	}
+	return r
+}
+
+func (r *fakeSubmissionRepo) add(submission *model.Submission) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if submission.ID.IsZero() {
+		submission.ID = primitive.NewObjectID()
+	}
+	r.submissions[submission.ID] = submission
+}
+
+func (r *fakeSubmissionRepo) Create(ctx context.Context, submission *model.Submission) error {
+	r.add(submission)
+	return nil
+}
+
+func (r *fakeSubmissionRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Submission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission, ok := r.submissions[id]
+	if !ok {
+		return nil, stdErrors.New("提交记录不存在")
+	}
+	copied := *submission
+	return &copied, nil
+}
+
+func (r *fakeSubmissionRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission, ok := r.submissions[id]
+	if !ok {
+		return stdErrors.New("提交记录不存在")
+	}
+	submission.Status = status
+	return nil
+}
+
+func (r *fakeSubmissionRepo) MarkRejudge(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission, ok := r.submissions[id]
+	if !ok {
+		return stdErrors.New("提交记录不存在")
+	}
+	if submission.Status == model.StatusPending || submission.Status == model.StatusJudging {
+		return repoInterface.ErrStatusTransition
+	}
+	submission.Status = model.StatusPending
+	return nil
+}
+
+func (r *fakeSubmissionRepo) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]*model.Submission, int64, error) {
+	matched := r.match(filters)
+	start := (page - 1) * pageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], int64(len(matched)), nil
+}
+
+func (r *fakeSubmissionRepo) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	return int64(len(r.match(filters))), nil
+}
+
+// match 按SubmissionRepository.List的筛选条件过滤，按提交时间倒序
+func (r *fakeSubmissionRepo) match(filters map[string]interface{}) []*model.Submission {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*model.Submission
+	for _, submission := range r.submissions {
+		if submissionMatches(submission, filters) {
+			copied := *submission
+			matched = append(matched, &copied)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].SubmittedAt.After(matched[j].SubmittedAt)
+	})
+	return matched
+}
+
+func submissionMatches(submission *model.Submission, filters map[string]interface{}) bool {
+	for key, want := range filters {
+		var got interface{}
+		switch key {
+		case "user_id":
+			got = submission.UserID
+		case "problem_id":
+			got = submission.ProblemID
+		case "contest_id":
+			got = derefID(submission.ContestID)
+		case "assignment_id":
+			got = derefID(submission.AssignmentID)
+		case "status":
+			got = submission.Status
+		case "language":
+			got = submission.Language
+		case "submitted_from":
+			if submission.SubmittedAt.Before(want.(time.Time)) {
+				return false
+			}
+			continue
+		case "submitted_to":
+			if !submission.SubmittedAt.Before(want.(time.Time)) {
+				return false
+			}
+			continue
+		default:
+			continue
+		}
+		if !valueMatches(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueMatches 比较字段值，want可以是{"$in": [...]}或{"$nin": [...]}
+func valueMatches(got, want interface{}) bool {
+	cond, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(got, derefValue(want))
+	}
+	for op, values := range cond {
+		found := false
+		list := reflect.ValueOf(values)
+		for i := 0; i < list.Len(); i++ {
+			if reflect.DeepEqual(got, list.Index(i).Interface()) {
+				found = true
+				break
+			}
+		}
+		if (op == "$in" && !found) || (op == "$nin" && found) {
+			return false
+		}
+	}
+	return true
+}
+
+func derefID(id *primitive.ObjectID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func derefValue(value interface{}) interface{} {
+	if id, ok := value.(*primitive.ObjectID); ok {
+		return derefID(id)
+	}
+	return value
+}
+
+// fakeJudgeTaskRepo 记录重置的判题任务
+type fakeJudgeTaskRepo struct {
+	repoInterface.JudgeTaskRepository
+
+	mu    sync.Mutex
+	reset []*model.JudgeTask
+	err   error
+}
+
+func (r *fakeJudgeTaskRepo) Reset(ctx context.Context, task *model.JudgeTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	copied := *task
+	copied.Status = model.JudgeTaskPending
+	r.reset = append(r.reset, &copied)
+	return nil
+}
+
+// fakeProducer 记录发布的判题任务
+type fakeProducer struct {
+	queue.Producer
+
+	mu    sync.Mutex
+	tasks []*queue.JudgeTask
+	err   error
+}
+
+func (p *fakeProducer) PublishJudgeTask(ctx context.Context, task *queue.JudgeTask) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	copied := *task
+	p.tasks = append(p.tasks, &copied)
+	return nil
+}
+
+// fakeEvents 丢弃实时推送事件
+type fakeEvents struct{}
+
+func (fakeEvents) Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) error {
+	return nil
+}
+
+// fakeContestRepo 内存竞赛仓储
+type fakeContestRepo struct {
+	repoInterface.ContestRepository
+
+	contests map[primitive.ObjectID]*model.Contest
+}
+
+func newFakeContestRepo(contests ...*model.Contest) *fakeContestRepo {
+	r := &fakeContestRepo{contests: make(map[primitive.ObjectID]*model.Contest)}
+	for _, contest := range contests {
+		r.contests[contest.ID] = contest
+	}
+	return r
+}
+
+func (r *fakeContestRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Contest, error) {
+	contest, ok := r.contests[id]
+	if !ok {
+		return nil, repoInterface.ErrContestNotFound
+	}
+	copied := *contest
+	return &copied, nil
+}
+
+// fakeProblemRepo 内存题目仓储
+type fakeProblemRepo struct {
+	repoInterface.ProblemRepository
+
+	problems map[primitive.ObjectID]*model.Problem
+}
+
+func newFakeProblemRepo(problems ...*model.Problem) *fakeProblemRepo {
+	r := &fakeProblemRepo{problems: make(map[primitive.ObjectID]*model.Problem)}
+	for _, problem := range problems {
+		if problem.ID.IsZero() {
+			problem.ID = primitive.NewObjectID()
+		}
+		r.problems[problem.ID] = problem
+	}
+	return r
+}
+
+func (r *fakeProblemRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Problem, error) {
+	problem, ok := r.problems[id]
+	if !ok {
+		return nil, repoInterface.ErrProblemNotFound
+	}
+	copied := *problem
+	return &copied, nil
+}
+
+// fakeCourseRepo 内存课程仓储
+type fakeCourseRepo struct {
+	repoInterface.CourseRepository
+
+	courses map[primitive.ObjectID]*model.Course
+}
+
+func newFakeCourseRepo(courses ...*model.Course) *fakeCourseRepo {
+	r := &fakeCourseRepo{courses: make(map[primitive.ObjectID]*model.Course)}
+	for _, course := range courses {
+		if course.ID.IsZero() {
+			course.ID = primitive.NewObjectID()
+		}
+		r.courses[course.ID] = course
+	}
+	return r
+}
+
+func (r *fakeCourseRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Course, error) {
+	course, ok := r.courses[id]
+	if !ok {
+		return nil, repoInterface.ErrCourseNotFound
+	}
+	copied := *course
+	return &copied, nil
+}
+
+// fakeAssignmentRepo 内存作业仓储
+type fakeAssignmentRepo struct {
+	repoInterface.AssignmentRepository
+
+	assignments map[primitive.ObjectID]*model.Assignment
+}
+
+func newFakeAssignmentRepo(assignments ...*model.Assignment) *fakeAssignmentRepo {
+	r := &fakeAssignmentRepo{assignments: make(map[primitive.ObjectID]*model.Assignment)}
+	for _, assignment := range assignments {
+		if assignment.ID.IsZero() {
+			assignment.ID = primitive.NewObjectID()
+		}
+		r.assignments[assignment.ID] = assignment
+	}
+	return r
+}
+
+func (r *fakeAssignmentRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Assignment, error) {
+	assignment, ok := r.assignments[id]
+	if !ok {
+		return nil, repoInterface.ErrAssignmentNotFound
+	}
+	copied := *assignment
+	return &copied, nil
+}
+
+// fakeUserRepo 内存用户仓储
+type fakeUserRepo struct {
+	repoInterface.UserRepository
+
+	mu    sync.Mutex
+	users map[primitive.ObjectID]*model.User
+}
+
+func newFakeUserRepo(users ...*model.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[primitive.ObjectID]*model.User)}
+	for _, user := range users {
+		if user.ID.IsZero() {
+			user.ID = primitive.NewObjectID()
+		}
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*model.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+// find 按条件查找用户，调用方需持有锁
+func (r *fakeUserRepo) find(match func(*model.User) bool) *model.User {
+	for _, user := range r.users {
+		if match(user) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case r.find(func(u *model.User) bool { return u.StudentID == user.StudentID }) != nil:
+		return repoInterface.ErrStudentIDExists
+	case r.find(func(u *model.User) bool { return u.Username == user.Username }) != nil:
+		return repoInterface.ErrUsernameExists
+	case r.find(func(u *model.User) bool { return u.Email == user.Email }) != nil:
+		return repoInterface.ErrEmailExists
+	}
+	user.ID = primitive.NewObjectID()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) GetByStudentID(ctx context.Context, studentID string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.find(func(u *model.User) bool { return u.StudentID == studentID })
+	if user == nil {
+		return nil, stdErrors.New("用户不存在")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) ExistsByStudentID(ctx context.Context, studentID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(func(u *model.User) bool { return u.StudentID == studentID }) != nil, nil
+}
+
+func (r *fakeUserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(func(u *model.User) bool { return u.Username == username }) != nil, nil
+}
+
+func (r *fakeUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(func(u *model.User) bool { return u.Email == email }) != nil, nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repoInterface.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID]; !ok {
+		return repoInterface.ErrUserNotFound
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string, mustChange bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return stdErrors.New("用户不存在")
+	}
+	user.Password = hashedPassword
+	user.MustChangePassword = mustChange
+	return nil
+}
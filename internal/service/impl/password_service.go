@@ -0,0 +1,255 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/mail"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	"zhku-oj/internal/pkg/utils"
+	"zhku-oj/internal/ratelimit"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/session"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码重置token在Redis中的键，只保存token的摘要
+const (
+	resetTokenKeyPrefix = "password:reset:token:" // {摘要} -> 用户ID
+	resetUserKeyPrefix  = "password:reset:user:"  // {用户ID} -> 当前有效的token摘要，重新申请时旧链接失效
+	resetSentKeyPrefix  = "password:reset:sent:"  // {用户ID}，存在期间不再发送邮件
+)
+
+// passwordService 密码重置服务实现
+type passwordService struct {
+	userRepo    repoInterface.UserRepository
+	redisClient *redis.Client
+	sessions    *session.Store
+	mailer      mail.Sender
+	guard       *ratelimit.LoginGuard
+	policy      *pwdpolicy.Policy
+	cfg         config.PasswordConfig
+}
+
+// NewPasswordService 创建密码重置服务实例
+func NewPasswordService(
+	userRepo repoInterface.UserRepository,
+	redisClient *redis.Client,
+	sessions *session.Store,
+	mailer mail.Sender,
+	guard *ratelimit.LoginGuard,
+	policy *pwdpolicy.Policy,
+	cfg *config.Config,
+) serviceInterface.PasswordService {
+	passwordCfg := cfg.Password
+	if passwordCfg.ResetTokenTTL <= 0 {
+		passwordCfg.ResetTokenTTL = 30 * time.Minute
+	}
+	return &passwordService{
+		userRepo:    userRepo,
+		redisClient: redisClient,
+		sessions:    sessions,
+		mailer:      mailer,
+		guard:       guard,
+		policy:      policy,
+		cfg:         passwordCfg,
+	}
+}
+
+// ForgotPassword 生成重置token并发送邮件
+func (s *passwordService) ForgotPassword(ctx context.Context, req *serviceInterface.ForgotPasswordRequest, client serviceInterface.ClientInfo) error {
+	// 占位邮箱(批量导入、统一身份认证生成)无法接收邮件
+	if strings.HasSuffix(req.Email, ".invalid") {
+		return nil
+	}
+	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
+	if err != nil {
+		return errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if !exists {
+		logger.Info("找回密码的邮箱未注册", "ip", client.IP)
+		return nil
+	}
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+	userID := user.ID.Hex()
+
+	if s.cfg.ResetInterval > 0 {
+		ok, err := s.redisClient.SetNX(ctx, resetSentKeyPrefix+userID, 1, s.cfg.ResetInterval).Result()
+		if err != nil {
+			return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+		}
+		if !ok {
+			logger.Info("找回密码邮件发送过于频繁", "user_id", userID, "ip", client.IP)
+			return nil
+		}
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	hash := session.HashToken(token)
+	previous, err := s.redisClient.Get(ctx, resetUserKeyPrefix+userID).Result()
+	if err != nil && err != redis.Nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" {
+			pipe.Del(ctx, resetTokenKeyPrefix+previous)
+		}
+		pipe.Set(ctx, resetTokenKeyPrefix+hash, userID, s.cfg.ResetTokenTTL)
+		pipe.Set(ctx, resetUserKeyPrefix+userID, hash, s.cfg.ResetTokenTTL)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+
+	link, err := url.Parse(s.cfg.ResetURL)
+	if err != nil {
+		return errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg := &mail.Message{
+		To:      []string{user.Email},
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置账号 %s 密码的请求。请在%d分钟内打开以下链接设置新密码：\n\n%s\n\n"+
+			"该链接只能使用一次。如果不是你本人操作，请忽略本邮件，你的密码不会被修改。\n",
+			user.RealName, user.Username, int(s.cfg.ResetTokenTTL/time.Minute), link.String()),
+	}
+	// 异步发送，响应时间不因账号是否存在而不同
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logger.Error("发送重置密码邮件失败", "user_id", userID, "error", err)
+		}
+	}()
+
+	logger.Info("发送重置密码邮件", "user_id", userID, "ip", client.IP)
+	return nil
+}
+
+// ResetPassword 校验并消费token后设置新密码
+// 新密码不符合强度要求时token不会被消费，用户可以修改后重新提交
+func (s *passwordService) ResetPassword(ctx context.Context, req *serviceInterface.ResetPasswordRequest) error {
+	key := resetTokenKeyPrefix + session.HashToken(req.Token)
+	owner, err := s.redisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return errors.New(errors.RESET_TOKEN_INVALID)
+	}
+	if err != nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+
+	userID, err := primitive.ObjectIDFromHex(owner)
+	if err != nil {
+		return errors.New(errors.RESET_TOKEN_INVALID)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New(errors.RESET_TOKEN_INVALID)
+	}
+	if !user.IsActive {
+		return errors.New(errors.USER_DISABLED)
+	}
+	if err := s.policy.Validate(req.NewPassword, user.Username, user.StudentID, user.Email); err != nil {
+		return errors.New(errors.PASSWORD_TOO_WEAK, err.Error())
+	}
+
+	// 原子地取出并删除token，并发提交时只有一个请求成功
+	var get *redis.StringCmd
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err == redis.Nil || (err == nil && get.Val() != owner) {
+		return errors.New(errors.RESET_TOKEN_INVALID)
+	}
+	if err != nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword), false); err != nil {
+		return errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	s.redisClient.Del(ctx, resetUserKeyPrefix+userID.Hex())
+	// 能收到邮件说明是本人，解除因登录失败造成的锁定
+	if _, err := s.guard.UnlockAccount(ctx, user.Username); err != nil {
+		logger.Warn("解除登录锁定失败", "user_id", userID.Hex(), "error", err)
+	}
+
+	revoked, err := s.sessions.RevokeAll(ctx, userID.Hex(), "")
+	if err != nil {
+		return errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	logger.Info("通过邮件重置密码", "user_id", userID.Hex(), "revoked_sessions", revoked)
+	return nil
+}
+
+// AdminResetPassword 设置临时密码并要求用户修改
+func (s *passwordService) AdminResetPassword(ctx context.Context, userID primitive.ObjectID, req *serviceInterface.AdminResetPasswordRequest) (*serviceInterface.AdminResetPasswordResult, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	// 未指定时按当前密码策略随机生成；指定的和生成的临时密码都需符合策略，用户登录后仍须修改
+	password := req.Password
+	if password == "" {
+		generated, err := s.policy.Generate(initialPasswordLength, user.Username, user.StudentID, user.Email)
+		if err != nil {
+			return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+		}
+		password = generated
+	}
+	if err := s.policy.Validate(password, user.Username, user.StudentID, user.Email); err != nil {
+		return nil, errors.New(errors.PASSWORD_TOO_WEAK, err.Error())
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword), true); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	// 尚未使用的找回密码链接一并失效，并解除登录锁定
+	if previous, err := s.redisClient.Get(ctx, resetUserKeyPrefix+userID.Hex()).Result(); err == nil {
+		s.redisClient.Del(ctx, resetTokenKeyPrefix+previous, resetUserKeyPrefix+userID.Hex())
+	}
+	if _, err := s.guard.UnlockAccount(ctx, user.Username); err != nil {
+		logger.Warn("解除登录锁定失败", "user_id", userID.Hex(), "error", err)
+	}
+
+	revoked, err := s.sessions.RevokeAll(ctx, userID.Hex(), "")
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	logger.Info("管理员重置密码", "user_id", userID.Hex(), "revoked_sessions", revoked)
+	return &serviceInterface.AdminResetPasswordResult{Password: password, RevokedSessions: revoked}, nil
+}
@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/ratelimit"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/session"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestAdminResetPassword 临时密码无论指定还是随机生成都需符合当前密码策略，重置后用户必须修改密码
+func TestAdminResetPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     config.PasswordPolicyConfig
+		password   string // 管理员指定的临时密码，为空时随机生成
+		wantCode   int
+		wantLength int
+	}{
+		{name: "generated default policy", wantLength: initialPasswordLength},
+		{name: "generated with all classes", policy: config.PasswordPolicyConfig{MinClasses: 4, CheckPersonal: true}, wantLength: initialPasswordLength},
+		{name: "generated longer than initial length", policy: config.PasswordPolicyConfig{MinLength: 16, MinClasses: 3}, wantLength: 16},
+		{name: "specified", policy: config.PasswordPolicyConfig{MinClasses: 3}, password: "Temp-2024x", wantLength: 10},
+		{name: "specified too weak", policy: config.PasswordPolicyConfig{MinClasses: 3}, password: "temp2024x", wantCode: errors.PASSWORD_TOO_WEAK},
+		{name: "specified personal info", policy: config.PasswordPolicyConfig{CheckPersonal: true}, password: "Zhangsan2024", wantCode: errors.PASSWORD_TOO_WEAK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			user := &model.User{Username: "zhangsan", StudentID: "2021001001", Email: "zhangsan@example.com", Password: "old-hash"}
+			users := newFakeUserRepo(user)
+			policy := newTestPolicy(t, tt.policy)
+			service := NewPasswordService(users, client, session.NewStore(client), nil,
+				ratelimit.NewLoginGuard(client, config.LoginLockoutConfig{}), policy, &config.Config{})
+
+			result, err := service.AdminResetPassword(context.Background(), user.ID, &serviceInterface.AdminResetPasswordRequest{Password: tt.password})
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("重置密码错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+			}
+			saved, _ := users.GetByID(context.Background(), user.ID)
+			if tt.wantCode != errors.SUCCESS {
+				if saved.Password != "old-hash" || saved.MustChangePassword {
+					t.Error("重置失败时不应修改密码")
+				}
+				return
+			}
+
+			if tt.password != "" && result.Password != tt.password {
+				t.Errorf("临时密码 = %q, 期望使用指定的密码", result.Password)
+			}
+			if utf8.RuneCountInString(result.Password) != tt.wantLength {
+				t.Errorf("临时密码%q长度 = %d, 期望 %d", result.Password, utf8.RuneCountInString(result.Password), tt.wantLength)
+			}
+			if err := policy.Validate(result.Password, user.Username, user.StudentID, user.Email); err != nil {
+				t.Errorf("临时密码%q不符合密码策略: %v", result.Password, err)
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(saved.Password), []byte(result.Password)); err != nil {
+				t.Errorf("临时密码与保存的哈希不匹配: %v", err)
+			}
+			if !saved.MustChangePassword {
+				t.Error("重置后应要求用户修改密码")
+			}
+		})
+	}
+}
+
+func TestAdminResetPasswordUnknownUser(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	policy := newTestPolicy(t, config.PasswordPolicyConfig{})
+	service := NewPasswordService(newFakeUserRepo(), client, session.NewStore(client), nil,
+		ratelimit.NewLoginGuard(client, config.LoginLockoutConfig{}), policy, &config.Config{})
+
+	_, err := service.AdminResetPassword(context.Background(), primitive.NewObjectID(), &serviceInterface.AdminResetPasswordRequest{})
+	if code := errorCode(err); code != errors.USER_NOT_FOUND {
+		t.Errorf("重置不存在的用户错误码 = %d, 期望 %d (%v)", code, errors.USER_NOT_FOUND, err)
+	}
+}
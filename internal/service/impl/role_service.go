@@ -0,0 +1,220 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"strings"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/rbac"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/session"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roleService 角色管理服务实现
+type roleService struct {
+	roleRepo   repoInterface.RoleRepository
+	userRepo   repoInterface.UserRepository
+	sessions   *session.Store
+	authorizer *rbac.Authorizer
+}
+
+// NewRoleService 创建角色管理服务实例
+func NewRoleService(roleRepo repoInterface.RoleRepository, userRepo repoInterface.UserRepository, sessions *session.Store, authorizer *rbac.Authorizer) serviceInterface.RoleService {
+	return &roleService{
+		roleRepo:   roleRepo,
+		userRepo:   userRepo,
+		sessions:   sessions,
+		authorizer: authorizer,
+	}
+}
+
+// ListPermissions 全部权限及说明
+func (s *roleService) ListPermissions() []rbac.Permission {
+	return rbac.Catalog()
+}
+
+// ListRoles 全部角色及其用户数
+func (s *roleService) ListRoles(ctx context.Context) ([]*serviceInterface.RoleInfo, error) {
+	// 先加载其他实例修改的角色
+	if err := s.authorizer.Reload(ctx); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	roles := s.authorizer.Roles()
+	result := make([]*serviceInterface.RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		count, err := s.countUsers(ctx, role.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &serviceInterface.RoleInfo{Role: role, UserCount: count})
+	}
+	return result, nil
+}
+
+// CreateRole 创建自定义角色
+func (s *roleService) CreateRole(ctx context.Context, req *serviceInterface.CreateRoleRequest) (*model.Role, error) {
+	if !rbac.ValidRoleName(req.Name) {
+		return nil, errors.New(errors.INVALID_PARAMS, "角色名需以小写字母开头，只能包含小写字母、数字、下划线和连字符，长度2-32")
+	}
+	if err := s.authorizer.Reload(ctx); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if _, exists := s.authorizer.Role(req.Name); exists {
+		return nil, errors.New(errors.ROLE_ALREADY_EXISTS, req.Name)
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if role.DisplayName == "" {
+		role.DisplayName = req.Name
+	}
+	return s.save(ctx, role)
+}
+
+// UpdateRole 修改角色
+func (s *roleService) UpdateRole(ctx context.Context, name string, req *serviceInterface.UpdateRoleRequest) (*model.Role, error) {
+	if name == model.RoleAdmin {
+		return nil, errors.New(errors.ROLE_READONLY, "admin角色始终拥有全部权限")
+	}
+	if err := s.authorizer.Reload(ctx); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	role, exists := s.authorizer.Role(name)
+	if !exists {
+		return nil, errors.New(errors.ROLE_NOT_FOUND, name)
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DisplayName != "" {
+		role.DisplayName = req.DisplayName
+	}
+	if req.Description != "" {
+		role.Description = req.Description
+	}
+	role.Permissions = permissions
+	return s.save(ctx, role)
+}
+
+// DeleteRole 删除角色
+func (s *roleService) DeleteRole(ctx context.Context, name string) error {
+	if name == model.RoleAdmin {
+		return errors.New(errors.ROLE_READONLY, "admin角色不能删除")
+	}
+
+	// 内置角色和配置文件中的角色只删除数据库中的修改
+	if s.authorizer.Configured(name) {
+		if err := s.roleRepo.Delete(ctx, name); err != nil && !stdErrors.Is(err, repoInterface.ErrRoleNotFound) {
+			return errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		logger.Info("恢复角色默认权限", "role", name)
+		return s.reload(ctx)
+	}
+
+	count, err := s.countUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.Newf(errors.ROLE_IN_USE, "%d个用户属于该角色", count)
+	}
+	if err := s.roleRepo.Delete(ctx, name); err != nil {
+		if stdErrors.Is(err, repoInterface.ErrRoleNotFound) {
+			return errors.New(errors.ROLE_NOT_FOUND, name)
+		}
+		return errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	logger.Info("删除角色", "role", name)
+	return s.reload(ctx)
+}
+
+// AssignRole 修改用户角色
+func (s *roleService) AssignRole(ctx context.Context, operatorID, userID primitive.ObjectID, role string) (*serviceInterface.AssignRoleResult, error) {
+	if operatorID == userID {
+		return nil, errors.New(errors.INVALID_PARAMS, "不能修改自己的角色")
+	}
+	if _, exists := s.authorizer.Role(role); !exists {
+		return nil, errors.New(errors.ROLE_NOT_FOUND, role)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	previous := user.Role
+	user.Role = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	// 访问token中的角色在刷新前不会改变，注销会话使新角色立即生效
+	revoked, err := s.sessions.RevokeAll(ctx, userID.Hex(), "")
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	logger.Info("修改用户角色", "user_id", userID.Hex(), "operator_id", operatorID.Hex(), "from", previous, "to", role, "revoked_sessions", revoked)
+
+	user.Password = ""
+	return &serviceInterface.AssignRoleResult{User: user, RevokedSessions: revoked}, nil
+}
+
+// save 保存角色并立即在本实例生效
+func (s *roleService) save(ctx context.Context, role *model.Role) (*model.Role, error) {
+	if err := s.roleRepo.Upsert(ctx, role); err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	logger.Info("保存角色", "role", role.Name, "permissions", strings.Join(role.Permissions, ","))
+	if err := s.reload(ctx); err != nil {
+		return nil, err
+	}
+	saved, _ := s.authorizer.Role(role.Name)
+	return saved, nil
+}
+
+func (s *roleService) reload(ctx context.Context) error {
+	if err := s.authorizer.Reload(ctx); err != nil {
+		return errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return nil
+}
+
+// countUsers 属于该角色的用户数
+func (s *roleService) countUsers(ctx context.Context, role string) (int64, error) {
+	_, total, err := s.userRepo.List(ctx, 1, 1, map[string]interface{}{"role": role})
+	if err != nil {
+		return 0, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return total, nil
+}
+
+// normalizePermissions 检查权限名称并去重
+func normalizePermissions(perms []string) ([]string, error) {
+	seen := make(map[string]bool, len(perms))
+	result := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		perm = strings.TrimSpace(perm)
+		if !rbac.Valid(perm) {
+			return nil, errors.New(errors.INVALID_PARAMS, "权限不存在: "+perm)
+		}
+		if !seen[perm] {
+			seen[perm] = true
+			result = append(result, perm)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+	"zhku-oj/internal/session"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestAssignRole 不能修改自己的角色，修改后注销用户的全部会话
+func TestAssignRole(t *testing.T) {
+	operator := &model.User{Username: "admin", Role: model.RoleAdmin}
+	student := &model.User{Username: "zhangsan", Role: model.RoleStudent}
+
+	tests := []struct {
+		name     string
+		userID   func() primitive.ObjectID
+		role     string
+		wantCode int
+	}{
+		{name: "assign", userID: func() primitive.ObjectID { return student.ID }, role: model.RoleTA},
+		{name: "self", userID: func() primitive.ObjectID { return operator.ID }, role: model.RoleStudent, wantCode: errors.INVALID_PARAMS},
+		{name: "unknown role", userID: func() primitive.ObjectID { return student.ID }, role: "grader", wantCode: errors.ROLE_NOT_FOUND},
+		{name: "unknown user", userID: primitive.NewObjectID, role: model.RoleTA, wantCode: errors.USER_NOT_FOUND},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			authorizer, err := rbac.NewAuthorizer(config.RBACConfig{}, nil)
+			if err != nil {
+				t.Fatalf("创建权限映射失败: %v", err)
+			}
+			users := newFakeUserRepo(operator, student)
+			sessions := session.NewStore(client)
+			if err := sessions.Create(context.Background(), &session.Session{
+				ID: "s1", UserID: student.ID.Hex(), RefreshHash: "h1", ExpiresAt: time.Now().Add(time.Hour),
+			}, 0); err != nil {
+				t.Fatalf("创建会话失败: %v", err)
+			}
+			service := NewRoleService(nil, users, sessions, authorizer)
+
+			result, err := service.AssignRole(context.Background(), operator.ID, tt.userID(), tt.role)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("修改角色错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+			}
+			saved, _ := users.GetByID(context.Background(), student.ID)
+			if tt.wantCode != errors.SUCCESS {
+				if saved.Role != model.RoleStudent {
+					t.Errorf("修改失败时角色 = %s, 期望不变", saved.Role)
+				}
+				return
+			}
+			if saved.Role != tt.role || result.RevokedSessions != 1 {
+				t.Errorf("角色 = %s, 注销%d个会话, 期望 %s 并注销1个会话", saved.Role, result.RevokedSessions, tt.role)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/queue"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	"zhku-oj/internal/scoreboard"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// submissionBatchSize 批量读取提交记录(构建排行榜、计算作业成绩)时每次读取的提交数
+const submissionBatchSize = 500
+
+// scoreboardService 竞赛排行榜服务实现
+type scoreboardService struct {
+	contestRepo    repoInterface.ContestRepository
+	submissionRepo repoInterface.SubmissionRepository
+	userRepo       repoInterface.UserRepository
+	cache          *scoreboard.Cache
+}
+
+// NewScoreboardService 创建竞赛排行榜服务实例
+func NewScoreboardService(
+	contestRepo repoInterface.ContestRepository,
+	submissionRepo repoInterface.SubmissionRepository,
+	userRepo repoInterface.UserRepository,
+	redisClient *redis.Client,
+) serviceInterface.ScoreboardService {
+	return &scoreboardService{
+		contestRepo:    contestRepo,
+		submissionRepo: submissionRepo,
+		userRepo:       userRepo,
+		cache:          scoreboard.NewCache(redisClient),
+	}
+}
+
+// GetScoreboard 获取竞赛排行榜
+func (s *scoreboardService) GetScoreboard(ctx context.Context, contestID primitive.ObjectID, viewer serviceInterface.Viewer, frozenView bool) (*scoreboard.Standings, error) {
+	contest, err := s.contestRepo.GetByID(ctx, contestID)
+	if err != nil {
+		return nil, contestError(err)
+	}
+	manager := canManage(contest, viewer)
+	if !manager && contest.StatusAt(time.Now()) == model.ContestStatusUpcoming {
+		return nil, errors.New(errors.CONTEST_NOT_STARTED)
+	}
+
+	cells, participants, err := s.load(ctx, contest)
+	if err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+
+	// 可管理竞赛的用户默认看到最终结果；封榜期间其他用户只能看到已揭晓的结果
+	if (manager && !frozenView) || !contest.FrozenAt(time.Now()) {
+		return scoreboard.Build(contest, participants, cells, revealAll), nil
+	}
+	revealed, err := s.cache.Revealed(ctx, contest.ID.Hex())
+	if err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	standings := scoreboard.Build(contest, participants, cells, revealedIn(revealed))
+	standings.Frozen = true
+	return standings, nil
+}
+
+// ApplyResult 判题结果到达时重新计算该用户在该题上的单元格
+func (s *scoreboardService) ApplyResult(ctx context.Context, update *queue.StatsUpdate) error {
+	if update.ContestID == nil {
+		return nil
+	}
+	contest, err := s.contestRepo.GetByID(ctx, *update.ContestID)
+	if err != nil {
+		if stdErrors.Is(err, repoInterface.ErrContestNotFound) {
+			return nil // 竞赛已删除
+		}
+		return err
+	}
+
+	built, err := s.cache.Built(ctx, contest)
+	if err != nil {
+		return err
+	}
+	if !built {
+		_, err := s.rebuild(ctx, contest)
+		return err
+	}
+
+	submissions, err := listAllSubmissions(ctx, s.submissionRepo, map[string]interface{}{
+		"contest_id": contest.ID,
+		"user_id":    update.UserID,
+		"problem_id": update.ProblemID,
+	})
+	if err != nil {
+		return err
+	}
+	cell := scoreboard.NewCell(contest, update.UserID, update.ProblemID, submissions)
+	return s.cache.SaveCell(ctx, contest.ID.Hex(), cell)
+}
+
+// ResolveStep 滚榜揭晓下一个结果
+func (s *scoreboardService) ResolveStep(ctx context.Context, contestID primitive.ObjectID, viewer serviceInterface.Viewer) (*serviceInterface.ResolveStep, error) {
+	contest, err := s.getResolvableContest(ctx, contestID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	cells, participants, err := s.load(ctx, contest)
+	if err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	if contest.ScoreboardUnfrozen {
+		return &serviceInterface.ResolveStep{
+			Done:      true,
+			Standings: scoreboard.Build(contest, participants, cells, revealAll),
+		}, nil
+	}
+
+	revealed, err := s.cache.Revealed(ctx, contest.ID.Hex())
+	if err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	before := scoreboard.Build(contest, participants, cells, revealedIn(revealed))
+	next := scoreboard.NextReveal(before, cells, revealedIn(revealed))
+	if next == nil {
+		if err := s.markUnfrozen(ctx, contest); err != nil {
+			return nil, err
+		}
+		return &serviceInterface.ResolveStep{
+			Done:      true,
+			Standings: scoreboard.Build(contest, participants, cells, revealAll),
+		}, nil
+	}
+
+	if err := s.cache.Reveal(ctx, contest.ID.Hex(), next.Key()); err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	revealed[next.Key()] = true
+	after := scoreboard.Build(contest, participants, cells, revealedIn(revealed))
+	after.Frozen = true
+
+	step := &serviceInterface.ResolveStep{
+		UserID:       next.UserID,
+		ProblemID:    next.ProblemID,
+		PreviousRank: before.Row(next.UserID).Rank,
+		Standings:    after,
+	}
+	row := after.Row(next.UserID)
+	step.Rank = row.Rank
+	for _, problem := range row.Problems {
+		if problem.ProblemID == next.ProblemID {
+			step.Result = problem
+			break
+		}
+	}
+
+	if scoreboard.NextReveal(after, cells, revealedIn(revealed)) == nil {
+		if err := s.markUnfrozen(ctx, contest); err != nil {
+			return nil, err
+		}
+		after.Frozen = false
+		step.Done = true
+	}
+	return step, nil
+}
+
+// Unfreeze 揭晓全部封榜结果
+func (s *scoreboardService) Unfreeze(ctx context.Context, contestID primitive.ObjectID, viewer serviceInterface.Viewer) (*scoreboard.Standings, error) {
+	contest, err := s.getResolvableContest(ctx, contestID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	cells, participants, err := s.load(ctx, contest)
+	if err != nil {
+		return nil, errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	if !contest.ScoreboardUnfrozen {
+		if err := s.markUnfrozen(ctx, contest); err != nil {
+			return nil, err
+		}
+	}
+	return scoreboard.Build(contest, participants, cells, revealAll), nil
+}
+
+// getResolvableContest 获取可以滚榜的竞赛：当前用户可以管理、竞赛已结束且设置了封榜
+func (s *scoreboardService) getResolvableContest(ctx context.Context, contestID primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Contest, error) {
+	contest, err := s.contestRepo.GetByID(ctx, contestID)
+	if err != nil {
+		return nil, contestError(err)
+	}
+	if !canManage(contest, viewer) {
+		return nil, errors.New(errors.FORBIDDEN, "只能对自己创建的竞赛滚榜")
+	}
+	if contest.StatusAt(time.Now()) != model.ContestStatusEnded {
+		return nil, errors.New(errors.CONTEST_NOT_ENDED)
+	}
+	if contest.Settings.FreezeTime == 0 {
+		return nil, errors.New(errors.SCOREBOARD_NOT_FROZEN)
+	}
+	return contest, nil
+}
+
+// markUnfrozen 记录封榜结果已全部揭晓并清除滚榜进度
+func (s *scoreboardService) markUnfrozen(ctx context.Context, contest *model.Contest) error {
+	if err := s.contestRepo.SetScoreboardUnfrozen(ctx, contest.ID, true); err != nil {
+		return contestError(err)
+	}
+	contest.ScoreboardUnfrozen = true
+	if err := s.cache.ClearRevealed(ctx, contest.ID.Hex()); err != nil {
+		return errors.Wrap(errors.CACHE_ERROR, err)
+	}
+	return nil
+}
+
+// load 读取排行榜单元格和用户信息，缓存未构建或竞赛设置已修改时从提交记录重建
+func (s *scoreboardService) load(ctx context.Context, contest *model.Contest) ([]scoreboard.Cell, []scoreboard.Participant, error) {
+	built, err := s.cache.Built(ctx, contest)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cells []scoreboard.Cell
+	if built {
+		cells, err = s.cache.Cells(ctx, contest.ID.Hex())
+	} else {
+		cells, err = s.rebuild(ctx, contest)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	participants, err := s.participants(ctx, contest, cells)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cells, participants, nil
+}
+
+// rebuild 从竞赛的全部提交记录构建排行榜并写入缓存
+func (s *scoreboardService) rebuild(ctx context.Context, contest *model.Contest) ([]scoreboard.Cell, error) {
+	submissions, err := listAllSubmissions(ctx, s.submissionRepo, map[string]interface{}{"contest_id": contest.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	type cellID struct{ userID, problemID primitive.ObjectID }
+	groups := make(map[cellID][]*model.Submission)
+	var order []cellID
+	for _, submission := range submissions {
+		id := cellID{submission.UserID, submission.ProblemID}
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], submission)
+	}
+
+	cells := make([]scoreboard.Cell, 0, len(order))
+	for _, id := range order {
+		cells = append(cells, scoreboard.NewCell(contest, id.userID, id.problemID, groups[id]))
+	}
+	if err := s.cache.SaveAll(ctx, contest, cells); err != nil {
+		return nil, err
+	}
+	return cells, nil
+}
+
+// listAllSubmissions 分页读取符合条件的全部提交记录
+// 读取期间新增的提交会使后续页面出现重复记录，按ID去重
+func listAllSubmissions(ctx context.Context, submissionRepo repoInterface.SubmissionRepository, filters map[string]interface{}) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	seen := make(map[primitive.ObjectID]bool)
+	for page := 1; ; page++ {
+		batch, total, err := submissionRepo.List(ctx, page, submissionBatchSize, filters)
+		if err != nil {
+			return nil, fmt.Errorf("查询提交记录失败: %w", err)
+		}
+		for _, submission := range batch {
+			if !seen[submission.ID] {
+				seen[submission.ID] = true
+				submissions = append(submissions, submission)
+			}
+		}
+		if len(batch) < submissionBatchSize || int64(page*submissionBatchSize) >= total {
+			return submissions, nil
+		}
+	}
+}
+
+// participants 获取报名用户和有提交的用户的信息，缓存中没有的从数据库读取
+func (s *scoreboardService) participants(ctx context.Context, contest *model.Contest, cells []scoreboard.Cell) ([]scoreboard.Participant, error) {
+	contestID := contest.ID.Hex()
+	cached, err := s.cache.Participants(ctx, contestID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(contest.ParticipantIDs))
+	seen := make(map[string]bool, len(contest.ParticipantIDs))
+	for _, id := range contest.ParticipantIDs {
+		if !seen[id.Hex()] {
+			seen[id.Hex()] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, cell := range cells {
+		if seen[cell.UserID] {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(cell.UserID)
+		if err != nil {
+			continue
+		}
+		seen[cell.UserID] = true
+		ids = append(ids, id)
+	}
+
+	var missing []primitive.ObjectID
+	for _, id := range ids {
+		if _, ok := cached[id.Hex()]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		users, err := s.userRepo.GetByIDs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		loaded := make([]scoreboard.Participant, 0, len(users))
+		for _, user := range users {
+			participant := scoreboard.Participant{
+				UserID:   user.ID.Hex(),
+				Username: user.Username,
+				RealName: user.RealName,
+				Class:    user.Class,
+			}
+			cached[participant.UserID] = participant
+			loaded = append(loaded, participant)
+		}
+		if err := s.cache.SaveParticipants(ctx, contestID, loaded); err != nil {
+			return nil, err
+		}
+	}
+
+	participants := make([]scoreboard.Participant, 0, len(ids))
+	for _, id := range ids {
+		participant, ok := cached[id.Hex()]
+		if !ok {
+			participant = scoreboard.Participant{UserID: id.Hex()} // 用户已删除
+		}
+		participants = append(participants, participant)
+	}
+	return participants, nil
+}
+
+// revealAll 显示全部单元格的最终结果
+func revealAll(*scoreboard.Cell) bool {
+	return true
+}
+
+// revealedIn 只显示滚榜已揭晓单元格的最终结果
+func revealedIn(revealed map[string]bool) func(*scoreboard.Cell) bool {
+	return func(cell *scoreboard.Cell) bool {
+		return revealed[cell.Key()]
+	}
+}
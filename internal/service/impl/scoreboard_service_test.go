@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/scoreboard"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestScoreboardService 使用miniredis缓存的排行榜服务
+func newTestScoreboardService(t *testing.T, contest *model.Contest, submissions *fakeSubmissionRepo, users *fakeUserRepo) serviceInterface.ScoreboardService {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewScoreboardService(newFakeContestRepo(contest), submissions, users, client)
+}
+
+// problemResult 排行榜中用户在题目上的结果
+func problemResult(t *testing.T, standings *scoreboard.Standings, userID, problemID primitive.ObjectID) *scoreboard.ProblemResult {
+	t.Helper()
+	row := standings.Row(userID.Hex())
+	if row == nil {
+		t.Fatalf("排行榜中没有用户%s", userID.Hex())
+	}
+	for _, problem := range row.Problems {
+		if problem.ProblemID == problemID.Hex() {
+			return problem
+		}
+	}
+	t.Fatalf("排行榜中没有题目%s", problemID.Hex())
+	return nil
+}
+
+// TestApplyResult 竞赛提交评测完成后，已缓存的排行榜按该用户该题的提交记录增量更新
+func TestApplyResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		before     []string // 已缓存的提交状态
+		judged     string   // 新评测完成的提交状态
+		score      int
+		wantSolved bool
+		wantTries  int
+		wantScore  int
+	}{
+		{name: "icpc accepted after wrong answer", rule: model.ContestRuleICPC,
+			before: []string{model.StatusWrongAnswer}, judged: model.StatusAccepted, wantSolved: true, wantTries: 1},
+		{name: "icpc wrong answer adds attempt", rule: model.ContestRuleICPC,
+			before: []string{model.StatusWrongAnswer}, judged: model.StatusWrongAnswer, wantTries: 2},
+		{name: "icpc first submission", rule: model.ContestRuleICPC,
+			judged: model.StatusAccepted, wantSolved: true},
+		{name: "oi higher score", rule: model.ContestRuleOI,
+			before: []string{model.StatusWrongAnswer}, judged: model.StatusPartialAccepted, score: 60, wantTries: 2, wantScore: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			user := &model.User{Username: "student"}
+			users := newFakeUserRepo(user)
+			problemID := primitive.NewObjectID()
+			contest := &model.Contest{
+				ID:             primitive.NewObjectID(),
+				ProblemIDs:     []primitive.ObjectID{problemID},
+				ParticipantIDs: []primitive.ObjectID{user.ID},
+				Settings:       model.ContestSettings{Rule: tt.rule, PenaltyTime: 1200},
+				StartTime:      now.Add(-time.Hour),
+				EndTime:        now.Add(time.Hour),
+			}
+
+			submissions := newFakeSubmissionRepo()
+			for i, status := range tt.before {
+				submissions.add(&model.Submission{
+					UserID: user.ID, ProblemID: problemID, ContestID: &contest.ID,
+					Status: status, SubmittedAt: now.Add(time.Duration(i-30) * time.Minute),
+				})
+			}
+			service := newTestScoreboardService(t, contest, submissions, users)
+			viewer := serviceInterface.Viewer{UserID: user.ID}
+
+			// 首次查看时从提交记录构建并缓存排行榜
+			if _, err := service.GetScoreboard(ctx, contest.ID, viewer, false); err != nil {
+				t.Fatalf("获取排行榜失败: %v", err)
+			}
+
+			judgedAt := now
+			submission := &model.Submission{
+				UserID: user.ID, ProblemID: problemID, ContestID: &contest.ID,
+				Status: tt.judged, Score: tt.score, SubmittedAt: now.Add(-time.Minute), JudgedAt: &judgedAt,
+			}
+			submissions.add(submission)
+
+			// 缓存有效期内排行榜不会重新读取提交记录
+			standings, err := service.GetScoreboard(ctx, contest.ID, viewer, false)
+			if err != nil {
+				t.Fatalf("获取排行榜失败: %v", err)
+			}
+			if got := problemResult(t, standings, user.ID, problemID); got.Attempts != len(tt.before) || got.Solved {
+				t.Fatalf("结果应用前的排行榜 = %+v, 期望仍为缓存中的结果", got)
+			}
+
+			err = service.ApplyResult(ctx, &queue.StatsUpdate{
+				SubmissionID: submission.ID,
+				UserID:       user.ID,
+				ProblemID:    problemID,
+				ContestID:    &contest.ID,
+				Status:       tt.judged,
+				Score:        tt.score,
+				JudgedAt:     judgedAt,
+			})
+			if err != nil {
+				t.Fatalf("更新排行榜失败: %v", err)
+			}
+
+			standings, err = service.GetScoreboard(ctx, contest.ID, viewer, false)
+			if err != nil {
+				t.Fatalf("获取排行榜失败: %v", err)
+			}
+			got := problemResult(t, standings, user.ID, problemID)
+			if got.Solved != tt.wantSolved || got.Attempts != tt.wantTries || got.Score != tt.wantScore || got.Pending != 0 {
+				t.Errorf("更新后的结果 = %+v, 期望 solved=%v attempts=%d score=%d",
+					got, tt.wantSolved, tt.wantTries, tt.wantScore)
+			}
+			if tt.wantSolved && standings.Row(user.ID.Hex()).Solved != 1 {
+				t.Errorf("通过题数 = %d, 期望1", standings.Row(user.ID.Hex()).Solved)
+			}
+		})
+	}
+}
+
+// TestApplyResultIgnored 非竞赛提交和已删除竞赛的提交不更新排行榜
+func TestApplyResultIgnored(t *testing.T) {
+	contest := &model.Contest{ID: primitive.NewObjectID()}
+	service := newTestScoreboardService(t, contest, newFakeSubmissionRepo(), newFakeUserRepo())
+	deleted := primitive.NewObjectID()
+
+	for _, update := range []*queue.StatsUpdate{
+		{UserID: primitive.NewObjectID(), ProblemID: primitive.NewObjectID()},
+		{UserID: primitive.NewObjectID(), ProblemID: primitive.NewObjectID(), ContestID: &deleted},
+	} {
+		if err := service.ApplyResult(context.Background(), update); err != nil {
+			t.Errorf("ApplyResult(%+v) = %v, 期望忽略", update, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/utils"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/sso"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ssoService 统一身份认证服务实现
+type ssoService struct {
+	providers   *sso.Registry
+	states      *sso.StateStore
+	userRepo    repoInterface.UserRepository
+	authService serviceInterface.AuthService
+	cfg         config.SSOConfig
+}
+
+// NewSSOService 创建统一身份认证服务实例
+func NewSSOService(
+	providers *sso.Registry,
+	states *sso.StateStore,
+	userRepo repoInterface.UserRepository,
+	authService serviceInterface.AuthService,
+	cfg *config.Config,
+) serviceInterface.SSOService {
+	return &ssoService{
+		providers:   providers,
+		states:      states,
+		userRepo:    userRepo,
+		authService: authService,
+		cfg:         cfg.SSO,
+	}
+}
+
+// Providers 已配置的统一身份认证
+func (s *ssoService) Providers() []*serviceInterface.SSOProviderInfo {
+	list := s.providers.List()
+	infos := make([]*serviceInterface.SSOProviderInfo, 0, len(list))
+	for _, p := range list {
+		infos = append(infos, &serviceInterface.SSOProviderInfo{
+			Name:        p.Name(),
+			Type:        p.Type(),
+			DisplayName: p.DisplayName(),
+		})
+	}
+	return infos
+}
+
+// callbackURL 本服务的回调地址，需要在身份提供方登记
+func (s *ssoService) callbackURL(provider string) string {
+	return strings.TrimSuffix(s.cfg.CallbackBase, "/") + "/" + url.PathEscape(provider) + "/callback"
+}
+
+// redirectAllowed 只允许跳转到配置的前端地址，避免开放重定向
+func (s *ssoService) redirectAllowed(redirect string) bool {
+	u, err := url.Parse(redirect)
+	if err != nil || u.Fragment != "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	for _, allowed := range s.cfg.AllowedRedirects {
+		if redirect == allowed || strings.HasPrefix(redirect, strings.TrimSuffix(allowed, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginURL 发起登录，返回跳转到身份提供方的地址
+func (s *ssoService) LoginURL(ctx context.Context, provider, redirect string) (string, error) {
+	p, ok := s.providers.Get(provider)
+	if !ok {
+		return "", errors.New(errors.SSO_PROVIDER_NOT_FOUND)
+	}
+	if redirect != "" && !s.redirectAllowed(redirect) {
+		return "", errors.New(errors.INVALID_PARAMS, "不允许跳转到该地址")
+	}
+
+	state, err := s.states.Create(ctx, provider, redirect)
+	if err != nil {
+		return "", errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	loginURL, err := p.LoginURL(ctx, state, s.callbackURL(provider))
+	if err != nil {
+		logger.Error("生成统一身份认证地址失败", "provider", provider, "error", err)
+		return "", errors.Wrap(errors.SSO_LOGIN_FAILED, err)
+	}
+	return loginURL, nil
+}
+
+// Callback 处理身份提供方回调
+func (s *ssoService) Callback(ctx context.Context, provider string, params url.Values, client serviceInterface.ClientInfo) (*serviceInterface.SSOResult, error) {
+	p, ok := s.providers.Get(provider)
+	if !ok {
+		return nil, errors.New(errors.SSO_PROVIDER_NOT_FOUND)
+	}
+	state, err := s.states.Take(ctx, params.Get("state"))
+	if err != nil {
+		return nil, errors.Wrap(errors.SERVICE_UNAVAILABLE, err)
+	}
+	if state == nil || state.Provider != provider {
+		return nil, errors.New(errors.SSO_LOGIN_FAILED, "登录已过期，请重新登录")
+	}
+	result := &serviceInterface.SSOResult{Redirect: state.Redirect}
+
+	identity, err := p.Authenticate(ctx, params, state, s.callbackURL(provider))
+	if err != nil {
+		logger.Warn("统一身份认证失败", "provider", provider, "ip", client.IP, "error", err)
+		return result, errors.Wrap(errors.SSO_LOGIN_FAILED, err)
+	}
+
+	user, created, err := s.findOrProvision(ctx, identity)
+	if err != nil {
+		return result, err
+	}
+	if !user.IsActive {
+		return result, errors.New(errors.USER_DISABLED)
+	}
+
+	tokens, err := s.authService.IssueSession(ctx, user, p.DisplayName(), client)
+	if err != nil {
+		return result, err
+	}
+	result.Tokens = tokens
+	result.Created = created
+	logger.Info("统一身份认证登录", "provider", provider, "user_id", user.ID.Hex(), "student_id", identity.StudentID, "created", created)
+	return result, nil
+}
+
+// findOrProvision 按学号查找用户，不存在且开启自动开通时创建账号
+// 学号未核实的账号(自行注册时任意填写的学号)不登录也不覆盖，需要管理员核实
+func (s *ssoService) findOrProvision(ctx context.Context, identity *sso.Identity) (*model.User, bool, error) {
+	user, err := s.getByStudentID(ctx, identity.StudentID)
+	if err != nil {
+		return nil, false, err
+	}
+	if user != nil {
+		if !user.StudentIDVerified {
+			logger.Warn("统一身份认证的学号对应未核实的账号", "provider", identity.Provider,
+				"student_id", identity.StudentID, "user_id", user.ID.Hex())
+			return nil, false, errors.New(errors.SSO_ACCOUNT_UNVERIFIED)
+		}
+		return user, false, nil
+	}
+	if !s.cfg.AutoProvision {
+		return nil, false, errors.New(errors.SSO_ACCOUNT_NOT_FOUND)
+	}
+
+	// 账号通过统一身份认证登录，本地密码随机生成
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+
+	user = &model.User{
+		StudentID: identity.StudentID,
+		Username:  s.pickUsername(ctx, identity),
+		Password:  string(hashedPassword),
+		Email:     s.pickEmail(ctx, identity),
+		RealName:  identity.RealName,
+		Role:      identity.Role,
+		Class:     identity.Class,
+		Grade:     identity.Grade,
+		IsActive:  true,
+		// 学号来自身份提供方
+		StudentIDVerified: true,
+	}
+	if user.RealName == "" {
+		user.RealName = user.Username
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// 同一用户并发首次登录，另一个请求已创建账号
+		if stdErrors.Is(err, repoInterface.ErrStudentIDExists) {
+			user, err := s.getByStudentID(ctx, identity.StudentID)
+			if err == nil && user == nil {
+				err = errors.New(errors.SSO_LOGIN_FAILED)
+			}
+			if err == nil && !user.StudentIDVerified {
+				err = errors.New(errors.SSO_ACCOUNT_UNVERIFIED)
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			return user, false, nil
+		}
+		if stdErrors.Is(err, repoInterface.ErrUsernameExists) || stdErrors.Is(err, repoInterface.ErrEmailExists) {
+			return nil, false, errors.New(errors.SSO_LOGIN_FAILED, "用户名或邮箱已被其他账号使用，请联系管理员")
+		}
+		return nil, false, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return user, true, nil
+}
+
+func (s *ssoService) getByStudentID(ctx context.Context, studentID string) (*model.User, error) {
+	exists, err := s.userRepo.ExistsByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	user, err := s.userRepo.GetByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return user, nil
+}
+
+// pickUsername 优先使用身份声明中的用户名，不合法或已被占用时使用学号
+func (s *ssoService) pickUsername(ctx context.Context, identity *sso.Identity) string {
+	name := identity.Username
+	if n := utf8.RuneCountInString(name); n < 3 || n > 20 {
+		return identity.StudentID
+	}
+	if exists, err := s.userRepo.ExistsByUsername(ctx, name); err != nil || exists {
+		return identity.StudentID
+	}
+	return name
+}
+
+// pickEmail 邮箱有唯一索引，身份声明中没有邮箱或已被占用时使用不可投递的占位地址
+func (s *ssoService) pickEmail(ctx context.Context, identity *sso.Identity) string {
+	if identity.Email != "" {
+		if exists, err := s.userRepo.ExistsByEmail(ctx, identity.Email); err == nil && !exists {
+			return identity.Email
+		}
+	}
+	return identity.StudentID + "@" + identity.Provider + ".sso.invalid"
+}
@@ -0,0 +1,208 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/sso"
+	"zhku-oj/internal/sso/ssotest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeAuthService 签发会话时记录登录的用户
+type fakeAuthService struct {
+	serviceInterface.AuthService
+	sessions []*model.User
+}
+
+func (s *fakeAuthService) IssueSession(ctx context.Context, user *model.User, deviceName string, client serviceInterface.ClientInfo) (*serviceInterface.TokenPair, error) {
+	s.sessions = append(s.sessions, user)
+	return &serviceInterface.TokenPair{AccessToken: "access-" + user.ID.Hex(), User: user}, nil
+}
+
+type ssoTest struct {
+	service serviceInterface.SSOService
+	users   *fakeUserRepo
+	auth    *fakeAuthService
+	idp     *ssotest.Server
+}
+
+// newSSOTest 连接模拟身份提供方(OIDC)的统一身份认证服务
+func newSSOTest(t *testing.T, autoProvision bool, users ...*model.User) *ssoTest {
+	t.Helper()
+	idp := ssotest.NewServer("zhku-oj", "fake-secret")
+	t.Cleanup(idp.Close)
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{SSO: config.SSOConfig{
+		CallbackBase:  "https://oj.example.edu.cn/api/v1/auth/sso",
+		AutoProvision: autoProvision,
+		Providers: []config.SSOProviderConfig{{
+			Name:         "campus",
+			Type:         sso.TypeOIDC,
+			DisplayName:  "校园统一身份认证",
+			Issuer:       idp.URL,
+			ClientID:     "zhku-oj",
+			ClientSecret: "fake-secret",
+			UserInfo:     true,
+			Claims: config.SSOClaimMapping{StudentID: "student_id", Username: "preferred_username", RealName: "name",
+				Email: "email", Role: "affiliation", Class: "class", Grade: "grade"},
+			RoleMap: map[string]string{"faculty": model.RoleTeacher},
+		}},
+	}}
+	providers, err := sso.NewRegistry(cfg.SSO, idp.Client())
+	if err != nil {
+		t.Fatalf("创建身份提供方失败: %v", err)
+	}
+
+	st := &ssoTest{users: newFakeUserRepo(users...), auth: &fakeAuthService{}, idp: idp}
+	st.service = NewSSOService(providers, sso.NewStateStore(client, time.Minute), st.users, st.auth, cfg)
+	return st
+}
+
+// login 以模拟身份提供方中的用户完成一次登录
+func (st *ssoTest) login(t *testing.T, user string) (*serviceInterface.SSOResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	loginURL, err := st.service.LoginURL(ctx, "campus", "")
+	if err != nil {
+		t.Fatalf("发起登录失败: %v", err)
+	}
+	params, err := st.idp.Login(loginURL, user)
+	if err != nil {
+		t.Fatalf("登录模拟身份提供方失败: %v", err)
+	}
+	return st.service.Callback(ctx, "campus", params, serviceInterface.ClientInfo{IP: "10.0.0.1"})
+}
+
+func errorCode(err error) int {
+	var businessErr *errors.BusinessError
+	if stdErrors.As(err, &businessErr) {
+		return businessErr.Code
+	}
+	if err != nil {
+		return -1
+	}
+	return errors.SUCCESS
+}
+
+func TestSSOCallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		autoProvision bool
+		existing      []*model.User
+		login         string
+		wantCode      int
+		wantCreated   bool
+		want          *model.User // 登录的账号，只比较下面检查的字段
+	}{
+		{
+			name: "provision student", autoProvision: true, login: "zhangsan", wantCreated: true,
+			want: &model.User{StudentID: "2021001001", Username: "zhangsan", Email: "zhangsan@example.edu.cn",
+				RealName: "张三", Role: model.RoleStudent, Class: "计算机科学与技术2021-1班", Grade: "2021"},
+		},
+		{
+			name: "provision teacher", autoProvision: true, login: "T2020001", wantCreated: true,
+			want: &model.User{StudentID: "T2020001", Username: "wanglaoshi", Email: "wang@example.edu.cn",
+				RealName: "王老师", Role: model.RoleTeacher},
+		},
+		{
+			// 用户名已被占用时使用学号，没有邮箱时使用占位地址
+			name: "provision with taken username and no email", autoProvision: true, login: "lisi", wantCreated: true,
+			existing: []*model.User{{StudentID: "2020009999", Username: "lisi", Email: "lisi@example.com", StudentIDVerified: true}},
+			want: &model.User{StudentID: "2021001002", Username: "2021001002", Email: "2021001002@campus.sso.invalid",
+				RealName: "李四", Role: model.RoleStudent, Class: "计算机科学与技术2021-2班", Grade: "2021"},
+		},
+		{
+			name: "verified account", login: "zhangsan",
+			existing: []*model.User{{StudentID: "2021001001", Username: "zs", Email: "zs@example.com", RealName: "张三",
+				Role: model.RoleTA, IsActive: true, StudentIDVerified: true}},
+			want: &model.User{StudentID: "2021001001", Username: "zs", Email: "zs@example.com", RealName: "张三", Role: model.RoleTA},
+		},
+		{
+			// 他人自行注册时填写了该学号，不能借此冒用统一身份认证登录，也不能占用
+			name: "unverified account rejected", autoProvision: true, login: "zhangsan",
+			existing: []*model.User{{StudentID: "2021001001", Username: "squatter", Email: "s@example.com",
+				Role: model.RoleStudent, IsActive: true}},
+			wantCode: errors.SSO_ACCOUNT_UNVERIFIED,
+		},
+		{
+			name: "disabled account", login: "zhangsan",
+			existing: []*model.User{{StudentID: "2021001001", Username: "zs", Email: "zs@example.com", StudentIDVerified: true}},
+			wantCode: errors.USER_DISABLED,
+		},
+		{
+			name: "auto provision off", login: "zhangsan",
+			wantCode: errors.SSO_ACCOUNT_NOT_FOUND,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSSOTest(t, tt.autoProvision, tt.existing...)
+			before := len(st.users.users)
+
+			result, err := st.login(t, tt.login)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("登录错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+			}
+			if tt.wantCode != errors.SUCCESS {
+				if len(st.auth.sessions) != 0 || len(st.users.users) != before {
+					t.Errorf("登录失败时签发了%d个会话、新增%d个账号", len(st.auth.sessions), len(st.users.users)-before)
+				}
+				return
+			}
+
+			if result.Created != tt.wantCreated || result.Tokens == nil || len(st.auth.sessions) != 1 {
+				t.Fatalf("登录结果 = %+v, 会话 %d 个, 期望 created=%v 且签发1个会话", result, len(st.auth.sessions), tt.wantCreated)
+			}
+			got, err := st.users.GetByStudentID(context.Background(), tt.want.StudentID)
+			if err != nil {
+				t.Fatalf("查找账号失败: %v", err)
+			}
+			if st.auth.sessions[0].ID != got.ID {
+				t.Errorf("登录的账号 = %s, 期望 %s", st.auth.sessions[0].ID.Hex(), got.ID.Hex())
+			}
+			if got.Username != tt.want.Username || got.Email != tt.want.Email || got.RealName != tt.want.RealName ||
+				got.Role != tt.want.Role || got.Class != tt.want.Class || got.Grade != tt.want.Grade {
+				t.Errorf("账号 = %+v, 期望 %+v", got, tt.want)
+			}
+			if !got.IsActive || !got.StudentIDVerified {
+				t.Errorf("账号 is_active=%v student_id_verified=%v, 期望均为true", got.IsActive, got.StudentIDVerified)
+			}
+			if tt.wantCreated && got.Password == "" {
+				t.Error("自动开通的账号应设置随机密码")
+			}
+		})
+	}
+}
+
+// TestSSOCallbackStateReused 登录状态只能使用一次
+func TestSSOCallbackStateReused(t *testing.T) {
+	st := newSSOTest(t, true)
+	ctx := context.Background()
+	loginURL, err := st.service.LoginURL(ctx, "campus", "")
+	if err != nil {
+		t.Fatalf("发起登录失败: %v", err)
+	}
+	params, err := st.idp.Login(loginURL, "zhangsan")
+	if err != nil {
+		t.Fatalf("登录模拟身份提供方失败: %v", err)
+	}
+	if _, err := st.service.Callback(ctx, "campus", params, serviceInterface.ClientInfo{}); err != nil {
+		t.Fatalf("首次回调失败: %v", err)
+	}
+	if _, err := st.service.Callback(ctx, "campus", params, serviceInterface.ClientInfo{}); errorCode(err) != errors.SSO_LOGIN_FAILED {
+		t.Errorf("重复回调错误码 = %d, 期望 %d", errorCode(err), errors.SSO_LOGIN_FAILED)
+	}
+}
@@ -0,0 +1,118 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// statsService 用户和题目统计服务实现
+type statsService struct {
+	userRepo       repoInterface.UserRepository
+	problemRepo    repoInterface.ProblemRepository
+	submissionRepo repoInterface.SubmissionRepository
+}
+
+// NewStatsService 创建统计服务实例
+func NewStatsService(
+	userRepo repoInterface.UserRepository,
+	problemRepo repoInterface.ProblemRepository,
+	submissionRepo repoInterface.SubmissionRepository,
+) serviceInterface.StatsService {
+	return &statsService{
+		userRepo:       userRepo,
+		problemRepo:    problemRepo,
+		submissionRepo: submissionRepo,
+	}
+}
+
+// judgedStatuses 已有判题结果的提交，统计时不计判题中的提交
+var judgedStatuses = map[string]interface{}{"$nin": []string{model.StatusPending, model.StatusJudging}}
+
+// UpdateStats 重新计算提交用户和题目的统计信息
+func (s *statsService) UpdateStats(ctx context.Context, update *queue.StatsUpdate) error {
+	if err := s.updateUserStats(ctx, update.UserID); err != nil {
+		return err
+	}
+	return s.updateProblemStats(ctx, update.ProblemID)
+}
+
+// ProcessNotification 处理用户通知
+// 站内通知尚未持久化；判题结果已由判题机通过实时推送送达提交用户，这里只记录日志
+func (s *statsService) ProcessNotification(ctx context.Context, notification *queue.Notification) error {
+	logger.Debug("收到用户通知", "type", notification.Type, "user_id", notification.UserID.Hex(), "title", notification.Title)
+	return nil
+}
+
+// updateUserStats 统计用户的提交数、通过数和通过的题目数，排名不变
+func (s *statsService) updateUserStats(ctx context.Context, userID primitive.ObjectID) error {
+	users, err := s.userRepo.GetByIDs(ctx, []primitive.ObjectID{userID})
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil // 用户已删除
+	}
+
+	total, err := s.submissionRepo.Count(ctx, map[string]interface{}{"user_id": userID, "status": judgedStatuses})
+	if err != nil {
+		return fmt.Errorf("统计用户提交数失败: %w", err)
+	}
+	accepted, err := listAllSubmissions(ctx, s.submissionRepo, map[string]interface{}{
+		"user_id": userID,
+		"status":  model.StatusAccepted,
+	})
+	if err != nil {
+		return err
+	}
+	solved := make(map[primitive.ObjectID]bool)
+	for _, submission := range accepted {
+		solved[submission.ProblemID] = true
+	}
+
+	stats := users[0].Stats
+	stats.TotalSubmissions = int(total)
+	stats.AcceptedCount = len(accepted)
+	stats.ProblemsSolved = len(solved)
+	return s.userRepo.UpdateStats(ctx, userID, stats)
+}
+
+// updateProblemStats 统计题目的提交数、通过数和通过率，平均用时和内存按通过的提交计算
+func (s *statsService) updateProblemStats(ctx context.Context, problemID primitive.ObjectID) error {
+	total, err := s.submissionRepo.Count(ctx, map[string]interface{}{"problem_id": problemID, "status": judgedStatuses})
+	if err != nil {
+		return fmt.Errorf("统计题目提交数失败: %w", err)
+	}
+	accepted, err := listAllSubmissions(ctx, s.submissionRepo, map[string]interface{}{
+		"problem_id": problemID,
+		"status":     model.StatusAccepted,
+	})
+	if err != nil {
+		return err
+	}
+
+	stats := model.ProblemStats{
+		TotalSubmissions: int(total),
+		AcceptedCount:    len(accepted),
+	}
+	if total > 0 {
+		stats.AcceptanceRate = float64(len(accepted)) / float64(total)
+	}
+	if len(accepted) > 0 {
+		timeUsed, memoryUsed := 0, 0
+		for _, submission := range accepted {
+			timeUsed += submission.TimeUsed
+			memoryUsed += submission.MemoryUsed
+		}
+		stats.AverageTime = timeUsed / len(accepted)
+		stats.AverageMemory = memoryUsed / len(accepted)
+	}
+	return s.problemRepo.UpdateStats(ctx, problemID, stats)
+}
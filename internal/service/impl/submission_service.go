@@ -0,0 +1,202 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/rbac"
+	"zhku-oj/internal/realtime"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// submissionService 代码提交服务实现
+type submissionService struct {
+	submissionRepo    repoInterface.SubmissionRepository
+	problemRepo       repoInterface.ProblemRepository
+	courseRepo        repoInterface.CourseRepository
+	judgeTaskRepo     repoInterface.JudgeTaskRepository
+	contestService    serviceInterface.ContestService
+	assignmentService serviceInterface.AssignmentService
+	producer          queue.Producer
+	events            realtime.Publisher
+}
+
+// NewSubmissionService 创建代码提交服务实例
+func NewSubmissionService(
+	submissionRepo repoInterface.SubmissionRepository,
+	problemRepo repoInterface.ProblemRepository,
+	courseRepo repoInterface.CourseRepository,
+	judgeTaskRepo repoInterface.JudgeTaskRepository,
+	contestService serviceInterface.ContestService,
+	assignmentService serviceInterface.AssignmentService,
+	producer queue.Producer,
+	events realtime.Publisher,
+) serviceInterface.SubmissionService {
+	return &submissionService{
+		submissionRepo:    submissionRepo,
+		problemRepo:       problemRepo,
+		courseRepo:        courseRepo,
+		judgeTaskRepo:     judgeTaskRepo,
+		contestService:    contestService,
+		assignmentService: assignmentService,
+		producer:          producer,
+		events:            events,
+	}
+}
+
+// Submit 提交代码
+func (s *submissionService) Submit(ctx context.Context, req *serviceInterface.SubmitRequest) (*model.Submission, error) {
+	problem, err := s.problemRepo.GetByID(ctx, req.ProblemID)
+	if err != nil {
+		return nil, errors.NewProblemNotFound()
+	}
+	if allowed := problem.Constraints.AllowedLanguages; len(allowed) > 0 && !containsString(allowed, req.Language) {
+		return nil, errors.New(errors.LANGUAGE_NOT_SUPPORTED, "该题目不允许使用"+req.Language)
+	}
+
+	// 竞赛提交检查竞赛时间和报名；非公开题目只能在竞赛中提交；作业提交检查课程成员和作业时间
+	lane := queue.LanePractice
+	switch {
+	case req.ContestID != nil && req.AssignmentID != nil:
+		return nil, errors.New(errors.INVALID_PARAMS, "竞赛提交不能同时指定作业")
+	case req.ContestID != nil:
+		if _, err := s.contestService.CheckSubmission(ctx, *req.ContestID, req.UserID, req.ProblemID); err != nil {
+			return nil, err
+		}
+		lane = queue.LaneContest
+	case !problem.IsPublic && !req.Permissions.Has(rbac.ProblemViewHidden):
+		return nil, errors.New(errors.PROBLEM_NOT_PUBLIC)
+	case req.AssignmentID != nil:
+		if _, err := s.assignmentService.CheckSubmission(ctx, *req.AssignmentID, req.UserID, req.ProblemID); err != nil {
+			return nil, err
+		}
+		lane = queue.LaneHomework
+	}
+
+	submission := &model.Submission{
+		UserID:       req.UserID,
+		ProblemID:    req.ProblemID,
+		ContestID:    req.ContestID,
+		AssignmentID: req.AssignmentID,
+		Code:         req.Code,
+		Language:     req.Language,
+		Status:       model.StatusPending,
+		SubmittedAt:  time.Now(),
+	}
+	if err := s.submissionRepo.Create(ctx, submission); err != nil {
+		return nil, errors.Wrap(errors.SUBMISSION_CREATE_FAILED, err)
+	}
+
+	if err := s.enqueue(ctx, submission, lane, submission.SubmittedAt); err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// Rejudge 重新判题，判题任务进入重判通道
+func (s *submissionService) Rejudge(ctx context.Context, submissionID primitive.ObjectID) (*model.Submission, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, errors.NewSubmissionNotFound()
+	}
+	// 状态检查与重置在同一次写操作中完成，并发的重新判题或正在进行的判题不会重复入队
+	if err := s.submissionRepo.MarkRejudge(ctx, submission.ID); err != nil {
+		if stdErrors.Is(err, repoInterface.ErrStatusTransition) {
+			return nil, errors.New(errors.SUBMISSION_JUDGING)
+		}
+		return nil, errors.Wrap(errors.SUBMISSION_UPDATE_FAILED, err)
+	}
+	submission.Status = model.StatusPending
+
+	// 发布前把judge_queue中已完成的任务重置为PENDING，否则判题机领取时会当作重复投递跳过
+	queuedAt := time.Now()
+	task := &model.JudgeTask{
+		SubmissionID: submission.ID,
+		Lane:         queue.LaneRejudge,
+		Priority:     queue.LanePriority(queue.LaneRejudge),
+		CreatedAt:    queuedAt,
+	}
+	if err := s.judgeTaskRepo.Reset(ctx, task); err != nil {
+		s.markSystemError(ctx, submission.ID)
+		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+
+	if err := s.enqueue(ctx, submission, queue.LaneRejudge, queuedAt); err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// markSystemError 判题任务未能入队时标记为系统错误，避免提交一直停留在PENDING，之后可以重新判题
+func (s *submissionService) markSystemError(ctx context.Context, id primitive.ObjectID) {
+	if err := s.submissionRepo.UpdateStatus(ctx, id, model.StatusSystemError); err != nil {
+		logger.Error("更新提交状态失败", "submission_id", id.Hex(), "error", err)
+	}
+}
+
+// enqueue 发布判题任务并向提交用户推送排队事件
+func (s *submissionService) enqueue(ctx context.Context, submission *model.Submission, lane string, queuedAt time.Time) error {
+	task := &queue.JudgeTask{
+		SubmissionID: submission.ID,
+		ProblemID:    submission.ProblemID,
+		UserID:       submission.UserID,
+		ContestID:    submission.ContestID,
+		Code:         submission.Code,
+		Language:     submission.Language,
+		Lane:         lane,
+		CreatedAt:    queuedAt,
+	}
+	if err := s.producer.PublishJudgeTask(ctx, task); err != nil {
+		s.markSystemError(ctx, submission.ID)
+		return errors.Wrap(errors.MESSAGE_QUEUE_ERROR, err)
+	}
+
+	// 推送排队事件，客户端提交后立即建立的连接可以看到完整的判题过程
+	progress := &realtime.JudgeProgress{
+		SubmissionID: submission.ID.Hex(),
+		Stage:        realtime.StageQueued,
+		Timestamp:    time.Now(),
+	}
+	if err := s.events.Publish(ctx, submission.UserID, realtime.EventJudgeProgress, progress); err != nil {
+		logger.Warn("推送判题事件失败", "submission_id", submission.ID.Hex(), "error", err)
+	}
+	return nil
+}
+
+// GetSubmission 获取提交详情
+func (s *submissionService) GetSubmission(ctx context.Context, submissionID primitive.ObjectID, viewer serviceInterface.Viewer) (*model.Submission, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, errors.NewSubmissionNotFound()
+	}
+	if submission.UserID == viewer.UserID || viewer.Permissions.Has(rbac.SubmissionViewAny) {
+		return submission, nil
+	}
+	if viewer.Permissions.Has(rbac.SubmissionViewClass) {
+		teaches, err := s.courseRepo.Teaches(ctx, viewer.UserID, submission.UserID)
+		if err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if teaches {
+			return submission, nil
+		}
+	}
+	return nil, errors.New(errors.SUBMISSION_ACCESS_DENIED)
+}
+
+// ListSubmissions 分页查询提交记录
+func (s *submissionService) ListSubmissions(ctx context.Context, filter map[string]interface{}, page, pageSize int) ([]*model.Submission, int64, error) {
+	submissions, total, err := s.submissionRepo.List(ctx, page, pageSize, filter)
+	if err != nil {
+		return nil, 0, errors.Wrap(errors.DATABASE_ERROR, err)
+	}
+	return submissions, total, nil
+}
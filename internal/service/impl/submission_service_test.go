@@ -0,0 +1,215 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/queue"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRejudge(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		resetErr   error
+		publishErr error
+		wantCode   int
+		wantStatus string // 提交最终状态
+	}{
+		{name: "accepted submission", status: model.StatusAccepted, wantStatus: model.StatusPending},
+		{name: "system error submission", status: model.StatusSystemError, wantStatus: model.StatusPending},
+		{name: "still judging", status: model.StatusJudging, wantCode: errors.SUBMISSION_JUDGING, wantStatus: model.StatusJudging},
+		{name: "still pending", status: model.StatusPending, wantCode: errors.SUBMISSION_JUDGING, wantStatus: model.StatusPending},
+		{name: "queue unavailable", status: model.StatusWrongAnswer, publishErr: stdErrors.New("连接断开"),
+			wantCode: errors.MESSAGE_QUEUE_ERROR, wantStatus: model.StatusSystemError},
+		{name: "judge task reset failed", status: model.StatusWrongAnswer, resetErr: stdErrors.New("写入超时"),
+			wantCode: errors.DATABASE_ERROR, wantStatus: model.StatusSystemError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission := &model.Submission{Status: tt.status, Language: "java", SubmittedAt: time.Now().Add(-time.Hour)}
+			submissions := newFakeSubmissionRepo(submission)
+			tasks := &fakeJudgeTaskRepo{err: tt.resetErr}
+			producer := &fakeProducer{err: tt.publishErr}
+			service := NewSubmissionService(submissions, nil, nil, tasks, nil, nil, producer, fakeEvents{})
+
+			_, err := service.Rejudge(context.Background(), submission.ID)
+			if tt.wantCode != 0 {
+				var be *errors.BusinessError
+				if !stdErrors.As(err, &be) || be.Code != tt.wantCode {
+					t.Fatalf("错误 = %v, 期望错误码 %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("重新判题失败: %v", err)
+			}
+
+			stored, _ := submissions.GetByID(context.Background(), submission.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("提交状态 = %s, 期望 %s", stored.Status, tt.wantStatus)
+			}
+			if tt.wantCode == errors.SUBMISSION_JUDGING || tt.resetErr != nil {
+				if len(tasks.reset) != 0 || len(producer.tasks) != 0 {
+					t.Error("正在判题或重置任务失败的提交不应重置或发布判题任务")
+				}
+				return
+			}
+
+			if len(tasks.reset) != 1 {
+				t.Fatalf("重置判题任务%d次, 期望1次", len(tasks.reset))
+			}
+			reset := tasks.reset[0]
+			if reset.SubmissionID != submission.ID || reset.Lane != queue.LaneRejudge ||
+				reset.Priority != queue.LanePriority(queue.LaneRejudge) || reset.Status != model.JudgeTaskPending {
+				t.Errorf("重置的判题任务 = %+v", reset)
+			}
+			if tt.publishErr != nil {
+				return
+			}
+			if len(producer.tasks) != 1 || producer.tasks[0].Lane != queue.LaneRejudge || producer.tasks[0].SubmissionID != submission.ID {
+				t.Fatalf("发布的判题任务 = %+v, 期望重判通道的一个任务", producer.tasks)
+			}
+			if !producer.tasks[0].CreatedAt.Equal(reset.CreatedAt) {
+				t.Error("判题任务的入队时间应与judge_queue一致")
+			}
+		})
+	}
+}
+
+// TestRejudgeConcurrent 同时重新判题同一提交只有一次成功入队，其余返回正在判题
+func TestRejudgeConcurrent(t *testing.T) {
+	submission := &model.Submission{Status: model.StatusAccepted, Language: "java", SubmittedAt: time.Now().Add(-time.Hour)}
+	submissions := newFakeSubmissionRepo(submission)
+	tasks := &fakeJudgeTaskRepo{}
+	producer := &fakeProducer{}
+	service := NewSubmissionService(submissions, nil, nil, tasks, nil, nil, producer, fakeEvents{})
+
+	const n = 8
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := service.Rejudge(context.Background(), submission.ID)
+			codes[i] = errorCode(err)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		switch code {
+		case errors.SUCCESS:
+			succeeded++
+		case errors.SUBMISSION_JUDGING:
+		default:
+			t.Errorf("错误码 = %d, 期望成功或正在判题", code)
+		}
+	}
+	if succeeded != 1 || len(tasks.reset) != 1 || len(producer.tasks) != 1 {
+		t.Errorf("成功%d次、重置任务%d次、发布任务%d次, 期望各1次", succeeded, len(tasks.reset), len(producer.tasks))
+	}
+}
+
+func TestRejudgeNotFound(t *testing.T) {
+	service := NewSubmissionService(newFakeSubmissionRepo(), nil, nil, &fakeJudgeTaskRepo{}, nil, nil, &fakeProducer{}, fakeEvents{})
+	_, err := service.Rejudge(context.Background(), primitive.NewObjectID())
+	var be *errors.BusinessError
+	if !stdErrors.As(err, &be) || be.Code != errors.SUBMISSION_NOT_FOUND {
+		t.Fatalf("错误 = %v, 期望提交记录不存在", err)
+	}
+}
+
+// TestSubmitAssignment 作业提交记录所属作业并进入作业通道，课程成员只能在作业开放到本人关闭时间之间提交作业的题目
+func TestSubmitAssignment(t *testing.T) {
+	now := time.Now()
+	student, extended, outsider := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	problem := &model.Problem{Title: "两数之和", IsPublic: true}
+	other := &model.Problem{Title: "水仙花数", IsPublic: true}
+	course := &model.Course{Name: "Java程序设计", StudentIDs: []primitive.ObjectID{student, extended}}
+	newAssignment := func(open, due, close time.Duration) *model.Assignment {
+		return &model.Assignment{
+			CourseID:  course.ID,
+			Problems:  []model.AssignmentProblem{{ProblemID: problem.ID, Points: 100}},
+			OpenTime:  now.Add(open),
+			DueTime:   now.Add(due),
+			CloseTime: now.Add(close),
+			// 延期的学生关闭时间推迟一天
+			Extensions: []model.AssignmentExtension{{UserID: extended, DueTime: now.Add(due + 24*time.Hour), CloseTime: now.Add(close + 24*time.Hour)}},
+		}
+	}
+	problems := newFakeProblemRepo(problem, other) // 创建仓储时分配ID
+	courses := newFakeCourseRepo(course)
+	open := newAssignment(-time.Hour, time.Hour, 2*time.Hour)
+	late := newAssignment(-2*time.Hour, -time.Hour, time.Hour)
+	closed := newAssignment(-3*time.Hour, -2*time.Hour, -time.Hour)
+	upcoming := newAssignment(time.Hour, 2*time.Hour, 3*time.Hour)
+	assignments := newFakeAssignmentRepo(open, late, closed, upcoming)
+	contestID, missing := primitive.NewObjectID(), primitive.NewObjectID()
+
+	tests := []struct {
+		name         string
+		userID       primitive.ObjectID
+		problemID    primitive.ObjectID
+		contestID    *primitive.ObjectID
+		assignmentID *primitive.ObjectID
+		wantCode     int
+		wantLane     string
+	}{
+		{name: "practice", userID: student, problemID: problem.ID, wantLane: queue.LanePractice},
+		{name: "open assignment", userID: student, problemID: problem.ID, assignmentID: &open.ID, wantLane: queue.LaneHomework},
+		{name: "late submission", userID: student, problemID: problem.ID, assignmentID: &late.ID, wantLane: queue.LaneHomework},
+		{name: "closed", userID: student, problemID: problem.ID, assignmentID: &closed.ID, wantCode: errors.ASSIGNMENT_CLOSED},
+		{name: "closed but extended", userID: extended, problemID: problem.ID, assignmentID: &closed.ID, wantLane: queue.LaneHomework},
+		{name: "not open", userID: student, problemID: problem.ID, assignmentID: &upcoming.ID, wantCode: errors.ASSIGNMENT_NOT_OPEN},
+		{name: "not a course member", userID: outsider, problemID: problem.ID, assignmentID: &open.ID, wantCode: errors.COURSE_ACCESS_DENIED},
+		{name: "problem not in assignment", userID: student, problemID: other.ID, assignmentID: &open.ID, wantCode: errors.PROBLEM_NOT_FOUND},
+		{name: "assignment not found", userID: student, problemID: problem.ID, assignmentID: &missing, wantCode: errors.ASSIGNMENT_NOT_FOUND},
+		{name: "contest and assignment", userID: student, problemID: problem.ID, contestID: &contestID, assignmentID: &open.ID, wantCode: errors.INVALID_PARAMS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submissions := newFakeSubmissionRepo()
+			producer := &fakeProducer{}
+			assignmentService := NewAssignmentService(assignments, courses, problems, nil, submissions)
+			service := NewSubmissionService(submissions, problems, courses, &fakeJudgeTaskRepo{}, nil, assignmentService, producer, fakeEvents{})
+
+			submission, err := service.Submit(context.Background(), &serviceInterface.SubmitRequest{
+				UserID:       tt.userID,
+				ProblemID:    tt.problemID,
+				ContestID:    tt.contestID,
+				AssignmentID: tt.assignmentID,
+				Code:         "print(1)",
+				Language:     "python",
+			})
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("提交错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+			}
+			if tt.wantCode != errors.SUCCESS {
+				if len(submissions.submissions) != 0 || len(producer.tasks) != 0 {
+					t.Error("提交被拒绝时不应创建提交记录或发布判题任务")
+				}
+				return
+			}
+
+			stored, _ := submissions.GetByID(context.Background(), submission.ID)
+			if !reflect.DeepEqual(stored.AssignmentID, tt.assignmentID) {
+				t.Errorf("提交所属作业 = %v, 期望 %v", stored.AssignmentID, tt.assignmentID)
+			}
+			if len(producer.tasks) != 1 || producer.tasks[0].Lane != tt.wantLane {
+				t.Fatalf("发布的判题任务 = %+v, 期望%s通道的一个任务", producer.tasks, tt.wantLane)
+			}
+		})
+	}
+}
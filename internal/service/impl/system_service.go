@@ -0,0 +1,120 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/queue"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// laneStatsWindow 判题通道排队时长统计窗口
+const laneStatsWindow = 5 * time.Minute
+
+// systemService 系统监控服务实现
+type systemService struct {
+	judgeTaskRepo repoInterface.JudgeTaskRepository
+	producer      queue.Producer
+	redisClient   *redis.Client
+	cfg           *config.Config
+}
+
+// NewSystemService 创建系统监控服务实例
+func NewSystemService(
+	judgeTaskRepo repoInterface.JudgeTaskRepository,
+	producer queue.Producer,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) serviceInterface.SystemService {
+	return &systemService{
+		judgeTaskRepo: judgeTaskRepo,
+		producer:      producer,
+		redisClient:   redisClient,
+		cfg:           cfg,
+	}
+}
+
+// GetSystemStatus 获取系统状态
+func (s *systemService) GetSystemStatus(ctx context.Context) (*serviceInterface.SystemStatus, error) {
+	status := &serviceInterface.SystemStatus{
+		Components: make(map[string]string),
+		CheckedAt:  time.Now(),
+	}
+
+	// 1. Redis
+	status.Components["redis"] = serviceInterface.ComponentHealthy
+	if err := s.redisClient.Ping(ctx).Err(); err != nil {
+		logger.Warn("Redis健康检查失败", "error", err)
+		status.Components["redis"] = serviceInterface.ComponentUnavailable
+	}
+
+	// 2. 判题队列：队列深度来自消息队列，任务数和排队时长来自judge_queue
+	status.JudgeQueue = s.judgeQueueStatus(ctx, status.Components)
+
+	return status, nil
+}
+
+// judgeQueueStatus 按通道汇总判题队列状态
+func (s *systemService) judgeQueueStatus(ctx context.Context, components map[string]string) *serviceInterface.JudgeQueueStatus {
+	lanes := make([]*serviceInterface.JudgeLaneStatus, 0, len(queue.Lanes))
+	byLane := make(map[string]*serviceInterface.JudgeLaneStatus, len(queue.Lanes))
+	for _, lane := range queue.Lanes {
+		item := &serviceInterface.JudgeLaneStatus{
+			Lane:   lane,
+			Weight: queue.LaneWeight(s.cfg.RabbitMQ.LaneWeights, lane),
+		}
+		lanes = append(lanes, item)
+		byLane[lane] = item
+	}
+
+	components["message_queue"] = serviceInterface.ComponentHealthy
+	for _, item := range lanes {
+		depth, err := s.producer.QueueLength(ctx, queue.JudgeTaskQueue(item.Lane))
+		if err != nil {
+			logger.Warn("获取判题队列长度失败", "lane", item.Lane, "error", err)
+			components["message_queue"] = serviceInterface.ComponentUnavailable
+			continue
+		}
+		item.Depth = depth
+	}
+
+	components["mongodb"] = serviceInterface.ComponentHealthy
+	stats, err := s.judgeTaskRepo.LaneStats(ctx, time.Now().Add(-laneStatsWindow))
+	if err != nil {
+		logger.Warn("统计判题通道失败", "error", err)
+		components["mongodb"] = serviceInterface.ComponentUnavailable
+		stats = nil
+	}
+	for _, stat := range stats {
+		item, ok := byLane[queue.NormalizeLane(stat.Lane)]
+		if !ok {
+			continue
+		}
+		mergeLaneStats(item, stat)
+	}
+
+	return &serviceInterface.JudgeQueueStatus{
+		Lanes:        lanes,
+		WindowSecond: int(laneStatsWindow / time.Second),
+	}
+}
+
+// mergeLaneStats 合并judge_queue的通道统计
+func mergeLaneStats(item *serviceInterface.JudgeLaneStatus, stat *model.JudgeLaneStats) {
+	// 计算加权平均排队时长（未知通道归入practice时可能有多条统计）
+	if total := item.Started + stat.Started; total > 0 {
+		item.AvgWaitMS = (item.AvgWaitMS*item.Started + stat.AvgWaitMS*stat.Started) / total
+	}
+	item.Pending += stat.Pending
+	item.Processing += stat.Processing
+	item.Started += stat.Started
+	if stat.MaxWaitMS > item.MaxWaitMS {
+		item.MaxWaitMS = stat.MaxWaitMS
+	}
+}
@@ -0,0 +1,329 @@
+package impl
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net/mail"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/logger"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	repoInterface "zhku-oj/internal/repository/interfaces"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxImportRows         = 2000  // 单次导入的最大行数
+	maxExportUsers        = 50000 // 单次导出的最大用户数
+	exportPageSize        = 500
+	initialPasswordLength = 10 // 初始密码长度
+)
+
+// importColumns 表头名称(中英文均可)到字段的映射，导出文件的表头也能直接导入
+var importColumns = map[string]string{
+	"student_id": "student_id", "学号": "student_id",
+	"real_name": "real_name", "姓名": "real_name",
+	"class": "class", "班级": "class",
+	"grade": "grade", "年级": "grade",
+	"username": "username", "用户名": "username",
+	"email": "email", "邮箱": "email",
+}
+
+// ImportUsers 批量导入用户
+func (s *userService) ImportUsers(ctx context.Context, req *serviceInterface.ImportUsersRequest) (*serviceInterface.ImportUsersResult, error) {
+	rows, err := parseImportRecords(req.Records)
+	if err != nil {
+		return nil, err
+	}
+	role := req.Role
+	if role == "" {
+		role = model.RoleStudent
+	}
+	if err := s.checkRole(role, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	result := &serviceInterface.ImportUsersResult{DryRun: req.DryRun, Total: len(rows), Rows: rows}
+	if err := s.validateImportRows(ctx, rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			result.Invalid++
+		}
+	}
+	if req.DryRun {
+		return result, nil
+	}
+	if result.Invalid > 0 && !req.SkipInvalid {
+		return result, errors.New(errors.USER_IMPORT_INVALID, fmt.Sprintf("%d行数据有误", result.Invalid))
+	}
+
+	var pending []*serviceInterface.ImportUserRow
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			pending = append(pending, row)
+		}
+	}
+	hashes, err := hashInitialPasswords(pending, s.policy)
+	if err != nil {
+		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
+	}
+
+	for i, row := range pending {
+		user := &model.User{
+			StudentID: row.StudentID,
+			Username:  row.Username,
+			Password:  hashes[i],
+			Email:     row.Email,
+			RealName:  row.RealName,
+			Role:      role,
+			Class:     row.Class,
+			Grade:     row.Grade,
+			IsActive:  true,
+			// 导入的名单来自教务，学号已核实
+			StudentIDVerified: true,
+			// 初始密码随机生成并随导入结果下发，首次登录后必须修改
+			MustChangePassword: true,
+		}
+		// 校验之后被其他请求占用的学号等，记为该行的错误，不影响其他行
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if stdErrors.Is(err, repoInterface.ErrUsernameExists) ||
+				stdErrors.Is(err, repoInterface.ErrEmailExists) ||
+				stdErrors.Is(err, repoInterface.ErrStudentIDExists) {
+				row.Password = ""
+				row.Errors = append(row.Errors, err.Error())
+				result.Invalid++
+				continue
+			}
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		row.Created = true
+		result.Created++
+	}
+
+	logger.Info("批量导入用户", "role", role, "total", result.Total, "created", result.Created, "invalid", result.Invalid)
+	return result, nil
+}
+
+// parseImportRecords 按表头取出每一行，跳过空行
+func parseImportRecords(records [][]string) ([]*serviceInterface.ImportUserRow, error) {
+	if len(records) == 0 {
+		return nil, errors.New(errors.INVALID_PARAMS, "文件内容为空")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		field, ok := importColumns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[field]; dup {
+			return nil, errors.New(errors.INVALID_PARAMS, fmt.Sprintf("表头中%s重复", name))
+		}
+		columns[field] = i
+	}
+	for _, required := range [][2]string{{"student_id", "学号"}, {"real_name", "姓名"}} {
+		if _, ok := columns[required[0]]; !ok {
+			return nil, errors.New(errors.INVALID_PARAMS, fmt.Sprintf("表头缺少%s(%s)列", required[1], required[0]))
+		}
+	}
+
+	var rows []*serviceInterface.ImportUserRow
+	for i, record := range records[1:] {
+		get := func(field string) string {
+			if idx, ok := columns[field]; ok && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		row := &serviceInterface.ImportUserRow{
+			Line:      i + 2,
+			StudentID: get("student_id"),
+			RealName:  get("real_name"),
+			Class:     get("class"),
+			Grade:     get("grade"),
+			Username:  get("username"),
+			Email:     get("email"),
+		}
+		if row.StudentID == "" && row.RealName == "" && row.Class == "" && row.Grade == "" && row.Username == "" && row.Email == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New(errors.INVALID_PARAMS, "文件中没有数据行")
+	}
+	if len(rows) > maxImportRows {
+		return nil, errors.New(errors.INVALID_PARAMS, fmt.Sprintf("单次最多导入%d行", maxImportRows))
+	}
+	return rows, nil
+}
+
+// validateImportRows 逐行校验格式、文件内重复和已有用户冲突，错误记录在行中
+// 未填写用户名时使用学号，未填写邮箱时使用不可投递的占位地址(邮箱有唯一索引)
+func (s *userService) validateImportRows(ctx context.Context, rows []*serviceInterface.ImportUserRow) error {
+	studentIDs := make(map[string]int)
+	usernames := make(map[string]int)
+	emails := make(map[string]int)
+
+	for _, row := range rows {
+		fail := func(format string, args ...interface{}) {
+			row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+		}
+
+		if row.Username == "" {
+			row.Username = row.StudentID
+		}
+		placeholderEmail := row.Email == ""
+		if placeholderEmail && row.StudentID != "" {
+			row.Email = row.StudentID + "@import.invalid"
+		}
+
+		// 格式校验，与创建用户接口的要求一致
+		studentIDOK := row.StudentID != "" && utf8.RuneCountInString(row.StudentID) <= 32 && !strings.ContainsFunc(row.StudentID, unicode.IsSpace)
+		if !studentIDOK {
+			fail("学号不能为空、不能包含空白且不超过32个字符")
+		}
+		if row.RealName == "" {
+			fail("姓名不能为空")
+		}
+		usernameOK := true
+		if n := utf8.RuneCountInString(row.Username); n < 3 || n > 20 {
+			usernameOK = false
+			fail("用户名长度需为3-20个字符")
+		}
+		emailOK := row.Email != ""
+		if !placeholderEmail {
+			if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+				emailOK = false
+				fail("邮箱格式不正确")
+			}
+		}
+
+		// 文件内重复
+		for _, check := range []struct {
+			ok    bool
+			value string
+			seen  map[string]int
+			label string
+		}{
+			{studentIDOK, row.StudentID, studentIDs, "学号"},
+			{usernameOK, row.Username, usernames, "用户名"},
+			{emailOK, row.Email, emails, "邮箱"},
+		} {
+			if !check.ok {
+				continue
+			}
+			if line, dup := check.seen[check.value]; dup {
+				fail("%s与第%d行重复", check.label, line)
+				continue
+			}
+			check.seen[check.value] = row.Line
+		}
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		// 已有用户冲突
+		exists, err := s.userRepo.ExistsByStudentID(ctx, row.StudentID)
+		if err != nil {
+			return errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if exists {
+			fail("学号已存在")
+		}
+		exists, err = s.userRepo.ExistsByUsername(ctx, row.Username)
+		if err != nil {
+			return errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if exists {
+			fail("用户名已存在")
+		}
+		exists, err = s.userRepo.ExistsByEmail(ctx, row.Email)
+		if err != nil {
+			return errors.Wrap(errors.DATABASE_ERROR, err)
+		}
+		if exists {
+			fail("邮箱已存在")
+		}
+	}
+	return nil
+}
+
+// hashInitialPasswords 为每行生成符合密码策略的初始密码并并发计算bcrypt哈希，返回与rows顺序一致的哈希
+func hashInitialPasswords(rows []*serviceInterface.ImportUserRow, policy *pwdpolicy.Policy) ([]string, error) {
+	hashes := make([]string, len(rows))
+	for _, row := range rows {
+		password, err := policy.Generate(initialPasswordLength, row.Username, row.StudentID, row.Email)
+		if err != nil {
+			return nil, err
+		}
+		row.Password = password
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	next := make(chan int)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				hash, err := bcrypt.GenerateFromPassword([]byte(rows[i].Password), bcrypt.DefaultCost)
+				if err != nil {
+					mu.Lock()
+					firstErr = err
+					mu.Unlock()
+					continue
+				}
+				hashes[i] = string(hash)
+			}
+		}()
+	}
+	for i := range rows {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return hashes, nil
+}
+
+// ExportUsers 导出符合筛选条件的全部用户
+func (s *userService) ExportUsers(ctx context.Context, req *serviceInterface.UserListRequest) ([]*model.User, error) {
+	filter := *req
+	filter.PageSize = exportPageSize
+
+	var users []*model.User
+	for page := 1; ; page++ {
+		filter.Page = page
+		resp, err := s.ListUsers(ctx, &filter)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Total > maxExportUsers {
+			return nil, errors.New(errors.INVALID_PARAMS, fmt.Sprintf("单次最多导出%d个用户，请缩小筛选范围", maxExportUsers))
+		}
+		users = append(users, resp.Users...)
+		if len(resp.Users) < exportPageSize || int64(len(users)) >= resp.Total {
+			return users, nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	"zhku-oj/internal/rbac"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestUserService(t *testing.T, users *fakeUserRepo, policy *pwdpolicy.Policy) serviceInterface.UserService {
+	t.Helper()
+	authorizer, err := rbac.NewAuthorizer(config.RBACConfig{}, nil)
+	if err != nil {
+		t.Fatalf("创建权限映射失败: %v", err)
+	}
+	return NewUserService(users, nil, nil, nil, policy, authorizer)
+}
+
+func newTestPolicy(t *testing.T, cfg config.PasswordPolicyConfig) *pwdpolicy.Policy {
+	t.Helper()
+	policy, err := pwdpolicy.New(cfg)
+	if err != nil {
+		t.Fatalf("创建密码策略失败: %v", err)
+	}
+	return policy
+}
+
+// TestImportUsers 导入的账号使用符合密码策略的随机初始密码，首次登录后必须修改，学号视为已核实
+func TestImportUsers(t *testing.T) {
+	users := newFakeUserRepo(&model.User{StudentID: "2021001003", Username: "wangwu", Email: "wangwu@example.com"})
+	policy := newTestPolicy(t, config.PasswordPolicyConfig{MinLength: 12, MinClasses: 4, CheckPersonal: true})
+	service := newTestUserService(t, users, policy)
+	records := [][]string{
+		{"学号", "姓名", "班级", "邮箱"},
+		{"2021001001", "张三", "1班", "zhangsan@example.com"},
+		{"2021001002", "李四", "1班", ""},
+		{"2021001003", "王五", "2班", ""}, // 学号已存在
+	}
+
+	result, err := service.ImportUsers(context.Background(), &serviceInterface.ImportUsersRequest{Records: records, SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.Total != 3 || result.Created != 2 || result.Invalid != 1 {
+		t.Fatalf("导入结果 total=%d created=%d invalid=%d, 期望 3/2/1", result.Total, result.Created, result.Invalid)
+	}
+
+	for _, row := range result.Rows[:2] {
+		if !row.Created || len(row.Password) != 12 {
+			t.Fatalf("第%d行 = %+v, 期望已创建并返回12位初始密码", row.Line, row)
+		}
+		if err := policy.Validate(row.Password, row.Username, row.StudentID, row.Email); err != nil {
+			t.Errorf("初始密码%q不符合密码策略: %v", row.Password, err)
+		}
+		user, err := users.GetByStudentID(context.Background(), row.StudentID)
+		if err != nil {
+			t.Fatalf("查找导入的账号失败: %v", err)
+		}
+		if !user.MustChangePassword || !user.StudentIDVerified || !user.IsActive || user.Role != model.RoleStudent {
+			t.Errorf("导入的账号 must_change_password=%v student_id_verified=%v is_active=%v role=%s, 期望前三项为true且为学生",
+				user.MustChangePassword, user.StudentIDVerified, user.IsActive, user.Role)
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(row.Password)); err != nil {
+			t.Errorf("初始密码与保存的哈希不匹配: %v", err)
+		}
+		if user.Username != row.StudentID {
+			t.Errorf("用户名 = %s, 期望默认使用学号", user.Username)
+		}
+	}
+	if got, _ := users.GetByStudentID(context.Background(), "2021001002"); got.Email != "2021001002@import.invalid" {
+		t.Errorf("未填写邮箱时 = %s, 期望占位地址", got.Email)
+	}
+	if row := result.Rows[2]; row.Created || row.Password != "" || len(row.Errors) == 0 {
+		t.Errorf("学号已存在的行 = %+v, 期望未创建且记录错误", row)
+	}
+}
+
+// TestImportUsersDryRun 只校验时不创建账号也不生成密码
+func TestImportUsersDryRun(t *testing.T) {
+	users := newFakeUserRepo()
+	service := newTestUserService(t, users, newTestPolicy(t, config.PasswordPolicyConfig{}))
+	result, err := service.ImportUsers(context.Background(), &serviceInterface.ImportUsersRequest{
+		Records: [][]string{{"student_id", "real_name"}, {"2021001001", "张三"}},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if result.Created != 0 || len(users.users) != 0 || result.Rows[0].Password != "" {
+		t.Errorf("只校验时创建了%d个账号, 初始密码=%q", len(users.users), result.Rows[0].Password)
+	}
+}
@@ -2,12 +2,16 @@ package impl
 
 import (
 	"context"
+	stdErrors "errors"
 	"fmt"
 	"math"
 	"zhku-oj/internal/model"
 	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/pkg/pwdpolicy"
+	"zhku-oj/internal/rbac"
 	repoInterface "zhku-oj/internal/repository/interfaces"
 	serviceInterface "zhku-oj/internal/service/interfaces"
+	"zhku-oj/internal/session"
 
 	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,17 +21,37 @@ import (
 // userService 用户服务实现 (类似Spring的@Service实现类)
 type userService struct {
 	userRepo    repoInterface.UserRepository
+	courseRepo  repoInterface.CourseRepository
 	redisClient *redis.Client
+	sessions    *session.Store
+	policy      *pwdpolicy.Policy
+	authorizer  *rbac.Authorizer
 }
 
 // NewUserService 创建用户服务实例 (类似Spring的@Autowired构造函数)
-func NewUserService(userRepo repoInterface.UserRepository, redisClient *redis.Client) serviceInterface.UserService {
+func NewUserService(userRepo repoInterface.UserRepository, courseRepo repoInterface.CourseRepository, redisClient *redis.Client, sessions *session.Store, policy *pwdpolicy.Policy, authorizer *rbac.Authorizer) serviceInterface.UserService {
 	return &userService{
 		userRepo:    userRepo,
+		courseRepo:  courseRepo,
 		redisClient: redisClient,
+		sessions:    sessions,
+		policy:      policy,
+		authorizer:  authorizer,
 	}
 }
 
+// checkRole 创建或导入用户时指定的角色：学生以外的角色相当于修改用户角色，需要role:manage权限；
+// 角色必须是内置、配置文件或数据库中定义的角色
+func (s *userService) checkRole(role string, perms rbac.Set) error {
+	if role != model.RoleStudent && !perms.Has(rbac.RoleManage) {
+		return errors.New(errors.FORBIDDEN, "指定学生以外的角色需要role:manage权限")
+	}
+	if _, ok := s.authorizer.Role(role); !ok {
+		return errors.New(errors.ROLE_NOT_FOUND, role)
+	}
+	return nil
+}
+
 // CreateUser 创建用户 (类似Spring的@Transactional方法)
 func (s *userService) CreateUser(ctx context.Context, req *serviceInterface.CreateUserRequest) (*model.User, error) {
 	// 1. 验证唯一性约束 (类似Spring的@Valid + 自定义验证)
@@ -55,7 +79,18 @@ func (s *userService) CreateUser(ctx context.Context, req *serviceInterface.Crea
 		return nil, fmt.Errorf("学号已存在")
 	}
 
-	// 2. 密码加密 (类似Spring Security的PasswordEncoder)
+	role := req.Role
+	if role == "" {
+		role = model.RoleStudent
+	}
+	if err := s.checkRole(role, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	// 2. 校验密码强度并加密 (类似Spring Security的PasswordEncoder)
+	if err := s.policy.Validate(req.Password, req.Username, req.StudentID, req.Email); err != nil {
+		return nil, errors.New(errors.PASSWORD_TOO_WEAK, err.Error())
+	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, errors.Wrap(errors.SYSTEM_ERROR, err)
@@ -68,10 +103,12 @@ func (s *userService) CreateUser(ctx context.Context, req *serviceInterface.Crea
 		Password:  string(hashedPassword),
 		Email:     req.Email,
 		RealName:  req.RealName,
-		Role:      req.Role,
+		Role:      role,
 		Class:     req.Class,
 		Grade:     req.Grade,
 		IsActive:  true,
+		// 管理员创建的账号学号已核实
+		StudentIDVerified: true,
 		Stats: model.UserStats{
 			TotalSubmissions: 0,
 			AcceptedCount:    0,
@@ -81,7 +118,13 @@ func (s *userService) CreateUser(ctx context.Context, req *serviceInterface.Crea
 	}
 
 	// 4. 保存到数据库 (类似Spring的@Transactional)
+	// 并发注册时唯一索引冲突，与上面的唯一性检查返回相同的错误
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		if stdErrors.Is(err, repoInterface.ErrUsernameExists) ||
+			stdErrors.Is(err, repoInterface.ErrEmailExists) ||
+			stdErrors.Is(err, repoInterface.ErrStudentIDExists) {
+			return nil, err
+		}
 		return nil, errors.Wrap(errors.DATABASE_ERROR, err)
 	}
 
@@ -93,9 +136,6 @@ func (s *userService) CreateUser(ctx context.Context, req *serviceInterface.Crea
 
 // GetUserByID 根据ID获取用户
 func (s *userService) GetUserByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
-	// 先尝试从Redis缓存获取 (类似Spring Cache)
-	cacheKey := fmt.Sprintf("user:%s", id.Hex())
-
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -157,9 +197,6 @@ func (s *userService) UpdateUser(ctx context.Context, id primitive.ObjectID, req
 	if req.RealName != "" {
 		user.RealName = req.RealName
 	}
-	if req.Role != "" {
-		user.Role = req.Role
-	}
 	if req.Class != "" {
 		user.Class = req.Class
 	}
@@ -172,6 +209,9 @@ func (s *userService) UpdateUser(ctx context.Context, id primitive.ObjectID, req
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
+	if req.StudentIDVerified != nil {
+		user.StudentIDVerified = *req.StudentIDVerified
+	}
 
 	// 4. 保存更新
 	if err := s.userRepo.Update(ctx, user); err != nil {
@@ -182,6 +222,13 @@ func (s *userService) UpdateUser(ctx context.Context, id primitive.ObjectID, req
 	cacheKey := fmt.Sprintf("user:%s", id.Hex())
 	s.redisClient.Del(ctx, cacheKey)
 
+	// 6. 停用后立即注销全部登录会话
+	if !user.IsActive {
+		if _, err := s.sessions.RevokeAll(ctx, id.Hex(), ""); err != nil {
+			return nil, fmt.Errorf("注销用户会话失败: %w", err)
+		}
+	}
+
 	// 清除密码字段
 	user.Password = ""
 	return user, nil
@@ -200,14 +247,22 @@ func (s *userService) ChangePassword(ctx context.Context, userID primitive.Objec
 		return fmt.Errorf("旧密码不正确")
 	}
 
-	// 3. 加密新密码
+	// 3. 校验新密码强度，新密码不能与当前密码相同(管理员重置后的临时密码必须更换)
+	if err := s.policy.Validate(req.NewPassword, user.Username, user.StudentID, user.Email); err != nil {
+		return errors.New(errors.PASSWORD_TOO_WEAK, err.Error())
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.NewPassword)) == nil {
+		return errors.New(errors.PASSWORD_TOO_WEAK, "新密码不能与当前密码相同")
+	}
+
+	// 4. 加密新密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("密码加密失败: %w", err)
 	}
 
-	// 4. 更新密码
-	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+	// 5. 更新密码
+	if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword), false); err != nil {
 		return fmt.Errorf("更新密码失败: %w", err)
 	}
 
@@ -231,6 +286,11 @@ func (s *userService) DeleteUser(ctx context.Context, id primitive.ObjectID) err
 	cacheKey := fmt.Sprintf("user:%s", id.Hex())
 	s.redisClient.Del(ctx, cacheKey)
 
+	// 注销全部登录会话
+	if _, err := s.sessions.RevokeAll(ctx, id.Hex(), ""); err != nil {
+		return fmt.Errorf("注销用户会话失败: %w", err)
+	}
+
 	return nil
 }
 
@@ -241,8 +301,14 @@ func (s *userService) ListUsers(ctx context.Context, req *serviceInterface.UserL
 	if req.Role != "" {
 		filters["role"] = req.Role
 	}
+	// 班级匹配用户填写的班级，以及班级名称相同的课程的学生
 	if req.Class != "" {
+		members, err := s.courseRepo.StudentIDsByClass(ctx, req.Class)
+		if err != nil {
+			return nil, errors.Wrap(errors.DATABASE_ERROR, err)
+		}
 		filters["class"] = req.Class
+		filters["class_members"] = members
 	}
 	if req.Grade != "" {
 		filters["grade"] = req.Grade
@@ -305,7 +371,7 @@ func (s *userService) GetUserStats(ctx context.Context, userID primitive.ObjectI
 }
 
 // ValidateUser 验证用户存在性和权限
-func (s *userService) ValidateUser(ctx context.Context, userID primitive.ObjectID, requiredRole string) (*model.User, error) {
+func (s *userService) ValidateUser(ctx context.Context, userID primitive.ObjectID, permission string) (*model.User, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -315,20 +381,18 @@ func (s *userService) ValidateUser(ctx context.Context, userID primitive.ObjectI
 		return nil, fmt.Errorf("用户已被停用")
 	}
 
-	if requiredRole != "" {
-		switch requiredRole {
-		case model.RoleAdmin:
-			if user.Role != model.RoleAdmin {
-				return nil, fmt.Errorf("需要管理员权限")
-			}
-		case model.RoleTeacher:
-			if user.Role != model.RoleTeacher && user.Role != model.RoleAdmin {
-				return nil, fmt.Errorf("需要教师或管理员权限")
-			}
-		}
+	if permission != "" && !s.authorizer.Permissions(user.Role).Has(permission) {
+		return nil, errors.New(errors.FORBIDDEN, "缺少权限: "+permission)
 	}
 
 	// 清除密码字段
 	user.Password = ""
 	return user, nil
 }
+
+func userError(err error) error {
+	if stdErrors.Is(err, repoInterface.ErrUserNotFound) {
+		return errors.New(errors.USER_NOT_FOUND)
+	}
+	return errors.Wrap(errors.DATABASE_ERROR, err)
+}
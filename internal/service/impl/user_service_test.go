@@ -0,0 +1,85 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/pkg/errors"
+	"zhku-oj/internal/rbac"
+	serviceInterface "zhku-oj/internal/service/interfaces"
+)
+
+// TestCreateUserRole 创建和导入用户时指定学生以外的角色相当于修改角色，只有user:manage权限时不能创建管理员
+func TestCreateUserRole(t *testing.T) {
+	authorizer, err := rbac.NewAuthorizer(config.RBACConfig{Roles: map[string]config.RoleConfig{
+		"registrar": {DisplayName: "教务", Permissions: []string{rbac.UserManage}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("创建权限映射失败: %v", err)
+	}
+	registrar := authorizer.Permissions("registrar")
+	admin := authorizer.Permissions(model.RoleAdmin)
+
+	tests := []struct {
+		name     string
+		perms    rbac.Set
+		role     string
+		wantCode int
+		wantRole string
+	}{
+		{name: "default role", perms: registrar, wantRole: model.RoleStudent},
+		{name: "student", perms: registrar, role: model.RoleStudent, wantRole: model.RoleStudent},
+		{name: "admin without role manage", perms: registrar, role: model.RoleAdmin, wantCode: errors.FORBIDDEN},
+		{name: "teacher without role manage", perms: registrar, role: model.RoleTeacher, wantCode: errors.FORBIDDEN},
+		{name: "admin with role manage", perms: admin, role: model.RoleAdmin, wantRole: model.RoleAdmin},
+		{name: "unknown role", perms: admin, role: "grader", wantCode: errors.ROLE_NOT_FOUND},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("create", func(t *testing.T) {
+				users := newFakeUserRepo()
+				service := NewUserService(users, nil, nil, nil, newTestPolicy(t, config.PasswordPolicyConfig{}), authorizer)
+				user, err := service.CreateUser(context.Background(), &serviceInterface.CreateUserRequest{
+					StudentID: "2021001001", Username: "zhangsan", Password: "Spring-Rain-42", Email: "zhangsan@example.com",
+					RealName: "张三", Role: tt.role, Permissions: tt.perms,
+				})
+				if code := errorCode(err); code != tt.wantCode {
+					t.Fatalf("创建用户错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+				}
+				if tt.wantCode != errors.SUCCESS {
+					if len(users.users) != 0 {
+						t.Error("创建失败时不应保存用户")
+					}
+					return
+				}
+				if user.Role != tt.wantRole {
+					t.Errorf("用户角色 = %s, 期望 %s", user.Role, tt.wantRole)
+				}
+			})
+
+			t.Run("import", func(t *testing.T) {
+				users := newFakeUserRepo()
+				service := NewUserService(users, nil, nil, nil, newTestPolicy(t, config.PasswordPolicyConfig{}), authorizer)
+				_, err := service.ImportUsers(context.Background(), &serviceInterface.ImportUsersRequest{
+					Records:     [][]string{{"学号", "姓名"}, {"2021001001", "张三"}},
+					Role:        tt.role,
+					Permissions: tt.perms,
+				})
+				if code := errorCode(err); code != tt.wantCode {
+					t.Fatalf("导入用户错误码 = %d, 期望 %d (%v)", code, tt.wantCode, err)
+				}
+				for _, user := range users.users {
+					if user.Role != tt.wantRole {
+						t.Errorf("导入的用户角色 = %s, 期望 %s", user.Role, tt.wantRole)
+					}
+				}
+				if tt.wantCode != errors.SUCCESS && len(users.users) != 0 {
+					t.Error("导入失败时不应创建用户")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AssignmentProblemRequest 作业题目，points为0时按100分计
+type AssignmentProblemRequest struct {
+	ProblemID string `json:"problem_id" binding:"required"`
+	Points    int    `json:"points" binding:"min=0,max=1000"`
+}
+
+// CreateAssignmentRequest 创建作业请求
+type CreateAssignmentRequest struct {
+	Title       string                     `json:"title" binding:"required,max=100"`
+	Description string                     `json:"description" binding:"max=5000"`
+	Problems    []AssignmentProblemRequest `json:"problems" binding:"required,min=1,max=50,dive"`
+	OpenTime    time.Time                  `json:"open_time" binding:"required"`
+	DueTime     time.Time                  `json:"due_time" binding:"required"`
+	CloseTime   *time.Time                 `json:"close_time"` // 为空时等于截止时间，不接受迟交
+	LatePenalty []model.LatePenaltyStep    `json:"late_penalty"`
+	ScoringMode string                     `json:"scoring_mode" binding:"omitempty,oneof=best last"` // 默认best
+}
+
+// UpdateAssignmentRequest 更新作业请求，字段为空时不修改；late_penalty传空数组时取消迟交扣分
+type UpdateAssignmentRequest struct {
+	Title       string                     `json:"title" binding:"omitempty,max=100"`
+	Description *string                    `json:"description" binding:"omitempty,max=5000"`
+	Problems    []AssignmentProblemRequest `json:"problems" binding:"omitempty,min=1,max=50,dive"`
+	OpenTime    *time.Time                 `json:"open_time"`
+	DueTime     *time.Time                 `json:"due_time"`
+	CloseTime   *time.Time                 `json:"close_time"`
+	LatePenalty *[]model.LatePenaltyStep   `json:"late_penalty"`
+	ScoringMode string                     `json:"scoring_mode" binding:"omitempty,oneof=best last"`
+}
+
+// AssignmentListRequest 作业列表查询请求
+type AssignmentListRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ExtensionRequest 学生延期请求
+type ExtensionRequest struct {
+	UserID    string     `json:"user_id" binding:"required"`
+	DueTime   time.Time  `json:"due_time" binding:"required"`
+	CloseTime *time.Time `json:"close_time"` // 为空时按作业截止到关闭的时长顺延
+	Reason    string     `json:"reason" binding:"max=200"`
+}
+
+// AssignmentDetail 作业详情
+type AssignmentDetail struct {
+	*model.Assignment
+	Status    string        `json:"status"`             // 当前用户看到的状态，有延期时按延期计算
+	MaxPoints int           `json:"max_points"`         // 各题分值之和
+	MyGrade   *StudentGrade `json:"my_grade,omitempty"` // 课程学生查看作业详情时返回本人的成绩
+}
+
+// AssignmentListResponse 作业列表响应
+type AssignmentListResponse struct {
+	Assignments []*AssignmentDetail `json:"assignments"`
+	Total       int64               `json:"total"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"page_size"`
+}
+
+// ProblemGrade 学生一道作业题目的成绩
+type ProblemGrade struct {
+	ProblemID    primitive.ObjectID  `json:"problem_id"`
+	Points       float64             `json:"points"`                  // 扣除迟交后的得分
+	Penalty      int                 `json:"penalty"`                 // 计分提交迟交扣除的百分比
+	Attempts     int                 `json:"attempts"`                // 计分时间内已判题的提交数，不含编译错误和系统错误
+	Pending      int                 `json:"pending"`                 // 计分时间内尚未判题完成的提交数
+	SubmissionID *primitive.ObjectID `json:"submission_id,omitempty"` // 计分的提交
+	SubmittedAt  *time.Time          `json:"submitted_at,omitempty"`
+}
+
+// StudentGrade 学生的作业成绩
+type StudentGrade struct {
+	UserID    primitive.ObjectID `json:"user_id"`
+	StudentID string             `json:"student_id"`
+	Username  string             `json:"username"`
+	RealName  string             `json:"real_name"`
+	Class     string             `json:"class"`
+	DueTime   time.Time          `json:"due_time"` // 该学生的截止时间
+	Extended  bool               `json:"extended"` // 是否有延期
+	Problems  []ProblemGrade     `json:"problems"`
+	Total     float64            `json:"total"`
+}
+
+// GradebookProblem 成绩册中的题目
+type GradebookProblem struct {
+	ProblemID primitive.ObjectID `json:"problem_id"`
+	Title     string             `json:"title"`
+	Points    int                `json:"points"`
+}
+
+// Gradebook 作业成绩册，包含课程的全部学生，按学号排序
+type Gradebook struct {
+	AssignmentID primitive.ObjectID `json:"assignment_id"`
+	Title        string             `json:"title"`
+	ScoringMode  string             `json:"scoring_mode"`
+	MaxPoints    int                `json:"max_points"`
+	Problems     []GradebookProblem `json:"problems"`
+	Students     []*StudentGrade    `json:"students"`
+}
+
+// AssignmentService 作业业务服务接口
+// 作业属于课程：课程的教师拥有course:manage:own时可以管理作业，拥有course:manage:any时可以管理全部作业；
+// 学生只能看到已开放的作业和本人的成绩，课程的教师和助教可以查看成绩册
+type AssignmentService interface {
+	// CreateAssignment 创建作业，题目需已公开
+	CreateAssignment(ctx context.Context, courseID primitive.ObjectID, viewer Viewer, req *CreateAssignmentRequest) (*AssignmentDetail, error)
+
+	// UpdateAssignment 更新作业，已有的延期不变；需有课程管理权限
+	UpdateAssignment(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *UpdateAssignmentRequest) (*AssignmentDetail, error)
+
+	// DeleteAssignment 删除作业，需有课程管理权限
+	DeleteAssignment(ctx context.Context, id primitive.ObjectID, viewer Viewer) error
+
+	// GetAssignment 获取作业详情，课程成员和拥有course:manage:any的用户可见；学生在开放前不可见
+	GetAssignment(ctx context.Context, id primitive.ObjectID, viewer Viewer) (*AssignmentDetail, error)
+
+	// ListAssignments 分页查询课程的作业，学生只能看到已开放的作业
+	ListAssignments(ctx context.Context, courseID primitive.ObjectID, viewer Viewer, req *AssignmentListRequest) (*AssignmentListResponse, error)
+
+	// SetExtension 为课程学生设置延期，已有延期时替换；需有课程管理权限
+	SetExtension(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *ExtensionRequest) (*model.AssignmentExtension, error)
+
+	// RemoveExtension 取消学生的延期，需有课程管理权限
+	RemoveExtension(ctx context.Context, id primitive.ObjectID, viewer Viewer, userID primitive.ObjectID) error
+
+	// GetGradebook 计算课程全部学生的作业成绩，需是课程的教师、助教，或拥有course:manage:any
+	GetGradebook(ctx context.Context, id primitive.ObjectID, viewer Viewer) (*Gradebook, error)
+
+	// CheckSubmission 检查作业提交：用户需是课程成员，题目属于作业，且在作业开放时间到该用户的关闭时间之间
+	CheckSubmission(ctx context.Context, assignmentID, userID, problemID primitive.ObjectID) (*model.Assignment, error)
+}
@@ -0,0 +1,96 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegisterRequest 注册请求，注册的用户均为学生
+// 班级限制班级竞赛的报名，只能由管理员创建、修改或导入用户时设置，注册时不能填写
+type RegisterRequest struct {
+	StudentID string `json:"student_id" binding:"required"`
+	Username  string `json:"username" binding:"required,min=3,max=20"`
+	Password  string `json:"password" binding:"required,min=6"`
+	Email     string `json:"email" binding:"required,email"`
+	RealName  string `json:"real_name" binding:"required"`
+	Grade     string `json:"grade"`
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=64"` // 设备名，显示在会话列表中
+}
+
+// RefreshRequest 刷新token请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ClientInfo 请求来源，记录在登录会话中
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// TokenPair 登录或刷新后签发的token
+type TokenPair struct {
+	AccessToken      string      `json:"access_token"`
+	RefreshToken     string      `json:"refresh_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresIn        int64       `json:"expires_in"`         // 访问token有效期(秒)
+	RefreshExpiresIn int64       `json:"refresh_expires_in"` // 刷新token有效期(秒)
+	SessionID        string      `json:"session_id"`
+	User             *model.User `json:"user"`
+}
+
+// SessionInfo 登录会话(设备)信息
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Device       string    `json:"device"`
+	UserAgent    string    `json:"user_agent"`
+	LoginIP      string    `json:"login_ip"`
+	LastIP       string    `json:"last_ip"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Current      bool      `json:"current"` // 是否为发起请求的会话
+}
+
+// AuthService 认证与登录会话服务接口
+type AuthService interface {
+	// Register 注册学生账号
+	Register(ctx context.Context, req *RegisterRequest) (*model.User, error)
+
+	// Login 登录，创建会话并签发访问token和刷新token
+	Login(ctx context.Context, req *LoginRequest, client ClientInfo) (*TokenPair, error)
+
+	// IssueSession 为已通过认证的用户创建会话并签发token，用于统一身份认证等外部登录
+	IssueSession(ctx context.Context, user *model.User, deviceName string, client ClientInfo) (*TokenPair, error)
+
+	// Refresh 使用刷新token换取新的token，刷新token随之轮换
+	// 已轮换过的刷新token再次使用时视为泄露，注销整个会话
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+
+	// Logout 注销当前会话
+	Logout(ctx context.Context, userID primitive.ObjectID, sessionID string) error
+
+	// ListSessions 获取用户的登录会话，currentSessionID标记当前会话
+	ListSessions(ctx context.Context, userID primitive.ObjectID, currentSessionID string) ([]*SessionInfo, error)
+
+	// RevokeSession 注销指定会话
+	RevokeSession(ctx context.Context, userID primitive.ObjectID, sessionID string) error
+
+	// RevokeOtherSessions 注销当前会话以外的全部会话，返回注销数量
+	RevokeOtherSessions(ctx context.Context, userID primitive.ObjectID, currentSessionID string) (int, error)
+
+	// UnlockUser 解除用户因登录失败过多被锁定的状态，返回解除前是否处于锁定状态
+	UnlockUser(ctx context.Context, userID primitive.ObjectID) (bool, error)
+
+	// UnlockIP 解除IP因登录失败过多被锁定的状态，返回解除前是否处于锁定状态
+	UnlockIP(ctx context.Context, ip string) (bool, error)
+}
@@ -0,0 +1,94 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/rbac"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Viewer 当前请求的用户，用于按权限和所有权控制可见性
+type Viewer struct {
+	UserID      primitive.ObjectID
+	Role        string
+	Permissions rbac.Set
+}
+
+// CreateContestRequest 创建竞赛请求
+type CreateContestRequest struct {
+	Title       string                `json:"title" binding:"required,max=100"`
+	Description string                `json:"description"`
+	Type        string                `json:"type" binding:"required,oneof=public class"`
+	Classes     []string              `json:"classes"` // type为class时必填
+	ProblemIDs  []string              `json:"problem_ids"`
+	Settings    model.ContestSettings `json:"settings"`
+	StartTime   time.Time             `json:"start_time" binding:"required"`
+	EndTime     time.Time             `json:"end_time" binding:"required"`
+}
+
+// UpdateContestRequest 更新竞赛请求，字段为空时不修改
+type UpdateContestRequest struct {
+	Title       string                 `json:"title" binding:"omitempty,max=100"`
+	Description *string                `json:"description"`
+	Type        string                 `json:"type" binding:"omitempty,oneof=public class"`
+	Classes     []string               `json:"classes"`
+	ProblemIDs  []string               `json:"problem_ids"`
+	Settings    *model.ContestSettings `json:"settings"`
+	StartTime   *time.Time             `json:"start_time"`
+	EndTime     *time.Time             `json:"end_time"`
+}
+
+// ContestListRequest 竞赛列表查询请求
+type ContestListRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Type     string `form:"type" binding:"omitempty,oneof=public class"`
+	Status   string `form:"status" binding:"omitempty,oneof=upcoming running ended"`
+	Keyword  string `form:"keyword"`
+}
+
+// ContestDetail 竞赛详情，附带当前状态和当前用户的报名情况
+type ContestDetail struct {
+	*model.Contest
+	Status           string `json:"status"`
+	Registered       bool   `json:"registered"`
+	ParticipantCount int    `json:"participant_count"`
+}
+
+// ContestListResponse 竞赛列表响应
+type ContestListResponse struct {
+	Contests []*ContestDetail `json:"contests"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
+
+// ContestService 竞赛业务服务接口
+// 拥有contest:manage:any，或拥有contest:manage:own的竞赛创建者可以管理竞赛；竞赛开始前题目只对他们可见
+type ContestService interface {
+	// CreateContest 创建竞赛，creator需拥有contest:create权限
+	CreateContest(ctx context.Context, creator Viewer, req *CreateContestRequest) (*model.Contest, error)
+
+	// UpdateContest 更新竞赛，需有管理权限
+	UpdateContest(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *UpdateContestRequest) (*model.Contest, error)
+
+	// DeleteContest 删除竞赛，需有管理权限
+	DeleteContest(ctx context.Context, id primitive.ObjectID, viewer Viewer) error
+
+	// GetContest 获取竞赛详情，竞赛开始前对其他用户隐藏题目
+	GetContest(ctx context.Context, id primitive.ObjectID, viewer Viewer) (*ContestDetail, error)
+
+	// ListContests 分页查询竞赛列表，列表中不包含题目
+	ListContests(ctx context.Context, req *ContestListRequest) (*ContestListResponse, error)
+
+	// Register 报名竞赛，竞赛结束前可以报名；班级竞赛只允许指定班级的用户报名
+	Register(ctx context.Context, id primitive.ObjectID, viewer Viewer) error
+
+	// ListProblems 获取竞赛题目，竞赛开始后对报名用户可见，不包含非公开测试用例
+	ListProblems(ctx context.Context, id primitive.ObjectID, viewer Viewer) ([]*model.Problem, error)
+
+	// CheckSubmission 检查用户能否在竞赛中提交该题目：竞赛进行中、已报名、题目属于竞赛且未超过提交次数限制
+	CheckSubmission(ctx context.Context, contestID, userID, problemID primitive.ObjectID) (*model.Contest, error)
+}
@@ -0,0 +1,162 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"zhku-oj/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateCourseRequest 创建课程请求，创建者作为课程的教师
+type CreateCourseRequest struct {
+	Name        string    `json:"name" binding:"required,max=100"`
+	ClassName   string    `json:"class_name" binding:"max=50"`
+	Term        string    `json:"term" binding:"required,max=32"`
+	Description string    `json:"description" binding:"max=2000"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+	EndDate     time.Time `json:"end_date" binding:"required"`
+	JoinEnabled bool      `json:"join_enabled"` // 生成邀请码，学生可以自行加入
+	EnrollClass bool      `json:"enroll_class"` // 同时加入班级字段为class_name的全部学生
+}
+
+// UpdateCourseRequest 更新课程请求，字段为空时不修改
+type UpdateCourseRequest struct {
+	Name        string     `json:"name" binding:"omitempty,max=100"`
+	ClassName   *string    `json:"class_name" binding:"omitempty,max=50"`
+	Term        string     `json:"term" binding:"omitempty,max=32"`
+	Description *string    `json:"description" binding:"omitempty,max=2000"`
+	StartDate   *time.Time `json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+}
+
+// CourseListRequest 课程列表查询请求
+type CourseListRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Term     string `form:"term"`
+	Keyword  string `form:"keyword"`
+	All      bool   `form:"all"` // 查询全部课程，需要course:manage:any；否则只查询自己所在的课程
+}
+
+// CourseDetail 课程详情，附带学生人数和当前用户在课程中的身份
+// 邀请码只对课程的教师、助教和可管理全部课程的用户可见
+type CourseDetail struct {
+	*model.Course
+	StudentCount int    `json:"student_count"`
+	MyRole       string `json:"my_role,omitempty"` // model.CourseRole*，不是成员时为空
+}
+
+// CourseListResponse 课程列表响应
+type CourseListResponse struct {
+	Courses  []*CourseDetail `json:"courses"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// JoinCourseRequest 通过邀请码加入课程请求
+type JoinCourseRequest struct {
+	JoinCode string `json:"join_code" binding:"required"`
+}
+
+// JoinCodeRequest 设置邀请码请求，enabled为true时生成新的邀请码(原邀请码失效)，为false时停用
+type JoinCodeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// AddCourseMembersRequest 添加课程成员请求，按学号(教师为工号)添加
+type AddCourseMembersRequest struct {
+	Role       string   `json:"role" binding:"required,oneof=student ta teacher"`
+	StudentIDs []string `json:"student_ids" binding:"required,min=1,max=500"`
+}
+
+// AddCourseMembersResult 添加课程成员结果
+type AddCourseMembersResult struct {
+	Added    int      `json:"added"`
+	NotFound []string `json:"not_found"` // 不存在的学号
+}
+
+// CourseMember 课程成员
+type CourseMember struct {
+	*model.User
+	CourseRole string `json:"course_role"`
+}
+
+// CourseSubmissionListRequest 课程学生提交列表查询请求
+type CourseSubmissionListRequest struct {
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	UserID    string `form:"user_id"`
+	ProblemID string `form:"problem_id"`
+	Status    string `form:"status"`
+	Language  string `form:"language"`
+}
+
+// CourseRankItem 课程排名中的一名学生
+type CourseRankItem struct {
+	Rank             int                `json:"rank"`
+	UserID           primitive.ObjectID `json:"user_id"`
+	StudentID        string             `json:"student_id"`
+	Username         string             `json:"username"`
+	RealName         string             `json:"real_name"`
+	ProblemsSolved   int                `json:"problems_solved"`
+	AcceptedCount    int                `json:"accepted_count"`
+	TotalSubmissions int                `json:"total_submissions"`
+}
+
+// CourseStats 课程统计
+type CourseStats struct {
+	StudentCount   int     `json:"student_count"`
+	TeacherCount   int     `json:"teacher_count"`
+	TACount        int     `json:"ta_count"`
+	ActiveStudents int     `json:"active_students"` // 有过提交的学生数
+	AverageSolved  float64 `json:"average_solved"`  // 学生平均解题数
+	// 课程起止日期内学生的提交
+	TermSubmissions int64 `json:"term_submissions"`
+	TermAccepted    int64 `json:"term_accepted"`
+}
+
+// CourseService 课程业务服务接口
+// 课程的教师拥有course:manage:own时可以管理课程，拥有course:manage:any时可以管理全部课程；
+// 教师和助教可以查看课程的成员、学生提交、排名和统计
+type CourseService interface {
+	// CreateCourse 创建课程，创建者作为课程的教师
+	CreateCourse(ctx context.Context, creator Viewer, req *CreateCourseRequest) (*CourseDetail, error)
+
+	// UpdateCourse 更新课程信息，需有管理权限
+	UpdateCourse(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *UpdateCourseRequest) (*CourseDetail, error)
+
+	// DeleteCourse 删除课程，需有管理权限
+	DeleteCourse(ctx context.Context, id primitive.ObjectID, viewer Viewer) error
+
+	// GetCourse 获取课程详情，课程成员和可管理全部课程的用户可见
+	GetCourse(ctx context.Context, id primitive.ObjectID, viewer Viewer) (*CourseDetail, error)
+
+	// ListCourses 分页查询当前用户所在的课程，或全部课程
+	ListCourses(ctx context.Context, viewer Viewer, req *CourseListRequest) (*CourseListResponse, error)
+
+	// JoinCourse 通过邀请码以学生身份加入课程，结课后不能加入
+	JoinCourse(ctx context.Context, viewer Viewer, joinCode string) (*CourseDetail, error)
+
+	// SetJoinCode 生成新的邀请码或停用邀请码，需有管理权限
+	SetJoinCode(ctx context.Context, id primitive.ObjectID, viewer Viewer, enabled bool) (*CourseDetail, error)
+
+	// ListMembers 获取课程成员，role为空时返回全部成员；需是课程的教师、助教，或拥有course:manage:any
+	ListMembers(ctx context.Context, id primitive.ObjectID, viewer Viewer, role string) ([]*CourseMember, error)
+
+	// AddMembers 按学号添加课程成员，已是成员的用户改为新身份；需有管理权限
+	AddMembers(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *AddCourseMembersRequest) (*AddCourseMembersResult, error)
+
+	// RemoveMember 将用户移出课程，课程至少保留一名教师；需有管理权限
+	RemoveMember(ctx context.Context, id primitive.ObjectID, viewer Viewer, userID primitive.ObjectID) error
+
+	// ListSubmissions 分页查询课程学生的提交，需是课程的教师或助教且拥有submission:view:class，或拥有submission:view:any
+	ListSubmissions(ctx context.Context, id primitive.ObjectID, viewer Viewer, req *CourseSubmissionListRequest) ([]*model.Submission, int64, error)
+
+	// GetRanking 课程学生按解题数排名，需是课程的教师、助教，或拥有course:manage:any
+	GetRanking(ctx context.Context, id primitive.ObjectID, viewer Viewer) ([]*CourseRankItem, error)
+
+	// GetStats 课程统计，需是课程的教师、助教，或拥有course:manage:any
+	GetStats(ctx context.Context, id primitive.ObjectID, viewer Viewer) (*CourseStats, error)
+}
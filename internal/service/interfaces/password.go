@@ -0,0 +1,41 @@
+package interfaces
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ForgotPasswordRequest 找回密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 通过邮件中的链接重置密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// AdminResetPasswordRequest 管理员重置用户密码
+type AdminResetPasswordRequest struct {
+	Password string `json:"password" binding:"omitempty,min=6"` // 为空时随机生成
+}
+
+// AdminResetPasswordResult 管理员重置密码结果
+type AdminResetPasswordResult struct {
+	Password        string `json:"password"`         // 临时密码，用户登录后必须修改
+	RevokedSessions int    `json:"revoked_sessions"` // 注销的登录会话数
+}
+
+// PasswordService 密码重置服务接口
+type PasswordService interface {
+	// ForgotPassword 向邮箱发送重置链接；邮箱未注册、账号停用或发送过于频繁时同样返回成功，不暴露账号是否存在
+	ForgotPassword(ctx context.Context, req *ForgotPasswordRequest, client ClientInfo) error
+
+	// ResetPassword 使用重置链接中的token设置新密码，token只能使用一次，成功后注销全部登录会话
+	ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+
+	// AdminResetPassword 管理员重置密码，用户下次登录后必须修改密码，全部登录会话随即注销
+	AdminResetPassword(ctx context.Context, userID primitive.ObjectID, req *AdminResetPasswordRequest) (*AdminResetPasswordResult, error)
+}
@@ -0,0 +1,64 @@
+package interfaces
+
+import (
+	"context"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/rbac"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"` // 小写字母开头，只能包含小写字母、数字、下划线和连字符
+	DisplayName string   `json:"display_name" binding:"max=32"`
+	Description string   `json:"description" binding:"max=200"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest 修改角色请求，display_name和description为空时不修改
+type UpdateRoleRequest struct {
+	DisplayName string   `json:"display_name" binding:"max=32"`
+	Description string   `json:"description" binding:"max=200"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// AssignRoleRequest 修改用户角色请求
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleInfo 角色及其用户数
+type RoleInfo struct {
+	*model.Role
+	UserCount int64 `json:"user_count"`
+}
+
+// AssignRoleResult 修改用户角色结果
+type AssignRoleResult struct {
+	User            *model.User `json:"user"`
+	RevokedSessions int         `json:"revoked_sessions"` // 注销的登录会话数，用户重新登录后新角色生效
+}
+
+// RoleService 角色管理服务接口
+// 修改后立即在本实例生效，其他实例在rbac.reload_interval内重新加载
+type RoleService interface {
+	// ListPermissions 全部权限及说明
+	ListPermissions() []rbac.Permission
+
+	// ListRoles 全部角色及其权限和用户数
+	ListRoles(ctx context.Context) ([]*RoleInfo, error)
+
+	// CreateRole 创建自定义角色
+	CreateRole(ctx context.Context, req *CreateRoleRequest) (*model.Role, error)
+
+	// UpdateRole 修改角色，内置角色修改后保存在数据库中覆盖默认权限；admin不可修改
+	UpdateRole(ctx context.Context, name string, req *UpdateRoleRequest) (*model.Role, error)
+
+	// DeleteRole 删除自定义角色，仍有用户属于该角色时不能删除；
+	// 内置角色和配置文件中的角色删除的是数据库中的修改，恢复默认权限
+	DeleteRole(ctx context.Context, name string) error
+
+	// AssignRole 修改用户角色并注销其全部登录会话，不能修改自己的角色
+	AssignRole(ctx context.Context, operatorID, userID primitive.ObjectID, role string) (*AssignRoleResult, error)
+}
@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"context"
+	"zhku-oj/internal/queue"
+	"zhku-oj/internal/scoreboard"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ResolveStep 滚榜的一步：揭晓一个用户在一道题上封榜后的提交结果
+type ResolveStep struct {
+	UserID       string                    `json:"user_id,omitempty"`
+	ProblemID    string                    `json:"problem_id,omitempty"`
+	Result       *scoreboard.ProblemResult `json:"result,omitempty"`
+	PreviousRank int                       `json:"previous_rank,omitempty"` // 揭晓前名次
+	Rank         int                       `json:"rank,omitempty"`          // 揭晓后名次
+	Done         bool                      `json:"done"`                    // 是否已全部揭晓
+	Standings    *scoreboard.Standings     `json:"standings"`               // 揭晓后的排行榜
+}
+
+// ScoreboardService 竞赛排行榜服务接口
+// 排行榜缓存在Redis中，判题结果到达时按用户和题目增量更新，缓存不存在时从提交记录重建；
+// 封榜后竞赛创建者和管理员以外的用户看到封榜视图，直到滚榜全部揭晓
+type ScoreboardService interface {
+	// GetScoreboard 获取竞赛排行榜，frozenView为true时创建者和管理员也获取封榜视图
+	GetScoreboard(ctx context.Context, contestID primitive.ObjectID, viewer Viewer, frozenView bool) (*scoreboard.Standings, error)
+
+	// ApplyResult 判题结果到达时更新竞赛排行榜，非竞赛提交直接返回
+	ApplyResult(ctx context.Context, update *queue.StatsUpdate) error
+
+	// ResolveStep 滚榜揭晓下一个结果，竞赛结束后由创建者和管理员调用
+	// 每次从排名最后的用户开始揭晓其题号最小的封榜题目
+	ResolveStep(ctx context.Context, contestID primitive.ObjectID, viewer Viewer) (*ResolveStep, error)
+
+	// Unfreeze 揭晓全部封榜结果，竞赛结束后由创建者和管理员调用
+	Unfreeze(ctx context.Context, contestID primitive.ObjectID, viewer Viewer) (*scoreboard.Standings, error)
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"net/url"
+)
+
+// SSOProviderInfo 统一身份认证入口
+type SSOProviderInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // oidc, cas
+	DisplayName string `json:"display_name"`
+}
+
+// SSOResult 统一身份认证登录结果
+type SSOResult struct {
+	Tokens   *TokenPair `json:"tokens"`
+	Created  bool       `json:"created"`            // 本次登录自动创建了账号
+	Redirect string     `json:"redirect,omitempty"` // 发起登录时指定的前端地址
+}
+
+// SSOService 统一身份认证服务接口
+type SSOService interface {
+	// Providers 已配置的统一身份认证
+	Providers() []*SSOProviderInfo
+
+	// LoginURL 发起登录，返回跳转到身份提供方的地址；redirect为登录完成后跳转的前端地址，可为空
+	LoginURL(ctx context.Context, provider, redirect string) (string, error)
+
+	// Callback 处理身份提供方回调，按学号匹配用户(必要时自动创建)并签发本系统的token
+	// 登录状态有效但登录失败时同时返回带Redirect的结果和错误，以便把错误带回前端
+	Callback(ctx context.Context, provider string, params url.Values, client ClientInfo) (*SSOResult, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"zhku-oj/internal/queue"
+)
+
+// StatsService 用户和题目统计服务接口
+// 由worker服务消费判题完成后发布的统计更新，统计值按提交记录重新计算，重复投递不会重复计数
+type StatsService interface {
+	// UpdateStats 重新计算提交用户和题目的统计信息
+	UpdateStats(ctx context.Context, update *queue.StatsUpdate) error
+
+	// ProcessNotification 处理用户通知
+	ProcessNotification(ctx context.Context, notification *queue.Notification) error
+}
@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/rbac"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SubmitRequest 代码提交请求
+type SubmitRequest struct {
+	UserID       primitive.ObjectID
+	Permissions  rbac.Set
+	ProblemID    primitive.ObjectID
+	ContestID    *primitive.ObjectID // 竞赛提交时不为空
+	AssignmentID *primitive.ObjectID // 作业提交时不为空，不能与ContestID同时指定
+	Code         string
+	Language     string
+}
+
+// SubmissionService 代码提交服务接口
+type SubmissionService interface {
+	// Submit 创建提交记录并发布判题任务
+	// 竞赛提交需满足竞赛的时间和报名限制；非竞赛提交只能提交公开题目(拥有problem:view:hidden权限除外)；
+	// 作业提交需是课程成员在作业开放到本人关闭时间之间提交作业的题目，进入作业通道判题
+	Submit(ctx context.Context, req *SubmitRequest) (*model.Submission, error)
+
+	// Rejudge 重新判题，需有submission:rejudge权限(由路由检查)
+	// 提交回到PENDING，判题任务进入重判通道；正在判题的提交返回SUBMISSION_JUDGING
+	Rejudge(ctx context.Context, submissionID primitive.ObjectID) (*model.Submission, error)
+
+	// GetSubmission 获取提交详情；可以查看自己的提交，拥有submission:view:any时可以查看任意提交，
+	// 拥有submission:view:class时可以查看所教课程(作为教师或助教)学生的提交
+	GetSubmission(ctx context.Context, submissionID primitive.ObjectID, viewer Viewer) (*model.Submission, error)
+
+	// ListSubmissions 分页查询提交记录，filter同SubmissionRepository.List
+	ListSubmissions(ctx context.Context, filter map[string]interface{}, page, pageSize int) ([]*model.Submission, int64, error)
+}
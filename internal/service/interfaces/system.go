@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// 组件健康状态
+const (
+	ComponentHealthy     = "healthy"
+	ComponentUnavailable = "unavailable"
+)
+
+// JudgeLaneStatus 判题通道状态
+type JudgeLaneStatus struct {
+	Lane       string `json:"lane"`
+	Weight     int    `json:"weight"`      // 调度权重
+	Depth      int64  `json:"depth"`       // 队列中等待判题的任务数
+	Pending    int64  `json:"pending"`     // 等待重试的任务数
+	Processing int64  `json:"processing"`  // 判题中的任务数
+	Started    int64  `json:"started"`     // 统计窗口内开始判题的任务数
+	AvgWaitMS  int64  `json:"avg_wait_ms"` // 统计窗口内平均排队时长
+	MaxWaitMS  int64  `json:"max_wait_ms"` // 统计窗口内最长排队时长
+}
+
+// JudgeQueueStatus 判题队列状态
+type JudgeQueueStatus struct {
+	Lanes        []*JudgeLaneStatus `json:"lanes"`
+	WindowSecond int                `json:"window_second"` // 排队时长统计窗口
+}
+
+// SystemStatus 系统状态
+type SystemStatus struct {
+	Components map[string]string `json:"components"` // 组件名 -> healthy/unavailable
+	JudgeQueue *JudgeQueueStatus `json:"judge_queue"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+// SystemService 系统监控服务接口
+type SystemService interface {
+	// GetSystemStatus 获取系统状态，单个组件不可用时不影响其他组件的状态
+	GetSystemStatus(ctx context.Context) (*SystemStatus, error)
+}
@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 	"zhku-oj/internal/model"
+	"zhku-oj/internal/rbac"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -14,9 +15,12 @@ type CreateUserRequest struct {
 	Password  string `json:"password" binding:"required,min=6"`
 	Email     string `json:"email" binding:"required,email"`
 	RealName  string `json:"real_name" binding:"required"`
-	Role      string `json:"role" binding:"required,oneof=student teacher admin"`
+	Role      string `json:"role" binding:"omitempty,max=32"` // 内置或自定义角色，默认student；其他角色需要role:manage权限
 	Class     string `json:"class"`
 	Grade     string `json:"grade"`
+
+	// Permissions 操作者的权限
+	Permissions rbac.Set `json:"-"`
 }
 
 // UpdateUserRequest 更新用户请求 (类似Spring的DTO)
@@ -24,11 +28,13 @@ type UpdateUserRequest struct {
 	Username string `json:"username" binding:"omitempty,min=3,max=20"`
 	Email    string `json:"email" binding:"omitempty,email"`
 	RealName string `json:"real_name" binding:"omitempty"`
-	Role     string `json:"role" binding:"omitempty,oneof=student teacher admin"`
 	Class    string `json:"class"`
 	Grade    string `json:"grade"`
 	Avatar   string `json:"avatar"`
 	IsActive *bool  `json:"is_active"`
+
+	// StudentIDVerified 管理员核实学号后置为true，统一身份认证才会登录该账号
+	StudentIDVerified *bool `json:"student_id_verified"`
 }
 
 // ChangePasswordRequest 修改密码请求
@@ -57,6 +63,38 @@ type UserListResponse struct {
 	TotalPages int           `json:"total_pages"`
 }
 
+// ImportUsersRequest 批量导入用户请求
+type ImportUsersRequest struct {
+	Records     [][]string `form:"-"`                               // 表格内容，第一行为表头
+	Role        string     `form:"role" binding:"omitempty,max=32"` // 导入用户的角色，默认student；其他角色需要role:manage权限
+	DryRun      bool       `form:"dry_run"`                         // 只校验不导入
+	SkipInvalid bool       `form:"skip_invalid"`                    // 跳过有错误的行；否则有任何错误时不导入
+	Permissions rbac.Set   `form:"-"`                               // 操作者的权限
+}
+
+// ImportUserRow 导入文件中的一行及其校验结果
+type ImportUserRow struct {
+	Line      int      `json:"line"` // 文件中的行号，表头为第1行
+	StudentID string   `json:"student_id"`
+	RealName  string   `json:"real_name"`
+	Class     string   `json:"class"`
+	Grade     string   `json:"grade"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	Password  string   `json:"password,omitempty"` // 初始密码，只在导入成功后返回
+	Created   bool     `json:"created"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ImportUsersResult 批量导入结果
+type ImportUsersResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`   // 数据行数
+	Invalid int              `json:"invalid"` // 有错误的行数
+	Created int              `json:"created"` // 实际创建的用户数
+	Rows    []*ImportUserRow `json:"rows"`
+}
+
 // UserService 用户业务服务接口 (类似Spring的@Service接口)
 type UserService interface {
 	// CreateUser 创建用户 (类似Spring的@Transactional方法)
@@ -78,8 +116,16 @@ type UserService interface {
 	DeleteUser(ctx context.Context, id primitive.ObjectID) error
 
 	// ListUsers 分页查询用户列表 (类似Spring的Page<User> findAll())
+	// 按班级筛选时包含填写了该班级的用户和班级名称相同的课程的学生
 	ListUsers(ctx context.Context, req *UserListRequest) (*UserListResponse, error)
 
+	// ImportUsers 批量导入用户，为每个用户生成初始密码
+	// 有错误的行且未设置SkipInvalid时不导入，同时返回校验结果和错误
+	ImportUsers(ctx context.Context, req *ImportUsersRequest) (*ImportUsersResult, error)
+
+	// ExportUsers 导出符合筛选条件的全部用户，忽略分页参数
+	ExportUsers(ctx context.Context, req *UserListRequest) ([]*model.User, error)
+
 	// ActivateUser 激活用户
 	ActivateUser(ctx context.Context, id primitive.ObjectID) error
 
@@ -89,6 +135,6 @@ type UserService interface {
 	// GetUserStats 获取用户统计信息
 	GetUserStats(ctx context.Context, userID primitive.ObjectID) (*model.UserStats, error)
 
-	// ValidateUser 验证用户存在性和权限
-	ValidateUser(ctx context.Context, userID primitive.ObjectID, requiredRole string) (*model.User, error)
+	// ValidateUser 验证用户存在、未停用且拥有权限，permission为空时只检查用户状态
+	ValidateUser(ctx context.Context, userID primitive.ObjectID, permission string) (*model.User, error)
 }
@@ -0,0 +1,288 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Session 登录会话，每次登录创建一个，刷新token时轮换
+type Session struct {
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	Device          string    `json:"device"` // 客户端提供的设备名
+	UserAgent       string    `json:"user_agent"`
+	LoginIP         string    `json:"login_ip"`
+	LastIP          string    `json:"last_ip"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastActivity    time.Time `json:"last_activity"`     // 最近一次登录或刷新
+	ExpiresAt       time.Time `json:"expires_at"`        // 刷新token过期时间
+	RefreshHash     string    `json:"refresh_hash"`      // 当前刷新token的摘要
+	AccessTokenID   string    `json:"access_token_id"`   // 最近签发的访问token ID
+	AccessExpiresAt time.Time `json:"access_expires_at"` // 最近签发的访问token过期时间
+}
+
+// RotateResult 刷新token轮换结果
+type RotateResult int
+
+const (
+	RotateOK      RotateResult = iota // 轮换成功
+	RotateMissing                     // 会话已注销或过期
+	RotateReused                      // 刷新token已被轮换过，可能已泄露
+)
+
+// rotateScript 刷新token轮换
+// 会话当前的刷新token摘要与请求一致时写入新会话并登记新的刷新token；
+// 旧的刷新token登记保留到过期，再次使用时可以识别为重放
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if not current then
+	return -1
+end
+if cjson.decode(current)['refresh_hash'] ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+redis.call('SET', KEYS[2], ARGV[4], 'PX', ARGV[5])
+return 1
+`)
+
+// Store Redis中的登录会话
+// session:{user_id} 哈希保存用户的全部会话，字段为会话ID；
+// session:refresh:{摘要} 指向刷新token所属的会话；token:revoked:{jti} 为撤销的访问token
+type Store struct {
+	client *redis.Client
+}
+
+// NewStore 创建会话存储
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+func sessionsKey(userID string) string {
+	return "session:" + userID
+}
+
+func refreshKey(hash string) string {
+	return "session:refresh:" + hash
+}
+
+func revokedKey(tokenID string) string {
+	return "token:revoked:" + tokenID
+}
+
+// HashToken 刷新token的摘要，Redis中只保存摘要
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create 保存新会话并登记刷新token，用户的会话超过maxSessions时注销最久未活动的会话
+func (s *Store) Create(ctx context.Context, session *Session, maxSessions int) error {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+	ttl := time.Until(session.ExpiresAt)
+	key := sessionsKey(session.UserID)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, session.ID, raw)
+		pipe.Expire(ctx, key, ttl)
+		pipe.Set(ctx, refreshKey(session.RefreshHash), session.UserID+":"+session.ID, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存会话失败: %w", err)
+	}
+
+	if maxSessions <= 0 {
+		return nil
+	}
+	sessions, err := s.List(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity.Before(sessions[j].LastActivity)
+	})
+	for i := 0; i < len(sessions)-maxSessions; i++ {
+		if sessions[i].ID == session.ID {
+			continue
+		}
+		if _, err := s.revoke(ctx, sessions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get 获取会话，不存在或已过期时返回nil
+func (s *Store) Get(ctx context.Context, userID, sessionID string) (*Session, error) {
+	raw, err := s.client.HGet(ctx, sessionsKey(userID), sessionID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取会话失败: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %w", err)
+	}
+	if !session.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// List 获取用户的全部有效会话，并清理已过期的会话
+func (s *Store) List(ctx context.Context, userID string) ([]*Session, error) {
+	values, err := s.client.HGetAll(ctx, sessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取会话失败: %w", err)
+	}
+
+	now := time.Now()
+	sessions := make([]*Session, 0, len(values))
+	var expired []string
+	for sessionID, raw := range values {
+		var session Session
+		if err := json.Unmarshal([]byte(raw), &session); err != nil || !session.ExpiresAt.After(now) {
+			expired = append(expired, sessionID)
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(expired) > 0 {
+		if err := s.client.HDel(ctx, sessionsKey(userID), expired...).Err(); err != nil {
+			return nil, fmt.Errorf("清理过期会话失败: %w", err)
+		}
+	}
+	return sessions, nil
+}
+
+// Lookup 根据刷新token查找会话，未登记时返回空串
+func (s *Store) Lookup(ctx context.Context, refreshToken string) (userID, sessionID string, err error) {
+	value, err := s.client.Get(ctx, refreshKey(HashToken(refreshToken))).Result()
+	if err == redis.Nil {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("读取刷新token失败: %w", err)
+	}
+	userID, sessionID, _ = strings.Cut(value, ":")
+	return userID, sessionID, nil
+}
+
+// Rotate 轮换刷新token：oldHash为会话当前的刷新token摘要时用updated替换会话
+func (s *Store) Rotate(ctx context.Context, oldHash string, updated *Session) (RotateResult, error) {
+	raw, err := json.Marshal(updated)
+	if err != nil {
+		return RotateMissing, fmt.Errorf("序列化会话失败: %w", err)
+	}
+	result, err := rotateScript.Run(ctx, s.client,
+		[]string{sessionsKey(updated.UserID), refreshKey(updated.RefreshHash)},
+		updated.ID, oldHash, raw, updated.UserID+":"+updated.ID, time.Until(updated.ExpiresAt).Milliseconds(),
+	).Int()
+	if err != nil {
+		return RotateMissing, fmt.Errorf("轮换刷新token失败: %w", err)
+	}
+	switch result {
+	case 1:
+		return RotateOK, nil
+	case 0:
+		return RotateReused, nil
+	default:
+		return RotateMissing, nil
+	}
+}
+
+// Revoke 注销会话：删除会话和刷新token，并撤销会话最近签发的访问token
+// 会话不存在时返回false
+func (s *Store) Revoke(ctx context.Context, userID, sessionID string) (bool, error) {
+	session, err := s.Get(ctx, userID, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil {
+		// 已过期的会话可能仍留在哈希中
+		return false, s.client.HDel(ctx, sessionsKey(userID), sessionID).Err()
+	}
+	return s.revoke(ctx, session)
+}
+
+// RevokeAll 注销用户除except以外的全部会话，返回注销的会话数
+func (s *Store) RevokeAll(ctx context.Context, userID, except string) (int, error) {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, session := range sessions {
+		if session.ID == except {
+			continue
+		}
+		revoked, err := s.revoke(ctx, session)
+		if err != nil {
+			return count, err
+		}
+		if revoked {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) revoke(ctx context.Context, session *Session) (bool, error) {
+	var deleted *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.HDel(ctx, sessionsKey(session.UserID), session.ID)
+		pipe.Del(ctx, refreshKey(session.RefreshHash))
+		if ttl := time.Until(session.AccessExpiresAt); session.AccessTokenID != "" && ttl > 0 {
+			pipe.Set(ctx, revokedKey(session.AccessTokenID), 1, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("注销会话失败: %w", err)
+	}
+	return deleted.Val() > 0, nil
+}
+
+// RevokeToken 撤销访问token，记录保留到token过期
+func (s *Store) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+	if err := s.client.Set(ctx, revokedKey(tokenID), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("撤销token失败: %w", err)
+	}
+	return nil
+}
+
+// Active 访问token是否有效：token未被撤销且所属会话未注销
+func (s *Store) Active(ctx context.Context, userID, sessionID, tokenID string) (bool, error) {
+	if sessionID == "" || tokenID == "" {
+		return false, nil
+	}
+	var revoked *redis.IntCmd
+	var present *redis.BoolCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		revoked = pipe.Exists(ctx, revokedKey(tokenID))
+		present = pipe.HExists(ctx, sessionsKey(userID), sessionID)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("检查token状态失败: %w", err)
+	}
+	return revoked.Val() == 0 && present.Val(), nil
+}
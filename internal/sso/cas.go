@@ -0,0 +1,113 @@
+package sso
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"zhku-oj/internal/config"
+)
+
+// casProvider CAS 3.0登录，通过 /p3/serviceValidate 校验ticket并取得属性
+type casProvider struct {
+	cfg    config.SSOProviderConfig
+	client *http.Client
+}
+
+func newCASProvider(cfg config.SSOProviderConfig, client *http.Client) *casProvider {
+	cfg.ServerURL = strings.TrimSuffix(cfg.ServerURL, "/")
+	return &casProvider{cfg: cfg, client: client}
+}
+
+func (p *casProvider) Name() string        { return p.cfg.Name }
+func (p *casProvider) Type() string        { return TypeCAS }
+func (p *casProvider) DisplayName() string { return p.cfg.DisplayName }
+
+// serviceURL CAS只回传ticket，state放在service地址中，校验时service必须与登录时一致
+func serviceURL(callback string, state *State) string {
+	return appendQuery(callback, url.Values{"state": {state.ID}})
+}
+
+// LoginURL CAS登录页地址
+func (p *casProvider) LoginURL(ctx context.Context, state *State, callback string) (string, error) {
+	return appendQuery(p.cfg.ServerURL+"/login", url.Values{"service": {serviceURL(callback, state)}}), nil
+}
+
+// casResponse serviceValidate响应，属性为CAS服务端配置的任意元素
+type casResponse struct {
+	XMLName xml.Name `xml:"serviceResponse"`
+	Success *struct {
+		User       string `xml:"user"`
+		Attributes struct {
+			Values []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"attributes"`
+	} `xml:"authenticationSuccess"`
+	Failure *struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	} `xml:"authenticationFailure"`
+}
+
+// Authenticate 校验ticket，CAS属性作为身份声明，"user"为登录名
+func (p *casProvider) Authenticate(ctx context.Context, params url.Values, state *State, callback string) (*Identity, error) {
+	ticket := params.Get("ticket")
+	if ticket == "" {
+		return nil, errors.New("回调缺少ticket")
+	}
+
+	endpoint := appendQuery(p.cfg.ServerURL+"/p3/serviceValidate", url.Values{
+		"service": {serviceURL(callback, state)},
+		"ticket":  {ticket},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("校验ticket失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("校验ticket失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("校验ticket失败: HTTP %d", resp.StatusCode)
+	}
+
+	var result casResponse
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析CAS响应失败: %w", err)
+	}
+	if result.Failure != nil {
+		return nil, fmt.Errorf("CAS拒绝登录: %s %s", result.Failure.Code, strings.TrimSpace(result.Failure.Message))
+	}
+	if result.Success == nil || strings.TrimSpace(result.Success.User) == "" {
+		return nil, errors.New("CAS响应缺少用户")
+	}
+
+	user := strings.TrimSpace(result.Success.User)
+	claims := map[string]interface{}{"user": user}
+	for _, attr := range result.Success.Attributes.Values {
+		name, value := attr.XMLName.Local, strings.TrimSpace(attr.Value)
+		if name == "user" {
+			continue
+		}
+		// 多值属性为多个同名元素
+		if existing, ok := claims[name].([]string); ok {
+			claims[name] = append(existing, value)
+		} else {
+			claims[name] = []string{value}
+		}
+	}
+	return mapIdentity(p.cfg, user, claims)
+}
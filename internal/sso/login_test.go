@@ -0,0 +1,287 @@
+package sso
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+	"zhku-oj/internal/sso/ssotest"
+)
+
+const callback = "https://oj.example.edu.cn/api/v1/auth/sso/campus/callback"
+
+// 模拟身份提供方中的身份声明名称
+var fakeClaims = map[string]config.SSOClaimMapping{
+	TypeOIDC: {StudentID: "student_id", Username: "preferred_username", RealName: "name",
+		Email: "email", Role: "affiliation", Class: "class", Grade: "grade"},
+	TypeCAS: {StudentID: "studentId", Username: "user", RealName: "name",
+		Email: "email", Role: "affiliation", Class: "class", Grade: "grade"},
+}
+
+// newFakeProvider 连接模拟身份提供方的OIDC或CAS登录
+func newFakeProvider(t *testing.T, srv *ssotest.Server, typ string, userInfo bool) Provider {
+	t.Helper()
+	pc := config.SSOProviderConfig{
+		Name:         "campus",
+		Type:         typ,
+		Issuer:       srv.URL,
+		ClientID:     "zhku-oj",
+		ClientSecret: "fake-secret",
+		UserInfo:     userInfo,
+		ServerURL:    srv.URL + "/cas",
+		Claims:       fakeClaims[typ],
+		RoleMap:      map[string]string{"faculty": model.RoleTeacher},
+	}
+	registry, err := NewRegistry(config.SSOConfig{Providers: []config.SSOProviderConfig{pc}}, srv.Client())
+	if err != nil {
+		t.Fatalf("创建身份提供方失败: %v", err)
+	}
+	p, _ := registry.Get("campus")
+	return p
+}
+
+func newState(id string) *State {
+	return &State{ID: id, Provider: "campus", Nonce: "nonce-" + id, Verifier: "verifier-" + id + "-0123456789abcdefghijklmnopqrstuvwxyz"}
+}
+
+// login 发起登录并在模拟身份提供方选择用户，返回回调参数
+func login(t *testing.T, srv *ssotest.Server, p Provider, state *State, user string) url.Values {
+	t.Helper()
+	loginURL, err := p.LoginURL(context.Background(), state, callback)
+	if err != nil {
+		t.Fatalf("生成登录地址失败: %v", err)
+	}
+	params, err := srv.Login(loginURL, user)
+	if err != nil {
+		t.Fatalf("登录模拟身份提供方失败: %v", err)
+	}
+	return params
+}
+
+func TestOIDCLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		userInfo bool
+		want     Identity
+	}{
+		{
+			name: "student with userinfo", user: "2021001001", userInfo: true,
+			want: Identity{Provider: "campus", Subject: "u-1001", StudentID: "2021001001", Username: "zhangsan",
+				RealName: "张三", Email: "zhangsan@example.edu.cn", Role: model.RoleStudent,
+				Class: "计算机科学与技术2021-1班", Grade: "2021"},
+		},
+		{
+			// 班级、年级只在userinfo中返回
+			name: "student without userinfo", user: "lisi",
+			want: Identity{Provider: "campus", Subject: "u-1002", StudentID: "2021001002", Username: "lisi",
+				RealName: "李四", Role: model.RoleStudent},
+		},
+		{
+			name: "faculty mapped to teacher", user: "T2020001", userInfo: true,
+			want: Identity{Provider: "campus", Subject: "u-2001", StudentID: "T2020001", Username: "wanglaoshi",
+				RealName: "王老师", Email: "wang@example.edu.cn", Role: model.RoleTeacher},
+		},
+	}
+
+	srv := ssotest.NewServer("zhku-oj", "fake-secret")
+	defer srv.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeProvider(t, srv, TypeOIDC, tt.userInfo)
+			state := newState("s1")
+
+			loginURL, err := p.LoginURL(context.Background(), state, callback)
+			if err != nil {
+				t.Fatalf("生成登录地址失败: %v", err)
+			}
+			u, _ := url.Parse(loginURL)
+			q := u.Query()
+			if !strings.HasPrefix(loginURL, srv.URL+"/authorize?") || q.Get("state") != state.ID || q.Get("nonce") != state.Nonce ||
+				q.Get("code_challenge") != state.CodeChallenge() || q.Get("code_challenge_method") != "S256" ||
+				q.Get("redirect_uri") != callback || q.Get("scope") != "openid profile email" {
+				t.Errorf("登录地址 = %s", loginURL)
+			}
+
+			params, err := srv.Login(loginURL, tt.user)
+			if err != nil {
+				t.Fatalf("登录模拟身份提供方失败: %v", err)
+			}
+			if params.Get("state") != state.ID || params.Get("code") == "" {
+				t.Fatalf("回调参数 = %v", params)
+			}
+			identity, err := p.Authenticate(context.Background(), params, state, callback)
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if *identity != tt.want {
+				t.Errorf("身份 = %+v, 期望 %+v", *identity, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginRejected(t *testing.T) {
+	srv := ssotest.NewServer("zhku-oj", "fake-secret")
+	defer srv.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// authenticate 用登录得到的回调参数认证，返回认证结果的error
+		authenticate func(p Provider, params url.Values, state *State) error
+	}{
+		{name: "nonce mismatch", authenticate: func(p Provider, params url.Values, state *State) error {
+			other := *state
+			other.Nonce = "other-nonce"
+			_, err := p.Authenticate(ctx, params, &other, callback)
+			return err
+		}},
+		{name: "pkce verifier mismatch", authenticate: func(p Provider, params url.Values, state *State) error {
+			other := *state
+			other.Verifier = "other-verifier-0123456789abcdefghijklmnopqrstuvwxyz"
+			_, err := p.Authenticate(ctx, params, &other, callback)
+			return err
+		}},
+		{name: "redirect uri mismatch", authenticate: func(p Provider, params url.Values, state *State) error {
+			_, err := p.Authenticate(ctx, params, state, callback+"/other")
+			return err
+		}},
+		{name: "code reused", authenticate: func(p Provider, params url.Values, state *State) error {
+			if _, err := p.Authenticate(ctx, params, state, callback); err != nil {
+				t.Fatalf("首次认证失败: %v", err)
+			}
+			_, err := p.Authenticate(ctx, params, state, callback)
+			return err
+		}},
+		{name: "missing code", authenticate: func(p Provider, params url.Values, state *State) error {
+			_, err := p.Authenticate(ctx, url.Values{"state": {state.ID}}, state, callback)
+			return err
+		}},
+		{name: "idp error", authenticate: func(p Provider, params url.Values, state *State) error {
+			_, err := p.Authenticate(ctx, url.Values{"error": {"access_denied"}}, state, callback)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeProvider(t, srv, TypeOIDC, true)
+			state := newState("s2")
+			params := login(t, srv, p, state, "zhangsan")
+			if err := tt.authenticate(p, params, state); err == nil {
+				t.Error("期望认证失败")
+			}
+		})
+	}
+}
+
+// TestOIDCWrongClientSecret client_secret错误时换取token失败
+func TestOIDCWrongClientSecret(t *testing.T) {
+	srv := ssotest.NewServer("zhku-oj", "other-secret")
+	defer srv.Close()
+	p := newFakeProvider(t, srv, TypeOIDC, false)
+	state := newState("s3")
+	params := login(t, srv, p, state, "zhangsan")
+	if _, err := p.Authenticate(context.Background(), params, state, callback); err == nil {
+		t.Error("期望认证失败")
+	}
+}
+
+func TestCASLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		want Identity
+	}{
+		{
+			name: "student",
+			user: "2021001001",
+			want: Identity{Provider: "campus", Subject: "zhangsan", StudentID: "2021001001", Username: "zhangsan",
+				RealName: "张三", Email: "zhangsan@example.edu.cn", Role: model.RoleStudent,
+				Class: "计算机科学与技术2021-1班", Grade: "2021"},
+		},
+		{
+			// affiliation为多值属性 member 和 faculty，取第一个在role_map中的值
+			name: "faculty mapped to teacher",
+			user: "wanglaoshi",
+			want: Identity{Provider: "campus", Subject: "wanglaoshi", StudentID: "T2020001", Username: "wanglaoshi",
+				RealName: "王老师", Email: "wang@example.edu.cn", Role: model.RoleTeacher},
+		},
+	}
+
+	srv := ssotest.NewServer("zhku-oj", "fake-secret")
+	defer srv.Close()
+	p := newFakeProvider(t, srv, TypeCAS, false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newState("s4")
+			loginURL, err := p.LoginURL(context.Background(), state, callback)
+			if err != nil {
+				t.Fatalf("生成登录地址失败: %v", err)
+			}
+			wantURL := srv.URL + "/cas/login?" + url.Values{"service": {callback + "?state=" + state.ID}}.Encode()
+			if loginURL != wantURL {
+				t.Errorf("登录地址 = %s, 期望 %s", loginURL, wantURL)
+			}
+
+			params, err := srv.Login(loginURL, tt.user)
+			if err != nil {
+				t.Fatalf("登录模拟身份提供方失败: %v", err)
+			}
+			// CAS只回传ticket，state来自service地址
+			if params.Get("state") != state.ID || !strings.HasPrefix(params.Get("ticket"), "ST-") {
+				t.Fatalf("回调参数 = %v", params)
+			}
+			identity, err := p.Authenticate(context.Background(), params, state, callback)
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if *identity != tt.want {
+				t.Errorf("身份 = %+v, 期望 %+v", *identity, tt.want)
+			}
+		})
+	}
+}
+
+func TestCASLoginRejected(t *testing.T) {
+	srv := ssotest.NewServer("zhku-oj", "fake-secret")
+	defer srv.Close()
+	p := newFakeProvider(t, srv, TypeCAS, false)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		authenticate func(params url.Values, state *State) error
+	}{
+		{name: "service mismatch", authenticate: func(params url.Values, state *State) error {
+			// 校验时的service与登录时不一致(state被替换)
+			_, err := p.Authenticate(ctx, params, newState("other"), callback)
+			return err
+		}},
+		{name: "ticket reused", authenticate: func(params url.Values, state *State) error {
+			if _, err := p.Authenticate(ctx, params, state, callback); err != nil {
+				t.Fatalf("首次认证失败: %v", err)
+			}
+			_, err := p.Authenticate(ctx, params, state, callback)
+			return err
+		}},
+		{name: "missing ticket", authenticate: func(params url.Values, state *State) error {
+			_, err := p.Authenticate(ctx, url.Values{"state": {state.ID}}, state, callback)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newState("s5")
+			params := login(t, srv, p, state, "zhangsan")
+			if err := tt.authenticate(params, state); err == nil {
+				t.Error("期望认证失败")
+			}
+		})
+	}
+}
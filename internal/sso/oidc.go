@@ -0,0 +1,336 @@
+package sso
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"zhku-oj/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwksRefreshInterval 遇到未知kid时重新拉取公钥的最短间隔
+const jwksRefreshInterval = time.Minute
+
+// oidcDiscovery OpenID Provider元数据
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider OpenID Connect授权码模式(PKCE)登录
+type oidcProvider struct {
+	cfg    config.SSOProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+func newOIDCProvider(cfg config.SSOProviderConfig, client *http.Client) *oidcProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &oidcProvider{cfg: cfg, client: client}
+}
+
+func (p *oidcProvider) Name() string        { return p.cfg.Name }
+func (p *oidcProvider) Type() string        { return TypeOIDC }
+func (p *oidcProvider) DisplayName() string { return p.cfg.DisplayName }
+
+// LoginURL 授权端点地址，携带state、nonce和PKCE code_challenge
+func (p *oidcProvider) LoginURL(ctx context.Context, state *State, callback string) (string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {callback},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state.ID},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {state.CodeChallenge()},
+		"code_challenge_method": {"S256"},
+	}
+	return appendQuery(discovery.AuthorizationEndpoint, query), nil
+}
+
+// Authenticate 用授权码换取ID Token，校验签名、签发者、受众和nonce
+func (p *oidcProvider) Authenticate(ctx context.Context, params url.Values, state *State, callback string) (*Identity, error) {
+	if errCode := params.Get("error"); errCode != "" {
+		return nil, fmt.Errorf("身份提供方拒绝登录: %s %s", errCode, params.Get("error_description"))
+	}
+	code := params.Get("code")
+	if code == "" {
+		return nil, errors.New("回调缺少code")
+	}
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {callback},
+		"code_verifier": {state.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+	}
+	if err := p.doJSON(req, &tokens); err != nil {
+		return nil, fmt.Errorf("换取token失败: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token响应缺少id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, tokens.IDToken, discovery.Issuer, state.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+
+	if p.cfg.UserInfo && discovery.UserInfoEndpoint != "" && tokens.AccessToken != "" {
+		userInfo, err := p.fetchUserInfo(ctx, discovery.UserInfoEndpoint, tokens.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		if sub, _ := userInfo["sub"].(string); sub != subject {
+			return nil, errors.New("userinfo的sub与ID Token不一致")
+		}
+		// ID Token中没有的声明使用userinfo补充
+		for name, value := range userInfo {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+	return mapIdentity(p.cfg, subject, claims)
+}
+
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw, issuer, nonce string) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ID Token校验失败: %w", err)
+	}
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, errors.New("ID Token签发者错误")
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("ID Token受众错误")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("ID Token缺少exp")
+	}
+	if value, _ := claims["nonce"].(string); value != nonce {
+		return nil, errors.New("ID Token的nonce不匹配")
+	}
+	return claims, nil
+}
+
+func (p *oidcProvider) fetchUserInfo(ctx context.Context, endpoint, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	var userInfo map[string]interface{}
+	if err := p.doJSON(req, &userInfo); err != nil {
+		return nil, fmt.Errorf("获取userinfo失败: %w", err)
+	}
+	return userInfo, nil
+}
+
+// getDiscovery 读取并缓存OpenID Provider元数据
+func (p *oidcProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	endpoint := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery oidcDiscovery
+	if err := p.doJSON(req, &discovery); err != nil {
+		return nil, fmt.Errorf("读取OIDC配置失败: %w", err)
+	}
+	if discovery.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC签发者不一致: %s", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("OIDC配置缺少端点")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// getKey 按kid获取ID Token公钥，未知kid时重新拉取(身份提供方可能已轮换密钥)
+func (p *oidcProvider) getKey(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksRefreshInterval {
+		return nil, fmt.Errorf("未知的签名密钥: %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("读取JWKS失败: %w", err)
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %q", kid)
+}
+
+// lookupKey token没有kid且只有一个公钥时使用该公钥
+func (p *oidcProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *oidcProvider) doJSON(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// jsonWebKey JWKS中的公钥，支持RSA和EC
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// appendQuery 在地址后追加查询参数，地址本身可以带参数
+func appendQuery(endpoint string, query url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode()
+	}
+	return endpoint + "?" + query.Encode()
+}
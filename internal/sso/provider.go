@@ -0,0 +1,173 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+)
+
+// 身份提供方类型
+const (
+	TypeOIDC = "oidc"
+	TypeCAS  = "cas"
+)
+
+// Identity 统一身份认证返回的身份，已按claims配置映射到用户字段
+type Identity struct {
+	Provider  string
+	Subject   string // 身份提供方中的唯一标识
+	StudentID string
+	Username  string
+	RealName  string
+	Email     string
+	Role      string // student或teacher，统一身份认证不会授予管理员
+	Class     string
+	Grade     string
+}
+
+// Provider 身份提供方
+type Provider interface {
+	Name() string
+	Type() string
+	DisplayName() string
+
+	// LoginURL 跳转到身份提供方登录页的地址，callback为本服务的回调地址
+	LoginURL(ctx context.Context, state *State, callback string) (string, error)
+
+	// Authenticate 校验回调参数并返回身份
+	Authenticate(ctx context.Context, params url.Values, state *State, callback string) (*Identity, error)
+}
+
+// Registry 已配置的身份提供方
+type Registry struct {
+	providers map[string]Provider
+	order     []string
+}
+
+// NewRegistry 根据配置创建身份提供方，client为空时使用10秒超时的默认客户端
+func NewRegistry(cfg config.SSOConfig, client *http.Client) (*Registry, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	r := &Registry{providers: make(map[string]Provider, len(cfg.Providers))}
+	for _, pc := range cfg.Providers {
+		if pc.Name == "" {
+			return nil, errors.New("统一身份认证缺少name")
+		}
+		if _, ok := r.providers[pc.Name]; ok {
+			return nil, fmt.Errorf("统一身份认证name重复: %s", pc.Name)
+		}
+		if pc.Claims.StudentID == "" {
+			return nil, fmt.Errorf("统一身份认证%s缺少claims.student_id", pc.Name)
+		}
+
+		var p Provider
+		switch pc.Type {
+		case TypeOIDC:
+			if pc.Issuer == "" || pc.ClientID == "" {
+				return nil, fmt.Errorf("统一身份认证%s缺少issuer或client_id", pc.Name)
+			}
+			p = newOIDCProvider(pc, client)
+		case TypeCAS:
+			if pc.ServerURL == "" {
+				return nil, fmt.Errorf("统一身份认证%s缺少server_url", pc.Name)
+			}
+			p = newCASProvider(pc, client)
+		default:
+			return nil, fmt.Errorf("不支持的统一身份认证类型: %s", pc.Type)
+		}
+		r.providers[pc.Name] = p
+		r.order = append(r.order, pc.Name)
+	}
+	return r, nil
+}
+
+// Get 获取身份提供方
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// List 按配置顺序返回全部身份提供方
+func (r *Registry) List() []Provider {
+	list := make([]Provider, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.providers[name])
+	}
+	return list
+}
+
+// mapIdentity 按claims配置从身份声明中取出用户字段
+func mapIdentity(cfg config.SSOProviderConfig, subject string, claims map[string]interface{}) (*Identity, error) {
+	get := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		values := claimValues(claims[name])
+		if len(values) == 0 {
+			return ""
+		}
+		return strings.TrimSpace(values[0])
+	}
+
+	identity := &Identity{
+		Provider:  cfg.Name,
+		Subject:   subject,
+		StudentID: get(cfg.Claims.StudentID),
+		Username:  get(cfg.Claims.Username),
+		RealName:  get(cfg.Claims.RealName),
+		Email:     get(cfg.Claims.Email),
+		Class:     get(cfg.Claims.Class),
+		Grade:     get(cfg.Claims.Grade),
+		Role:      mapRole(cfg, claimValues(claims[cfg.Claims.Role])),
+	}
+	if identity.StudentID == "" {
+		return nil, fmt.Errorf("身份声明中缺少%s", cfg.Claims.StudentID)
+	}
+	return identity, nil
+}
+
+// mapRole 取第一个在role_map中的角色值，只允许映射为学生或教师
+func mapRole(cfg config.SSOProviderConfig, values []string) string {
+	for _, value := range values {
+		if role, ok := cfg.RoleMap[value]; ok && (role == model.RoleStudent || role == model.RoleTeacher) {
+			return role
+		}
+	}
+	if cfg.DefaultRole == model.RoleTeacher {
+		return model.RoleTeacher
+	}
+	return model.RoleStudent
+}
+
+// claimValues 声明值转为字符串列表，多值声明保持顺序
+func claimValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, claimValues(item)...)
+		}
+		return values
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package sso
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"zhku-oj/internal/config"
+	"zhku-oj/internal/model"
+)
+
+func TestMapIdentity(t *testing.T) {
+	cfg := config.SSOProviderConfig{
+		Name: "campus",
+		Claims: config.SSOClaimMapping{
+			StudentID: "student_id",
+			Username:  "preferred_username",
+			RealName:  "name",
+			Email:     "email",
+			Role:      "affiliation",
+			Class:     "class",
+			Grade:     "grade",
+		},
+		RoleMap: map[string]string{"faculty": model.RoleTeacher, "staff": model.RoleAdmin},
+	}
+	tests := []struct {
+		name    string
+		cfg     config.SSOProviderConfig
+		claims  string // JSON格式的身份声明
+		want    *Identity
+		wantErr bool
+	}{
+		{
+			name:   "all claims",
+			cfg:    cfg,
+			claims: `{"student_id":" 2021001001 ","preferred_username":"zhangsan","name":"张三","email":"z@example.edu.cn","affiliation":"student","class":"1班","grade":"2021"}`,
+			want: &Identity{Provider: "campus", Subject: "sub", StudentID: "2021001001", Username: "zhangsan",
+				RealName: "张三", Email: "z@example.edu.cn", Role: model.RoleStudent, Class: "1班", Grade: "2021"},
+		},
+		{
+			name:   "numeric student id and multi-valued role",
+			cfg:    cfg,
+			claims: `{"student_id":2021001001,"affiliation":["member","faculty"]}`,
+			want:   &Identity{Provider: "campus", Subject: "sub", StudentID: "2021001001", Role: model.RoleTeacher},
+		},
+		{
+			name:   "first value of multi-valued claim",
+			cfg:    cfg,
+			claims: `{"student_id":["2021001001","old-id"],"name":["张三","Zhang San"]}`,
+			want:   &Identity{Provider: "campus", Subject: "sub", StudentID: "2021001001", RealName: "张三", Role: model.RoleStudent},
+		},
+		{
+			name:   "role map cannot grant admin",
+			cfg:    cfg,
+			claims: `{"student_id":"S1","affiliation":"staff"}`,
+			want:   &Identity{Provider: "campus", Subject: "sub", StudentID: "S1", Role: model.RoleStudent},
+		},
+		{
+			name: "default role teacher",
+			cfg: func() config.SSOProviderConfig {
+				c := cfg
+				c.DefaultRole = model.RoleTeacher
+				return c
+			}(),
+			claims: `{"student_id":"T1","affiliation":"unknown"}`,
+			want:   &Identity{Provider: "campus", Subject: "sub", StudentID: "T1", Role: model.RoleTeacher},
+		},
+		{
+			name:    "missing student id",
+			cfg:     cfg,
+			claims:  `{"preferred_username":"zhangsan","student_id":""}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.claims), &claims); err != nil {
+				t.Fatalf("解析身份声明失败: %v", err)
+			}
+			got, err := mapIdentity(tt.cfg, "sub", claims)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("mapIdentity = %+v, 期望返回error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mapIdentity失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapIdentity = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	claims := config.SSOClaimMapping{StudentID: "student_id"}
+	tests := []struct {
+		name      string
+		providers []config.SSOProviderConfig
+		wantErr   bool
+	}{
+		{name: "oidc and cas", providers: []config.SSOProviderConfig{
+			{Name: "oidc", Type: TypeOIDC, Issuer: "https://idp.example.edu.cn", ClientID: "oj", Claims: claims},
+			{Name: "cas", Type: TypeCAS, ServerURL: "https://cas.example.edu.cn/cas", Claims: claims},
+		}},
+		{name: "missing name", providers: []config.SSOProviderConfig{{Type: TypeCAS, ServerURL: "https://cas", Claims: claims}}, wantErr: true},
+		{name: "duplicate name", providers: []config.SSOProviderConfig{
+			{Name: "cas", Type: TypeCAS, ServerURL: "https://cas", Claims: claims},
+			{Name: "cas", Type: TypeCAS, ServerURL: "https://cas", Claims: claims},
+		}, wantErr: true},
+		{name: "missing student id claim", providers: []config.SSOProviderConfig{{Name: "cas", Type: TypeCAS, ServerURL: "https://cas"}}, wantErr: true},
+		{name: "oidc missing client id", providers: []config.SSOProviderConfig{{Name: "oidc", Type: TypeOIDC, Issuer: "https://idp", Claims: claims}}, wantErr: true},
+		{name: "cas missing server url", providers: []config.SSOProviderConfig{{Name: "cas", Type: TypeCAS, Claims: claims}}, wantErr: true},
+		{name: "unknown type", providers: []config.SSOProviderConfig{{Name: "saml", Type: "saml", Claims: claims}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := NewRegistry(config.SSOConfig{Providers: tt.providers}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("期望返回error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("创建身份提供方失败: %v", err)
+			}
+			list := registry.List()
+			if len(list) != len(tt.providers) {
+				t.Fatalf("身份提供方数量 = %d, 期望 %d", len(list), len(tt.providers))
+			}
+			for i, p := range list {
+				if p.Name() != tt.providers[i].Name || p.Type() != tt.providers[i].Type {
+					t.Errorf("第%d个身份提供方 = %s/%s, 期望按配置顺序", i, p.Name(), p.Type())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+// Package ssotest 本地模拟的统一身份认证服务，同时提供OIDC和CAS 3.0接口
+// 登录页直接选择预置用户，不校验密码；用于开发联调(cmd/fakeidp)和测试
+package ssotest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeyID ID Token签名密钥的kid
+const KeyID = "fake-1"
+
+// User 预置用户，字段同时作为OIDC声明和CAS属性
+// 班级、年级只在userinfo和CAS属性中返回，ID Token中没有，用于验证声明合并
+type User struct {
+	Subject     string `json:"sub"`
+	Username    string `json:"preferred_username"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	StudentID   string `json:"student_id"`
+	Affiliation string `json:"affiliation"`
+	Class       string `json:"class"`
+	Grade       string `json:"grade"`
+}
+
+// DefaultUsers 默认的预置用户
+var DefaultUsers = []User{
+	{"u-1001", "zhangsan", "张三", "zhangsan@example.edu.cn", "2021001001", "student", "计算机科学与技术2021-1班", "2021"},
+	{"u-1002", "lisi", "李四", "", "2021001002", "student", "计算机科学与技术2021-2班", "2021"},
+	{"u-2001", "wanglaoshi", "王老师", "wang@example.edu.cn", "T2020001", "faculty", "", ""},
+}
+
+// authCode 授权码，一次性使用
+type authCode struct {
+	user        User
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// casTicket 服务票据，一次性使用
+type casTicket struct {
+	user    User
+	service string
+}
+
+// IdP 模拟的身份提供方，OIDC签发者为issuer，CAS服务地址为 {issuer}/cas
+type IdP struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu      sync.Mutex
+	users   []User
+	codes   map[string]authCode
+	tickets map[string]casTicket
+	tokens  map[string]User
+}
+
+// New 创建模拟的身份提供方，使用DefaultUsers
+func New(issuer, clientID, clientSecret string) (*IdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return &IdP{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		users:        append([]User(nil), DefaultUsers...),
+		codes:        make(map[string]authCode),
+		tickets:      make(map[string]casTicket),
+		tokens:       make(map[string]User),
+	}, nil
+}
+
+// Handler OIDC和CAS接口
+func (s *IdP) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userinfo)
+	mux.HandleFunc("/cas/login", s.casLogin)
+	mux.HandleFunc("/cas/p3/serviceValidate", s.casValidate)
+	return mux
+}
+
+// Issuer OIDC签发者
+func (s *IdP) Issuer() string {
+	return s.issuer
+}
+
+// SetUsers 替换预置用户
+func (s *IdP) SetUsers(users ...User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append([]User(nil), users...)
+}
+
+func (s *IdP) findUser(id string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.StudentID == id || u.Username == id {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>模拟统一身份认证</title></head>
+<body><h3>模拟统一身份认证</h3>
+{{range .Users}}<p><a href="{{$.Action}}&user={{.StudentID}}">{{.Name}} ({{.StudentID}}, {{.Affiliation}})</a></p>{{end}}
+</body></html>`))
+
+// renderLogin 没有user参数时显示用户选择页
+func (s *IdP) renderLogin(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get("user") != "" {
+		return false
+	}
+	s.mu.Lock()
+	users := s.users
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	loginPage.Execute(w, map[string]interface{}{"Users": users, "Action": r.URL.Path + "?" + r.URL.RawQuery})
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *IdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"userinfo_endpoint":                     s.issuer + "/userinfo",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	e := big.NewInt(int64(s.key.PublicKey.E)).Bytes()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA", "kid": KeyID, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(s.key.PublicKey.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(e),
+	}}})
+}
+
+func (s *IdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.clientID || q.Get("response_type") != "code" || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if s.renderLogin(w, r) {
+		return
+	}
+	user, ok := s.findUser(q.Get("user"))
+	if !ok {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authCode{user, q.Get("client_id"), q.Get("redirect_uri"), q.Get("nonce"), q.Get("code_challenge")}
+	s.mu.Unlock()
+
+	redirect := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (s *IdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != s.clientID || clientSecret != s.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	r.ParseForm()
+
+	s.mu.Lock()
+	code, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		(code.challenge != "" && base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": s.issuer, "aud": s.clientID, "sub": code.user.Subject, "nonce": code.nonce,
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		"preferred_username": code.user.Username, "name": code.user.Name, "email": code.user.Email,
+		"student_id": code.user.StudentID, "affiliation": code.user.Affiliation,
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = KeyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.tokens[accessToken] = code.user
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken, "token_type": "Bearer", "expires_in": 300, "id_token": signed,
+	})
+}
+
+func (s *IdP) userinfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	user, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *IdP) casLogin(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		http.Error(w, "missing service", http.StatusBadRequest)
+		return
+	}
+	if s.renderLogin(w, r) {
+		return
+	}
+	user, ok := s.findUser(r.URL.Query().Get("user"))
+	if !ok {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+
+	ticket := "ST-" + randomString()
+	s.mu.Lock()
+	s.tickets[ticket] = casTicket{user, service}
+	s.mu.Unlock()
+
+	sep := "?"
+	if strings.Contains(service, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, service+sep+url.Values{"ticket": {ticket}}.Encode(), http.StatusFound)
+}
+
+func (s *IdP) casValidate(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	ticket, ok := s.tickets[q.Get("ticket")]
+	delete(s.tickets, q.Get("ticket"))
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	fmt.Fprintln(w, `<cas:serviceResponse xmlns:cas="http://www.yale.edu/tp/cas">`)
+	if !ok || ticket.service != q.Get("service") {
+		fmt.Fprintln(w, `  <cas:authenticationFailure code="INVALID_TICKET">ticket无效或service不匹配</cas:authenticationFailure>`)
+	} else {
+		u := ticket.user
+		fmt.Fprintf(w, "  <cas:authenticationSuccess>\n    <cas:user>%s</cas:user>\n    <cas:attributes>\n", escape(u.Username))
+		for name, value := range map[string]string{
+			"studentId": u.StudentID, "name": u.Name, "email": u.Email,
+			"class": u.Class, "grade": u.Grade,
+		} {
+			fmt.Fprintf(w, "      <cas:%s>%s</cas:%s>\n", name, escape(value), name)
+		}
+		// 多值属性
+		fmt.Fprintf(w, "      <cas:affiliation>member</cas:affiliation>\n      <cas:affiliation>%s</cas:affiliation>\n", escape(u.Affiliation))
+		fmt.Fprintln(w, "    </cas:attributes>\n  </cas:authenticationSuccess>")
+	}
+	fmt.Fprintln(w, `</cas:serviceResponse>`)
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// Server 在httptest上运行的模拟身份提供方，签发者为服务地址
+type Server struct {
+	*httptest.Server
+	*IdP
+}
+
+// NewServer 启动模拟身份提供方
+func NewServer(clientID, clientSecret string) *Server {
+	srv := httptest.NewUnstartedServer(nil)
+	idp, err := New("http://"+srv.Listener.Addr().String(), clientID, clientSecret)
+	if err != nil {
+		panic(err)
+	}
+	srv.Config.Handler = idp.Handler()
+	srv.Start()
+	return &Server{Server: srv, IdP: idp}
+}
+
+// Login 模拟浏览器在登录页选择用户，返回身份提供方跳转回本服务的回调参数
+// loginURL为服务生成的登录地址(OIDC授权端点或CAS登录页)
+func (s *Server) Login(loginURL, user string) (url.Values, error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(loginURL + "&" + url.Values{"user": {user}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("登录失败: HTTP %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, err
+	}
+	if location.RawQuery == "" {
+		return nil, errors.New("回调地址缺少参数")
+	}
+	return location.Query(), nil
+}
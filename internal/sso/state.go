@@ -0,0 +1,93 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"zhku-oj/internal/pkg/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// State 发起登录时保存的状态，回调时一次性取出
+type State struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`              // oidc: 写入ID Token，防止重放
+	Verifier string `json:"verifier"`           // oidc: PKCE code_verifier
+	Redirect string `json:"redirect,omitempty"` // 登录完成后跳转的前端地址
+}
+
+// CodeChallenge PKCE S256 code_challenge
+func (s *State) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// StateStore Redis中的登录状态，键为 sso:state:{id}
+type StateStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewStateStore 创建登录状态存储，ttl为发起登录到回调的最长时间
+func NewStateStore(client *redis.Client, ttl time.Duration) *StateStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &StateStore{client: client, ttl: ttl}
+}
+
+func stateKey(id string) string {
+	return "sso:state:" + id
+}
+
+// Create 生成并保存登录状态
+func (s *StateStore) Create(ctx context.Context, provider, redirect string) (*State, error) {
+	state := &State{Provider: provider, Redirect: redirect}
+	for _, field := range []*string{&state.ID, &state.Nonce, &state.Verifier} {
+		token, err := utils.RandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		*field = token
+	}
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("序列化登录状态失败: %w", err)
+	}
+	if err := s.client.Set(ctx, stateKey(state.ID), raw, s.ttl).Err(); err != nil {
+		return nil, fmt.Errorf("保存登录状态失败: %w", err)
+	}
+	return state, nil
+}
+
+// Take 取出并删除登录状态，不存在或已过期时返回nil
+func (s *StateStore) Take(ctx context.Context, id string) (*State, error) {
+	if id == "" {
+		return nil, nil
+	}
+	var get *redis.StringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, stateKey(id))
+		pipe.Del(ctx, stateKey(id))
+		return nil
+	})
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取登录状态失败: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte(get.Val()), &state); err != nil {
+		return nil, fmt.Errorf("解析登录状态失败: %w", err)
+	}
+	return &state, nil
+}